@@ -28,6 +28,9 @@ class Sona < Formula
     bin.install "sona-darwin-arm64" => "sona" if OS.mac? && Hardware::CPU.arm?
     bin.install "sona-linux-amd64" => "sona" if OS.linux? && Hardware::CPU.intel?
     bin.install "sona-linux-arm64" => "sona" if OS.linux? && Hardware::CPU.arm?
+
+    system bin/"sona", "docs", "man", buildpath/"man"
+    man1.install Dir[buildpath/"man/*.1"]
   end
 
   test do
@@ -1,17 +1,35 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/backup"
 	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/docs"
+	"github.com/Harsh-2002/Sona/pkg/history"
 	"github.com/Harsh-2002/Sona/pkg/interactive"
+	"github.com/Harsh-2002/Sona/pkg/library"
 	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/project"
+	"github.com/Harsh-2002/Sona/pkg/service"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/tokens"
 	"github.com/Harsh-2002/Sona/pkg/transcriber"
+	"github.com/Harsh-2002/Sona/pkg/usage"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
 	"github.com/Harsh-2002/Sona/pkg/youtube"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Version will be set by the build process
@@ -29,71 +47,308 @@ Features:
 - Download and transcribe YouTube videos
 - Save transcripts to custom or default paths
 - Interactive mode for guided experience`,
+	Example: `  sona
+  sona transcribe "./audio.mp3"
+  sona status
+  sona --help`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		logger.SetVerbosity(verbosity)
+		if noColor {
+			style.Disable()
+		}
+		if config.GetAccessible() {
+			style.SetAccessible()
+		}
+		useToolPath("ffmpeg", ffmpegPath)
+		useToolPath("yt-dlp", ytdlpPath)
+		overrideTimeout(cmd, "connect-timeout", "network.connect_timeout", connectTimeout)
+		overrideTimeout(cmd, "request-timeout", "network.request_timeout", requestTimeout)
+		overrideTimeout(cmd, "upload-idle-timeout", "network.upload_idle_timeout", uploadIdleTimeout)
+		if debugHTTP {
+			startHTTPDebug()
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		interactive.InteractiveCmd.Run(cmd, args)
 	},
 }
 
+// verbosity counts -v flags: -v echoes log messages, -vv also streams the
+// output of ffmpeg and yt-dlp
+var verbosity int
+
+// noColor turns colored output off; NO_COLOR does the same
+var noColor bool
+
+// ffmpegPath and ytdlpPath override tools.ffmpeg_path and tools.ytdlp_path
+var (
+	ffmpegPath string
+	ytdlpPath  string
+)
+
+// useToolPath points sona at the binary given on the command line or in the
+// config file for a tool, if any
+func useToolPath(tool string, flagValue string) {
+	path := flagValue
+	if path == "" {
+		path = config.GetToolPath(tool)
+	}
+	deps.SetPath(tool, path)
+}
+
+// debugHTTP logs the requests to AssemblyAI and their responses to a file
+var debugHTTP bool
+
+// startHTTPDebug opens a debug file of its own for this run in the debug
+// directory and logs AssemblyAI traffic to it. The file may hold parts of
+// transcripts, so only the user can read it.
+func startHTTPDebug() {
+	path, err := datadir.Path("debug", "http-"+time.Now().Format("20060102-150405")+".log")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	var file *os.File
+	if err == nil {
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	}
+	if err != nil {
+		fmt.Println(style.Warning("Could not create the HTTP debug file: %v", err))
+		return
+	}
+	fmt.Fprintf(file, "sona %s on %s/%s\nCommand: sona %s\n\n", version, runtime.GOOS, runtime.GOARCH, strings.Join(os.Args[1:], " "))
+	assemblyai.SetDebugLog(file)
+	fmt.Println(style.Info("Logging AssemblyAI requests to %s", path))
+}
+
+// connectTimeout, requestTimeout and uploadIdleTimeout override the
+// network.*_timeout config keys
+var (
+	connectTimeout    time.Duration
+	requestTimeout    time.Duration
+	uploadIdleTimeout time.Duration
+)
+
+// overrideTimeout uses the timeout given on the command line, if any, instead
+// of the config key for this run
+func overrideTimeout(cmd *cobra.Command, flag string, key string, value time.Duration) {
+	if cmd.Flags().Changed(flag) {
+		config.OverrideTimeout(key, value)
+	}
+}
+
+var (
+	usePackageManager bool
+	noPackageManager  bool
+	installFromDir    string
+	installMirror     string
+	uninstallDeps     bool
+)
+
 var installCmd = &cobra.Command{
 	Use:   "install",
 	Short: "Install dependencies for the current platform",
-	Long:  "Install yt-dlp and FFmpeg dependencies for the current platform. This command will download and install the appropriate binaries for your operating system.",
+	Long: `Install yt-dlp and FFmpeg dependencies for the current platform.
+
+When a supported package manager is found (Homebrew, apt, dnf, winget or
+scoop), Sona offers to install the dependencies through it so they receive
+system updates. Otherwise, or when the package manager fails, the appropriate
+binaries for your operating system are downloaded directly.
+
+Binaries are installed into ~/.sona/bin, which Sona searches before PATH.
+Use --uninstall to remove them again. Setting security.allow_install to
+false turns both off, e.g. on a shared server.
+
+For machines without internet access, install from a local directory or an
+internal mirror instead. The directory may contain ready binaries (yt-dlp,
+ffmpeg, ffprobe) or the same release assets Sona would download; a mirror
+must serve those assets under their original file names.`,
+	Example: `  sona install
+  sona install --from-dir /mnt/share/sona-deps
+  sona install --mirror https://artifacts.internal/sona
+  sona install --uninstall`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Sona Dependency Installation")
+		if !config.GetAllowInstall() {
+			fmt.Println(style.Error("Installing and removing dependencies is turned off by security.allow_install; ask the administrator of this machine"))
+			os.Exit(1)
+		}
+		if uninstallDeps {
+			runUninstall()
+			return
+		}
+
+		fmt.Println(style.Bold("Sona Dependency Installation"))
 		fmt.Println("============================")
 
+		source, err := deps.NewInstallSource(installFromDir, installMirror)
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		// Air-gapped installs must not reach out through the package manager
+		var pm *deps.PackageManager
+		if !noPackageManager && !source.IsOffline() {
+			pm = deps.DetectPackageManager()
+		}
+
 		// Install yt-dlp
 		fmt.Println("\n1. YouTube Download (yt-dlp):")
-		fmt.Println("   Installing...")
-		if err := youtube.InstallYtDlp(); err != nil {
-			fmt.Printf("   Failed: %v\n", err)
-			fmt.Println("   💡 Check logs at:", logger.GetLogPath())
+		if err := installDependency(pm, "yt-dlp", func() error { return youtube.InstallYtDlp(source) }); err != nil {
+			fmt.Println("   " + style.Failure("%v", err))
+			fmt.Println("   " + style.Hint("Check logs at: %s", logger.GetLogPath()))
 			os.Exit(1)
 		}
-		fmt.Println("   ✅ Installed successfully")
+		fmt.Println("   " + style.Success("Installed successfully"))
 
 		// Install FFmpeg
 		fmt.Println("\n2. Audio Processing (FFmpeg):")
-		fmt.Println("   Installing...")
-		if err := transcriber.InstallFFmpeg(); err != nil {
-			fmt.Printf("   Failed: %v\n", err)
-			fmt.Println("   💡 Check logs at:", logger.GetLogPath())
+		if err := installDependency(pm, "ffmpeg", func() error { return transcriber.InstallFFmpeg(source) }); err != nil {
+			fmt.Println("   " + style.Failure("%v", err))
+			fmt.Println("   " + style.Hint("Check logs at: %s", logger.GetLogPath()))
 			os.Exit(1)
 		}
-		fmt.Println("   ✅ Installed successfully")
+		fmt.Println("   " + style.Success("Installed successfully"))
 
 		// On macOS, also check for ffprobe
 		if runtime.GOOS == "darwin" {
 			fmt.Println("\n3. macOS Audio Tools (ffprobe):")
 			if _, err := transcriber.FindBinary("ffprobe"); err != nil {
-				fmt.Println("   ⚠️  ffprobe not found after FFmpeg installation")
-				fmt.Println("   💡 This might cause issues with YouTube downloads")
+				fmt.Println("   " + style.Warning("ffprobe not found after FFmpeg installation"))
+				fmt.Println("   " + style.Hint("This might cause issues with YouTube downloads"))
 			} else {
-				fmt.Println("   ✅ Available")
+				fmt.Println("   " + style.Success("Available"))
 			}
 		}
 
-		fmt.Println("\nInstallation completed!")
-		fmt.Println("💡 Run 'sona status' to verify the installation")
+		fmt.Println("\n" + style.Success("Installation completed"))
+		fmt.Println(style.Hint("Run 'sona status' to verify the installation"))
 	},
 }
 
+// runUninstall removes the dependencies sona installed into its own bin directory
+func runUninstall() {
+	fmt.Println(style.Bold("Sona Dependency Removal"))
+	fmt.Println("=======================")
+
+	removed, err := deps.UninstallManaged()
+	for _, path := range removed {
+		fmt.Printf("   Removed %s\n", path)
+	}
+	if err != nil {
+		fmt.Println("   " + style.Failure("%v", err))
+		os.Exit(1)
+	}
+	if len(removed) == 0 {
+		fmt.Println("   Nothing to remove")
+	}
+
+	// Older versions installed into ~/bin; those may be shared with other tools
+	if legacy := deps.LegacyBinaries(); len(legacy) > 0 {
+		fmt.Println("\n" + style.Hint("Found dependencies in ~/bin, possibly installed by an older Sona version:"))
+		for _, path := range legacy {
+			fmt.Printf("   %s\n", path)
+		}
+		fmt.Println("   Remove them manually if no other tool uses them")
+	}
+}
+
+// installDependency installs a dependency through the package manager when
+// the user agrees, falling back to the direct download
+func installDependency(pm *deps.PackageManager, name string, directInstall func() error) error {
+	if pm != nil {
+		if commandLine, err := pm.CommandLine(name); err == nil {
+			if confirmPackageManagerInstall(strings.Join(commandLine, " ")) {
+				fmt.Printf("   Installing with %s...\n", pm.Name)
+				err := pm.Install(name)
+				if err == nil {
+					return nil
+				}
+				logger.LogWarning("Package manager install of %s failed: %v", name, err)
+				fmt.Println("   " + style.Warning("%v", err))
+				fmt.Println("   Falling back to direct download...")
+			}
+		} else {
+			logger.LogInfo("Skipping package manager for %s: %v", name, err)
+		}
+	}
+
+	fmt.Println("   Installing...")
+	return directInstall()
+}
+
+// confirmPackageManagerInstall asks for consent before running the package manager.
+// Without a terminal on stdin the package manager is only used with --use-package-manager.
+func confirmPackageManagerInstall(commandLine string) bool {
+	if usePackageManager {
+		return true
+	}
+
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	fmt.Printf("   Install using the system package manager (%s)? (y/n): ", commandLine)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+}
+
 func init() {
 	// Initialize configuration
 	config.InitConfig()
 
 	// Add commands
 	rootCmd.AddCommand(transcriber.TranscribeCmd)
+	rootCmd.AddCommand(transcriber.QueueCmd)
+	rootCmd.AddCommand(transcriber.JobsCmd)
+	rootCmd.AddCommand(transcriber.RetryCmd)
+	rootCmd.AddCommand(transcriber.PresetCmd)
+	rootCmd.AddCommand(transcriber.LiveCmd)
+	rootCmd.AddCommand(transcriber.ServeCmd)
+	rootCmd.AddCommand(transcriber.WatchCmd)
+	rootCmd.AddCommand(service.ServiceCmd)
+	rootCmd.AddCommand(tokens.TokenCmd)
+	rootCmd.AddCommand(library.ListCmd)
+	rootCmd.AddCommand(library.ShowCmd)
+	rootCmd.AddCommand(library.StatsCmd)
+	rootCmd.AddCommand(project.ProjectCmd)
+	rootCmd.AddCommand(transcriber.ReviewCmd)
+	rootCmd.AddCommand(transcriber.AlignCmd)
+	rootCmd.AddCommand(transcriber.QuotesCmd)
+	rootCmd.AddCommand(transcriber.FindCmd)
+	rootCmd.AddCommand(transcriber.SubtitleCmd)
 	rootCmd.AddCommand(config.ConfigCmd)
+	rootCmd.AddCommand(backup.BackupCmd)
 	rootCmd.AddCommand(interactive.InteractiveCmd)
+	rootCmd.AddCommand(history.HistoryCmd)
+	rootCmd.AddCommand(history.RerunCmd)
+	rootCmd.AddCommand(usage.UsageCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(workspace.CleanCmd)
+	rootCmd.AddCommand(docs.DocsCmd)
+
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Show log messages on the terminal; -vv also streams ffmpeg and yt-dlp output")
+	rootCmd.PersistentFlags().StringVar(&ffmpegPath, "ffmpeg-path", "", "ffmpeg binary to use (overrides tools.ffmpeg_path)")
+	rootCmd.PersistentFlags().StringVar(&ytdlpPath, "ytdlp-path", "", "yt-dlp binary to use (overrides tools.ytdlp_path)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Print without colors (also set by NO_COLOR or when output is not a terminal)")
+	rootCmd.PersistentFlags().DurationVar(&connectTimeout, "connect-timeout", 0, "Longest to wait for a connection to AssemblyAI (overrides network.connect_timeout)")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 0, "Longest to wait for each AssemblyAI request other than uploads (overrides network.request_timeout)")
+	rootCmd.PersistentFlags().DurationVar(&uploadIdleTimeout, "upload-idle-timeout", 0, "Longest an upload may go without sending anything (overrides network.upload_idle_timeout)")
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug-http", false, "Log AssemblyAI requests and responses, API key redacted, to a file in ~/.sona/debug to attach to bug reports")
+
+	installCmd.Flags().BoolVar(&usePackageManager, "use-package-manager", false, "Install through the detected package manager without asking")
+	installCmd.Flags().BoolVar(&noPackageManager, "no-package-manager", false, "Always download binaries directly")
+	installCmd.Flags().StringVar(&installFromDir, "from-dir", "", "Install from binaries or release assets in a local directory")
+	installCmd.Flags().StringVar(&installMirror, "mirror", "", "Download release assets from this base URL instead of upstream")
+	installCmd.Flags().BoolVar(&uninstallDeps, "uninstall", false, "Remove dependencies installed into ~/.sona/bin")
 }
 
 var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Check system status and dependencies",
-	Long:  "Check the status of yt-dlp and FFmpeg dependencies and system configuration",
+	Use:     "status",
+	Short:   "Check system status and dependencies",
+	Long:    "Check the status of yt-dlp and FFmpeg dependencies and system configuration",
+	Example: `  sona status`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Sona System Status")
 		fmt.Println("==================")
@@ -103,7 +358,7 @@ var statusCmd = &cobra.Command{
 		if ytdlpPath, err := youtube.FindBinary("yt-dlp"); err == nil {
 			fmt.Printf("   Available at: %s\n", ytdlpPath)
 		} else {
-			fmt.Println("   Not found (run 'sona install' to install)")
+			fmt.Println("   " + notFound(err))
 		}
 
 		// Check FFmpeg
@@ -120,7 +375,13 @@ var statusCmd = &cobra.Command{
 				}
 			}
 		} else {
-			fmt.Println("   Not found (run 'sona install' to install)")
+			fmt.Println("   " + notFound(err))
+			var formats []string
+			for _, ext := range transcriber.UnconvertedFormats() {
+				formats = append(formats, strings.ToUpper(strings.TrimPrefix(ext, ".")))
+			}
+			fmt.Printf("   Without it, %s files are uploaded unconverted;\n", strings.Join(formats, ", "))
+			fmt.Println("   video, other formats, YouTube and live captions need FFmpeg")
 		}
 
 		// Check API key
@@ -154,6 +415,71 @@ var statusCmd = &cobra.Command{
 	},
 }
 
+// notFound describes why sona status cannot use a dependency, e.g. that a
+// binary sona installed has changed since
+func notFound(err error) string {
+	var missing *deps.MissingError
+	if errors.As(err, &missing) && missing.Message != "" {
+		return style.Warning("%v", err)
+	}
+	return "Not found (run 'sona install' to install)"
+}
+
+// expandAlias replaces an alias defined in the config file with the command
+// line it stands for, the way git expands aliases. Aliases may use other
+// aliases, but cannot replace built-in commands.
+func expandAlias(args []string) ([]string, error) {
+	expanded := make(map[string]bool)
+	for {
+		i := skipRootFlags(args)
+		if i == len(args) {
+			return args, nil
+		}
+		name := args[i]
+		if cmd, _, err := rootCmd.Find([]string{name}); err == nil && cmd != rootCmd {
+			return args, nil
+		}
+		expansion, ok := config.GetAlias(name)
+		if !ok {
+			return args, nil
+		}
+		if expanded[name] {
+			return nil, fmt.Errorf("alias %s expands to itself", name)
+		}
+		expanded[name] = true
+
+		rest := append(append([]string{}, expansion...), args[i+1:]...)
+		args = append(append([]string{}, args[:i]...), rest...)
+	}
+}
+
+// skipRootFlags returns the index of the first argument after the root flags
+// that lead args, passing over the value of a flag given as a separate
+// argument, e.g. --connect-timeout 5s
+func skipRootFlags(args []string) int {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		arg := args[i]
+		i++
+		if arg == "--" {
+			return len(args)
+		}
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		var flag *pflag.Flag
+		if name, ok := strings.CutPrefix(arg, "--"); ok {
+			flag = rootCmd.PersistentFlags().Lookup(name)
+		} else if len(arg) == 2 {
+			flag = rootCmd.PersistentFlags().ShorthandLookup(arg[1:])
+		}
+		if flag != nil && flag.NoOptDefVal == "" {
+			i++
+		}
+	}
+	return min(i, len(args))
+}
+
 func main() {
 	// Initialize logger
 	if err := logger.InitLogger(); err != nil {
@@ -162,8 +488,18 @@ func main() {
 	}
 	defer logger.CloseLogger()
 
+	// Remove temp files when interrupted
+	workspace.HandleSignals()
+
+	args, err := expandAlias(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, style.Error("%v", err))
+		os.Exit(1)
+	}
+	rootCmd.SetArgs(args)
+
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintln(os.Stderr, style.Error("%v", err))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSkipRootFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{[]string{"meeting", "a.mp3"}, 0},
+		{[]string{"--connect-timeout", "5s", "meeting"}, 2},
+		{[]string{"--connect-timeout=5s", "meeting"}, 1},
+		{[]string{"-vv", "meeting"}, 1},
+		{[]string{"-v", "meeting"}, 1},
+		{[]string{"--no-color", "meeting"}, 1},
+		{[]string{"--verbose", "--ffmpeg-path", "/usr/bin/ffmpeg", "meeting"}, 3},
+		{[]string{"--", "meeting"}, 2},
+		{[]string{"--connect-timeout"}, 1},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := skipRootFlags(tt.args); got != tt.want {
+			t.Errorf("skipRootFlags(%q) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestExpandAlias(t *testing.T) {
+	viper.Reset()
+	viper.Set("alias", map[string]string{
+		"meeting": "transcribe --format md",
+		"team":    "meeting --speakers-expected 4",
+		"loop":    "loop --format md",
+		"config":  "transcribe",
+	})
+	t.Cleanup(viper.Reset)
+
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: []string{"meeting", "a.mp3"}, want: "transcribe --format md a.mp3"},
+		{args: []string{"--connect-timeout", "5s", "meeting", "a.mp3"}, want: "--connect-timeout 5s transcribe --format md a.mp3"},
+		{args: []string{"--connect-timeout=5s", "meeting"}, want: "--connect-timeout=5s transcribe --format md"},
+		{args: []string{"-vv", "meeting"}, want: "-vv transcribe --format md"},
+		{args: []string{"team", "a.mp3"}, want: "transcribe --format md --speakers-expected 4 a.mp3"},
+		{args: []string{"--", "meeting"}, want: "-- meeting"},
+		{args: []string{"config", "list"}, want: "config list"},
+		{args: []string{"transcribe", "meeting"}, want: "transcribe meeting"},
+		{args: []string{"loop"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := expandAlias(slices.Clone(tt.args))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expandAlias(%q) = %q, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandAlias(%q) error = %v", tt.args, err)
+			continue
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("expandAlias(%q) = %q, want %q", tt.args, strings.Join(got, " "), tt.want)
+		}
+	}
+}
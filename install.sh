@@ -159,13 +159,13 @@ download_binary() {
     if command -v yt-dlp >/dev/null 2>&1; then
         ytdlp_found=true
     elif [ -n "$real_user" ] && [ "$real_user" != "root" ]; then
-        # Check user's bin directory
-        if [ -f "/home/$real_user/bin/yt-dlp" ]; then
+        # Check sona's bin directory and the user's bin directory
+        if [ -f "/home/$real_user/.sona/bin/yt-dlp" ] || [ -f "/home/$real_user/bin/yt-dlp" ]; then
             ytdlp_found=true
         fi
     elif [ "$real_user" = "root" ] || [ -z "$real_user" ]; then
-        # Check root's bin directory
-        if [ -f "/root/bin/yt-dlp" ]; then
+        # Check sona's bin directory and root's bin directory
+        if [ -f "/root/.sona/bin/yt-dlp" ] || [ -f "/root/bin/yt-dlp" ]; then
             ytdlp_found=true
         fi
     fi
@@ -175,13 +175,13 @@ download_binary() {
     if command -v ffmpeg >/dev/null 2>&1; then
         ffmpeg_found=true
     elif [ -n "$real_user" ] && [ "$real_user" != "root" ]; then
-        # Check user's bin directory
-        if [ -f "/home/$real_user/bin/ffmpeg" ]; then
+        # Check sona's bin directory and the user's bin directory
+        if [ -f "/home/$real_user/.sona/bin/ffmpeg" ] || [ -f "/home/$real_user/bin/ffmpeg" ]; then
             ffmpeg_found=true
         fi
     elif [ "$real_user" = "root" ] || [ -z "$real_user" ]; then
-        # Check root's bin directory
-        if [ -f "/root/bin/ffmpeg" ]; then
+        # Check sona's bin directory and root's bin directory
+        if [ -f "/root/.sona/bin/ffmpeg" ] || [ -f "/root/bin/ffmpeg" ]; then
             ffmpeg_found=true
         fi
     fi
@@ -0,0 +1,115 @@
+package assemblyai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// LimitError is a request AssemblyAI refused because of an account limit:
+// too many concurrent jobs or requests, or no remaining balance
+type LimitError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match ErrQuotaExceeded
+func (e *LimitError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// isLimitStatus reports whether a response status means an account limit
+// was hit: 429 for concurrency and rate limits, 402 for an empty balance
+func isLimitStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusPaymentRequired
+}
+
+// IsLimitError reports whether err was caused by an account limit
+func IsLimitError(err error) bool {
+	return errors.Is(err, ErrQuotaExceeded)
+}
+
+// TranscriptSummary is a transcript as listed by the transcript list
+// endpoint. Times are as sent by the API, without a time zone.
+type TranscriptSummary struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Created   string `json:"created"`
+	Completed string `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// maxListLimit is the most transcripts the list endpoint returns per page
+const maxListLimit = 200
+
+// ListTranscripts returns the account's most recent transcripts with the
+// status (all when empty), newest first, at most maxListLimit of them
+func (c *Client) ListTranscripts(status string) ([]TranscriptSummary, error) {
+	query := url.Values{"limit": {strconv.Itoa(maxListLimit)}}
+	if status != "" {
+		query.Set("status", status)
+	}
+
+	endpoint, err := c.apiURL("/v2/transcript?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcripts: %v", err)
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+		return nil, &ProviderError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("listing transcripts failed with status %d: %s", resp.StatusCode, errorMessage(resp.StatusCode, body))}
+	}
+
+	var page struct {
+		Transcripts []TranscriptSummary `json:"transcripts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode transcript list: %v", err)
+	}
+	return page.Transcripts, nil
+}
+
+// DeleteTranscript deletes a transcript and its uploaded audio from the
+// account. AssemblyAI keeps only a stub of the job afterwards.
+func (c *Client) DeleteTranscript(transcriptID string) error {
+	endpoint, err := c.apiURL("/v2/transcript/" + url.PathEscape(transcriptID))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete transcript: %v", err)
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+		return &ProviderError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("deleting transcript %s failed with status %d: %s", transcriptID, resp.StatusCode, errorMessage(resp.StatusCode, body))}
+	}
+	return nil
+}
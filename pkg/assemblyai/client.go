@@ -1,19 +1,43 @@
 package assemblyai
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"time"
 )
 
 type TranscriptionRequest struct {
-	AudioURL    string `json:"audio_url"`
-	SpeechModel string `json:"speech_model"`
+	AudioURL     string `json:"audio_url"`
+	SpeechModel  string `json:"speech_model"`
+	LanguageCode string `json:"language_code,omitempty"`
+	// LanguageDetection identifies the spoken language when LanguageCode is empty
+	LanguageDetection bool `json:"language_detection,omitempty"`
+	// Disfluencies keeps filler words such as "um" and "uh"
+	Disfluencies bool `json:"disfluencies,omitempty"`
+	// FormatText controls casing and number formatting; nil uses the API default (on)
+	FormatText *bool `json:"format_text,omitempty"`
+	// SpeakerLabels returns utterances attributed to speakers
+	SpeakerLabels bool `json:"speaker_labels,omitempty"`
+	// SpeakersExpected tells diarization how many speakers to tell apart; 0 lets it decide
+	SpeakersExpected int `json:"speakers_expected,omitempty"`
+	// AutoChapters splits the audio into chapters with a headline and summary each
+	AutoChapters bool `json:"auto_chapters,omitempty"`
+	// AutoHighlights returns the key phrases of the audio
+	AutoHighlights bool `json:"auto_highlights,omitempty"`
+	// EntityDetection returns named entities such as people and organizations
+	EntityDetection bool `json:"entity_detection,omitempty"`
+	// AudioStartFrom and AudioEndAt limit transcription to part of the audio, in milliseconds
+	AudioStartFrom int64 `json:"audio_start_from,omitempty"`
+	AudioEndAt     int64 `json:"audio_end_at,omitempty"`
+	// SpeechThreshold rejects audio in which less than this share (0-1) is speech
+	SpeechThreshold float64 `json:"speech_threshold,omitempty"`
 }
 
 type TranscriptionResponse struct {
@@ -27,98 +51,330 @@ type TranscriptResult struct {
 	Status string `json:"status"`
 	Text   string `json:"text"`
 	Error  string `json:"error,omitempty"`
+	// Confidence is how confident the model is in the whole transcript (0-1)
+	Confidence float64 `json:"confidence,omitempty"`
+	// LanguageCode is the detected language when language detection was requested
+	LanguageCode       string      `json:"language_code,omitempty"`
+	LanguageConfidence float64     `json:"language_confidence,omitempty"`
+	Utterances         []Utterance `json:"utterances,omitempty"`
+	Words              []Word      `json:"words,omitempty"`
+	// AudioDuration is the length of the audio in seconds
+	AudioDuration float64 `json:"audio_duration,omitempty"`
+	// Chapters, Highlights and Entities are only set when requested
+	Chapters   []Chapter         `json:"chapters,omitempty"`
+	Highlights *HighlightsResult `json:"auto_highlights_result,omitempty"`
+	Entities   []Entity          `json:"entities,omitempty"`
+	// APIKey is the key whose account ran the transcript. It is set by
+	// the client, not sent by the API.
+	APIKey string `json:"-"`
+	// Region is where the transcript was made and is stored. It is set by
+	// the client, not sent by the API.
+	Region string `json:"-"`
+	// LocalSeconds is audio transcribed on this machine instead, which
+	// AssemblyAI does not bill
+	LocalSeconds float64 `json:"-"`
 }
 
+// Chapter is a stretch of the audio on one topic. Start and End are in milliseconds.
+type Chapter struct {
+	Gist     string `json:"gist"`
+	Headline string `json:"headline"`
+	Summary  string `json:"summary"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+}
+
+// HighlightsResult holds the key phrases found by auto highlights
+type HighlightsResult struct {
+	Status  string      `json:"status"`
+	Results []Highlight `json:"results"`
+}
+
+// Highlight is a key phrase with how often it occurs and its relevance (0-1)
+type Highlight struct {
+	Text  string  `json:"text"`
+	Count int     `json:"count"`
+	Rank  float64 `json:"rank"`
+}
+
+// Entity is a named entity mentioned in the audio. Start and End are in milliseconds.
+type Entity struct {
+	EntityType string `json:"entity_type"`
+	Text       string `json:"text"`
+	Start      int64  `json:"start"`
+	End        int64  `json:"end"`
+}
+
+// Word is a single recognized word. Start and End are in milliseconds.
+type Word struct {
+	Text       string  `json:"text"`
+	Start      int64   `json:"start"`
+	End        int64   `json:"end"`
+	Confidence float64 `json:"confidence"`
+	Speaker    string  `json:"speaker,omitempty"`
+}
+
+// Utterance is a stretch of speech by one speaker. Start and End are in milliseconds.
+type Utterance struct {
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+	Start   int64  `json:"start"`
+	End     int64  `json:"end"`
+}
+
+// Transcription phases reported through Client.Progress
+const (
+	PhaseUploading  = "uploading"
+	PhaseQueued     = "queued"
+	PhaseProcessing = "processing"
+	PhaseCompleted  = "completed"
+)
+
+// ProgressFunc receives the current phase of a transcription job
+type ProgressFunc func(phase string)
+
 // Client represents an AssemblyAI client
 type Client struct {
-	APIKey     string
-	HTTPClient *http.Client
+	APIKey string
+	// FallbackKeys are tried in order when the account of APIKey hits a
+	// limit or the key is rejected. APIKey is then replaced by the key in
+	// use, so after a job it names the key that served it.
+	FallbackKeys []string
+	HTTPClient   *http.Client
+	// Progress is called whenever the job moves to a new phase.
+	// When nil, the client prints simple status lines instead.
+	Progress ProgressFunc
+	// Polling controls how often the transcript status is checked
+	Polling PollPolicy
+	// Region is where audio is processed and stored, RegionUS or RegionEU;
+	// empty means the US
+	Region string
+	// transport is what HTTPClient and uploads connect with
+	transport TransportOptions
 }
 
 // NewClient creates a new AssemblyAI client
 func NewClient(apiKey string) *Client {
+	transport := DefaultTransportOptions()
 	return &Client{
 		APIKey: apiKey,
 		HTTPClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   transport.RequestTimeout,
+			Transport: roundTripper(transport),
 		},
+		transport: transport,
 	}
 }
 
-// TranscribeAudio transcribes an audio file using AssemblyAI
-func (c *Client) TranscribeAudio(audioPath string, speechModel string) (string, error) {
-	fmt.Println("Starting transcription...")
+// Reachable reports whether the AssemblyAI API of region can be contacted.
+// Any HTTP response counts, since the request is unauthenticated. The check
+// goes over the shared transport for options, so given the options of the
+// client that runs the job, the connection it opens is reused by the job.
+func Reachable(region string, options TransportOptions, timeout time.Duration) bool {
+	api, _, err := hosts(region)
+	if err != nil {
+		return false
+	}
+	httpClient := &http.Client{Timeout: timeout, Transport: roundTripper(options)}
+	resp, err := httpClient.Head(api + "/v2/transcript")
+	if err != nil {
+		return false
+	}
+	closeBody(resp.Body)
+	return true
+}
+
+// TranscribeAudio transcribes an audio file using AssemblyAI.
+// The request's AudioURL is filled in by the client after uploading the file.
+// Uploads belong to an account, so the file is uploaded again when the job
+// moves on to a fallback key.
+func (c *Client) TranscribeAudio(audioPath string, request TranscriptionRequest) (*TranscriptResult, error) {
+	return c.withKeys(request, func() (*TranscriptResult, error) {
+		return c.transcribeAudio(audioPath, request)
+	})
+}
+
+// TranscribeURL transcribes audio that AssemblyAI can download itself, such
+// as a podcast enclosure or a presigned S3 URL, so nothing is uploaded
+func (c *Client) TranscribeURL(audioURL string, request TranscriptionRequest) (*TranscriptResult, error) {
+	request.AudioURL = audioURL
+	return c.withKeys(request, func() (*TranscriptResult, error) {
+		return c.transcribe(request)
+	})
+}
+
+// withKeys runs a job with the current key and then each fallback key the
+// previous one could not serve, and notes the key and region of the result
+func (c *Client) withKeys(request TranscriptionRequest, job func() (*TranscriptResult, error)) (*TranscriptResult, error) {
+	if err := CheckModel(c.Region, request.SpeechModel); err != nil {
+		return nil, err
+	}
+	for {
+		result, err := job()
+		if err != nil && c.rotateKey(err) {
+			continue
+		}
+		if result != nil {
+			result.APIKey = c.APIKey
+			result.Region = normalizeRegion(c.Region)
+		}
+		return result, err
+	}
+}
+
+// transcribeAudio uploads, submits and polls one job with the current key
+func (c *Client) transcribeAudio(audioPath string, request TranscriptionRequest) (*TranscriptResult, error) {
+	c.reportProgress(PhaseUploading)
 
 	// First, upload the audio file
 	uploadURL, err := c.uploadAudioFile(audioPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to upload audio file: %v", err)
+		return nil, fmt.Errorf("failed to upload audio file: %w", err)
 	}
 
-	// Submit transcription request
-	transcriptID, err := c.submitTranscription(uploadURL, speechModel)
+	request.AudioURL = uploadURL
+	return c.transcribe(request)
+}
+
+// transcribe submits and polls one job for the request's AudioURL with the
+// current key
+func (c *Client) transcribe(request TranscriptionRequest) (*TranscriptResult, error) {
+	transcriptID, err := c.submitTranscription(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to submit transcription: %v", err)
+		return nil, fmt.Errorf("failed to submit transcription: %w", err)
 	}
 
-	fmt.Println("Processing audio...")
+	c.reportProgress(PhaseQueued)
 
 	// Poll for completion
 	transcript, err := c.pollTranscription(transcriptID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get transcription: %v", err)
+		return nil, fmt.Errorf("failed to get transcription: %w", err)
 	}
 
 	if transcript.Status == "error" {
-		return "", fmt.Errorf("transcription failed: %s", transcript.Error)
+		return nil, &ProviderError{Message: "transcription failed: " + transcript.Error}
 	}
 
-	return transcript.Text, nil
+	c.reportProgress(PhaseCompleted)
+	return transcript, nil
 }
 
-// uploadAudioFile uploads an audio file to AssemblyAI and returns the upload URL
+// reportProgress forwards the phase to the Progress callback, falling back to plain output
+func (c *Client) reportProgress(phase string) {
+	if c.Progress != nil {
+		c.Progress(phase)
+		return
+	}
+
+	switch phase {
+	case PhaseUploading:
+		fmt.Println("Starting transcription...")
+	case PhaseQueued:
+		fmt.Println("Processing audio...")
+	}
+}
+
+// Upload transport tuning. The upload endpoint takes the whole file as one
+// request body, so throughput comes from a single well-fed connection.
+const (
+	// uploadBufferSize keeps fast links busy instead of writing 4 KB at a time
+	uploadBufferSize = 1 << 20
+	// uploadResponseTimeout bounds the wait for a response once the body is sent
+	uploadResponseTimeout = 5 * time.Minute
+)
+
+// errUploadStalled cancels an upload that stopped moving
+var errUploadStalled = errors.New("upload stalled")
+
+// uploadAudioFile uploads an audio file to AssemblyAI and returns the upload URL.
+// With HTTP/2 on it is tried first, falling back to a plain HTTP/1.1
+// connection, since some proxies mishandle large HTTP/2 uploads.
 func (c *Client) uploadAudioFile(audioPath string) (string, error) {
+	if !c.transport.HTTP2 {
+		return c.upload(audioPath, c.uploadClient(false))
+	}
+	uploadURL, err := c.upload(audioPath, c.uploadClient(true))
+	if err == nil {
+		return uploadURL, nil
+	}
+	if rejected, ok := err.(uploadRejectedError); ok && rejected.status < http.StatusInternalServerError {
+		return "", err
+	}
+
+	uploadURL, fallbackErr := c.upload(audioPath, c.uploadClient(false))
+	if fallbackErr != nil {
+		return "", fmt.Errorf("%w (retry over HTTP/1.1: %w)", err, fallbackErr)
+	}
+	return uploadURL, nil
+}
+
+// uploadRejectedError is an error response to the upload. Client errors
+// such as a bad API key are not retried.
+type uploadRejectedError struct {
+	status  int
+	message string
+}
+
+func (e uploadRejectedError) Error() string {
+	return fmt.Sprintf("upload failed with status %d: %s", e.status, e.message)
+}
+
+// Is lets errors.Is match ErrQuotaExceeded for account limits and
+// ErrProvider for other rejections
+func (e uploadRejectedError) Is(target error) bool {
+	if isLimitStatus(e.status) {
+		return target == ErrQuotaExceeded
+	}
+	return target == ErrProvider
+}
+
+// upload streams the file as the raw request body
+func (c *Client) upload(audioPath string, httpClient *http.Client) (string, error) {
 	file, err := os.Open(audioPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to open audio file: %v", err)
 	}
 	defer file.Close()
 
-	// Create multipart form
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	part, err := writer.CreateFormFile("file", "audio.mp3")
+	info, err := file.Stat()
 	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %v", err)
+		return "", fmt.Errorf("failed to read audio file: %v", err)
 	}
 
-	_, err = io.Copy(part, file)
+	endpoint, err := c.apiURL("/v2/upload")
 	if err != nil {
-		return "", fmt.Errorf("failed to copy file data: %v", err)
+		return "", err
 	}
 
-	writer.Close()
+	// Without an overall deadline, a hung connection is only noticed by the
+	// upload no longer moving
+	options := c.transport.withDefaults()
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	body := newStallReader(bufio.NewReaderSize(file, options.WriteBufferSize), options.UploadIdleTimeout, func() { cancel(errUploadStalled) })
+	defer body.stop()
 
-	// Create request
-	req, err := http.NewRequest("POST", "https://api.assemblyai.com/v2/upload", &buf)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, body)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %v", err)
 	}
-
+	req.ContentLength = info.Size()
 	req.Header.Set("Authorization", c.APIKey)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", "application/octet-stream")
 
-	// Make request
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
+		if errors.Is(context.Cause(ctx), errUploadStalled) {
+			return "", fmt.Errorf("upload stalled: nothing was sent for %s (raise network.upload_idle_timeout on unsteady connections)", options.UploadIdleTimeout)
+		}
 		return "", fmt.Errorf("failed to make upload request: %v", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+		return "", uploadRejectedError{status: resp.StatusCode, message: errorMessage(resp.StatusCode, body)}
 	}
 
 	// Parse response
@@ -134,18 +390,17 @@ func (c *Client) uploadAudioFile(audioPath string) (string, error) {
 }
 
 // submitTranscription submits a transcription request to AssemblyAI
-func (c *Client) submitTranscription(audioURL string, speechModel string) (string, error) {
-	request := TranscriptionRequest{
-		AudioURL:    audioURL,
-		SpeechModel: speechModel,
-	}
-
+func (c *Client) submitTranscription(request TranscriptionRequest) (string, error) {
 	jsonData, err := json.Marshal(request)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.assemblyai.com/v2/transcript", bytes.NewBuffer(jsonData))
+	endpoint, err := c.apiURL("/v2/transcript")
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %v", err)
 	}
@@ -157,11 +412,15 @@ func (c *Client) submitTranscription(audioURL string, speechModel string) (strin
 	if err != nil {
 		return "", fmt.Errorf("failed to submit transcription: %v", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("transcription submission failed with status %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+		message := fmt.Sprintf("transcription submission failed with status %d: %s", resp.StatusCode, errorMessage(resp.StatusCode, body))
+		if isLimitStatus(resp.StatusCode) {
+			return "", &LimitError{StatusCode: resp.StatusCode, Message: message}
+		}
+		return "", &ProviderError{StatusCode: resp.StatusCode, Message: message}
 	}
 
 	var transcriptResp TranscriptionResponse
@@ -172,12 +431,20 @@ func (c *Client) submitTranscription(audioURL string, speechModel string) (strin
 	return transcriptResp.ID, nil
 }
 
-// pollTranscription polls the transcription status until completion
+// pollTranscription polls the transcription status until completion,
+// spacing requests according to the client's PollPolicy
 func (c *Client) pollTranscription(transcriptID string) (*TranscriptResult, error) {
-	const maxAttempts = 100 // Maximum polling attempts (5 minutes at 3s intervals)
+	policy := c.Polling.withDefaults()
+	timeout := policy.timeout()
+	started := time.Now()
+	var interval time.Duration
+	endpoint, err := c.apiURL("/v2/transcript/" + transcriptID)
+	if err != nil {
+		return nil, err
+	}
 
-	for attempts := 0; attempts < maxAttempts; attempts++ {
-		req, err := http.NewRequest("GET", fmt.Sprintf("https://api.assemblyai.com/v2/transcript/%s", transcriptID), nil)
+	for polls := 1; ; polls++ {
+		req, err := http.NewRequest("GET", endpoint, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create polling request: %v", err)
 		}
@@ -192,31 +459,40 @@ func (c *Client) pollTranscription(transcriptID string) (*TranscriptResult, erro
 		// Read response body properly
 		var result TranscriptResult
 		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(resp.Body)
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
 			resp.Body.Close()
-			return nil, fmt.Errorf("polling failed with status %d: %s", resp.StatusCode, string(body))
+			message := fmt.Sprintf("polling failed with status %d: %s", resp.StatusCode, errorMessage(resp.StatusCode, body))
+			return nil, &ProviderError{StatusCode: resp.StatusCode, Message: message}
 		}
 
-		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		if err := decodeTranscript(resp.Body, &result); err != nil {
 			resp.Body.Close()
 			return nil, fmt.Errorf("failed to decode polling response: %v", err)
 		}
-		resp.Body.Close()
+		// Reading to the end lets the connection be reused for the next poll
+		closeBody(resp.Body)
 
 		switch result.Status {
-		case "completed":
-			return &result, nil
-		case "error":
+		case "completed", "error":
 			return &result, nil
 		case "queued", "processing", "":
-			// Continue polling
-			time.Sleep(3 * time.Second)
+			if result.Status == PhaseProcessing {
+				c.reportProgress(PhaseProcessing)
+			}
 		default:
-			// Unknown status - log and continue with limited attempts
+			// Unknown status - keep polling until the timeout
 			fmt.Printf("Warning: Unknown transcription status '%s', continuing...\n", result.Status)
-			time.Sleep(3 * time.Second)
 		}
-	}
 
-	return nil, fmt.Errorf("transcription polling timed out after %d attempts", maxAttempts)
+		elapsed := time.Since(started)
+		if elapsed >= timeout {
+			return nil, fmt.Errorf("transcription polling timed out after %s (%d requests); raise polling.timeout for long recordings", timeout.Round(time.Second), polls)
+		}
+
+		interval = policy.next(elapsed, interval)
+		if remaining := timeout - elapsed; interval > remaining {
+			interval = remaining
+		}
+		time.Sleep(interval)
+	}
 }
@@ -0,0 +1,146 @@
+package assemblyai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDebugBody is how much of each request and response body the HTTP
+// debug log keeps; transcripts and word lists run far longer
+const maxDebugBody = 4 * 1024
+
+// redactedHeaders carry credentials and are never written to the debug log
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+var (
+	debugMu  sync.Mutex
+	debugLog io.Writer
+)
+
+// SetDebugLog writes every request to the API and its response to w, with
+// credentials redacted and bodies truncated, for reports of API problems.
+// It must be called before the first client is created; nil turns it off.
+func SetDebugLog(w io.Writer) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	debugLog = w
+}
+
+// roundTripper returns the shared transport for options, logging through
+// it when a debug log is set
+func roundTripper(options TransportOptions) http.RoundTripper {
+	transport := sharedTransport(options)
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugLog == nil {
+		return transport
+	}
+	return &debugTransport{next: transport}
+}
+
+// debugTransport writes each exchange to the debug log once the response
+// headers and the start of its body have arrived
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var b bytes.Buffer
+	start := time.Now()
+	fmt.Fprintf(&b, "=== %s %s %s\n", start.Format("15:04:05.000"), req.Method, req.URL.Redacted())
+	writeHeaders(&b, req.Header)
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body = debugBody(&b, req.Body, req.Header.Get("Content-Type"), req.ContentLength)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Fprintf(&b, "--- failed after %v: %v\n\n", elapsed, err)
+		writeDebug(b.Bytes(), req.Header.Get("Authorization"))
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "--- %s %s after %v\n", resp.Proto, resp.Status, elapsed)
+	writeHeaders(&b, resp.Header)
+	resp.Body = debugBody(&b, resp.Body, resp.Header.Get("Content-Type"), resp.ContentLength)
+	b.WriteString("\n")
+	writeDebug(b.Bytes(), req.Header.Get("Authorization"))
+	return resp, nil
+}
+
+// writeHeaders writes headers in a stable order, credentials redacted
+func writeHeaders(b *bytes.Buffer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[redacted]"
+		}
+		fmt.Fprintf(b, "%s: %s\n", name, value)
+	}
+}
+
+// debugBody writes the start of a text body to b and returns a body that
+// still yields all of it. Audio is only described: uploads would otherwise
+// be read into memory here, and their bytes are of no use in a report.
+func debugBody(b *bytes.Buffer, body io.ReadCloser, contentType string, length int64) io.ReadCloser {
+	if !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "text/") {
+		if length >= 0 {
+			fmt.Fprintf(b, "[%d bytes of %s]\n", length, contentType)
+		} else {
+			fmt.Fprintf(b, "[%s body]\n", contentType)
+		}
+		return body
+	}
+
+	head, err := io.ReadAll(io.LimitReader(body, maxDebugBody+1))
+	shown := head
+	if len(shown) > maxDebugBody {
+		shown = shown[:maxDebugBody]
+	}
+	b.Write(shown)
+	if len(head) > maxDebugBody {
+		if length >= 0 {
+			fmt.Fprintf(b, "\n[truncated, %d bytes in all]", length)
+		} else {
+			b.WriteString("\n[truncated]")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(b, "\n[reading the body failed: %v]", err)
+	}
+	b.WriteString("\n")
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), body), body}
+}
+
+// writeDebug appends an exchange to the debug log, removing the API key
+// wherever it was echoed, e.g. in an error message
+func writeDebug(entry []byte, apiKey string) {
+	if apiKey != "" {
+		entry = bytes.ReplaceAll(entry, []byte(apiKey), []byte("[redacted]"))
+	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if debugLog != nil {
+		debugLog.Write(entry)
+	}
+}
@@ -0,0 +1,86 @@
+package assemblyai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// decodeTranscript reads a transcript response as it arrives. json.Decoder
+// holds a whole value in memory before decoding it, which for a multi-hour
+// transcript means tens of MB of JSON next to the decoded result, so the
+// word and utterance lists that make up most of it are decoded one entry at
+// a time. The transport asks for and unpacks gzip, so r is plain JSON.
+func decodeTranscript(r io.Reader, result *TranscriptResult) error {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	// The remaining fields are small and decoded together at the end
+	rest := make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "words":
+			result.Words, err = decodeList[Word](decoder)
+		case "utterances":
+			result.Utterances, err = decodeList[Utterance](decoder)
+		default:
+			var value json.RawMessage
+			err = decoder.Decode(&value)
+			rest[key] = value
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// decodeList decodes a JSON array one element at a time; null is an empty list
+func decodeList[T any](decoder *json.Decoder) ([]T, error) {
+	token, err := decoder.Token()
+	if err != nil || token == nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("expected a list, got %v", token)
+	}
+
+	var items []T
+	for decoder.More() {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	_, err = decoder.Token()
+	return items, err
+}
+
+// expectDelim reads the next token, which must be the delimiter
+func expectDelim(decoder *json.Decoder, want json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %v, got %v", want, token)
+	}
+	return nil
+}
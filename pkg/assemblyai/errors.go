@@ -0,0 +1,135 @@
+package assemblyai
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Causes of failure that callers can test for with errors.Is
+var (
+	// ErrQuotaExceeded means AssemblyAI refused the request because of an
+	// account limit; see LimitError
+	ErrQuotaExceeded = errors.New("account limit reached")
+	// ErrProvider means AssemblyAI rejected the request or failed the
+	// transcript; see ProviderError
+	ErrProvider = errors.New("transcription provider error")
+)
+
+// Causes of API errors, as told apart by Cause
+const (
+	// CauseAuthentication means the API key is missing, wrong or revoked
+	CauseAuthentication = "authentication"
+	// CauseInsufficientFunds means the account has no balance left
+	CauseInsufficientFunds = "insufficient_funds"
+	// CauseRateLimit means the account sent too many requests at once
+	CauseRateLimit = "rate_limit"
+	// CauseUnsupportedFile means the file is not audio or video AssemblyAI can decode
+	CauseUnsupportedFile = "unsupported_file"
+	// CauseInvalidAudio means the file decodes but its audio cannot be
+	// transcribed, e.g. because it is too short or has no audio stream
+	CauseInvalidAudio = "invalid_audio"
+	// CauseInvalidRequest means an option is not available for the model,
+	// language or plan
+	CauseInvalidRequest = "invalid_request"
+	// CauseUnavailable means AssemblyAI failed on its side
+	CauseUnavailable = "unavailable"
+)
+
+// maxErrorBody bounds how much of a response that is not JSON, such as the
+// HTML page of a proxy, ends up in an error message
+const maxErrorBody = 300
+
+// ProviderError is an error response from AssemblyAI, or a transcript that
+// failed after it was accepted
+type ProviderError struct {
+	// StatusCode is the HTTP status, or 0 for a failed transcript
+	StatusCode int
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match ErrProvider
+func (e *ProviderError) Is(target error) bool {
+	return target == ErrProvider
+}
+
+// errorMessage returns what an error response says went wrong: the API
+// sends {"error": "..."}, anything else is shown as it came
+func errorMessage(status int, body []byte) string {
+	var response struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &response) == nil && response.Error != "" {
+		return response.Error
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return http.StatusText(status)
+	}
+	if len(message) > maxErrorBody {
+		message = message[:maxErrorBody] + "..."
+	}
+	return message
+}
+
+// causeKeywords pick out the cause from the wording of the API's messages,
+// checked in order. Failed transcripts carry no status code at all.
+var causeKeywords = []struct {
+	cause    string
+	keywords []string
+}{
+	{CauseAuthentication, []string{"invalid api key", "api key is invalid", "authentication error", "api token"}},
+	{CauseInsufficientFunds, []string{"balance", "insufficient funds", "top up", "add funds", "payment"}},
+	{CauseRateLimit, []string{"rate limit", "too many requests", "concurrency limit"}},
+	{CauseUnsupportedFile, []string{"file type", "unsupported file", "not a supported", "does not appear to contain audio", "transcoding failed", "could not process file", "unsupported media"}},
+	{CauseInvalidAudio, []string{"audio duration", "too short", "too long", "no audio", "corrupt", "invalid audio", "could not decode"}},
+}
+
+// Cause tells why AssemblyAI rejected a request or failed a transcript, as
+// one of the Cause constants, or "" when err did not come from the API
+func Cause(err error) string {
+	var status int
+	var message string
+	var limit *LimitError
+	var provider *ProviderError
+	var rejected uploadRejectedError
+	switch {
+	case errors.As(err, &limit):
+		status, message = limit.StatusCode, limit.Message
+	case errors.As(err, &provider):
+		status, message = provider.StatusCode, provider.Message
+	case errors.As(err, &rejected):
+		status, message = rejected.status, rejected.message
+	default:
+		return ""
+	}
+
+	switch status {
+	case http.StatusUnauthorized:
+		return CauseAuthentication
+	case http.StatusPaymentRequired:
+		return CauseInsufficientFunds
+	case http.StatusTooManyRequests:
+		return CauseRateLimit
+	}
+	message = strings.ToLower(message)
+	for _, entry := range causeKeywords {
+		for _, keyword := range entry.keywords {
+			if strings.Contains(message, keyword) {
+				return entry.cause
+			}
+		}
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		return CauseUnavailable
+	case status >= http.StatusBadRequest:
+		return CauseInvalidRequest
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package assemblyai
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// keyExhausted reports whether another API key may succeed where the
+// current one failed: its account hit a limit or the key was rejected
+func keyExhausted(err error) bool {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return true
+	}
+	var provider *ProviderError
+	if errors.As(err, &provider) && provider.StatusCode == http.StatusUnauthorized {
+		return true
+	}
+	var rejected uploadRejectedError
+	return errors.As(err, &rejected) && rejected.status == http.StatusUnauthorized
+}
+
+// rotateKey moves on to the next of FallbackKeys after err, and reports
+// whether there was one left
+func (c *Client) rotateKey(err error) bool {
+	if len(c.FallbackKeys) == 0 || !keyExhausted(err) {
+		return false
+	}
+	fmt.Printf("Warning: %v; retrying with the next API key\n", err)
+	c.APIKey, c.FallbackKeys = c.FallbackKeys[0], c.FallbackKeys[1:]
+	return true
+}
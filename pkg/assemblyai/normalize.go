@@ -0,0 +1,40 @@
+package assemblyai
+
+import "github.com/Harsh-2002/Sona/pkg/transcript"
+
+// Normalize converts the API's result into sona's transcript model
+func (r *TranscriptResult) Normalize() *transcript.Transcript {
+	t := &transcript.Transcript{
+		ID:                 r.ID,
+		Text:               r.Text,
+		Confidence:         r.Confidence,
+		LanguageCode:       r.LanguageCode,
+		LanguageConfidence: r.LanguageConfidence,
+		Duration:           r.AudioDuration,
+		APIKey:             r.APIKey,
+		Region:             r.Region,
+		LocalSeconds:       r.LocalSeconds,
+	}
+	// Words are most of a long transcript; sized up front, they are copied once
+	if len(r.Words) > 0 {
+		t.Words = make([]transcript.Word, len(r.Words))
+		for i, w := range r.Words {
+			t.Words[i] = transcript.Word(w)
+		}
+	}
+	for _, u := range r.Utterances {
+		t.Segments = append(t.Segments, transcript.Segment(u))
+	}
+	for _, c := range r.Chapters {
+		t.Chapters = append(t.Chapters, transcript.Chapter(c))
+	}
+	if r.Highlights != nil {
+		for _, h := range r.Highlights.Results {
+			t.Highlights = append(t.Highlights, transcript.Highlight(h))
+		}
+	}
+	for _, e := range r.Entities {
+		t.Entities = append(t.Entities, transcript.Entity(e))
+	}
+	return t
+}
@@ -0,0 +1,72 @@
+package assemblyai
+
+import "time"
+
+// Polling defaults used when a PollPolicy leaves a field unset
+const (
+	DefaultMinPollInterval = 2 * time.Second
+	DefaultMaxPollInterval = 30 * time.Second
+	// minimumPollTimeout is the shortest time sona waits for any job
+	minimumPollTimeout = 30 * time.Minute
+	// backoffFactor grows the interval once a job runs past its estimate
+	backoffFactor = 1.5
+)
+
+// PollPolicy controls how often a transcript's status is checked. Long jobs
+// are polled rarely at first and more often as the expected completion nears.
+type PollPolicy struct {
+	// Expected is the estimated processing time, 0 if unknown
+	Expected time.Duration
+	// MinInterval and MaxInterval bound the wait between polls
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// Timeout is the longest to wait overall; 0 derives it from Expected
+	Timeout time.Duration
+}
+
+// withDefaults fills in unset bounds
+func (p PollPolicy) withDefaults() PollPolicy {
+	if p.MinInterval <= 0 {
+		p.MinInterval = DefaultMinPollInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = DefaultMaxPollInterval
+	}
+	if p.MaxInterval < p.MinInterval {
+		p.MaxInterval = p.MinInterval
+	}
+	return p
+}
+
+// timeout returns how long to keep polling before giving up
+func (p PollPolicy) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	if limit := 3 * p.Expected; limit > minimumPollTimeout {
+		return limit
+	}
+	return minimumPollTimeout
+}
+
+// next returns the wait before the following poll. Before the estimate it
+// sleeps half of the remaining time; afterwards it backs off gradually.
+func (p PollPolicy) next(elapsed time.Duration, previous time.Duration) time.Duration {
+	var interval time.Duration
+	if remaining := p.Expected - elapsed; p.Expected > 0 && remaining > 0 {
+		interval = remaining / 2
+	} else if previous > 0 {
+		interval = time.Duration(float64(previous) * backoffFactor)
+	}
+	return p.clamp(interval)
+}
+
+func (p PollPolicy) clamp(interval time.Duration) time.Duration {
+	if interval < p.MinInterval {
+		return p.MinInterval
+	}
+	if interval > p.MaxInterval {
+		return p.MaxInterval
+	}
+	return interval
+}
@@ -0,0 +1,74 @@
+package assemblyai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Regions in which AssemblyAI processes and stores audio and transcripts
+const (
+	RegionUS = "us"
+	RegionEU = "eu"
+)
+
+// regionHosts are the API and streaming hosts of each region
+var regionHosts = map[string]struct{ api, streaming string }{
+	RegionUS: {"https://api.assemblyai.com", "wss://streaming.assemblyai.com"},
+	RegionEU: {"https://api.eu.assemblyai.com", "wss://streaming.eu.assemblyai.com"},
+}
+
+// regionModels lists the speech models of regions that do not offer all of
+// them; a region without an entry offers every model
+var regionModels = map[string][]string{
+	RegionEU: {"best", "nano", "universal"},
+}
+
+// normalizeRegion returns the region's canonical name, with the US as the
+// default
+func normalizeRegion(region string) string {
+	if region == "" {
+		return RegionUS
+	}
+	return strings.ToLower(region)
+}
+
+// CheckRegion returns an error when region is not an AssemblyAI region
+func CheckRegion(region string) error {
+	if _, ok := regionHosts[normalizeRegion(region)]; !ok {
+		return fmt.Errorf("unknown AssemblyAI region %q (use us or eu)", region)
+	}
+	return nil
+}
+
+// CheckModel returns an error when the speech model cannot be used in region
+func CheckModel(region string, model string) error {
+	region = normalizeRegion(region)
+	models, limited := regionModels[region]
+	if !limited || model == "" {
+		return nil
+	}
+	for _, available := range models {
+		if strings.EqualFold(model, available) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the %s model is not available in the AssemblyAI %s region; use one of %s", model, strings.ToUpper(region), strings.Join(models, ", "))
+}
+
+// hosts returns the hosts of region. Unknown regions are refused by
+// CheckRegion before anything is sent, so they never fall back to another
+// region's hosts.
+func hosts(region string) (api string, streaming string, err error) {
+	if err := CheckRegion(region); err != nil {
+		return "", "", err
+	}
+	h := regionHosts[normalizeRegion(region)]
+	return h.api, h.streaming, nil
+}
+
+// apiURL returns the URL of an API path, e.g. /v2/upload, in the client's
+// region
+func (c *Client) apiURL(path string) (string, error) {
+	api, _, err := hosts(c.Region)
+	return api + path, err
+}
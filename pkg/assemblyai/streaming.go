@@ -0,0 +1,146 @@
+package assemblyai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// StreamingSampleRate is the PCM sample rate sona streams audio at
+const StreamingSampleRate = 16000
+
+// streamingChunkBytes is 100ms of 16 kHz mono 16-bit audio
+const streamingChunkBytes = StreamingSampleRate * 2 / 10
+
+// StreamingOptions configures a realtime session
+type StreamingOptions struct {
+	// SpeechModel selects the streaming model; empty uses the English default
+	SpeechModel string
+}
+
+// Turn is a partial or final hypothesis for one speaker turn
+type Turn struct {
+	Order      int    `json:"turn_order"`
+	Transcript string `json:"transcript"`
+	EndOfTurn  bool   `json:"end_of_turn"`
+	Formatted  bool   `json:"turn_is_formatted"`
+}
+
+// IsFinal reports whether the turn will not change anymore
+func (t Turn) IsFinal() bool {
+	return t.EndOfTurn && t.Formatted
+}
+
+// TurnFunc receives every partial and final turn as it arrives
+type TurnFunc func(turn Turn)
+
+type streamingMessage struct {
+	Type  string `json:"type"`
+	Error string `json:"error,omitempty"`
+	Turn
+}
+
+// Stream sends 16 kHz mono 16-bit little-endian PCM from audio to the
+// realtime API and reports turns as they are recognized. It returns once
+// audio is exhausted and the server has finalized the session.
+func (c *Client) Stream(audio io.Reader, opts StreamingOptions, onTurn TurnFunc) error {
+	query := url.Values{}
+	query.Set("sample_rate", strconv.Itoa(StreamingSampleRate))
+	query.Set("encoding", "pcm_s16le")
+	query.Set("format_turns", "true")
+	if opts.SpeechModel != "" {
+		query.Set("speech_model", opts.SpeechModel)
+	}
+
+	_, streamingHost, err := hosts(c.Region)
+	if err != nil {
+		return err
+	}
+
+	var conn *wsConn
+	for {
+		header := http.Header{}
+		header.Set("Authorization", c.APIKey)
+
+		var err error
+		conn, err = dialWebSocket(streamingHost+"/v3/ws?"+query.Encode(), header, c.transport.withDefaults().ConnectTimeout)
+		if err == nil {
+			break
+		}
+		if !c.rotateKey(err) {
+			return fmt.Errorf("failed to open streaming session: %w", err)
+		}
+	}
+	defer conn.Close()
+
+	// Receive turns while audio is being sent
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveTurns(conn, onTurn)
+	}()
+
+	sendErr := sendAudio(conn, audio)
+	if sendErr == nil {
+		terminate, _ := json.Marshal(map[string]string{"type": "Terminate"})
+		sendErr = conn.WriteMessage(opText, terminate)
+	}
+	if sendErr != nil {
+		conn.Close()
+		<-done
+		return sendErr
+	}
+
+	return <-done
+}
+
+func sendAudio(conn *wsConn, audio io.Reader) error {
+	buf := make([]byte, streamingChunkBytes)
+	for {
+		n, err := io.ReadFull(audio, buf)
+		if n > 0 {
+			if writeErr := conn.WriteMessage(opBinary, buf[:n]); writeErr != nil {
+				return fmt.Errorf("failed to send audio: %v", writeErr)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audio: %v", err)
+		}
+	}
+}
+
+// receiveTurns reads server messages until the session terminates
+func receiveTurns(conn *wsConn, onTurn TurnFunc) error {
+	for {
+		opcode, data, err := conn.ReadMessage()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("streaming session failed: %v", err)
+		}
+		if opcode != opText {
+			continue
+		}
+
+		var message streamingMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			return fmt.Errorf("failed to decode streaming message: %v", err)
+		}
+
+		switch message.Type {
+		case "Turn":
+			onTurn(message.Turn)
+		case "Termination":
+			return nil
+		case "Error":
+			return &ProviderError{Message: "streaming error: " + message.Error}
+		}
+	}
+}
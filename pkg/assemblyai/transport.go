@@ -0,0 +1,186 @@
+package assemblyai
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOptions tune the connections to the API. Clients with the same
+// options share one transport, so the connection that carried an upload is
+// reused to submit and poll the job instead of dialing and handshaking again.
+type TransportOptions struct {
+	// HTTP2 negotiates HTTP/2 with the API; uploads fall back to HTTP/1.1
+	// when it fails, since some proxies mishandle large HTTP/2 uploads
+	HTTP2 bool
+	// WriteBufferSize is how much of an upload is handed to the connection at
+	// a time; larger buffers keep fast links busy
+	WriteBufferSize int
+	// IdleTimeout is how long an unused connection is kept open for the next
+	// request
+	IdleTimeout time.Duration
+	// ConnectTimeout bounds dialing the API and the TLS handshake
+	ConnectTimeout time.Duration
+	// RequestTimeout bounds each request other than uploads: submitting a
+	// job, checking its status, listing and deleting transcripts
+	RequestTimeout time.Duration
+	// UploadIdleTimeout is how long an upload may go without sending any
+	// data. Uploads have no overall deadline, which would cut off large
+	// files on slow links.
+	UploadIdleTimeout time.Duration
+}
+
+// DefaultTransportOptions are used by NewClient
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		HTTP2:             true,
+		WriteBufferSize:   uploadBufferSize,
+		IdleTimeout:       90 * time.Second,
+		ConnectTimeout:    30 * time.Second,
+		RequestTimeout:    60 * time.Second,
+		UploadIdleTimeout: 2 * time.Minute,
+	}
+}
+
+func (o TransportOptions) withDefaults() TransportOptions {
+	defaults := DefaultTransportOptions()
+	if o.WriteBufferSize <= 0 {
+		o.WriteBufferSize = defaults.WriteBufferSize
+	}
+	if o.IdleTimeout <= 0 {
+		o.IdleTimeout = defaults.IdleTimeout
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = defaults.RequestTimeout
+	}
+	if o.UploadIdleTimeout <= 0 {
+		o.UploadIdleTimeout = defaults.UploadIdleTimeout
+	}
+	return o
+}
+
+// Connection pool limits. Batch runs poll many jobs at once, so enough idle
+// connections are kept to serve them without dialing again over HTTP/1.1.
+const (
+	maxIdleConns        = 32
+	maxIdleConnsPerHost = 16
+)
+
+// maxDrainSize bounds how much of an unread response body is read so its
+// connection can be reused; larger remainders are cheaper to drop
+const maxDrainSize = 64 * 1024
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[TransportOptions]*http.Transport)
+
+	// sessionCache is shared by every transport and the streaming
+	// WebSocket, so a new connection resumes an earlier TLS session, e.g.
+	// the HTTP/1.1 upload fallback the session of the HTTP/2 connection
+	sessionCache = tls.NewLRUClientSessionCache(64)
+)
+
+// tlsConfig returns the TLS settings of connections to the API
+func tlsConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+		ClientSessionCache: sessionCache,
+	}
+}
+
+// sharedTransport returns the transport for options, creating it on first use.
+// The request and upload timeouts are not the transport's, so clients that
+// only differ in those share one.
+func sharedTransport(options TransportOptions) *http.Transport {
+	options = options.withDefaults()
+	options.RequestTimeout, options.UploadIdleTimeout = 0, 0
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if transport, ok := transports[options]; ok {
+		return transport
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   options.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		// Setting TLSClientConfig turns off HTTP/2 unless it is forced
+		TLSClientConfig:       tlsConfig(""),
+		ForceAttemptHTTP2:     options.HTTP2,
+		TLSHandshakeTimeout:   options.ConnectTimeout,
+		ResponseHeaderTimeout: uploadResponseTimeout,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       options.IdleTimeout,
+		WriteBufferSize:       options.WriteBufferSize,
+		ReadBufferSize:        64 * 1024,
+	}
+	if !options.HTTP2 {
+		// A non-nil, empty map disables HTTP/2 negotiation
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	transports[options] = transport
+	return transport
+}
+
+// UseTransport switches the client to the shared transport for options and
+// its timeouts
+func (c *Client) UseTransport(options TransportOptions) {
+	c.transport = options
+	c.HTTPClient.Transport = roundTripper(options)
+	c.HTTPClient.Timeout = options.withDefaults().RequestTimeout
+}
+
+// uploadClient returns an HTTP client for large uploads over the client's
+// transport, or its HTTP/1.1 twin: the file is streamed from disk without an
+// overall timeout, which would cut off multi-gigabyte uploads on slow links
+func (c *Client) uploadClient(http2 bool) *http.Client {
+	options := c.transport
+	options.HTTP2 = http2
+	return &http.Client{Transport: roundTripper(options)}
+}
+
+// closeBody reads what is left of a response body before closing it: a
+// connection is only reused once its last response was read to the end
+func closeBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainSize))
+	body.Close()
+}
+
+// stallReader feeds an upload and calls stall when the transport takes
+// nothing from it for idle, e.g. because the connection hangs
+type stallReader struct {
+	r     io.Reader
+	idle  time.Duration
+	timer *time.Timer
+}
+
+func newStallReader(r io.Reader, idle time.Duration, stall func()) *stallReader {
+	return &stallReader{r: r, idle: idle, timer: time.AfterFunc(idle, stall)}
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil {
+		// The body is sent; the wait for the response has its own timeout
+		s.timer.Stop()
+	} else if n > 0 {
+		s.timer.Reset(s.idle)
+	}
+	return n, err
+}
+
+// stop disarms the watchdog once the upload is over
+func (s *stallReader) stop() {
+	s.timer.Stop()
+}
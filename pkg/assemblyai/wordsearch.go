@@ -0,0 +1,58 @@
+package assemblyai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WordSearchMatch lists where one searched word or phrase occurs.
+// Each timestamp is a [start, end] pair in milliseconds.
+type WordSearchMatch struct {
+	Text       string     `json:"text"`
+	Count      int        `json:"count"`
+	Timestamps [][2]int64 `json:"timestamps"`
+	Indexes    []int      `json:"indexes"`
+}
+
+// WordSearchResult is the response of the word search endpoint
+type WordSearchResult struct {
+	ID         string            `json:"id"`
+	TotalCount int               `json:"total_count"`
+	Matches    []WordSearchMatch `json:"matches"`
+}
+
+// WordSearch finds words or short phrases in a completed transcript stored at AssemblyAI
+func (c *Client) WordSearch(transcriptID string, phrases []string) (*WordSearchResult, error) {
+	query := url.Values{"words": {strings.Join(phrases, ",")}}
+	endpoint, err := c.apiURL(fmt.Sprintf("/v2/transcript/%s/word-search?%s", url.PathEscape(transcriptID), query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transcript: %v", err)
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDrainSize))
+		return nil, &ProviderError{StatusCode: resp.StatusCode, Message: fmt.Sprintf("word search failed with status %d: %s", resp.StatusCode, errorMessage(resp.StatusCode, body))}
+	}
+
+	var result WordSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode word search response: %v", err)
+	}
+	return &result, nil
+}
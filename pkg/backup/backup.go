@@ -0,0 +1,565 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/library"
+)
+
+// formatVersion is bumped when the archive layout changes incompatibly
+const formatVersion = 1
+
+const manifestName = "manifest.json"
+
+// secretPattern matches the api_key settings, which are blanked in the
+// archived config and carried in the manifest's encrypted secrets instead
+var secretPattern = regexp.MustCompile(`(?m)^(\s*api_key\s*=\s*).*$`)
+
+// secretListPattern matches assemblyai.api_keys, which is emptied the same
+// way. The array may span several lines when edited by hand.
+var secretListPattern = regexp.MustCompile(`(?ms)^(\s*api_keys\s*=\s*)\[.*?\]`)
+
+// manifest describes a backup archive. It is always the first entry.
+type manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Hostname  string    `json:"hostname"`
+	// HomeDir is the home directory on the exporting machine, so paths
+	// under it can be moved to the new home directory on import
+	HomeDir string `json:"home_dir"`
+	// Transcripts maps archived transcript files to their original paths
+	Transcripts map[string]string `json:"transcripts,omitempty"`
+	Secrets     *sealedSecrets    `json:"secrets,omitempty"`
+}
+
+// secrets are the API keys carried in a backup
+type secrets struct {
+	APIKey          string `json:"api_key,omitempty"`
+	ProofreadAPIKey string `json:"proofread_api_key,omitempty"`
+	// FallbackAPIKeys are the keys of assemblyai.api_keys
+	FallbackAPIKeys []string `json:"fallback_api_keys,omitempty"`
+}
+
+// ExportOptions selects what goes into a backup
+type ExportOptions struct {
+	// Transcripts also archives the transcript files of library records
+	Transcripts bool
+	// NoSecrets leaves the API keys out, so no passphrase is needed
+	NoSecrets bool
+	// Force overwrites an existing archive
+	Force bool
+}
+
+// ImportOptions controls how a backup is restored
+type ImportOptions struct {
+	// Force replaces an existing configuration and library records
+	Force bool
+	// TranscriptsDir restores transcripts into one directory instead of their original paths
+	TranscriptsDir string
+	// OriginalPaths restores transcripts to their original paths even
+	// outside the output directory. The paths come from the archive, so
+	// only archives you made yourself should be restored this way.
+	OriginalPaths bool
+}
+
+// Summary counts what an export or import handled
+type Summary struct {
+	Files       int
+	Records     int
+	Transcripts int
+	Skipped     int
+	Secrets     bool
+	// Ignored lists the archive entries an import did not restore, because
+	// sona does not restore such files or their path was not safe
+	Ignored []string
+}
+
+// restorable reports whether an archive entry is one of the data files an
+// import restores into the data directory. Anything else, e.g. tokens.json
+// or binaries, could change what sona trusts or runs.
+func restorable(name string) bool {
+	switch name {
+	case "corrections.yaml", "queue.json":
+		return true
+	}
+	dir, file := path.Split(name)
+	return (dir == "batches/" || dir == "projects/") && path.Ext(file) == ".json"
+}
+
+// within reports whether p is dir or a path inside it
+func within(p string, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// relocatePath moves a path under oldHome to newHome
+func relocatePath(p string, oldHome string, newHome string) string {
+	if oldHome == "" || oldHome == newHome {
+		return p
+	}
+	if p == oldHome || strings.HasPrefix(p, oldHome+string(filepath.Separator)) {
+		return newHome + p[len(oldHome):]
+	}
+	return p
+}
+
+// sonaDir returns the data directory, ~/.sona unless moved
+func sonaDir() (string, error) {
+	return datadir.Dir()
+}
+
+// Export writes the configuration, API keys (encrypted with a passphrase),
+// corrections, queue, batch history, projects, library and optionally the
+// transcripts to a .tar.gz archive
+func Export(archivePath string, opts ExportOptions) (Summary, error) {
+	var summary Summary
+	dir, err := sonaDir()
+	if err != nil {
+		return summary, err
+	}
+	homeDir := filepath.Dir(dir)
+
+	if _, err := os.Stat(archivePath); err == nil && !opts.Force {
+		return summary, fmt.Errorf("%s already exists (use --force to overwrite)", archivePath)
+	}
+
+	m := manifest{Version: formatVersion, CreatedAt: time.Now(), HomeDir: homeDir}
+	m.Hostname, _ = os.Hostname()
+
+	if !opts.NoSecrets {
+		keys := secrets{
+			APIKey:          config.GetAPIKeyNoExit(),
+			ProofreadAPIKey: config.GetProofreadAPIKey(),
+			FallbackAPIKeys: config.GetFallbackAPIKeys(),
+		}
+		if keys.APIKey != "" || keys.ProofreadAPIKey != "" || len(keys.FallbackAPIKeys) > 0 {
+			passphrase, err := readPassphrase("Passphrase to protect the API keys: ", true)
+			if err != nil {
+				return summary, err
+			}
+			data, _ := json.Marshal(keys)
+			if m.Secrets, err = seal(data, passphrase); err != nil {
+				return summary, err
+			}
+			summary.Secrets = true
+		}
+	}
+
+	records, err := library.List()
+	if err != nil {
+		return summary, err
+	}
+	if opts.Transcripts {
+		m.Transcripts = make(map[string]string)
+		for _, record := range records {
+			for _, file := range record.Files {
+				if _, err := os.Stat(file); err == nil {
+					m.Transcripts[path.Join("transcripts", record.Name, filepath.Base(file))] = file
+				}
+			}
+		}
+	}
+
+	out, err := os.Create(archivePath + ".tmp")
+	if err != nil {
+		return summary, fmt.Errorf("failed to create archive: %v", err)
+	}
+	defer os.Remove(archivePath + ".tmp")
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	err = func() error {
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode manifest: %v", err)
+		}
+		if err := writeEntry(tw, manifestName, data); err != nil {
+			return err
+		}
+
+		if data, err := os.ReadFile(config.FilePath()); err == nil {
+			data = secretPattern.ReplaceAll(data, []byte("${1}''"))
+			data = secretListPattern.ReplaceAll(data, []byte("${1}[]"))
+			if err := writeEntry(tw, "config.toml", data); err != nil {
+				return err
+			}
+			summary.Files++
+		}
+
+		for _, name := range []string{"corrections.yaml", "queue.json"} {
+			added, err := addFile(tw, name, filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			if added {
+				summary.Files++
+			}
+		}
+
+		for _, name := range []string{"batches", "projects"} {
+			count, err := addDir(tw, name, filepath.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			summary.Files += count
+		}
+
+		// Transcripts come before the library so imports can rewrite the
+		// records' file paths as they go
+		for name, file := range m.Transcripts {
+			if _, err := addFile(tw, name, file); err != nil {
+				return err
+			}
+			summary.Transcripts++
+		}
+
+		libraryDir, err := library.Dir()
+		if err != nil {
+			return err
+		}
+		if summary.Records, err = addDir(tw, "library", libraryDir); err != nil {
+			return err
+		}
+		return nil
+	}()
+
+	if closeErr := tw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return summary, err
+	}
+
+	if err := os.Rename(archivePath+".tmp", archivePath); err != nil {
+		return summary, fmt.Errorf("failed to write archive: %v", err)
+	}
+	return summary, nil
+}
+
+// Import restores a backup made with Export. Paths under the old home
+// directory are moved to the current one.
+func Import(archivePath string, opts ImportOptions) (Summary, error) {
+	var summary Summary
+	dir, err := sonaDir()
+	if err != nil {
+		return summary, err
+	}
+	homeDir := filepath.Dir(dir)
+
+	if config.GetAPIKeyNoExit() != "" && !opts.Force {
+		return summary, fmt.Errorf("sona is already configured on this machine (use --force to replace the configuration)")
+	}
+	// Taken before the archived config replaces it, which could point it anywhere
+	outputDir, err := filepath.Abs(config.GetOutputPath())
+	if err != nil {
+		return summary, fmt.Errorf("invalid output directory: %v", err)
+	}
+	transcriptsDir := opts.TranscriptsDir
+	if transcriptsDir != "" {
+		if transcriptsDir, err = filepath.Abs(transcriptsDir); err != nil {
+			return summary, fmt.Errorf("invalid transcripts directory: %v", err)
+		}
+	}
+
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return summary, fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return summary, fmt.Errorf("%s is not a sona backup: %v", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+
+	header, err := tr.Next()
+	if err != nil || header.Name != manifestName {
+		return summary, fmt.Errorf("%s is not a sona backup (no manifest)", archivePath)
+	}
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return summary, fmt.Errorf("invalid backup manifest: %v", err)
+	}
+	if m.Version > formatVersion {
+		return summary, fmt.Errorf("backup was made by a newer sona (format %d); please upgrade", m.Version)
+	}
+
+	relocate := func(p string) string {
+		return relocatePath(p, m.HomeDir, homeDir)
+	}
+
+	// Decrypt first so a wrong passphrase fails before anything is written
+	var keys secrets
+	if m.Secrets != nil {
+		passphrase, err := readPassphrase("Backup passphrase: ", false)
+		if err != nil {
+			return summary, err
+		}
+		data, err := m.Secrets.open(passphrase)
+		if err != nil {
+			return summary, err
+		}
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return summary, fmt.Errorf("invalid secrets in backup: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return summary, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	// restored maps original transcript paths to where they were written
+	restored := make(map[string]string)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if strings.HasPrefix(name, "..") || path.IsAbs(name) {
+			return summary, fmt.Errorf("unsafe path in archive: %s", header.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return summary, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+
+		switch {
+		case name == "config.toml":
+			if err := writeFile(config.FilePath(), relocateText(data, m.HomeDir, homeDir)); err != nil {
+				return summary, err
+			}
+			if err := config.Reload(); err != nil {
+				return summary, err
+			}
+			summary.Files++
+
+		case strings.HasPrefix(name, "transcripts/"):
+			original, ok := m.Transcripts[name]
+			if !ok {
+				summary.Ignored = append(summary.Ignored, name)
+				continue
+			}
+			target, ok := transcriptTarget(relocate(original), outputDir, transcriptsDir, opts.OriginalPaths)
+			if !ok {
+				summary.Ignored = append(summary.Ignored, name+" ("+original+")")
+				continue
+			}
+			if _, err := os.Stat(target); err == nil && !opts.Force {
+				summary.Skipped++
+				restored[original] = target
+				continue
+			}
+			if err := writeFile(target, data); err != nil {
+				return summary, err
+			}
+			restored[original] = target
+			summary.Transcripts++
+
+		case strings.HasPrefix(name, "library/"):
+			var record library.Record
+			if err := json.Unmarshal(data, &record); err != nil {
+				return summary, fmt.Errorf("invalid library record %s: %v", name, err)
+			}
+			// The name becomes the record's file name in the library
+			if record.Name == "" || record.Name == "." || record.Name == ".." || strings.ContainsAny(record.Name, `/\`) {
+				summary.Ignored = append(summary.Ignored, name)
+				continue
+			}
+			if _, err := library.Load(record.Name); err == nil && !opts.Force {
+				summary.Skipped++
+				continue
+			}
+			record.Source = relocate(record.Source)
+			for i, file := range record.Files {
+				if target, ok := restored[file]; ok {
+					record.Files[i] = target
+				} else {
+					record.Files[i] = relocate(file)
+				}
+			}
+			if err := library.Save(record); err != nil {
+				return summary, err
+			}
+			summary.Records++
+
+		case restorable(name):
+			target := filepath.Join(dir, filepath.FromSlash(name))
+			if _, err := os.Stat(target); err == nil && !opts.Force {
+				summary.Skipped++
+				continue
+			}
+			if err := writeFile(target, relocateText(data, m.HomeDir, homeDir)); err != nil {
+				return summary, err
+			}
+			summary.Files++
+
+		default:
+			summary.Ignored = append(summary.Ignored, name)
+		}
+	}
+
+	// Keys are encrypted again for this machine
+	if keys.APIKey != "" {
+		if err := config.SaveAPIKey(keys.APIKey); err != nil {
+			return summary, fmt.Errorf("failed to save API key: %v", err)
+		}
+		summary.Secrets = true
+	}
+	if keys.ProofreadAPIKey != "" {
+		if err := config.SaveProofreadAPIKey(keys.ProofreadAPIKey); err != nil {
+			return summary, fmt.Errorf("failed to save proofread API key: %v", err)
+		}
+		summary.Secrets = true
+	}
+	if len(keys.FallbackAPIKeys) > 0 {
+		if err := config.SaveFallbackAPIKeys(keys.FallbackAPIKeys); err != nil {
+			return summary, fmt.Errorf("failed to save fallback API keys: %v", err)
+		}
+		summary.Secrets = true
+	}
+
+	return summary, nil
+}
+
+// transcriptTarget returns where a transcript from the archive, relocated
+// to this machine, is restored: into transcriptsDir when given, else at its
+// path if that is inside the output directory or originalPaths allows any
+// absolute path. It reports false when the transcript must not be written.
+func transcriptTarget(original string, outputDir string, transcriptsDir string, originalPaths bool) (string, bool) {
+	if transcriptsDir != "" {
+		base := filepath.Base(original)
+		if base == "." || base == ".." || base == string(filepath.Separator) {
+			return "", false
+		}
+		return filepath.Join(transcriptsDir, base), true
+	}
+	if !filepath.IsAbs(original) {
+		return "", false
+	}
+	target := filepath.Clean(original)
+	if originalPaths || within(target, outputDir) {
+		return target, true
+	}
+	return "", false
+}
+
+// relocateText replaces the old home directory in the paths of a config or
+// JSON file, including the form with escaped backslashes used on Windows
+func relocateText(data []byte, oldHome string, newHome string) []byte {
+	if oldHome == "" || oldHome == newHome {
+		return data
+	}
+	text := replacePath(string(data), oldHome, newHome)
+	escape := strings.NewReplacer(`\`, `\\`)
+	text = replacePath(text, escape.Replace(oldHome), escape.Replace(newHome))
+	return []byte(text)
+}
+
+// replacePath replaces oldHome with newHome where it is a whole path or the
+// start of one, so /home/al does not match in /home/alice
+func replacePath(text string, oldHome string, newHome string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, oldHome)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(oldHome)
+		b.WriteString(text[:i])
+		if end == len(text) || strings.ContainsRune(`/\"' `+"\n", rune(text[end])) {
+			b.WriteString(newHome)
+		} else {
+			b.WriteString(oldHome)
+		}
+		text = text[end:]
+	}
+}
+
+// writeEntry adds a file with the given contents to the archive
+func writeEntry(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// addFile archives the file at path under name, if it exists
+func addFile(tw *tar.Writer, name string, path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return true, writeEntry(tw, name, data)
+}
+
+// addDir archives the .json files of dir under prefix
+func addDir(tw *tar.Writer, prefix string, dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		if _, err := addFile(tw, path.Join(prefix, entry.Name()), filepath.Join(dir, entry.Name())); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// writeFile writes data to path, creating its directory
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
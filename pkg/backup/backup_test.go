@@ -0,0 +1,186 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/spf13/viper"
+)
+
+// writeArchive writes a backup archive with the given manifest and entries
+func writeArchive(t *testing.T, m manifest, entries map[string]string) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "backup.tar.gz")
+	out, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{manifestName}
+	contents := map[string]string{manifestName: string(data)}
+	for name, content := range entries {
+		names = append(names, name)
+		contents[name] = content
+	}
+	slices.Sort(names[1:])
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(contents[name])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestImport(t *testing.T) {
+	const oldHome = "/home/old"
+
+	tests := []struct {
+		name          string
+		transcripts   map[string]string
+		entries       map[string]string
+		useDir        bool
+		originalPaths bool
+		// written are the files expected afterwards, relative to the new home
+		written []string
+		// absent are files that must not exist, relative to the new home
+		absent  []string
+		ignored int
+	}{
+		{
+			name:    "data files are restored",
+			entries: map[string]string{"corrections.yaml": "x", "queue.json": "[]", "batches/b1.json": "{}", "projects/p.json": "{}"},
+			written: []string{".sona/corrections.yaml", ".sona/queue.json", ".sona/batches/b1.json", ".sona/projects/p.json"},
+		},
+		{
+			name:    "other files are ignored",
+			entries: map[string]string{"tokens.json": "[]", "binaries.json": "{}", "bin/ffmpeg": "#!/bin/sh", "batches/b1.sh": "x", "batches/nested/b.json": "{}"},
+			absent:  []string{".sona/tokens.json", ".sona/binaries.json", ".sona/bin/ffmpeg", ".sona/batches/b1.sh", ".sona/batches/nested/b.json"},
+			ignored: 5,
+		},
+		{
+			name:        "transcripts inside the output directory are restored",
+			transcripts: map[string]string{"transcripts/1/a.txt": oldHome + "/out/a.txt"},
+			entries:     map[string]string{"transcripts/1/a.txt": "hello"},
+			written:     []string{"out/a.txt"},
+		},
+		{
+			name:        "transcripts outside the output directory are ignored",
+			transcripts: map[string]string{"transcripts/1/a.txt": oldHome + "/.bashrc", "transcripts/2/b.txt": "/etc/cron.d/b", "transcripts/3/c.txt": oldHome + "/out/../.profile", "transcripts/4/d.txt": "out/d.txt"},
+			entries:     map[string]string{"transcripts/1/a.txt": "x", "transcripts/2/b.txt": "x", "transcripts/3/c.txt": "x", "transcripts/4/d.txt": "x"},
+			absent:      []string{".bashrc", ".profile"},
+			ignored:     4,
+		},
+		{
+			name:        "transcripts directory takes the base name",
+			transcripts: map[string]string{"transcripts/1/a.txt": "/etc/cron.d/a.txt"},
+			entries:     map[string]string{"transcripts/1/a.txt": "x"},
+			useDir:      true,
+			written:     []string{"restored/a.txt"},
+		},
+		{
+			name:          "original paths allow other directories",
+			transcripts:   map[string]string{"transcripts/1/a.txt": oldHome + "/notes/a.txt"},
+			entries:       map[string]string{"transcripts/1/a.txt": "x"},
+			originalPaths: true,
+			written:       []string{"notes/a.txt"},
+		},
+		{
+			name:    "library records with unsafe names are ignored",
+			entries: map[string]string{"library/a.json": `{"name":"../../evil"}`, "library/b.json": `{"name":"ok"}`},
+			written: []string{".sona/library/ok.json"},
+			absent:  []string{"evil.json"},
+			ignored: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv(datadir.EnvVar, filepath.Join(home, ".sona"))
+			viper.Reset()
+			viper.Set("output.default_path", filepath.Join(home, "out"))
+			t.Cleanup(viper.Reset)
+
+			archivePath := writeArchive(t, manifest{Version: formatVersion, HomeDir: oldHome, Transcripts: tt.transcripts}, tt.entries)
+			opts := ImportOptions{OriginalPaths: tt.originalPaths}
+			if tt.useDir {
+				opts.TranscriptsDir = filepath.Join(home, "restored")
+			}
+			summary, err := Import(archivePath, opts)
+			if err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+
+			for _, name := range tt.written {
+				if _, err := os.Stat(filepath.Join(home, name)); err != nil {
+					t.Errorf("%s not restored: %v", name, err)
+				}
+			}
+			for _, name := range tt.absent {
+				if _, err := os.Stat(filepath.Join(home, name)); err == nil {
+					t.Errorf("%s restored, want it ignored", name)
+				}
+			}
+			if len(summary.Ignored) != tt.ignored {
+				t.Errorf("Ignored = %q, want %d entries", summary.Ignored, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestRelocateText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`path = "/home/al/out"`, `path = "/home/bo/out"`},
+		{`path = "/home/al"`, `path = "/home/bo"`},
+		{`path = "/home/alice/out"`, `path = "/home/alice/out"`},
+		{"/home/al", "/home/bo"},
+	}
+	for _, tt := range tests {
+		if got := string(relocateText([]byte(tt.text), "/home/al", "/home/bo")); got != tt.want {
+			t.Errorf("relocateText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRelocatePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/home/al", "/home/bo"},
+		{"/home/al/out/a.txt", "/home/bo/out/a.txt"},
+		{"/home/alice/a.txt", "/home/alice/a.txt"},
+		{"/srv/a.txt", "/srv/a.txt"},
+	}
+	for _, tt := range tests {
+		if got := relocatePath(tt.path, "/home/al", "/home/bo"); got != tt.want {
+			t.Errorf("relocatePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
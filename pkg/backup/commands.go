@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportTranscripts bool
+	exportNoSecrets   bool
+	exportForce       bool
+	importForce       bool
+	importTranscripts string
+	importOriginal    bool
+)
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Move sona's settings and history to another machine",
+	Long: `Bundle the configuration, API keys, corrections, queue, batch history,
+projects and transcript library into one archive, and restore it on another machine.
+
+API keys are stored encrypted for the current machine, so they cannot be
+copied as they are. The backup carries them encrypted with a passphrase
+instead, and import encrypts them again for the new machine. Set
+SONA_BACKUP_PASSPHRASE to avoid the prompt in scripts.`,
+}
+
+var backupExportCmd = &cobra.Command{
+	Use:   "export <archive>",
+	Short: "Write a backup archive",
+	Long:  `Write sona's configuration and history to a .tar.gz archive.`,
+	Example: `  sona backup export sona-backup.tar.gz
+  sona backup export sona-backup.tar.gz --transcripts
+  sona backup export sona-backup.tar.gz --no-secrets`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		summary, err := Export(args[0], ExportOptions{
+			Transcripts: exportTranscripts,
+			NoSecrets:   exportNoSecrets,
+			Force:       exportForce,
+		})
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		fmt.Println(style.Success("Backup written to %s", args[0]))
+		fmt.Printf("   %d files, %d library records, %d transcripts\n", summary.Files, summary.Records, summary.Transcripts)
+		if summary.Secrets {
+			fmt.Println("   API keys are protected with your passphrase")
+		}
+	},
+}
+
+var backupImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Restore a backup archive",
+	Long: `Restore a backup made with 'sona backup export'.
+
+Paths under the old home directory are moved to this machine's home
+directory. Existing library records and files are kept unless --force is
+given, and an already configured sona is only replaced with --force.
+
+Only the configuration, corrections, queue, batches, projects and library
+are restored; other files in the archive are skipped. Transcripts are only
+written inside the output directory unless --transcripts-dir or
+--original-paths is given.`,
+	Example: `  sona backup import sona-backup.tar.gz
+  sona backup import sona-backup.tar.gz --transcripts-dir ~/transcripts`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		summary, err := Import(args[0], ImportOptions{
+			Force:          importForce,
+			TranscriptsDir: importTranscripts,
+			OriginalPaths:  importOriginal,
+		})
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		fmt.Println(style.Success("Restored %d files, %d library records, %d transcripts", summary.Files, summary.Records, summary.Transcripts))
+		if summary.Skipped > 0 {
+			fmt.Printf("   %d existing items kept (use --force to replace them)\n", summary.Skipped)
+		}
+		if summary.Secrets {
+			fmt.Println("   API keys encrypted for this machine")
+		}
+		transcripts := false
+		for _, name := range summary.Ignored {
+			fmt.Println(style.Warning("Not restored: %s", name))
+			transcripts = transcripts || strings.HasPrefix(name, "transcripts/")
+		}
+		if transcripts && !importOriginal && importTranscripts == "" {
+			fmt.Println(style.Hint("Transcripts outside the output directory need --transcripts-dir or --original-paths"))
+		}
+	},
+}
+
+func init() {
+	BackupCmd.AddCommand(backupExportCmd)
+	BackupCmd.AddCommand(backupImportCmd)
+
+	backupExportCmd.Flags().BoolVar(&exportTranscripts, "transcripts", false, "Include the transcript files of library records")
+	backupExportCmd.Flags().BoolVar(&exportNoSecrets, "no-secrets", false, "Leave out the API keys")
+	backupExportCmd.Flags().BoolVarP(&exportForce, "force", "f", false, "Overwrite an existing archive")
+
+	backupImportCmd.Flags().BoolVarP(&importForce, "force", "f", false, "Replace existing configuration, records and files")
+	backupImportCmd.Flags().StringVar(&importTranscripts, "transcripts-dir", "", "Restore transcripts into this directory instead of their original paths")
+	backupImportCmd.Flags().BoolVar(&importOriginal, "original-paths", false, "Restore transcripts to their original paths even outside the output directory")
+}
@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+)
+
+// keyIterations is the PBKDF2 work factor for the passphrase
+const keyIterations = 600000
+
+// sealedSecrets holds secrets encrypted with a key derived from a passphrase
+type sealedSecrets struct {
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	// Data is the AES-256-GCM nonce followed by the ciphertext
+	Data []byte `json:"data"`
+}
+
+// seal encrypts plaintext with a key derived from the passphrase
+func seal(plaintext []byte, passphrase string) (*sealedSecrets, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	gcm, err := newGCM(passphrase, salt, keyIterations)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return &sealedSecrets{
+		Salt:       salt,
+		Iterations: keyIterations,
+		Data:       gcm.Seal(nonce, nonce, plaintext, nil),
+	}, nil
+}
+
+// open decrypts the secrets, failing on a wrong passphrase
+func (s *sealedSecrets) open(passphrase string) ([]byte, error) {
+	gcm, err := newGCM(passphrase, s.Salt, s.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted secrets are truncated")
+	}
+
+	nonce, ciphertext := s.Data[:gcm.NonceSize()], s.Data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase or damaged backup")
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := deriveKey(passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %v", err)
+	}
+	return gcm, nil
+}
+
+// deriveKey derives a key of keyLen bytes from the passphrase with
+// PBKDF2-HMAC-SHA256
+func deriveKey(passphrase string, salt []byte, iterations int, keyLen int) ([]byte, error) {
+	if iterations < 1 {
+		return nil, fmt.Errorf("invalid key derivation iterations: %d", iterations)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	return key, nil
+}
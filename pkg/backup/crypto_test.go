@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 test vectors from RFC 7914, section 11
+	tests := []struct {
+		passphrase string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		want, _ := hex.DecodeString(tt.want)
+		got, err := deriveKey(tt.passphrase, []byte(tt.salt), tt.iterations, len(want))
+		if err != nil {
+			t.Fatalf("deriveKey(%q) error = %v", tt.passphrase, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("deriveKey(%q, %q, %d) = %x, want %x", tt.passphrase, tt.salt, tt.iterations, got, want)
+		}
+	}
+
+	if _, err := deriveKey("passwd", []byte("salt"), 0, 32); err == nil {
+		t.Error("deriveKey with 0 iterations succeeded, want an error")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	sealed, err := seal([]byte("secret"), "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := sealed.open("correct horse")
+	if err != nil {
+		t.Fatalf("open() error = %v", err)
+	}
+	if string(got) != "secret" {
+		t.Errorf("open() = %q, want %q", got, "secret")
+	}
+
+	if _, err := sealed.open("wrong"); err == nil {
+		t.Error("open() with the wrong passphrase succeeded")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+
+package backup
+
+import (
+	"errors"
+	"os"
+)
+
+// disableEcho is not supported on this platform
+func disableEcho(f *os.File) (func(), error) {
+	return nil, errors.New("hiding input is not supported on this platform")
+}
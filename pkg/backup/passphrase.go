@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// passphraseEnv lets scripts supply the passphrase without a prompt
+const passphraseEnv = "SONA_BACKUP_PASSPHRASE"
+
+// readPassphrase returns the passphrase from SONA_BACKUP_PASSPHRASE or asks
+// for it on the terminal without echoing it. When confirm is set it must be
+// typed twice.
+func readPassphrase(prompt string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return "", fmt.Errorf("no terminal to ask for the passphrase; set %s", passphraseEnv)
+	}
+
+	passphrase, err := promptHidden(prompt)
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("the passphrase cannot be empty")
+	}
+
+	if confirm {
+		again, err := promptHidden("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// promptHidden reads a line from the terminal with echo turned off
+func promptHidden(prompt string) (string, error) {
+	fmt.Print(prompt)
+	restore, err := disableEcho(os.Stdin)
+	if err != nil {
+		// Reading the passphrase visibly is better than not at all
+		fmt.Print("(input will be visible) ")
+	} else {
+		defer restore()
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Println()
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,193 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+)
+
+// keepBatches is how many batch records are kept; older ones are pruned
+const keepBatches = 20
+
+// Outcomes of a source in a batch
+const (
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+	// StatusEmpty marks audio without speech, which retrying cannot fix
+	StatusEmpty = "empty"
+	// StatusDeferred marks a source moved to the queue by the daily budget
+	StatusDeferred = "deferred"
+)
+
+// Entry is one source of a batch with the options it was run with
+type Entry struct {
+	Job        queue.Job `json:"job"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+}
+
+// Batch records a multi-source transcription run so failed sources can be
+// retried with their original options
+type Batch struct {
+	ID        string    `json:"id"`
+	StartedAt time.Time `json:"started_at"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Dir returns the directory holding batch records (~/.sona/batches)
+func Dir() (string, error) {
+	return datadir.Path("batches")
+}
+
+// New starts a batch for the jobs, all pending
+func New(jobs []queue.Job) *Batch {
+	now := time.Now()
+	b := &Batch{ID: now.Format("20060102-150405"), StartedAt: now}
+	for _, job := range jobs {
+		b.Entries = append(b.Entries, Entry{Job: job, Status: StatusPending})
+	}
+	return b
+}
+
+// Finish records the outcome of entry i
+func (b *Batch) Finish(i int, status string, err error) {
+	b.Entries[i].Status = status
+	b.Entries[i].Error = ""
+	if err != nil {
+		b.Entries[i].Error = err.Error()
+	}
+	b.Entries[i].FinishedAt = time.Now()
+}
+
+// Count returns how many entries have the status
+func (b *Batch) Count(status string) int {
+	count := 0
+	for _, entry := range b.Entries {
+		if entry.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Retryable returns the indexes of entries that failed or never ran
+func (b *Batch) Retryable() []int {
+	var indexes []int
+	for i, entry := range b.Entries {
+		if entry.Status == StatusFailed || entry.Status == StatusPending {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Save writes the batch atomically and prunes old batch records
+func Save(b *Batch) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create batch directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode batch: %v", err)
+	}
+
+	path := filepath.Join(dir, b.ID+".json")
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write batch: %v", err)
+	}
+
+	prune(dir)
+	return nil
+}
+
+// Load reads the batch with the given ID
+func Load(id string) (*Batch, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no batch with ID %s", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch %s: %v", id, err)
+	}
+
+	var b Batch
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("batch %s is corrupted: %v", id, err)
+	}
+	return &b, nil
+}
+
+// IDs returns the recorded batch IDs, newest first
+func IDs() ([]string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batches: %v", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasSuffix(name, ".json") {
+			ids = append(ids, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	// IDs are timestamps, so they sort by age
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// LatestRetryable returns the newest batch with failed sources
+func LatestRetryable() (*Batch, error) {
+	ids, err := IDs()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		b, err := Load(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(b.Retryable()) > 0 {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("no batch has failed sources")
+}
+
+// prune removes all but the newest keepBatches records
+func prune(dir string) {
+	ids, err := IDs()
+	if err != nil || len(ids) <= keepBatches {
+		return
+	}
+	for _, id := range ids[keepBatches:] {
+		os.Remove(filepath.Join(dir, id+".json"))
+	}
+}
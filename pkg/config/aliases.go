@@ -0,0 +1,125 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// aliasPrefix starts the keys of aliases, e.g. alias.yt
+const aliasPrefix = "alias."
+
+// IsAliasKey reports whether key defines an alias
+func IsAliasKey(key string) bool {
+	return strings.HasPrefix(key, aliasPrefix)
+}
+
+// validateAlias checks an alias: a name usable as a command and the
+// command line it stands for
+func validateAlias(key string, value string) (interface{}, error) {
+	name := strings.TrimPrefix(key, aliasPrefix)
+	if !presetNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid alias name %q (use lowercase letters, digits, - and _)", name)
+	}
+	args, err := SplitArgs(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s needs a command, e.g. \"transcribe --format md\"", key)
+	}
+	if strings.HasPrefix(args[0], "-") {
+		return nil, fmt.Errorf("%s must start with a command, not the flag %s", key, args[0])
+	}
+	return value, nil
+}
+
+// GetAlias returns the command line an alias stands for, split into arguments
+func GetAlias(name string) ([]string, bool) {
+	value, ok := viper.GetStringMapString("alias")[name]
+	if !ok {
+		return nil, false
+	}
+	args, err := SplitArgs(value)
+	if err != nil || len(args) == 0 {
+		return nil, false
+	}
+	return args, true
+}
+
+// Aliases returns the defined aliases and the command lines they stand for
+func Aliases() map[string]string {
+	return viper.GetStringMapString("alias")
+}
+
+// AliasNames returns the names of the defined aliases, sorted
+func AliasNames() []string {
+	var names []string
+	for name := range Aliases() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeleteAlias removes an alias from the config file
+func DeleteAlias(name string) error {
+	if _, ok := Aliases()[name]; !ok {
+		return fmt.Errorf("no alias named %s", name)
+	}
+	return rewriteConfig(func(settings map[string]interface{}) {
+		if aliases, ok := settings["alias"].(map[string]interface{}); ok {
+			delete(aliases, name)
+		}
+	})
+}
+
+// SplitArgs splits a command line into arguments the way a shell would for
+// plain words, single and double quotes and backslash escapes
+func SplitArgs(line string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
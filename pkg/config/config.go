@@ -1,10 +1,17 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/style"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,16 +19,109 @@ import (
 var encryptionManager *EncryptionManager
 var configFilePath string
 
+// DefaultOutputFileMode is the permissions of output files when output.file_mode is not set
+const DefaultOutputFileMode os.FileMode = 0644
+
+// DefaultTimestampFormat is the time layout appended to generated transcript filenames
+const DefaultTimestampFormat = "20060102"
+
 var ConfigCmd = &cobra.Command{
 	Use:   "config",
 	Short: "Manage configuration settings",
 	Long:  `Manage configuration settings for the sona tool.`,
+	Example: `  sona config set api_key <your-assemblyai-key>
+  sona config set defaults.model best
+  sona config set alias.mt "transcribe --preset meeting"
+  sona config show
+  sona config edit
+  sona config path`,
 }
 
 var configSetCmd = &cobra.Command{
 	Use:   "set [key] [value]",
 	Short: "Set a configuration value",
-	Long:  `Set a configuration value. Available keys: api_key`,
+	Long: `Set a configuration value. Available keys:
+  api_key            AssemblyAI API key (stored encrypted)
+  api_keys           Further AssemblyAI keys, comma-separated, tried in order when an
+                     account hits its limit or a key is rejected (stored encrypted)
+  defaults.model     Speech model used when --model is not given
+  defaults.provider  Transcription provider used when --provider is not given
+  defaults.formats   Comma-separated output formats used when --format is not given
+  defaults.profile   Output profile used when --profile is not given (legal, broadcast, casual)
+  filename.timestamp_format
+                     Go time layout appended to generated filenames (e.g. 2006-01-02_1504)
+  corrections.file   Glossary of corrections applied to every transcript
+                     (default: ~/.sona/corrections.yaml)
+  output.file_mode   Permissions of transcripts and other output files, in octal (default: 0644)
+  output.group       Group that owns output files, by name or ID (default: your primary group)
+  network.max_download_rate
+                     Bandwidth limit for downloads, e.g. 500K or 2M (0 = unlimited)
+  network.http2      Use HTTP/2 with AssemblyAI, falling back to HTTP/1.1 for uploads
+                     it fails (true or false, default: true)
+  network.upload_buffer
+                     Size of the chunks uploads are written in, e.g. 4M (default: 1M)
+  network.idle_timeout
+                     How long a connection to AssemblyAI is kept open between
+                     requests (default: 90s)
+  network.connect_timeout
+                     Longest to wait for a connection to AssemblyAI (default: 30s)
+  network.request_timeout
+                     Longest to wait for each request other than uploads, e.g.
+                     a status check (default: 60s)
+  network.upload_idle_timeout
+                     Longest an upload may go without sending anything; uploads
+                     have no overall deadline (default: 2m)
+  uncertain.open, uncertain.close
+                     Markers around words flagged by --mark-uncertain (default: [? and ?])
+  polling.min_interval, polling.max_interval
+                     Bounds on the wait between status checks (default: 2s, 30s)
+  polling.timeout    Longest to wait for a transcript, e.g. 2h (0 = 3x the expected time, at least 30m)
+  budget.daily_minutes
+                     Most minutes of audio to transcribe per day; jobs beyond it wait
+                     in the queue for the next day (0 = unlimited)
+  archive.audio      Keep the source audio beside the transcript: discard (default),
+                     keep, or keep-days N to have 'sona clean' remove it after N days
+  quality.rerun_below
+                     Offer to re-transcribe when the quality score is below this
+                     (0-100, default: 70, 0 = never)
+  usage.price_per_hour
+                     Price per hour of audio, for the cost estimate in 'sona usage'
+                     and the cost of each transcript
+  usage.model_prices Prices per hour for models priced differently, e.g. best=0.37,nano=0.12
+  usage.concurrency_limit
+                     Concurrent transcripts your AssemblyAI plan allows, for 'sona usage'
+  multilingual.models
+                     Models for languages found by --multilingual, e.g. en=slam-1,hi=best
+  whisper.model      whisper.cpp model file used by --provider hybrid, e.g. ~/models/ggml-base.en.bin
+  tools.ffmpeg_path  ffmpeg binary to use instead of searching ~/.sona/bin and PATH;
+                     ffprobe and ffplay are looked for next to it first
+  tools.ytdlp_path   yt-dlp binary to use instead of searching ~/.sona/bin and PATH
+  youtube.metadata_ttl
+                     How long video details from yt-dlp are cached in ~/.sona/cache
+                     (default: 24h, 0 = always ask YouTube)
+  hybrid.min_confidence
+                     Local segments below this confidence are sent to AssemblyAI
+                     by --provider hybrid (0-1, default: 0.8)
+  proofread.provider Proofreading backend for --proofread (languagetool, llm)
+  proofread.url      LanguageTool /v2/check URL or OpenAI-compatible chat completions URL
+  proofread.language LanguageTool language code (default: auto)
+  proofread.model    Model name for the llm provider
+  proofread.api_key  API key for the llm provider, stored encrypted (or SONA_PROOFREAD_API_KEY)
+  history.size       Transcription runs kept for 'sona history' (default: 50, 0 = none)
+  assemblyai.region  Where AssemblyAI processes and stores your audio (us, eu; default: us)
+  ui.accessible      Plain sequential status lines without spinners, emoji or colors,
+                     for screen readers (true/false, default: false)
+  security.allow_install
+                     Allow 'sona install' to install or remove ffmpeg and yt-dlp
+                     (true/false, default: true)
+  security.allow_remote_delete
+                     Allow DELETE /v1/jobs/{id} on 'sona serve', even with an admin
+                     token (true/false, default: true)
+  security.max_upload_size
+                     Largest request 'sona serve' accepts, e.g. 500M (default: 2G,
+                     0 = unlimited)
+  alias.<name>       Command line run by 'sona <name>', e.g. "transcribe --format md --tag yt";
+                     an empty value removes the alias`,
 	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := args[0]
@@ -38,27 +138,73 @@ var configSetCmd = &cobra.Command{
 					viper.Set("assemblyai.api_key", value)
 				} else {
 					viper.Set("assemblyai.api_key", encryptedValue)
-					fmt.Printf("🔒 API key encrypted and saved successfully!\n")
+					fmt.Printf("%sAPI key encrypted and saved successfully!\n", style.Icon("🔒 "))
 				}
 			} else {
 				viper.Set("assemblyai.api_key", value)
-				fmt.Printf("⚠️  API key saved in plain text (encryption not available)\n")
+				fmt.Println(style.Warning("API key saved in plain text (encryption not available)"))
 			}
 			
 			// Persist config: always write to ~/.sona/config.toml
-			var err error
-			if _, statErr := os.Stat(configFilePath); os.IsNotExist(statErr) {
-				err = viper.WriteConfigAs(configFilePath)
+			if err := persistConfig(); err != nil {
+				fmt.Printf("Error saving config: %v\n", err)
+				return
+			}
+		case "api_keys":
+			var keys []string
+			for _, key := range strings.Split(value, ",") {
+				if key = strings.TrimSpace(key); key != "" {
+					keys = append(keys, key)
+				}
+			}
+			if err := SaveFallbackAPIKeys(keys); err != nil {
+				fmt.Printf("Error saving config: %v\n", err)
+				return
+			}
+			if len(keys) == 0 {
+				fmt.Println("Fallback API keys cleared")
 			} else {
-				err = viper.WriteConfig()
+				fmt.Printf("%s%d fallback API keys saved\n", style.Icon("🔒 "), len(keys))
 			}
-			
-			if err != nil {
+		case "proofread.api_key":
+			if err := SaveProofreadAPIKey(value); err != nil {
 				fmt.Printf("Error saving config: %v\n", err)
 				return
 			}
+			if value == "" {
+				fmt.Printf("%s cleared\n", key)
+			} else {
+				fmt.Printf("%s%s encrypted and saved\n", style.Icon("🔒 "), key)
+			}
 		default:
-			fmt.Printf("Unknown config key: %s\n", key)
+			if IsAliasKey(key) && value == "" {
+				if err := DeleteAlias(strings.TrimPrefix(key, aliasPrefix)); err != nil {
+					fmt.Println(style.Error("%v", err))
+					return
+				}
+				fmt.Printf("%s removed\n", key)
+				return
+			}
+			stored, err := ValidateSetting(key, value)
+			if errors.Is(err, ErrUnknownKey) {
+				fmt.Printf("Unknown config key: %s\n", key)
+				return
+			}
+			if err != nil {
+				fmt.Println(style.Error("%v", err))
+				return
+			}
+			viper.Set(key, stored)
+			if err := persistConfig(); err != nil {
+				fmt.Printf("Error saving config: %v\n", err)
+				return
+			}
+			switch {
+			case value == "" && key == "output.group":
+				fmt.Printf("%s cleared\n", key)
+			default:
+				fmt.Printf("%s set to %s\n", key, settingString(stored))
+			}
 		}
 	},
 }
@@ -69,6 +215,116 @@ var configShowCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("Current Configuration:")
 		fmt.Printf("API Key: %s\n", MaskAPIKey(viper.GetString("assemblyai.api_key")))
+		if keys := GetFallbackAPIKeys(); len(keys) > 0 {
+			masked := make([]string, len(keys))
+			for i, key := range keys {
+				masked[i] = MaskAPIKey(key)
+			}
+			fmt.Printf("Fallback API Keys: %s\n", strings.Join(masked, ", "))
+		} else {
+			fmt.Println("Fallback API Keys: none")
+		}
+		fmt.Printf("AssemblyAI Region: %s\n", strings.ToUpper(GetRegion()))
+		fmt.Printf("Default Model: %s\n", GetDefaultModel())
+		fmt.Printf("Default Provider: %s\n", GetDefaultProvider())
+		fmt.Printf("Default Formats: %s\n", strings.Join(GetDefaultFormats(), ","))
+		if profile := GetDefaultProfile(); profile != "" {
+			fmt.Printf("Default Profile: %s\n", profile)
+		} else {
+			fmt.Println("Default Profile: none")
+		}
+		fmt.Printf("Filename Timestamp Format: %s\n", GetTimestampFormat())
+		if rate := GetMaxDownloadRate(); rate > 0 {
+			fmt.Printf("Max Download Rate: %s\n", viper.GetString("network.max_download_rate"))
+		} else {
+			fmt.Println("Max Download Rate: unlimited")
+		}
+		protocol := "HTTP/2"
+		if !GetHTTP2() {
+			protocol = "HTTP/1.1"
+		}
+		fmt.Printf("AssemblyAI Connections: %s, %s upload buffer, %s idle timeout\n", protocol, viper.GetString("network.upload_buffer"), GetIdleTimeout())
+		fmt.Printf("AssemblyAI Timeouts: %s to connect, %s per request, uploads until idle for %s\n", GetConnectTimeout(), GetRequestTimeout(), GetUploadIdleTimeout())
+		fmt.Printf("Output File Mode: %04o\n", GetOutputFileMode())
+		if group := GetOutputGroup(); group != "" {
+			fmt.Printf("Output Group: %s\n", group)
+		} else {
+			fmt.Println("Output Group: unchanged")
+		}
+		fmt.Printf("Polling: every %s to %s", GetPollingDuration("polling.min_interval"), GetPollingDuration("polling.max_interval"))
+		if timeout := GetPollingDuration("polling.timeout"); timeout > 0 {
+			fmt.Printf(", timeout %s\n", timeout)
+		} else {
+			fmt.Println(", timeout automatic")
+		}
+		if minutes := GetDailyMinutes(); minutes > 0 {
+			fmt.Printf("Daily Budget: %d minutes\n", minutes)
+		} else {
+			fmt.Println("Daily Budget: unlimited")
+		}
+		fmt.Printf("Archive Audio: %s\n", GetAudioArchive())
+		if score := GetRerunBelow(); score > 0 {
+			fmt.Printf("Re-run Below: quality %d\n", score)
+		} else {
+			fmt.Println("Re-run Below: never")
+		}
+		if price := GetPricePerHour(); price > 0 {
+			fmt.Printf("Price Per Hour: %g\n", price)
+		} else {
+			fmt.Println("Price Per Hour: not set")
+		}
+		if prices := viper.GetString("usage.model_prices"); prices != "" {
+			fmt.Printf("Model Prices: %s\n", prices)
+		}
+		if limit := GetConcurrencyLimit(); limit > 0 {
+			fmt.Printf("Concurrency Limit: %d\n", limit)
+		} else {
+			fmt.Println("Concurrency Limit: unknown")
+		}
+		if models := viper.GetString("multilingual.models"); models != "" {
+			fmt.Printf("Multilingual Models: %s\n", models)
+		} else {
+			fmt.Println("Multilingual Models: none")
+		}
+		if model := GetWhisperModel(); model != "" {
+			fmt.Printf("Whisper Model: %s\n", model)
+		} else {
+			fmt.Println("Whisper Model: not set")
+		}
+		for _, tool := range []string{"ffmpeg", "yt-dlp"} {
+			if path := GetToolPath(tool); path != "" {
+				fmt.Printf("%s Path: %s\n", tool, path)
+			} else {
+				fmt.Printf("%s Path: auto\n", tool)
+			}
+		}
+		if ttl := GetMetadataTTL(); ttl > 0 {
+			fmt.Printf("YouTube Metadata Cache: %s\n", ttl)
+		} else {
+			fmt.Println("YouTube Metadata Cache: off")
+		}
+		fmt.Printf("Hybrid Min Confidence: %g\n", GetHybridConfidence())
+		fmt.Printf("Proofread Provider: %s\n", GetProofreadProvider())
+		if url := GetProofreadURL(); url != "" {
+			fmt.Printf("Proofread URL: %s\n", url)
+		} else {
+			fmt.Println("Proofread URL: not set")
+		}
+		fmt.Printf("History Size: %d runs\n", GetHistorySize())
+		fmt.Printf("Accessible Output: %t\n", GetAccessible())
+		fmt.Printf("Allow Install: %t\n", GetAllowInstall())
+		fmt.Printf("Allow Remote Delete: %t\n", GetAllowRemoteDelete())
+		if size := GetMaxUploadSize(); size > 0 {
+			fmt.Printf("Max Upload Size: %s\n", viper.GetString("security.max_upload_size"))
+		} else {
+			fmt.Println("Max Upload Size: unlimited")
+		}
+		if names := AliasNames(); len(names) > 0 {
+			fmt.Println("Aliases:")
+			for _, name := range names {
+				fmt.Printf("  %s = %s\n", name, Aliases()[name])
+			}
+		}
 		fmt.Printf("Config File: %s\n", viper.ConfigFileUsed())
 	},
 }
@@ -89,13 +345,11 @@ func InitConfig() {
 	}
 
 	// Set default config file path
-	home, err := os.UserHomeDir()
+	configDir, err := datadir.Dir()
 	if err != nil {
 		fmt.Printf("Error getting home directory: %v\n", err)
 		return
 	}
-
-	configDir := filepath.Join(home, ".sona")
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		fmt.Printf("Error creating config directory: %v\n", err)
 		return
@@ -105,12 +359,59 @@ func InitConfig() {
 	viper.SetConfigFile(configFilePath)
 	viper.SetConfigType("toml")
 
+	// Every setting can also come from the environment, e.g. defaults.model
+	// from SONA_DEFAULTS_MODEL, so containers need no config file
+	viper.SetEnvPrefix("SONA")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+
 	// Set defaults
 	viper.SetDefault("assemblyai.api_key", "")
-	viper.SetDefault("output.default_path", filepath.Join(home, "sona"))
-	viper.SetDefault("last_session.source_type", "")
-	viper.SetDefault("last_session.speech_model", "slam-1")
-	viper.SetDefault("last_session.output_path", "")
+	viper.SetDefault("assemblyai.api_keys", []string{})
+	viper.SetDefault("assemblyai.region", "us")
+	viper.SetDefault("output.default_path", defaultOutputPath(configDir))
+	viper.SetDefault("defaults.model", "slam-1")
+	viper.SetDefault("defaults.provider", "assemblyai")
+	viper.SetDefault("defaults.formats", []string{"txt"})
+	viper.SetDefault("defaults.profile", "")
+	viper.SetDefault("filename.timestamp_format", DefaultTimestampFormat)
+	viper.SetDefault("corrections.file", "")
+	viper.SetDefault("output.file_mode", "0644")
+	viper.SetDefault("output.group", "")
+	viper.SetDefault("network.max_download_rate", "0")
+	viper.SetDefault("network.http2", true)
+	viper.SetDefault("network.upload_buffer", "1M")
+	viper.SetDefault("network.idle_timeout", "90s")
+	viper.SetDefault("network.connect_timeout", "30s")
+	viper.SetDefault("network.request_timeout", "60s")
+	viper.SetDefault("network.upload_idle_timeout", "2m")
+	viper.SetDefault("uncertain.open", "[?")
+	viper.SetDefault("uncertain.close", "?]")
+	viper.SetDefault("polling.min_interval", "2s")
+	viper.SetDefault("polling.max_interval", "30s")
+	viper.SetDefault("polling.timeout", "0")
+	viper.SetDefault("budget.daily_minutes", 0)
+	viper.SetDefault("archive.audio", "discard")
+	viper.SetDefault("quality.rerun_below", 70)
+	viper.SetDefault("usage.price_per_hour", 0)
+	viper.SetDefault("usage.model_prices", "")
+	viper.SetDefault("usage.concurrency_limit", 0)
+	viper.SetDefault("multilingual.models", "")
+	viper.SetDefault("whisper.model", "")
+	viper.SetDefault("tools.ffmpeg_path", "")
+	viper.SetDefault("tools.ytdlp_path", "")
+	viper.SetDefault("youtube.metadata_ttl", "24h")
+	viper.SetDefault("hybrid.min_confidence", 0.8)
+	viper.SetDefault("proofread.provider", "languagetool")
+	viper.SetDefault("proofread.url", "")
+	viper.SetDefault("proofread.language", "auto")
+	viper.SetDefault("proofread.model", "")
+	viper.SetDefault("proofread.api_key", "")
+	viper.SetDefault("history.size", 50)
+	viper.SetDefault("ui.accessible", false)
+	viper.SetDefault("security.allow_install", true)
+	viper.SetDefault("security.allow_remote_delete", true)
+	viper.SetDefault("security.max_upload_size", "2G")
 
 	// Read config file (if exists)
 	if err := viper.ReadInConfig(); err != nil {
@@ -121,7 +422,7 @@ func InitConfig() {
 
 	// Write default config if it doesn't exist
 	if _, err := os.Stat(configFilePath); os.IsNotExist(err) {
-		if err := viper.WriteConfigAs(configFilePath); err != nil {
+		if err := persistConfig(); err != nil {
 			fmt.Printf("Warning: Could not write default config file: %v\n", err)
 		}
 	}
@@ -143,7 +444,7 @@ func MaskAPIKey(apiKey string) string {
 func GetAPIKey() string {
 	apiKey := GetAPIKeyNoExit()
 	if apiKey == "" {
-		fmt.Println("Error: AssemblyAI API key not found!")
+		fmt.Println(style.Error("AssemblyAI API key not found!"))
 		fmt.Println("Please set it using one of these methods:")
 		fmt.Println("1. Set environment variable: export ASSEMBLYAI_API_KEY='your_key_here'")
 		fmt.Println("2. Use config command: sona config set api_key 'your_key_here'")
@@ -166,7 +467,7 @@ func GetAPIKeyNoExit() string {
 	if encryptionManager != nil && encryptionManager.IsEncrypted(apiKey) {
 		decryptedKey, err := encryptionManager.Decrypt(apiKey)
 		if err != nil {
-			fmt.Printf("Error: Failed to decrypt API key: %v\n", err)
+			fmt.Println(style.Error("Failed to decrypt API key: %v", err))
 			fmt.Println("Please reset your API key using: sona config set api_key 'your_key_here'")
 			return ""
 		}
@@ -176,6 +477,47 @@ func GetAPIKeyNoExit() string {
 	return apiKey
 }
 
+// GetFallbackAPIKeys returns the keys in assemblyai.api_keys, in the order
+// they are tried, leaving out the main key and keys that cannot be decrypted
+func GetFallbackAPIKeys() []string {
+	primary := GetAPIKeyNoExit()
+	var keys []string
+	var entries []string
+	for _, entry := range viper.GetStringSlice("assemblyai.api_keys") {
+		// SONA_ASSEMBLYAI_API_KEYS gives the keys as one comma-separated value
+		entries = append(entries, strings.Split(entry, ",")...)
+	}
+	for _, key := range entries {
+		key = strings.TrimSpace(key)
+		if encryptionManager != nil && encryptionManager.IsEncrypted(key) {
+			decrypted, err := encryptionManager.Decrypt(key)
+			if err != nil {
+				fmt.Printf("Warning: Skipping a fallback API key that cannot be decrypted: %v\n", err)
+				continue
+			}
+			key = decrypted
+		}
+		if key != "" && key != primary {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// encryptKey encrypts an API key for the config file, or returns it as-is
+// when encryption is not available
+func encryptKey(key string) string {
+	if encryptionManager == nil {
+		return key
+	}
+	encrypted, err := encryptionManager.Encrypt(key)
+	if err != nil {
+		fmt.Printf("Warning: Could not encrypt API key, storing it in plain text: %v\n", err)
+		return key
+	}
+	return encrypted
+}
+
 // SaveAPIKey saves the API key to the config file
 func SaveAPIKey(apiKey string) error {
 	// Encrypt the API key if encryption is available
@@ -194,14 +536,100 @@ func SaveAPIKey(apiKey string) error {
 	}
 	
 	// Persist config
-	var err error
-	if _, statErr := os.Stat(configFilePath); os.IsNotExist(statErr) {
-		err = viper.WriteConfigAs(configFilePath)
+	return persistConfig()
+}
+
+// SaveFallbackAPIKeys replaces assemblyai.api_keys with keys, encrypted
+// like the main key
+func SaveFallbackAPIKeys(keys []string) error {
+	encrypted := make([]string, len(keys))
+	for i, key := range keys {
+		encrypted[i] = encryptKey(key)
+	}
+	viper.Set("assemblyai.api_keys", encrypted)
+	return persistConfig()
+}
+
+// SaveProofreadAPIKey saves the API key of the llm proofreading backend,
+// encrypted like the AssemblyAI key
+func SaveProofreadAPIKey(apiKey string) error {
+	if apiKey != "" {
+		apiKey = encryptKey(apiKey)
+	}
+	viper.Set("proofread.api_key", apiKey)
+	return persistConfig()
+}
+
+// defaultOutputPath is where transcripts go unless configured: ~/sona, or
+// beside the data directory in a container, where only volumes are writable
+func defaultOutputPath(dataDir string) string {
+	if datadir.InContainer() {
+		return filepath.Join(dataDir, "transcripts")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "sona")
+	}
+	return filepath.Join(dataDir, "transcripts")
+}
+
+// FilePath returns the location of the config file (~/.sona/config.toml)
+func FilePath() string {
+	return configFilePath
+}
+
+// Reload reads the config file again, e.g. after it was replaced on disk
+func Reload() error {
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	return nil
+}
+
+// persistConfig writes the current settings to the config file
+func persistConfig() error {
+	settings := viper.AllSettings()
+
+	// Values given as SONA_* environment variables are not settings of the
+	// file; keep what the file had for them
+	file := viper.New()
+	file.SetConfigFile(configFilePath)
+	file.SetConfigType("toml")
+	file.ReadInConfig()
+	for _, key := range viper.AllKeys() {
+		value, ok := os.LookupEnv(EnvVar(key))
+		if !ok || settingString(viper.Get(key)) != value {
+			continue
+		}
+		setNested(settings, key, file.Get(key), file.IsSet(key))
+	}
+	return writeSettings(settings)
+}
+
+// setNested sets or, when keep is false, removes a dotted key in settings
+func setNested(settings map[string]interface{}, key string, value interface{}, keep bool) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := settings[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		settings = next
+	}
+	if keep {
+		settings[parts[len(parts)-1]] = value
 	} else {
-		err = viper.WriteConfig()
+		delete(settings, parts[len(parts)-1])
 	}
-	
-	return err
+}
+
+// writeSettings replaces the config file with settings
+func writeSettings(settings map[string]interface{}) error {
+	updated := viper.New()
+	updated.SetConfigType("toml")
+	if err := updated.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("failed to update config: %v", err)
+	}
+	return updated.WriteConfigAs(configFilePath)
 }
 
 // GetOutputPath returns the default output path
@@ -209,31 +637,508 @@ func GetOutputPath() string {
 	return viper.GetString("output.default_path")
 }
 
-// GetLastSourceType returns the last used source type
-func GetLastSourceType() string {
-	return viper.GetString("last_session.source_type")
+// GetRegion returns the AssemblyAI region, us or eu
+func GetRegion() string {
+	return strings.ToLower(viper.GetString("assemblyai.region"))
 }
 
-// GetLastSpeechModel returns the last used speech model
-func GetLastSpeechModel() string {
-	model := viper.GetString("last_session.speech_model")
+// GetDefaultModel returns the speech model used when none is given on the command line
+func GetDefaultModel() string {
+	model := viper.GetString("defaults.model")
 	if model == "" {
-		return "slam-1" // Default if not set
+		return "slam-1"
 	}
 	return model
 }
 
-// GetLastOutputPath returns the last used output path
-func GetLastOutputPath() string {
-	return viper.GetString("last_session.output_path")
+// GetDefaultProvider returns the transcription provider used when none is given
+func GetDefaultProvider() string {
+	provider := viper.GetString("defaults.provider")
+	if provider == "" {
+		return "assemblyai"
+	}
+	return provider
 }
 
-// SaveLastSession saves the last session settings
-func SaveLastSession(sourceType, speechModel, outputPath string) error {
-	viper.Set("last_session.source_type", sourceType)
-	viper.Set("last_session.speech_model", speechModel)
-	viper.Set("last_session.output_path", outputPath)
-	
-	// Persist config
-	return viper.WriteConfig()
+// GetDefaultFormats returns the output formats used when none are given
+func GetDefaultFormats() []string {
+	var formats []string
+	for _, format := range viper.GetStringSlice("defaults.formats") {
+		formats = append(formats, splitList(format)...)
+	}
+	if len(formats) == 0 {
+		return []string{"txt"}
+	}
+	return formats
+}
+
+// GetDefaultProfile returns the output profile used when none is given, or "" for none
+func GetDefaultProfile() string {
+	return viper.GetString("defaults.profile")
+}
+
+// GetTimestampFormat returns the Go time layout used in generated filenames.
+// An explicitly empty value disables the timestamp.
+func GetTimestampFormat() string {
+	return viper.GetString("filename.timestamp_format")
+}
+
+// GetCorrectionsFile returns the configured glossary file, or "" to use the default location
+func GetCorrectionsFile() string {
+	return viper.GetString("corrections.file")
+}
+
+// GetMaxDownloadRate returns the download bandwidth limit in bytes per second, or 0 for unlimited
+func GetMaxDownloadRate() int64 {
+	value := viper.GetString("network.max_download_rate")
+	rate, err := ParseRate(value)
+	if err != nil {
+		fmt.Printf("Warning: ignoring network.max_download_rate: %v\n", err)
+		return 0
+	}
+	return rate
+}
+
+// GetHTTP2 reports whether connections to AssemblyAI may use HTTP/2
+func GetHTTP2() bool {
+	return viper.GetBool("network.http2")
+}
+
+// GetUploadBuffer returns the size in bytes of the chunks uploads are
+// written in, or 0 for the default
+func GetUploadBuffer() int {
+	size, err := ParseRate(viper.GetString("network.upload_buffer"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring network.upload_buffer: %v\n", err)
+		return 0
+	}
+	return int(size)
+}
+
+// GetIdleTimeout returns how long idle connections to AssemblyAI are kept
+// open, or 0 for the default
+func GetIdleTimeout() time.Duration {
+	d, err := ParseDuration(viper.GetString("network.idle_timeout"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring network.idle_timeout: %v\n", err)
+		return 0
+	}
+	return d
+}
+
+// timeoutOverrides are network timeouts given on the command line, which win
+// over the config file for this run
+var timeoutOverrides = make(map[string]time.Duration)
+
+// OverrideTimeout makes one of the network.*_timeout keys d for this run
+// without changing the config file
+func OverrideTimeout(key string, d time.Duration) {
+	timeoutOverrides[key] = d
+}
+
+// networkTimeout returns one of the network.*_timeout keys, or 0 for the
+// default
+func networkTimeout(key string) time.Duration {
+	if d, ok := timeoutOverrides[key]; ok {
+		return d
+	}
+	d, err := ParseDuration(viper.GetString(key))
+	if err != nil {
+		fmt.Printf("Warning: ignoring %s: %v\n", key, err)
+		return 0
+	}
+	return d
+}
+
+// GetConnectTimeout returns the longest to wait for a connection to
+// AssemblyAI, or 0 for the default
+func GetConnectTimeout() time.Duration {
+	return networkTimeout("network.connect_timeout")
+}
+
+// GetRequestTimeout returns the longest to wait for an AssemblyAI request
+// other than an upload, or 0 for the default
+func GetRequestTimeout() time.Duration {
+	return networkTimeout("network.request_timeout")
+}
+
+// GetUploadIdleTimeout returns how long an upload may go without sending
+// anything, or 0 for the default
+func GetUploadIdleTimeout() time.Duration {
+	return networkTimeout("network.upload_idle_timeout")
+}
+
+// GetOutputFileMode returns the permissions given to transcripts and other output files
+func GetOutputFileMode() os.FileMode {
+	mode, err := ParseFileMode(viper.GetString("output.file_mode"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring output.file_mode: %v\n", err)
+		return DefaultOutputFileMode
+	}
+	return mode
+}
+
+// GetOutputGroup returns the group that should own output files, or "" to leave it unchanged
+func GetOutputGroup() string {
+	return strings.TrimSpace(viper.GetString("output.group"))
+}
+
+// GetDailyMinutes returns the daily limit on transcribed audio in minutes, or 0 for unlimited
+func GetDailyMinutes() int {
+	return viper.GetInt("budget.daily_minutes")
+}
+
+// GetHistorySize returns how many runs 'sona history' keeps
+func GetHistorySize() int {
+	return viper.GetInt("history.size")
+}
+
+// GetAccessible reports whether output is meant for a screen reader
+func GetAccessible() bool {
+	return viper.GetBool("ui.accessible")
+}
+
+// GetAllowInstall reports whether 'sona install' may install or remove
+// dependencies; shared servers turn it off so binaries change only through
+// their admins
+func GetAllowInstall() bool {
+	return viper.GetBool("security.allow_install")
+}
+
+// GetAllowRemoteDelete reports whether 'sona serve' lets clients remove jobs
+func GetAllowRemoteDelete() bool {
+	return viper.GetBool("security.allow_remote_delete")
+}
+
+// GetMaxUploadSize returns the largest request body 'sona serve' accepts,
+// in bytes, or 0 for no limit
+func GetMaxUploadSize() int64 {
+	size, err := ParseRate(viper.GetString("security.max_upload_size"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring security.max_upload_size: %v\n", err)
+		return 0
+	}
+	return size
+}
+
+// GetRerunBelow returns the quality score below which a re-run is offered, or 0 to never offer one
+func GetRerunBelow() int {
+	return viper.GetInt("quality.rerun_below")
+}
+
+// GetPricePerHour returns the price per hour of audio used for cost estimates, or 0 when not set
+func GetPricePerHour() float64 {
+	return viper.GetFloat64("usage.price_per_hour")
+}
+
+// GetModelPrice returns the price per hour of audio transcribed with the
+// model: its entry in usage.model_prices, else usage.price_per_hour. It
+// returns 0 when no price is set. Invalid settings are ignored with a warning.
+func GetModelPrice(model string) float64 {
+	prices, err := ParseModelPrices(viper.GetString("usage.model_prices"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring usage.model_prices: %v\n", err)
+	}
+	if price, ok := prices[model]; ok {
+		return price
+	}
+	return GetPricePerHour()
+}
+
+// ParseModelPrices parses "model=price" pairs such as "best=0.37,nano=0.12"
+func ParseModelPrices(value string) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	for _, pair := range splitList(value) {
+		model, price, ok := strings.Cut(pair, "=")
+		model = strings.TrimSpace(model)
+		amount, err := strconv.ParseFloat(strings.TrimSpace(price), 64)
+		if !ok || model == "" || err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid model price %q (use e.g. best=0.37,nano=0.12)", pair)
+		}
+		prices[model] = amount
+	}
+	return prices, nil
+}
+
+// GetConcurrencyLimit returns the concurrent transcripts the account allows, or 0 when unknown
+func GetConcurrencyLimit() int {
+	return viper.GetInt("usage.concurrency_limit")
+}
+
+// GetWhisperModel returns the whisper.cpp model file, with ~ expanded, or "" when not set
+func GetWhisperModel() string {
+	return expandHome(viper.GetString("whisper.model"))
+}
+
+// toolKeys are the config keys holding the binary to use for a tool
+var toolKeys = map[string]string{
+	"ffmpeg": "tools.ffmpeg_path",
+	"yt-dlp": "tools.ytdlp_path",
+}
+
+// GetToolPath returns the configured binary for ffmpeg or yt-dlp, with ~
+// expanded, or "" to look for it in ~/.sona/bin and on PATH
+func GetToolPath(tool string) string {
+	key, ok := toolKeys[tool]
+	if !ok {
+		return ""
+	}
+	return expandHome(viper.GetString(key))
+}
+
+// GetMetadataTTL returns how long cached YouTube video details stay fresh,
+// or 0 to not cache them
+func GetMetadataTTL() time.Duration {
+	d, err := ParseDuration(viper.GetString("youtube.metadata_ttl"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring youtube.metadata_ttl: %v\n", err)
+		return 0
+	}
+	return d
+}
+
+// expandHome trims a path and expands a leading ~/ to the home directory
+func expandHome(path string) string {
+	path = strings.TrimSpace(path)
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// GetHybridConfidence returns the confidence below which --provider hybrid
+// sends a local segment to AssemblyAI
+func GetHybridConfidence() float64 {
+	return viper.GetFloat64("hybrid.min_confidence")
+}
+
+// GetProofreadProvider returns the proofreading backend used by --proofread
+func GetProofreadProvider() string {
+	provider := viper.GetString("proofread.provider")
+	if provider == "" {
+		return "languagetool"
+	}
+	return provider
+}
+
+// GetProofreadURL returns the proofreading endpoint
+func GetProofreadURL() string {
+	return viper.GetString("proofread.url")
+}
+
+// GetProofreadLanguage returns the LanguageTool language code
+func GetProofreadLanguage() string {
+	return viper.GetString("proofread.language")
+}
+
+// GetProofreadModel returns the model used by the llm proofreading backend
+func GetProofreadModel() string {
+	return viper.GetString("proofread.model")
+}
+
+// GetProofreadAPIKey returns the proofreading API key, preferring SONA_PROOFREAD_API_KEY
+func GetProofreadAPIKey() string {
+	if apiKey := os.Getenv("SONA_PROOFREAD_API_KEY"); apiKey != "" {
+		return apiKey
+	}
+	apiKey := viper.GetString("proofread.api_key")
+	if encryptionManager != nil && encryptionManager.IsEncrypted(apiKey) {
+		decrypted, err := encryptionManager.Decrypt(apiKey)
+		if err != nil {
+			fmt.Println(style.Error("Failed to decrypt proofread API key: %v", err))
+			fmt.Println("Please reset it using: sona config set proofread.api_key 'your_key_here'")
+			return ""
+		}
+		return decrypted
+	}
+	return apiKey
+}
+
+// GetUncertainMarkers returns the markers placed around low-confidence words
+func GetUncertainMarkers() (string, string) {
+	return viper.GetString("uncertain.open"), viper.GetString("uncertain.close")
+}
+
+// GetPollingDuration returns one of the polling.* durations, or 0 if it is unset or invalid
+func GetPollingDuration(key string) time.Duration {
+	d, err := ParseDuration(viper.GetString(key))
+	if err != nil {
+		fmt.Printf("Warning: ignoring %s: %v\n", key, err)
+		return 0
+	}
+	return d
+}
+
+// GetLanguageModels returns the speech model to use for each language code
+// found by --multilingual. Invalid settings are ignored with a warning.
+func GetLanguageModels() map[string]string {
+	models, err := ParseLanguageModels(viper.GetString("multilingual.models"))
+	if err != nil {
+		fmt.Printf("Warning: ignoring multilingual.models: %v\n", err)
+		return nil
+	}
+	return models
+}
+
+// ParseLanguageModels parses "language=model" pairs such as "en=slam-1,hi=best"
+func ParseLanguageModels(value string) (map[string]string, error) {
+	models := make(map[string]string)
+	for _, pair := range splitList(value) {
+		language, model, ok := strings.Cut(pair, "=")
+		language = strings.ToLower(strings.TrimSpace(language))
+		model = strings.TrimSpace(model)
+		if !ok || language == "" || model == "" {
+			return nil, fmt.Errorf("invalid language model %q (use e.g. en=slam-1,hi=best)", pair)
+		}
+		models[language] = model
+	}
+	return models, nil
+}
+
+// ParseDuration parses a Go duration such as "30s" or "1h30m". "0" and "" mean unset.
+func ParseDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q (use e.g. 5s, 2m or 1h30m)", value)
+	}
+	return d, nil
+}
+
+// ParseRate parses a rate such as "500K", "2M" or "1.5M" into bytes per second.
+// Suffixes are binary multiples, matching curl and yt-dlp. "0" and "" mean unlimited.
+func ParseRate(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+	number := strings.TrimRight(value, "KMG")
+
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("invalid rate %q (use a number with an optional K, M or G suffix, e.g. 2M)", value)
+	}
+	return int64(amount * multiplier), nil
+}
+
+// AudioArchive is the archive.audio policy for the source audio of a
+// transcript: discarded with the temp files, or kept beside the transcript
+// for good or for a number of days
+type AudioArchive struct {
+	Keep bool
+	// Days is how long kept audio stays, 0 for good
+	Days int
+}
+
+// String renders the policy as it is written in the config
+func (a AudioArchive) String() string {
+	switch {
+	case !a.Keep:
+		return "discard"
+	case a.Days > 0:
+		return fmt.Sprintf("keep-days %d", a.Days)
+	default:
+		return "keep"
+	}
+}
+
+// Expired reports whether audio archived at archived is past the policy
+// and should be removed
+func (a AudioArchive) Expired(archived time.Time) bool {
+	if !a.Keep {
+		return true
+	}
+	return a.Days > 0 && time.Since(archived) > time.Duration(a.Days)*24*time.Hour
+}
+
+// ParseAudioArchive parses an archive.audio policy: "discard", "keep" or
+// "keep-days N"
+func ParseAudioArchive(value string) (AudioArchive, error) {
+	fields := strings.Fields(strings.ReplaceAll(strings.ToLower(value), "=", " "))
+	switch {
+	case len(fields) == 0, len(fields) == 1 && fields[0] == "discard":
+		return AudioArchive{}, nil
+	case len(fields) == 1 && fields[0] == "keep":
+		return AudioArchive{Keep: true}, nil
+	case len(fields) == 2 && fields[0] == "keep-days":
+		days, err := strconv.Atoi(fields[1])
+		if err == nil && days > 0 {
+			return AudioArchive{Keep: true, Days: days}, nil
+		}
+	}
+	return AudioArchive{}, fmt.Errorf("invalid audio archive policy %q (use discard, keep or keep-days N)", value)
+}
+
+// GetAudioArchive returns the archive.audio policy, discard if it is invalid
+func GetAudioArchive() AudioArchive {
+	archive, err := ParseAudioArchive(viper.GetString("archive.audio"))
+	if err != nil {
+		fmt.Printf("Warning: %v, discarding audio\n", err)
+	}
+	return archive
+}
+
+// ParseFileMode parses octal permissions such as "0640" or "664". "" means
+// DefaultOutputFileMode.
+func ParseFileMode(value string) (os.FileMode, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultOutputFileMode, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q (use octal permissions, e.g. 0640)", value)
+	}
+	if mode&0600 != 0600 {
+		return 0, fmt.Errorf("file mode %s would keep sona from rewriting its own output (the owner needs read and write)", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// LookupGroupID resolves a group name or numeric ID to a group ID. "" means
+// no group and returns -1, which leaves ownership unchanged.
+func LookupGroupID(group string) (int, error) {
+	group = strings.TrimSpace(group)
+	if group == "" {
+		return -1, nil
+	}
+	if gid, err := strconv.Atoi(group); err == nil && gid >= 0 {
+		return gid, nil
+	}
+	found, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %q", group)
+	}
+	gid, err := strconv.Atoi(found.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("group %q has no numeric ID on this system", group)
+	}
+	return gid, nil
+}
+
+// splitList splits a comma-separated value into trimmed, lowercase, non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
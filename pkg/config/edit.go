@@ -0,0 +1,162 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the location of the config file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(FilePath())
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:   "edit",
+	Short: "Edit the config file in your editor",
+	Long: `Open a copy of the config file in $VISUAL or $EDITOR (vi, or notepad on
+Windows, when neither is set). After you save and close the editor, every
+setting is checked the way 'config set' checks it. The config file is only
+replaced when the copy is valid; otherwise you can edit it again or discard
+the changes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := editConfig(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ConfigCmd.AddCommand(configPathCmd)
+	ConfigCmd.AddCommand(configEditCmd)
+}
+
+// editConfig edits a copy of the config file until it validates or the
+// changes are discarded, then replaces the config file with it
+func editConfig() error {
+	path := FilePath()
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	// The copy keeps the .toml extension so editors highlight it
+	draft, err := os.CreateTemp(filepath.Dir(path), "config-edit-*.toml")
+	if err != nil {
+		return fmt.Errorf("failed to create a copy of the config: %v", err)
+	}
+	draftPath := draft.Name()
+	defer os.Remove(draftPath)
+	_, err = draft.Write(original)
+	if closeErr := draft.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create a copy of the config: %v", err)
+	}
+
+	for {
+		if err := runEditor(draftPath); err != nil {
+			return err
+		}
+		edited, err := os.ReadFile(draftPath)
+		if err != nil {
+			return fmt.Errorf("failed to read the edited config: %v", err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes")
+			return nil
+		}
+
+		if valid := reportProblems(draftPath); !valid {
+			if confirm("Edit again? (y/n): ") {
+				continue
+			}
+			fmt.Println("Changes discarded")
+			return nil
+		}
+
+		if err := atomicfile.WriteFile(path, edited, perm); err != nil {
+			return fmt.Errorf("failed to save config: %v", err)
+		}
+		if err := Reload(); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %s\n", path)
+		return nil
+	}
+}
+
+// reportProblems validates the edited config and prints what is wrong with
+// it. Unknown keys are only warned about, since sona ignores them.
+func reportProblems(path string) bool {
+	problems, err := ValidateFile(path)
+	if err != nil {
+		fmt.Println(style.Failure("%v", err))
+		return false
+	}
+
+	valid := true
+	for _, problem := range problems {
+		if errors.Is(problem.Err, ErrUnknownKey) {
+			fmt.Println(style.Warning("Unknown key %s will be ignored", problem.Key))
+			continue
+		}
+		fmt.Println(style.Failure("%s", problem))
+		valid = false
+	}
+	return valid
+}
+
+// runEditor opens path in the user's editor and waits for it to close. The
+// editor setting may carry arguments, e.g. "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %v", fields[0], err)
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Print(question)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+}
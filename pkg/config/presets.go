@@ -0,0 +1,201 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// presetNamePattern keeps preset names usable as config keys
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidatePresetName checks the name a preset is saved under
+func ValidatePresetName(name string) error {
+	if !presetNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q (use lowercase letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// Preset is a named set of transcribe flags. Each flag has one value, or
+// several for flags that can be repeated such as --tag.
+type Preset map[string][]string
+
+// builtinPresets are ready-made presets for common kinds of recording. A
+// preset saved under the same name takes their place.
+var builtinPresets = map[string]Preset{
+	"meeting": {
+		"profile":       {"broadcast"},
+		"show-notes":    {"true"},
+		"format":        {"txt", "md"},
+		"name-template": {"{date}-meeting-{title}"},
+	},
+	"podcast": {
+		"profile":    {"broadcast"},
+		"show-notes": {"true"},
+		"music":      {"trim"},
+		"format":     {"txt", "md"},
+	},
+	"lecture": {
+		"profile":       {"broadcast"},
+		"show-notes":    {"true"},
+		"format":        {"txt", "md"},
+		"name-template": {"{date}-lecture-{title}"},
+	},
+	"call": {
+		"profile":           {"casual"},
+		"speakers-expected": {"2"},
+		"name-template":     {"{date}-call-{title}"},
+	},
+	"interview": {
+		"profile":           {"broadcast"},
+		"speakers-expected": {"2"},
+		"format":            {"txt", "md"},
+	},
+}
+
+// GetPreset returns the preset saved under name, or the built-in one
+func GetPreset(name string) (Preset, bool) {
+	if preset, ok := savedPreset(name); ok {
+		return preset, true
+	}
+	preset, ok := builtinPresets[name]
+	return preset, ok
+}
+
+// IsBuiltinPreset reports whether name is a built-in preset that no saved
+// preset replaces
+func IsBuiltinPreset(name string) bool {
+	_, builtin := builtinPresets[name]
+	_, saved := savedPreset(name)
+	return builtin && !saved
+}
+
+// savedPreset returns the preset saved in the config file under name
+func savedPreset(name string) (Preset, bool) {
+	settings, ok := viper.GetStringMap("presets")[name].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	preset := make(Preset, len(settings))
+	for flag, value := range settings {
+		switch items := value.(type) {
+		case []interface{}:
+			for _, item := range items {
+				preset[flag] = append(preset[flag], fmt.Sprint(item))
+			}
+		default:
+			preset[flag] = []string{fmt.Sprint(value)}
+		}
+	}
+	return preset, true
+}
+
+// PresetNames returns the names of the saved and built-in presets, sorted
+func PresetNames() []string {
+	var names []string
+	for name := range viper.GetStringMap("presets") {
+		names = append(names, name)
+	}
+	for name := range builtinPresets {
+		if _, saved := savedPreset(name); !saved {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SavePreset stores a preset in the config file, replacing one with the same name
+func SavePreset(name string, preset Preset) error {
+	return rewriteConfig(func(settings map[string]interface{}) {
+		presets, _ := settings["presets"].(map[string]interface{})
+		if presets == nil {
+			presets = make(map[string]interface{})
+		}
+		flags := make(map[string]interface{}, len(preset))
+		for flag, values := range preset {
+			if len(values) == 1 {
+				flags[flag] = values[0]
+			} else {
+				flags[flag] = values
+			}
+		}
+		presets[name] = flags
+		settings["presets"] = presets
+	})
+}
+
+// DeletePreset removes a preset from the config file. Built-in presets
+// cannot be deleted, only replaced, and deleting the preset that replaced
+// one brings it back.
+func DeletePreset(name string) error {
+	if IsBuiltinPreset(name) {
+		return fmt.Errorf("%s is a built-in preset and cannot be deleted; save a preset named %s to replace it", name, name)
+	}
+	if _, ok := savedPreset(name); !ok {
+		return fmt.Errorf("no preset named %s", name)
+	}
+	return rewriteConfig(func(settings map[string]interface{}) {
+		if presets, ok := settings["presets"].(map[string]interface{}); ok {
+			delete(presets, name)
+		}
+	})
+}
+
+// rewriteConfig edits the settings in the config file and writes it back.
+// Unlike persistConfig it can remove settings, which viper cannot unset.
+func rewriteConfig(edit func(settings map[string]interface{})) error {
+	file := viper.New()
+	file.SetConfigFile(configFilePath)
+	file.SetConfigType("toml")
+	if err := file.ReadInConfig(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+
+	settings := file.AllSettings()
+	edit(settings)
+
+	if err := writeSettings(settings); err != nil {
+		return fmt.Errorf("failed to save config: %v", err)
+	}
+	return Reload()
+}
+
+// FormatPreset renders a preset as the flags it stands for, e.g.
+// "--model best --format md"
+func FormatPreset(preset Preset) string {
+	flags := make([]string, 0, len(preset))
+	for flag := range preset {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+
+	var parts []string
+	for _, flag := range flags {
+		for _, value := range preset[flag] {
+			switch value {
+			case "true":
+				parts = append(parts, "--"+flag)
+			case "false":
+				parts = append(parts, "--"+flag+"=false")
+			default:
+				parts = append(parts, "--"+flag, quoteArg(value))
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// quoteArg quotes a value that a shell would otherwise split
+func quoteArg(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"'") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ErrUnknownKey is returned for keys sona does not read
+var ErrUnknownKey = errors.New("unknown config key")
+
+// validator checks a value given for key and returns it in the form it is
+// stored in the config file
+type validator func(key string, value string) (interface{}, error)
+
+// anyString accepts every value as-is
+func anyString(key string, value string) (interface{}, error) {
+	return value, nil
+}
+
+// toolPath accepts an existing file, or "" to search for the tool again
+func toolPath(key string, value string) (interface{}, error) {
+	if value == "" {
+		return value, nil
+	}
+	info, err := os.Stat(expandHome(value))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", key, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s must be the binary itself, not the directory %s", key, value)
+	}
+	return value, nil
+}
+
+// validators covers every key 'config set' accepts except api_key, which is
+// encrypted before it is stored
+var validators = map[string]validator{
+	"defaults.model":            anyString,
+	"defaults.provider":         anyString,
+	"defaults.profile":          anyString,
+	"filename.timestamp_format": anyString,
+	"corrections.file":          anyString,
+	"uncertain.open":            anyString,
+	"uncertain.close":           anyString,
+	"proofread.provider":        anyString,
+	"proofread.url":             anyString,
+	"proofread.language":        anyString,
+	"proofread.model":           anyString,
+	"proofread.api_key":         anyString,
+	"whisper.model":             anyString,
+	"tools.ffmpeg_path":         toolPath,
+	"tools.ytdlp_path":          toolPath,
+	"youtube.metadata_ttl":      durationValue,
+	"network.max_download_rate": func(key string, value string) (interface{}, error) {
+		_, err := ParseRate(value)
+		return value, err
+	},
+	"network.http2": boolValue,
+	"network.upload_buffer": func(key string, value string) (interface{}, error) {
+		size, err := ParseRate(value)
+		if err != nil || size > 64*1024*1024 {
+			return nil, fmt.Errorf("%s must be a size up to 64M, e.g. 4M", key)
+		}
+		return value, nil
+	},
+	"network.idle_timeout":        durationValue,
+	"network.connect_timeout":     durationValue,
+	"network.request_timeout":     durationValue,
+	"network.upload_idle_timeout": durationValue,
+	"output.file_mode": func(key string, value string) (interface{}, error) {
+		mode, err := ParseFileMode(value)
+		return fmt.Sprintf("%04o", mode), err
+	},
+	"output.group": func(key string, value string) (interface{}, error) {
+		_, err := LookupGroupID(value)
+		return value, err
+	},
+	"polling.min_interval": durationValue,
+	"polling.max_interval": durationValue,
+	"polling.timeout":      durationValue,
+	"budget.daily_minutes": func(key string, value string) (interface{}, error) {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			return nil, fmt.Errorf("%s must be a whole number of minutes, 0 for unlimited", key)
+		}
+		return minutes, nil
+	},
+	"archive.audio": func(key string, value string) (interface{}, error) {
+		archive, err := ParseAudioArchive(value)
+		return archive.String(), err
+	},
+	"quality.rerun_below": func(key string, value string) (interface{}, error) {
+		score, err := strconv.Atoi(value)
+		if err != nil || score < 0 || score > 100 {
+			return nil, fmt.Errorf("%s must be a score between 0 and 100, 0 to never re-run", key)
+		}
+		return score, nil
+	},
+	"usage.price_per_hour": func(key string, value string) (interface{}, error) {
+		price, err := strconv.ParseFloat(value, 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative number, e.g. 0.37", key)
+		}
+		return price, nil
+	},
+	"hybrid.min_confidence": func(key string, value string) (interface{}, error) {
+		confidence, err := strconv.ParseFloat(value, 64)
+		if err != nil || confidence < 0 || confidence > 1 {
+			return nil, fmt.Errorf("%s must be a confidence between 0 and 1, e.g. 0.8", key)
+		}
+		return confidence, nil
+	},
+	"usage.model_prices": func(key string, value string) (interface{}, error) {
+		_, err := ParseModelPrices(value)
+		return value, err
+	},
+	"usage.concurrency_limit": func(key string, value string) (interface{}, error) {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("%s must be a whole number, 0 for unknown", key)
+		}
+		return limit, nil
+	},
+	"history.size": func(key string, value string) (interface{}, error) {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("%s must be a whole number of runs, 0 to keep none", key)
+		}
+		return size, nil
+	},
+	"ui.accessible":                boolValue,
+	"security.allow_install":       boolValue,
+	"security.allow_remote_delete": boolValue,
+	"security.max_upload_size": func(key string, value string) (interface{}, error) {
+		_, err := ParseRate(value)
+		return value, err
+	},
+	"assemblyai.region": func(key string, value string) (interface{}, error) {
+		region := strings.ToLower(strings.TrimSpace(value))
+		if region != "us" && region != "eu" {
+			return nil, fmt.Errorf("%s must be us or eu", key)
+		}
+		return region, nil
+	},
+	"multilingual.models": func(key string, value string) (interface{}, error) {
+		_, err := ParseLanguageModels(value)
+		return value, err
+	},
+	"defaults.formats": func(key string, value string) (interface{}, error) {
+		formats := splitList(value)
+		if len(formats) == 0 {
+			return nil, fmt.Errorf("at least one format is required")
+		}
+		return formats, nil
+	},
+}
+
+// internalKeys are written by sona itself rather than 'config set'
+var internalKeys = map[string]bool{
+	"assemblyai.api_key":  true,
+	"assemblyai.api_keys": true,
+	"output.default_path": true,
+	// Written by versions before 'sona history'
+	"last_session.source_type":  true,
+	"last_session.speech_model": true,
+	"last_session.output_path":  true,
+}
+
+// durationValue accepts the polling durations ParseDuration understands
+func durationValue(key string, value string) (interface{}, error) {
+	_, err := ParseDuration(value)
+	return value, err
+}
+
+// boolValue accepts true or false
+func boolValue(key string, value string) (interface{}, error) {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", key)
+	}
+	return enabled, nil
+}
+
+// ValidateSetting checks a value for key and returns it in the form it is
+// stored in the config file
+func ValidateSetting(key string, value string) (interface{}, error) {
+	if IsAliasKey(key) {
+		return validateAlias(key, value)
+	}
+	validate, ok := validators[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+	return validate(key, value)
+}
+
+// Problem is a setting of a config file that sona cannot use
+type Problem struct {
+	Key string
+	Err error
+}
+
+// String names the key unless the error already starts with it
+func (p Problem) String() string {
+	if message := p.Err.Error(); strings.HasPrefix(message, p.Key) {
+		return message
+	}
+	return fmt.Sprintf("%s: %v", p.Key, p.Err)
+}
+
+// ValidateFile reads a config file and checks every setting in it the way
+// 'config set' would. It returns an error when the file cannot be parsed at
+// all. Unknown keys are reported as problems too; they are ignored by sona,
+// which usually means a typo.
+func ValidateFile(path string) ([]Problem, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	keys := v.AllKeys()
+	sort.Strings(keys)
+	var problems []Problem
+	for _, key := range keys {
+		// Presets are flags, checked by 'sona preset save'
+		if internalKeys[key] || strings.HasPrefix(key, "presets.") {
+			continue
+		}
+		if _, err := ValidateSetting(key, settingString(v.Get(key))); err != nil {
+			problems = append(problems, Problem{Key: key, Err: err})
+		}
+	}
+	return problems, nil
+}
+
+// EnvVar returns the environment variable that sets key, e.g.
+// SONA_DEFAULTS_MODEL for defaults.model
+func EnvVar(key string) string {
+	return "SONA_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ValidateEnv checks the settings given as SONA_* environment variables the
+// way 'config set' would, so a misconfigured container fails at start
+func ValidateEnv() []Problem {
+	keys := make([]string, 0, len(validators))
+	for key := range validators {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []Problem
+	for _, key := range keys {
+		value, ok := os.LookupEnv(EnvVar(key))
+		if !ok {
+			continue
+		}
+		if _, err := validators[key](key, value); err != nil {
+			problems = append(problems, Problem{Key: EnvVar(key), Err: err})
+		}
+	}
+	return problems
+}
+
+// settingString renders a value read from the config file the way it would
+// be given to 'config set', lists as comma-separated items
+func settingString(value interface{}) string {
+	switch items := value.(type) {
+	case []string:
+		return strings.Join(items, ",")
+	case []interface{}:
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
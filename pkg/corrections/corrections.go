@@ -0,0 +1,218 @@
+package corrections
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"gopkg.in/yaml.v3"
+)
+
+// regexKey is the reserved top-level key holding regex rules
+const regexKey = "regex"
+
+// Rule replaces every match of Pattern with Replacement
+type Rule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+	// Source is the original glossary entry, used in reports
+	Source string
+	// Literal rules insert Replacement as-is instead of expanding $1 references
+	Literal bool
+}
+
+// Glossary is an ordered list of correction rules and the terms near
+// misses are corrected to
+type Glossary struct {
+	Rules []Rule
+	Terms []Term
+}
+
+// regexEntry is a regex rule as written in corrections.yaml
+type regexEntry struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// DefaultPath returns the default glossary location (~/.sona/corrections.yaml)
+func DefaultPath() (string, error) {
+	return datadir.Path("corrections.yaml")
+}
+
+// Load reads a glossary file. The file maps misrecognized words to their
+// correct spelling, plus an optional "regex" list of pattern/replace rules
+// and an optional "terms" list of names to fuzzy-match:
+//
+//	assemblyai: AssemblyAI
+//	sona: Sona
+//	regex:
+//	  - pattern: '\bk ?8 ?s\b'
+//	    replace: Kubernetes
+//	terms:
+//	  - PostgreSQL
+//	  - Kubernetes
+func Load(path string) (*Glossary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse builds a glossary from YAML. Word pairs are matched case-insensitively
+// on word boundaries, longest first, and are applied before regex rules.
+func Parse(data []byte) (*Glossary, error) {
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid glossary: %v", err)
+	}
+
+	glossary := &Glossary{}
+	var words []string
+	pairs := make(map[string]string)
+
+	for key, node := range raw {
+		if key == regexKey || key == termsKey {
+			continue
+		}
+		var replacement string
+		if err := node.Decode(&replacement); err != nil {
+			return nil, fmt.Errorf("invalid replacement for %q (line %d): %v", key, node.Line, err)
+		}
+		words = append(words, key)
+		pairs[key] = replacement
+	}
+
+	// Longer phrases first so "assembly ai" wins over "ai"
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+
+	for _, word := range words {
+		glossary.Rules = append(glossary.Rules, Rule{
+			Pattern:     wordPattern(word),
+			Replacement: pairs[word],
+			Source:      word,
+			Literal:     true,
+		})
+	}
+
+	if node, ok := raw[regexKey]; ok {
+		var entries []regexEntry
+		if err := node.Decode(&entries); err != nil {
+			return nil, fmt.Errorf("invalid regex rules (line %d): %v", node.Line, err)
+		}
+		for _, entry := range entries {
+			pattern, err := regexp.Compile(entry.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %v", entry.Pattern, err)
+			}
+			glossary.Rules = append(glossary.Rules, Rule{
+				Pattern:     pattern,
+				Replacement: entry.Replace,
+				Source:      entry.Pattern,
+			})
+		}
+	}
+
+	if node, ok := raw[termsKey]; ok {
+		var terms []string
+		if err := node.Decode(&terms); err != nil {
+			return nil, fmt.Errorf("invalid terms (line %d): %v", node.Line, err)
+		}
+		for _, spelling := range terms {
+			if normalize(spelling) == "" {
+				return nil, fmt.Errorf("invalid term %q: it has no letters or digits", spelling)
+			}
+			glossary.Terms = append(glossary.Terms, newTerm(spelling))
+		}
+	}
+
+	return glossary, nil
+}
+
+// AddTerms adds spellings near misses are corrected to, skipping ones the
+// glossary has already and ones without letters or digits
+func (g *Glossary) AddTerms(spellings []string) {
+	for _, spelling := range spellings {
+		term := newTerm(spelling)
+		if term.normalized == "" {
+			continue
+		}
+		known := false
+		for _, existing := range g.Terms {
+			if existing.normalized == term.normalized {
+				known = true
+				break
+			}
+		}
+		if !known {
+			g.Terms = append(g.Terms, term)
+		}
+	}
+}
+
+// wordPattern matches a word or phrase case-insensitively. Word boundaries
+// are only required on edges that are word characters, so entries like
+// "c++" still match.
+func wordPattern(word string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(word)
+
+	first, _ := utf8.DecodeRuneInString(word)
+	last, _ := utf8.DecodeLastRuneInString(word)
+	if isWordRune(first) {
+		pattern = `\b` + pattern
+	}
+	if isWordRune(last) {
+		pattern += `\b`
+	}
+
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Apply runs every rule over the text, then corrects near misses of the
+// terms, and returns the corrected text and the changes made
+func (g *Glossary) Apply(text string) (string, []Change) {
+	var changes []Change
+	for _, rule := range g.Rules {
+		text, changes = rule.apply(text, changes)
+	}
+	return g.correctTerms(text, changes)
+}
+
+// apply replaces every match of the rule, adding what actually changed to
+// changes
+func (r Rule) apply(text string, changes []Change) (string, []Change) {
+	matches := r.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, changes
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		replacement := r.Replacement
+		if !r.Literal {
+			replacement = string(r.Pattern.ExpandString(nil, r.Replacement, text, m))
+		}
+		b.WriteString(text[last:m[0]])
+		b.WriteString(replacement)
+		last = m[1]
+		if original := text[m[0]:m[1]]; original != replacement {
+			changes = addChange(changes, original, replacement)
+		}
+	}
+	b.WriteString(text[last:])
+	return b.String(), changes
+}
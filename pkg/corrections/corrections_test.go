@@ -0,0 +1,43 @@
+package corrections
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	glossary, err := Parse([]byte(`
+assemblyai: AssemblyAI
+regex:
+  - pattern: '\bk ?8 ?s\b'
+    replace: Kubernetes
+  - pattern: '(\d+) ?percent'
+    replace: '$1%'
+terms:
+  - PostgreSQL
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text    string
+		want    string
+		changes []Change
+	}{
+		{"we use assemblyai", "we use AssemblyAI", []Change{{"assemblyai", "AssemblyAI", 1}}},
+		{"AssemblyAI is right", "AssemblyAI is right", nil},
+		{"k8s and k 8 s", "Kubernetes and Kubernetes", []Change{{"k8s", "Kubernetes", 1}, {"k 8 s", "Kubernetes", 1}}},
+		{"up 20 percent", "up 20%", []Change{{"20 percent", "20%", 1}}},
+		{"on post gres QL", "on PostgreSQL", []Change{{"post gres QL", "PostgreSQL", 1}}},
+	}
+	for _, tt := range tests {
+		got, changes := glossary.Apply(tt.text)
+		if got != tt.want {
+			t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if !slices.Equal(changes, tt.changes) {
+			t.Errorf("Apply(%q) changes = %v, want %v", tt.text, changes, tt.changes)
+		}
+	}
+}
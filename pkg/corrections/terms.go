@@ -0,0 +1,202 @@
+package corrections
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// termsKey is the reserved top-level key holding glossary terms
+const termsKey = "terms"
+
+// Term is a correctly spelled name that near misses in a transcript are
+// corrected to, e.g. a product name the speech model keeps getting wrong
+type Term struct {
+	Spelling string
+	// normalized is the spelling in lowercase letters and digits only
+	normalized string
+	phonetic   string
+	words      int
+}
+
+// Change is one kind of correction made to a transcript
+type Change struct {
+	From  string
+	To    string
+	Count int
+}
+
+// tokenPattern finds the words a transcript is matched on
+var tokenPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+func newTerm(spelling string) Term {
+	normalized := normalize(spelling)
+	return Term{
+		Spelling:   spelling,
+		normalized: normalized,
+		phonetic:   phonetic(normalized),
+		words:      len(strings.Fields(spelling)),
+	}
+}
+
+// normalize keeps the lowercase letters and digits of text
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// maxEdits is how many letters a spelling may be off by and still be
+// corrected; names under five letters must match exactly, bar case and spacing
+func maxEdits(length int) int {
+	return length / 5
+}
+
+// distance scores how far candidate is from the term, or returns false when
+// it is too far to be the same word. Candidates that sound like the term may
+// be further off in spelling.
+func (t Term) distance(candidate string) (int, bool) {
+	if candidate == t.normalized {
+		return 0, true
+	}
+	length := len([]rune(t.normalized))
+	edits := levenshtein(candidate, t.normalized)
+	if edits <= maxEdits(length) {
+		return edits, true
+	}
+	if length >= 6 && edits <= length/3 && phonetic(candidate) == t.phonetic {
+		return edits, true
+	}
+	return 0, false
+}
+
+// correctTerms replaces the words in text that are spelled or sound close
+// to a glossary term with the term, adding the changes made to changes.
+// Words already spelled like the term are left alone.
+func (g *Glossary) correctTerms(text string, changes []Change) (string, []Change) {
+	if len(g.Terms) == 0 {
+		return text, changes
+	}
+
+	tokens := tokenPattern.FindAllStringIndex(text, -1)
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(tokens); {
+		term, size, ok := g.bestTerm(text, tokens[i:])
+		if !ok {
+			i++
+			continue
+		}
+		start, end := tokens[i][0], tokens[i+size-1][1]
+		if original := text[start:end]; original != term.Spelling {
+			b.WriteString(text[last:start])
+			b.WriteString(term.Spelling)
+			last = end
+			changes = addChange(changes, original, term.Spelling)
+		}
+		i += size
+	}
+	b.WriteString(text[last:])
+	return b.String(), changes
+}
+
+// bestTerm finds the term closest to the words at the start of tokens. A
+// term may span one word more or less than it has, so "Postgre SQL" and
+// "post gres Q L" both match PostgreSQL; the closest match wins, and the
+// fewest words on a tie.
+func (g *Glossary) bestTerm(text string, tokens [][]int) (Term, int, bool) {
+	var best Term
+	bestSize, bestDistance := 0, -1
+	// Only words in one phrase can make up a term
+	phrase := 1
+	for phrase < len(tokens) && joined(text[tokens[phrase-1][1]:tokens[phrase][0]]) {
+		phrase++
+	}
+
+	for _, term := range g.Terms {
+		for size := max(1, term.words-1); size <= term.words+2 && size <= phrase; size++ {
+			candidate := normalize(text[tokens[0][0]:tokens[size-1][1]])
+			distance, ok := term.distance(candidate)
+			if !ok {
+				continue
+			}
+			if bestDistance < 0 || distance < bestDistance || (distance == bestDistance && size < bestSize) {
+				best, bestSize, bestDistance = term, size, distance
+			}
+		}
+	}
+	return best, bestSize, bestDistance >= 0
+}
+
+// joined reports whether the text between two words keeps them in one
+// phrase: spaces and hyphens do, punctuation does not
+func joined(gap string) bool {
+	return strings.Trim(gap, " -") == ""
+}
+
+func addChange(changes []Change, from string, to string) []Change {
+	for i := range changes {
+		if changes[i].From == from && changes[i].To == to {
+			changes[i].Count++
+			return changes
+		}
+	}
+	return append(changes, Change{From: from, To: to, Count: 1})
+}
+
+// levenshtein counts the letters to insert, delete or replace to turn a into b
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// phoneticRules rewrite spellings that sound alike to one form, in order
+var phoneticRules = strings.NewReplacer(
+	"sch", "sk", "ph", "f", "ck", "k", "qu", "kw", "q", "k", "x", "ks",
+	"z", "s", "dg", "j", "wh", "w", "gh", "g", "ce", "se", "ci", "si", "cy", "sy",
+	"c", "k", "v", "f",
+)
+
+// phonetic reduces a normalized word to how it sounds: the first letter
+// and the consonants after it, with silent and doubled letters dropped
+func phonetic(word string) string {
+	for _, prefix := range []string{"kn", "wr", "ps"} {
+		if strings.HasPrefix(word, prefix) {
+			word = word[1:]
+			break
+		}
+	}
+	word = phoneticRules.Replace(word)
+
+	var b strings.Builder
+	var previous rune
+	for i, r := range word {
+		if i > 0 && (strings.ContainsRune("aeiouyh", r) || r == previous) {
+			previous = r
+			continue
+		}
+		b.WriteRune(r)
+		previous = r
+	}
+	return b.String()
+}
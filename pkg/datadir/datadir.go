@@ -0,0 +1,52 @@
+package datadir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvVar moves sona's data directory, e.g. onto a mounted volume
+const EnvVar = "SONA_HOME"
+
+// containerDir is the data directory inside a container, usually a volume
+const containerDir = "/data"
+
+// Dir returns the directory sona keeps its config, library, logs and other
+// state in: $SONA_HOME when set, /data when running in a container that
+// has it, otherwise ~/.sona
+func Dir() (string, error) {
+	if dir := os.Getenv(EnvVar); dir != "" {
+		return dir, nil
+	}
+	if InContainer() {
+		if info, err := os.Stat(containerDir); err == nil && info.IsDir() {
+			return containerDir, nil
+		}
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".sona"), nil
+}
+
+// Path returns a file or directory inside Dir
+func Path(elem ...string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{dir}, elem...)...), nil
+}
+
+// InContainer reports whether sona runs in a Docker or Podman container
+func InContainer() bool {
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package deps
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// ErrDependencyMissing is matched by errors.Is when a required binary such
+// as ffmpeg or yt-dlp is not installed; see MissingError
+var ErrDependencyMissing = errors.New("dependency missing")
+
+// MissingError reports a binary that could not be found
+type MissingError struct {
+	Binary string
+	// Message replaces the default "<binary> not found", e.g. to add install advice
+	Message string
+}
+
+func (e *MissingError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Binary + " not found"
+}
+
+// Is lets errors.Is match ErrDependencyMissing
+func (e *MissingError) Is(target error) bool {
+	return target == ErrDependencyMissing
+}
+
+// ManagedBinaries lists the binaries sona installs into its own bin directory
+var ManagedBinaries = []string{"yt-dlp", "ffmpeg", "ffprobe"}
+
+// BinDir returns the sona-managed directory dependencies are installed into (~/.sona/bin)
+func BinDir() (string, error) {
+	return datadir.Path("bin")
+}
+
+// EnsureBinDir creates the managed bin directory and returns its path
+func EnsureBinDir() (string, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bin directory: %v", err)
+	}
+	return binDir, nil
+}
+
+// legacyBinDir returns ~/bin, where older sona versions installed dependencies
+func legacyBinDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, "bin")
+}
+
+// executableNames returns the file names a binary may have on this platform
+func executableNames(binaryName string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{binaryName + ".exe", binaryName}
+	}
+	return []string{binaryName}
+}
+
+// overrides are binaries the user pointed sona at, by name
+var overrides = map[string]string{}
+
+// companions are found next to the binary they come with, so ffprobe and
+// ffplay are taken from the same custom ffmpeg build
+var companions = map[string]string{
+	"ffprobe": "ffmpeg",
+	"ffplay":  "ffmpeg",
+}
+
+// SetPath makes FindBinary use path for a binary instead of searching for
+// it; an empty path searches again
+func SetPath(binaryName string, path string) {
+	if path == "" {
+		delete(overrides, binaryName)
+		return
+	}
+	overrides[binaryName] = path
+}
+
+// FindBinary locates a dependency: the path set with SetPath, then the
+// sona-managed bin directory, then PATH, then ~/bin for installs made by
+// older versions
+func FindBinary(binaryName string) (string, error) {
+	if path, ok := overrides[binaryName]; ok {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return "", &MissingError{Binary: binaryName, Message: fmt.Sprintf("%s not found at %s", binaryName, path)}
+		}
+		return path, nil
+	}
+	if path, ok := overrides[companions[binaryName]]; ok {
+		if found, ok := findIn(filepath.Dir(path), binaryName); ok {
+			return found, nil
+		}
+	}
+
+	if binDir, err := BinDir(); err == nil {
+		if path, ok := findIn(binDir, binaryName); ok {
+			// Binaries sona downloaded are only run while they are the ones it verified
+			if err := checkManaged(binaryName, path); err != nil {
+				return "", err
+			}
+			return path, nil
+		}
+	}
+
+	if path, err := exec.LookPath(binaryName); err == nil {
+		return path, nil
+	}
+
+	if runtime.GOOS != "windows" {
+		if legacyDir := legacyBinDir(); legacyDir != "" {
+			if path, ok := findIn(legacyDir, binaryName); ok {
+				return path, nil
+			}
+		}
+	}
+
+	return "", &MissingError{Binary: binaryName}
+}
+
+func findIn(dir string, binaryName string) (string, bool) {
+	for _, name := range executableNames(binaryName) {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// UninstallManaged removes the binaries sona installed into its bin directory
+// and returns the paths that were removed
+func UninstallManaged() ([]string, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	for _, binary := range ManagedBinaries {
+		for _, name := range executableNames(binary) {
+			path := filepath.Join(binDir, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if err := os.Remove(path); err != nil {
+				return removed, fmt.Errorf("failed to remove %s: %v", path, err)
+			}
+			removed = append(removed, path)
+		}
+	}
+
+	if err := forgetChecksums(ManagedBinaries); err != nil {
+		return removed, err
+	}
+
+	// Drop the directory itself once it is empty
+	if entries, err := os.ReadDir(binDir); err == nil && len(entries) == 0 {
+		os.Remove(binDir)
+	}
+
+	return removed, nil
+}
+
+// LegacyBinaries returns dependencies found in ~/bin that older sona versions may have installed
+func LegacyBinaries() []string {
+	legacyDir := legacyBinDir()
+	if legacyDir == "" || runtime.GOOS == "windows" {
+		return nil
+	}
+
+	var found []string
+	for _, binary := range ManagedBinaries {
+		if path, ok := findIn(legacyDir, binary); ok {
+			found = append(found, path)
+		}
+	}
+	return found
+}
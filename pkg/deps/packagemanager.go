@@ -0,0 +1,135 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// PackageManager describes a system package manager sona can install dependencies with
+type PackageManager struct {
+	// Name is the command name of the package manager, e.g. "brew"
+	Name string
+	// packages maps a dependency (ffmpeg, yt-dlp) to the package that provides it
+	packages map[string]string
+	// installArgs builds the install command line for a package
+	installArgs func(pkg string) []string
+	// needsRoot is true when installs must run through sudo for non-root users
+	needsRoot bool
+}
+
+var (
+	brew = PackageManager{
+		Name:        "brew",
+		packages:    map[string]string{"ffmpeg": "ffmpeg", "yt-dlp": "yt-dlp"},
+		installArgs: func(pkg string) []string { return []string{"install", pkg} },
+	}
+	apt = PackageManager{
+		Name:        "apt-get",
+		packages:    map[string]string{"ffmpeg": "ffmpeg", "yt-dlp": "yt-dlp"},
+		installArgs: func(pkg string) []string { return []string{"install", "-y", pkg} },
+		needsRoot:   true,
+	}
+	dnf = PackageManager{
+		Name:        "dnf",
+		packages:    map[string]string{"ffmpeg": "ffmpeg-free", "yt-dlp": "yt-dlp"},
+		installArgs: func(pkg string) []string { return []string{"install", "-y", pkg} },
+		needsRoot:   true,
+	}
+	winget = PackageManager{
+		Name:     "winget",
+		packages: map[string]string{"ffmpeg": "Gyan.FFmpeg", "yt-dlp": "yt-dlp.yt-dlp"},
+		installArgs: func(pkg string) []string {
+			return []string{"install", "--id", pkg, "-e", "--accept-source-agreements", "--accept-package-agreements"}
+		},
+	}
+	scoop = PackageManager{
+		Name:        "scoop",
+		packages:    map[string]string{"ffmpeg": "ffmpeg", "yt-dlp": "yt-dlp"},
+		installArgs: func(pkg string) []string { return []string{"install", pkg} },
+	}
+)
+
+// candidatesFor returns the package managers worth probing on an OS, in order of preference
+func candidatesFor(goos string) []PackageManager {
+	switch goos {
+	case "darwin":
+		return []PackageManager{brew}
+	case "linux":
+		return []PackageManager{apt, dnf, brew}
+	case "windows":
+		return []PackageManager{winget, scoop}
+	default:
+		return nil
+	}
+}
+
+// DetectPackageManager returns the first supported package manager found on
+// this system, or nil when none is available
+func DetectPackageManager() *PackageManager {
+	for _, pm := range candidatesFor(runtime.GOOS) {
+		if _, err := exec.LookPath(pm.Name); err == nil {
+			logger.LogInfo("Detected package manager: %s", pm.Name)
+			found := pm
+			return &found
+		}
+	}
+	logger.LogInfo("No supported package manager detected")
+	return nil
+}
+
+// PackageFor returns the package name that provides a dependency
+func (pm *PackageManager) PackageFor(dependency string) (string, bool) {
+	pkg, ok := pm.packages[dependency]
+	return pkg, ok
+}
+
+// CommandLine returns the command that installs a dependency, for display and execution
+func (pm *PackageManager) CommandLine(dependency string) ([]string, error) {
+	pkg, ok := pm.PackageFor(dependency)
+	if !ok {
+		return nil, fmt.Errorf("%s does not provide %s", pm.Name, dependency)
+	}
+
+	args := append([]string{pm.Name}, pm.installArgs(pkg)...)
+	if pm.needsRoot && os.Geteuid() != 0 {
+		if _, err := exec.LookPath("sudo"); err != nil {
+			return nil, fmt.Errorf("%s requires root privileges and sudo is not available", pm.Name)
+		}
+		args = append([]string{"sudo"}, args...)
+	}
+	return args, nil
+}
+
+// Install installs a dependency through the package manager. The command is
+// attached to the terminal so that sudo and the package manager can prompt.
+func (pm *PackageManager) Install(dependency string) error {
+	args, err := pm.CommandLine(dependency)
+	if err != nil {
+		return err
+	}
+
+	logger.LogInfo("Installing %s with: %s", dependency, strings.Join(args, " "))
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	logger.LogCommand(args[0], args[1:], "", err)
+	if err != nil {
+		return fmt.Errorf("%s failed to install %s: %v", pm.Name, dependency, err)
+	}
+
+	// The package may install to a location that is not yet on PATH
+	if _, err := exec.LookPath(dependency); err != nil {
+		return fmt.Errorf("%s reported success but %s is not on PATH", pm.Name, dependency)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package deps
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// InstallSource selects where dependency binaries and archives come from.
+// The zero value downloads from the upstream project URLs.
+type InstallSource struct {
+	// Dir is a local directory holding ready binaries or the upstream archives
+	Dir string
+	// MirrorURL is a base URL serving the upstream assets under their original file names
+	MirrorURL string
+}
+
+// IsOffline reports whether the source avoids the upstream download hosts
+func (s InstallSource) IsOffline() bool {
+	return s.Dir != "" || s.MirrorURL != ""
+}
+
+// NewInstallSource validates the directory or mirror and returns the source.
+// The directory is made absolute since installers change the working directory.
+func NewInstallSource(dir string, mirrorURL string) (InstallSource, error) {
+	if dir != "" && mirrorURL != "" {
+		return InstallSource{}, fmt.Errorf("--from-dir and --mirror cannot be used together")
+	}
+
+	if dir != "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return InstallSource{}, fmt.Errorf("invalid install directory: %v", err)
+		}
+		info, err := os.Stat(absDir)
+		if err != nil {
+			return InstallSource{}, fmt.Errorf("install directory not found: %v", err)
+		}
+		if !info.IsDir() {
+			return InstallSource{}, fmt.Errorf("%s is not a directory", absDir)
+		}
+		dir = absDir
+	}
+
+	if mirrorURL != "" && !strings.HasPrefix(mirrorURL, "http://") && !strings.HasPrefix(mirrorURL, "https://") {
+		return InstallSource{}, fmt.Errorf("mirror must be an http(s) URL: %s", mirrorURL)
+	}
+
+	return InstallSource{Dir: dir, MirrorURL: mirrorURL}, nil
+}
+
+// Describe returns a human readable name of the source
+func (s InstallSource) Describe() string {
+	switch {
+	case s.Dir != "":
+		return "local directory " + s.Dir
+	case s.MirrorURL != "":
+		return "mirror " + s.MirrorURL
+	default:
+		return "upstream downloads"
+	}
+}
+
+// LocalBinary returns a ready-to-run binary with the given name from the
+// source directory, if one is present
+func (s InstallSource) LocalBinary(name string) (string, bool) {
+	if s.Dir == "" {
+		return "", false
+	}
+
+	candidates := []string{name}
+	if runtime.GOOS == "windows" {
+		candidates = append([]string{name + ".exe"}, candidates...)
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(s.Dir, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Fetch stores the asset at dest. It is copied from the source directory,
+// downloaded from the mirror, or downloaded from upstreamURL by default.
+func (s InstallSource) Fetch(asset string, upstreamURL string, dest string) error {
+	switch {
+	case s.Dir != "":
+		src := filepath.Join(s.Dir, asset)
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("%s not found in %s", asset, s.Dir)
+		}
+		logger.LogInfo("Copying %s from %s", asset, s.Dir)
+		return CopyFile(src, dest, 0644)
+	case s.MirrorURL != "":
+		return download(strings.TrimRight(s.MirrorURL, "/")+"/"+asset, dest)
+	default:
+		return download(upstreamURL, dest)
+	}
+}
+
+// download fetches a URL to dest using curl
+func download(url string, dest string) error {
+	logger.LogInfo("Downloading %s to %s", url, dest)
+
+	args := []string{"-fL", "-o", dest}
+	if rate := config.GetMaxDownloadRate(); rate > 0 {
+		args = append(args, "--limit-rate", strconv.FormatInt(rate, 10))
+	}
+	args = append(args, url)
+	cmd := exec.Command("curl", args...)
+	output, err := cmd.CombinedOutput()
+	logger.LogCommand("curl", args, string(output), err)
+	if err != nil {
+		return fmt.Errorf("download of %s failed: %v", url, err)
+	}
+	return nil
+}
+
+// CopyFile copies src to dest with the given permissions
+func CopyFile(src string, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %v", src, err)
+	}
+
+	return out.Close()
+}
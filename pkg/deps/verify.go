@@ -0,0 +1,228 @@
+package deps
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// verifyTimeout bounds the version check of a binary
+const verifyTimeout = 30 * time.Second
+
+// verification is a harmless invocation of a binary and what it must print
+// to be the binary it claims to be
+type verification struct {
+	args   []string
+	output *regexp.Regexp
+}
+
+var verifications = map[string]verification{
+	"yt-dlp":  {args: []string{"--version"}, output: regexp.MustCompile(`^\d{4}\.\d{1,2}\.\d{1,2}`)},
+	"ffmpeg":  {args: []string{"-version"}, output: regexp.MustCompile(`^ffmpeg version \S+`)},
+	"ffprobe": {args: []string{"-version"}, output: regexp.MustCompile(`^ffprobe version \S+`)},
+}
+
+// Checksum is what sona recorded about a binary in its bin directory when
+// it verified it
+type Checksum struct {
+	SHA256     string    `json:"sha256"`
+	Version    string    `json:"version"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+var (
+	checksumMu sync.Mutex
+	// checked holds the paths whose hash matched during this run, so large
+	// binaries are hashed once rather than on every lookup
+	checked = make(map[string]bool)
+)
+
+func checksumsPath() (string, error) {
+	return datadir.Path("binaries.json")
+}
+
+// loadChecksums returns the recorded checksums by binary name
+func loadChecksums() (map[string]Checksum, error) {
+	path, err := checksumsPath()
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]Checksum)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read binary checksums: %v", err)
+	}
+	if err := json.Unmarshal(data, &checksums); err != nil {
+		return nil, fmt.Errorf("failed to parse binary checksums %s: %v", path, err)
+	}
+	return checksums, nil
+}
+
+func saveChecksums(checksums map[string]Checksum) error {
+	path, err := checksumsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(checksums, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0644)
+}
+
+// VerifyManaged checks a binary just installed into the sona bin directory
+// by running it with a harmless version flag, and records its hash so a
+// later change to the file is noticed. It returns the version reported.
+func VerifyManaged(binaryName string) (string, error) {
+	binDir, err := BinDir()
+	if err != nil {
+		return "", err
+	}
+	path, ok := findIn(binDir, binaryName)
+	if !ok {
+		return "", &MissingError{Binary: binaryName, Message: fmt.Sprintf("%s was not installed into %s", binaryName, binDir)}
+	}
+
+	checksumMu.Lock()
+	defer checksumMu.Unlock()
+	checksums, err := loadChecksums()
+	if err != nil {
+		return "", err
+	}
+	checksum, err := verify(binaryName, path)
+	if err != nil {
+		return "", err
+	}
+	checksums[binaryName] = checksum
+	checked[path] = true
+	return checksum.Version, saveChecksums(checksums)
+}
+
+// ManagedPath returns the binary in the sona bin directory, if there is one
+func ManagedPath(binaryName string) (string, bool) {
+	binDir, err := BinDir()
+	if err != nil {
+		return "", false
+	}
+	return findIn(binDir, binaryName)
+}
+
+// verify runs the version check of a binary and hashes it
+func verify(binaryName string, path string) (Checksum, error) {
+	check, ok := verifications[binaryName]
+	if !ok {
+		return Checksum{}, fmt.Errorf("no way to verify %s", binaryName)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, check.args...)
+	output, err := cmd.CombinedOutput()
+	logger.LogCommand(path, check.args, string(output), err)
+	version := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	if err != nil {
+		return Checksum{}, fmt.Errorf("%s failed its version check: %v", path, err)
+	}
+	if !check.output.MatchString(version) {
+		return Checksum{}, fmt.Errorf("%s does not look like %s: '%s %s' printed %q", path, binaryName, binaryName, strings.Join(check.args, " "), version)
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	logger.LogInfo("Verified %s (%s), sha256 %s", path, version, sum)
+	return Checksum{SHA256: sum, Version: version, VerifiedAt: time.Now()}, nil
+}
+
+// checkManaged makes sure a binary in the sona bin directory is the one
+// sona verified. Binaries installed before hashes were recorded are
+// verified and recorded on first use.
+func checkManaged(binaryName string, path string) error {
+	if _, ok := verifications[binaryName]; !ok {
+		return nil
+	}
+
+	checksumMu.Lock()
+	defer checksumMu.Unlock()
+	if checked[path] {
+		return nil
+	}
+	checksums, err := loadChecksums()
+	if err != nil {
+		return err
+	}
+
+	recorded, ok := checksums[binaryName]
+	if !ok {
+		checksum, err := verify(binaryName, path)
+		if err != nil {
+			return &MissingError{Binary: binaryName, Message: fmt.Sprintf("%v; run 'sona install' to reinstall it", err)}
+		}
+		checksums[binaryName] = checksum
+		if err := saveChecksums(checksums); err != nil {
+			logger.LogWarning("Failed to record checksum of %s: %v", path, err)
+		}
+		checked[path] = true
+		return nil
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if sum != recorded.SHA256 {
+		logger.LogError("%s changed since it was verified: sha256 %s, recorded %s", path, sum, recorded.SHA256)
+		return &MissingError{Binary: binaryName, Message: fmt.Sprintf("%s changed since sona installed it and will not be run; run 'sona install' to reinstall it", path)}
+	}
+	checked[path] = true
+	return nil
+}
+
+// forgetChecksums drops the recorded checksums of removed binaries
+func forgetChecksums(binaryNames []string) error {
+	checksumMu.Lock()
+	defer checksumMu.Unlock()
+	checksums, err := loadChecksums()
+	if err != nil {
+		return err
+	}
+	for _, name := range binaryNames {
+		delete(checksums, name)
+	}
+	return saveChecksums(checksums)
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,253 @@
+package fingerprint
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// SampleRate is the rate of the mono samples Compute expects; speech
+// fingerprints need nothing above 2 kHz
+const SampleRate = 5512
+
+// Frames are about 186ms long and start every 23ms, so a trimmed copy is
+// never more than 12ms off the nearest frame. Each frame yields 16 bits, one
+// per pair of neighbouring bands between minFrequency and maxFrequency,
+// telling whether their energy difference grew since the previous frame.
+// The bits survive re-encoding, resampling and volume changes, and trimmed
+// copies still line up at some frame offset.
+const (
+	frameSize    = 1024
+	frameHop     = 128
+	bandCount    = 17
+	minFrequency = 300.0
+	maxFrequency = 2000.0
+)
+
+// FrameDuration is the time between fingerprint frames in seconds
+const FrameDuration = float64(frameHop) / SampleRate
+
+// Fingerprint is the acoustic fingerprint of a recording, one value per frame
+type Fingerprint []uint16
+
+// Compute fingerprints mono samples at SampleRate
+func Compute(samples []int16) Fingerprint {
+	if len(samples) < frameSize {
+		return nil
+	}
+
+	edges := bandEdges()
+	window := make([]float64, frameSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(frameSize-1))
+	}
+
+	var fp Fingerprint
+	var previous []float64
+	spectrum := make([]complex128, frameSize)
+	for start := 0; start+frameSize <= len(samples); start += frameHop {
+		for i := range spectrum {
+			spectrum[i] = complex(float64(samples[start+i])*window[i], 0)
+		}
+		fft(spectrum)
+
+		energies := make([]float64, bandCount)
+		for b := 0; b < bandCount; b++ {
+			for k := edges[b]; k < edges[b+1]; k++ {
+				magnitude := cmplx.Abs(spectrum[k])
+				energies[b] += magnitude * magnitude
+			}
+		}
+
+		if previous != nil {
+			var value uint16
+			for b := 0; b < bandCount-1; b++ {
+				if energies[b]-energies[b+1]-(previous[b]-previous[b+1]) > 0 {
+					value |= 1 << b
+				}
+			}
+			fp = append(fp, value)
+		}
+		previous = energies
+	}
+	return fp
+}
+
+// bandEdges returns the FFT bins bounding the logarithmically spaced bands
+func bandEdges() []int {
+	edges := make([]int, bandCount+1)
+	ratio := math.Pow(maxFrequency/minFrequency, 1/float64(bandCount))
+	for b := range edges {
+		frequency := minFrequency * math.Pow(ratio, float64(b))
+		edges[b] = int(math.Round(frequency * frameSize / SampleRate))
+	}
+	return edges
+}
+
+// fft transforms x in place; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(length)))
+		for start := 0; start < n; start += length {
+			w := complex(1, 0)
+			for k := 0; k < length/2; k++ {
+				even, odd := x[start+k], x[start+k+length/2]*w
+				x[start+k] = even + odd
+				x[start+k+length/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// Match is the best alignment of two fingerprints
+type Match struct {
+	// Similarity is the share of matching bits where the recordings
+	// overlap; unrelated audio scores about 0.5
+	Similarity float64
+	// Offset is the position of the first fingerprint's start in the second, in frames
+	Offset int
+	// Overlap is the number of frames the recordings share at that offset
+	Overlap int
+}
+
+// maxCandidates is how many of the most voted offsets are compared bit by bit
+const maxCandidates = 5
+
+// Compare finds the offset at which a lines up best with b. Offsets are
+// proposed by frames with identical values and then scored bit by bit.
+func Compare(a Fingerprint, b Fingerprint) Match {
+	positions := make(map[uint16][]int)
+	for j, value := range b {
+		positions[value] = append(positions[value], j)
+	}
+
+	votes := make(map[int]int)
+	for i, value := range a {
+		// Very common values (e.g. silence) say nothing about the offset
+		if candidates := positions[value]; len(candidates) <= 32 {
+			for _, j := range candidates {
+				votes[j-i]++
+			}
+		}
+	}
+
+	var best Match
+	for _, offset := range topOffsets(votes, maxCandidates) {
+		if match := score(a, b, offset); match.Similarity > best.Similarity {
+			best = match
+		}
+	}
+	return best
+}
+
+// topOffsets returns up to n offsets with the most votes
+func topOffsets(votes map[int]int, n int) []int {
+	var offsets []int
+	for len(offsets) < n {
+		bestOffset, bestVotes := 0, 0
+		for offset, count := range votes {
+			if count > bestVotes {
+				bestOffset, bestVotes = offset, count
+			}
+		}
+		if bestVotes == 0 {
+			break
+		}
+		offsets = append(offsets, bestOffset)
+		delete(votes, bestOffset)
+	}
+	return offsets
+}
+
+// score compares a placed at offset in b bit by bit
+func score(a Fingerprint, b Fingerprint, offset int) Match {
+	match := Match{Offset: offset}
+	differing := 0
+	for i, value := range a {
+		j := i + offset
+		if j < 0 || j >= len(b) {
+			continue
+		}
+		differing += bits.OnesCount16(value ^ b[j])
+		match.Overlap++
+	}
+	if match.Overlap > 0 {
+		match.Similarity = 1 - float64(differing)/float64(16*match.Overlap)
+	}
+	return match
+}
+
+// Dir returns the directory holding stored fingerprints (~/.sona/fingerprints)
+func Dir() (string, error) {
+	return datadir.Path("fingerprints")
+}
+
+// Save stores the fingerprint of the named transcript
+func Save(name string, fp Fingerprint) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create fingerprint directory: %v", err)
+	}
+
+	data := make([]byte, 2*len(fp))
+	for i, value := range fp {
+		binary.LittleEndian.PutUint16(data[2*i:], value)
+	}
+	if err := atomicfile.WriteFile(filepath.Join(dir, name+".fp"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write fingerprint: %v", err)
+	}
+	return nil
+}
+
+// Load reads the fingerprint of the named transcript
+func Load(name string) (Fingerprint, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".fp"))
+	if err != nil {
+		return nil, err
+	}
+	fp := make(Fingerprint, len(data)/2)
+	for i := range fp {
+		fp[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return fp, nil
+}
+
+// Delete removes the fingerprint of the named transcript, if there is one
+func Delete(name string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name+".fp")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove fingerprint: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var clearHistory bool
+
+var HistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent transcription runs",
+	Long: `List the most recent 'sona transcribe' and interactive runs, newest first,
+with the command line and how each ended. Repeat one with 'sona rerun <n>'.
+How many runs are kept is set by history.size (default: 50, 0 = none).`,
+	Example: `  sona history
+  sona rerun 3
+  sona history --clear`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if clearHistory {
+			if err := Clear(); err != nil {
+				fmt.Println(style.Error("%v", err))
+				os.Exit(1)
+			}
+			fmt.Println("History cleared")
+			return
+		}
+
+		entries, err := Load()
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Println("No runs recorded yet")
+			return
+		}
+		for i, entry := range entries {
+			line := style.Success("%s", entry.CommandLine())
+			if !entry.OK {
+				line = style.Failure("%s", entry.CommandLine())
+			}
+			fmt.Printf("%3d  %s  %s\n", i+1, entry.Time.Local().Format("2006-01-02 15:04"), line)
+			fmt.Printf("     %s\n", style.Dim(summarize(entry.Result)))
+		}
+	},
+}
+
+var RerunCmd = &cobra.Command{
+	Use:   "rerun [n]",
+	Short: "Repeat a recent transcription run",
+	Long: `Run the n-th most recent run listed by 'sona history' again, with the same
+command line and from the same directory, so relative paths still resolve.
+Without n, the most recent run is repeated.`,
+	Example: `  sona rerun
+  sona rerun 3`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := rerun(args); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	HistoryCmd.Flags().BoolVar(&clearHistory, "clear", false, "Forget every recorded run")
+}
+
+// summarize shortens a result to fit one line of the listing
+func summarize(result string) string {
+	const width = 100
+	if runes := []rune(result); len(runes) > width {
+		return string(runes[:width-3]) + "..."
+	}
+	return result
+}
+
+// rerun runs a recorded command line again as a new sona process, exiting
+// with its exit code
+func rerun(args []string) error {
+	n := 1
+	if len(args) == 1 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("invalid run number %q (see 'sona history')", args[0])
+		}
+	}
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+	if n > len(entries) {
+		if len(entries) == 0 {
+			return fmt.Errorf("no runs recorded yet")
+		}
+		return fmt.Errorf("only %d runs recorded (see 'sona history')", len(entries))
+	}
+	entry := entries[n-1]
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the sona executable: %v", err)
+	}
+	child := exec.Command(self, entry.Args...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if info, err := os.Stat(entry.Dir); err == nil && info.IsDir() {
+		child.Dir = entry.Dir
+	} else if entry.Dir != "" {
+		fmt.Println(style.Warning("%s no longer exists, running from the current directory", entry.Dir))
+	}
+
+	fmt.Println(style.Info("Running: %s", entry.CommandLine()))
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// Entry is one transcription run, as it was invoked and how it ended
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Dir is the working directory, so relative sources resolve on a rerun
+	Dir string `json:"dir"`
+	// Args is the command line after "sona", as typed
+	Args    []string `json:"args"`
+	Sources []string `json:"sources"`
+	Model   string   `json:"model,omitempty"`
+	Output  string   `json:"output,omitempty"`
+	OK      bool     `json:"ok"`
+	Result  string   `json:"result"`
+}
+
+// CommandLine returns the entry's command line, quoted where needed to be
+// pasted into a shell
+func (e Entry) CommandLine() string {
+	parts := []string{"sona"}
+	for _, arg := range e.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'|&;<>()$`\\*?") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Path returns the location of the history file (~/.sona/history.json)
+func Path() (string, error) {
+	return datadir.Path("history.json")
+}
+
+// Load returns the recorded runs, newest first
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("history file %s is corrupted: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Last returns the most recent run, if any
+func Last() (Entry, bool) {
+	entries, err := Load()
+	if err != nil || len(entries) == 0 {
+		return Entry{}, false
+	}
+	return entries[0], true
+}
+
+// Add records a run, keeping the newest size runs. A size of 0 keeps no
+// history at all.
+func Add(entry Entry, size int) error {
+	if size <= 0 {
+		return nil
+	}
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+	entries = append([]Entry{entry}, entries...)
+	if len(entries) > size {
+		entries = entries[:size]
+	}
+	return save(entries)
+}
+
+// Forget drops the runs that transcribed source and returns how many
+func Forget(source string) (int, error) {
+	entries, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	var kept []Entry
+	for _, entry := range entries {
+		if !entry.mentions(source) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(entries) {
+		return 0, nil
+	}
+	return len(entries) - len(kept), save(kept)
+}
+
+// mentions reports whether the run transcribed source, given as an
+// absolute path or URL
+func (e Entry) mentions(source string) bool {
+	for _, s := range e.Sources {
+		if s == source || (!filepath.IsAbs(s) && filepath.Join(e.Dir, s) == source) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clear forgets every recorded run
+func Clear() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear history: %v", err)
+	}
+	return nil
+}
+
+func save(entries []Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %v", err)
+	}
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	return nil
+}
@@ -2,21 +2,34 @@ package interactive
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/history"
+	"github.com/Harsh-2002/Sona/pkg/style"
 	"github.com/Harsh-2002/Sona/pkg/transcriber"
 	"github.com/Harsh-2002/Sona/pkg/youtube"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+var input = bufio.NewScanner(os.Stdin)
+
 // InteractiveCmd represents the interactive command
 var InteractiveCmd = &cobra.Command{
 	Use:   "interactive",
 	Short: "Start interactive mode",
-	Long:  `Start interactive mode to guide you through the transcription process step by step.`,
+	Long: `Start interactive mode to guide you through the transcription process step by step.
+
+The answers become a 'sona transcribe' command line, which is shown before it
+runs and recorded in the history like any other run. Any transcribe flag can
+be added at the "Other options" prompt.`,
+	Example: `  sona interactive`,
 	Run: func(cmd *cobra.Command, args []string) {
 		runInteractiveMode(cmd, args)
 	},
@@ -24,16 +37,24 @@ var InteractiveCmd = &cobra.Command{
 
 func runInteractiveMode(cmd *cobra.Command, args []string) {
 	fmt.Println("--------------------------------")
-	fmt.Println("❇️  Sona is your go-to tool for turning audio files or YouTube videos into text—fast, easy, and accurate.")
+	fmt.Println(style.Icon("❇️  ") + "Sona is your go-to tool for turning audio files or YouTube videos into text—fast, easy, and accurate.")
 	fmt.Println("--------------------------------")
 
 	// Check and set API key if needed
 	checkAndSetAPIKey()
 
-	// Get last used settings
-	lastSourceType := config.GetLastSourceType()
-	lastSpeechModel := config.GetLastSpeechModel()
-	lastOutputPath := config.GetLastOutputPath()
+	// Offer the settings of the last run
+	var lastSourceType, lastSpeechModel, lastOutputPath string
+	if last, ok := history.Last(); ok {
+		lastSourceType = "local"
+		if len(last.Sources) > 0 && youtube.IsYouTubeURL(last.Sources[0]) {
+			lastSourceType = "youtube"
+		} else if len(last.Sources) > 0 && isURL(last.Sources[0]) {
+			lastSourceType = "url"
+		}
+		lastSpeechModel = last.Model
+		lastOutputPath = last.Output
+	}
 
 	// Prompt for source type
 	sourceType := promptSourceType(lastSourceType)
@@ -47,38 +68,76 @@ func runInteractiveMode(cmd *cobra.Command, args []string) {
 	// Prompt for speech model
 	speechModel := promptSpeechModel(lastSpeechModel)
 
+	formats := promptFormats()
+	language := promptLanguage()
+	options := promptOptions()
+
+	// Model and formats left blank come from a preset or the defaults.*
+	// settings, as for any transcribe run
+	command := append([]string{"transcribe", source}, rootFlags(cmd)...)
+	if speechModel != "" {
+		command = append(command, "--model", speechModel)
+	}
+	if formats != "" {
+		command = append(command, "--format", formats)
+	}
+	if outputPath != "" {
+		command = append(command, "--output", outputPath)
+	}
+	if language != "" {
+		command = append(command, "--language", language)
+	}
+	command = append(command, options...)
+
 	// Show summary and confirm
-	if !confirmSettings(sourceType, source, outputPath, speechModel) {
+	if !confirmSettings(sourceType, source, outputPath, speechModel, formats, language, command) {
 		fmt.Println("Operation cancelled.")
 		return
 	}
 
-	// Save last used settings
-	config.SaveLastSession(sourceType, speechModel, outputPath)
-
-	// Set command-line flags
-	if outputPath != "" {
-		transcriber.SetOutputPath(outputPath)
-	}
-	if speechModel != "" {
-		transcriber.SetSpeechModel(speechModel)
+	if err := runTranscribe(command); err != nil {
+		fmt.Println(style.Error("%v", err))
+		os.Exit(1)
 	}
+}
 
-	// Process based on source type
-	var err error
-	if sourceType == "youtube" {
-		err = transcriber.ProcessYouTubeVideo(source, outputPath, speechModel)
-	} else {
-		err = transcriber.ProcessLocalAudio(source, outputPath, speechModel)
-	}
+// rootFlags returns the sona flags given before the interactive command,
+// e.g. --debug-http or --connect-timeout, for the transcription to run with
+func rootFlags(cmd *cobra.Command) []string {
+	var args []string
+	// The flags are parsed into the running command's flag set, so only
+	// Changed tells which were given
+	cmd.Root().PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			args = append(args, "--"+flag.Name+"="+flag.Value.String())
+		}
+	})
+	return args
+}
 
+// runTranscribe runs the transcribe command line as a new sona process, so
+// interactive runs take exactly the path of the CLI, and exits with its
+// exit code when it fails
+func runTranscribe(args []string) error {
+	self, err := os.Executable()
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
+		return fmt.Errorf("cannot find the sona executable: %v", err)
+	}
+	child := exec.Command(self, args...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := child.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return err
 	}
+	return nil
 }
 
-// checkAndSetAPIKey checks if API key is set and prompts user to set it if not
+// checkAndSetAPIKey checks if API key is set and prompts user to set it if
+// not. A key that is not saved is handed to the transcription through the
+// environment.
 func checkAndSetAPIKey() string {
 	apiKey := ""
 
@@ -92,9 +151,7 @@ func checkAndSetAPIKey() string {
 
 		for {
 			fmt.Print("\nPlease enter your AssemblyAI API key: ")
-			scanner := bufio.NewScanner(os.Stdin)
-			scanner.Scan()
-			apiKey = strings.TrimSpace(scanner.Text())
+			apiKey = readLine()
 
 			if apiKey == "" {
 				fmt.Println("API key cannot be empty. Please try again.")
@@ -103,11 +160,14 @@ func checkAndSetAPIKey() string {
 
 			// Save the API key
 			fmt.Print("Do you want to save this API key for future use? (y/n): ")
-			scanner.Scan()
-			if strings.ToLower(strings.TrimSpace(scanner.Text())) == "y" {
-				config.SaveAPIKey(apiKey)
-				fmt.Println("API key saved successfully")
+			if strings.ToLower(readLine()) == "y" {
+				if err := config.SaveAPIKey(apiKey); err != nil {
+					fmt.Println(style.Warning("Could not save the API key, using it for this run only: %v", err))
+				} else {
+					fmt.Println("API key saved successfully")
+				}
 			}
+			os.Setenv("ASSEMBLYAI_API_KEY", apiKey)
 
 			break
 		}
@@ -120,7 +180,8 @@ func checkAndSetAPIKey() string {
 func promptSourceType(lastSourceType string) string {
 	fmt.Println("\nWhat type of source would you like to transcribe?")
 	fmt.Println("1. YouTube video")
-	fmt.Println("2. Local audio file")
+	fmt.Println("2. Local audio file or folder")
+	fmt.Println("3. Audio URL")
 
 	// Show last used option if available
 	defaultOption := ""
@@ -129,19 +190,20 @@ func promptSourceType(lastSourceType string) string {
 		fmt.Println("Last used: YouTube video")
 	} else if lastSourceType == "local" {
 		defaultOption = "2"
-		fmt.Println("Last used: Local audio file")
+		fmt.Println("Last used: Local audio file or folder")
+	} else if lastSourceType == "url" {
+		defaultOption = "3"
+		fmt.Println("Last used: Audio URL")
 	}
 
 	for {
 		if defaultOption != "" {
-			fmt.Printf("\nEnter your choice (1 or 2, press Enter for last used [%s]): ", defaultOption)
+			fmt.Printf("\nEnter your choice (1-3, press Enter for last used [%s]): ", defaultOption)
 		} else {
-			fmt.Print("\nEnter your choice (1 or 2): ")
+			fmt.Print("\nEnter your choice (1-3): ")
 		}
 
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		choice := strings.TrimSpace(scanner.Text())
+		choice := readLine()
 
 		// Use default if empty
 		if choice == "" && defaultOption != "" {
@@ -152,8 +214,10 @@ func promptSourceType(lastSourceType string) string {
 			return "youtube"
 		} else if choice == "2" {
 			return "local"
+		} else if choice == "3" {
+			return "url"
 		} else {
-			fmt.Println("Invalid choice. Please enter 1 or 2.")
+			fmt.Println("Invalid choice. Please enter 1, 2 or 3.")
 		}
 	}
 }
@@ -163,15 +227,15 @@ func promptSource(sourceType string) string {
 	var prompt string
 	if sourceType == "youtube" {
 		prompt = "Enter YouTube URL: "
+	} else if sourceType == "url" {
+		prompt = "Enter audio URL: "
 	} else {
-		prompt = "Enter path to audio file: "
+		prompt = "Enter path to audio file or folder: "
 	}
 
 	for {
 		fmt.Print("\n" + prompt)
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
-		source := strings.TrimSpace(scanner.Text())
+		source := readLine()
 
 		if source == "" {
 			fmt.Println("Source cannot be empty. Please try again.")
@@ -182,6 +246,9 @@ func promptSource(sourceType string) string {
 		if sourceType == "youtube" && !youtube.IsYouTubeURL(source) {
 			fmt.Println("Invalid YouTube URL. Please enter a valid URL.")
 			continue
+		} else if sourceType == "url" && !isURL(source) {
+			fmt.Println("Invalid URL. Please enter an http:// or https:// URL.")
+			continue
 		} else if sourceType == "local" {
 			if _, err := os.Stat(source); os.IsNotExist(err) {
 				fmt.Println("File not found. Please enter a valid path.")
@@ -203,9 +270,7 @@ func promptOutputPath(lastOutputPath string) string {
 	}
 
 	fmt.Print(prompt + ": ")
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	path := strings.TrimSpace(scanner.Text())
+	path := readLine()
 
 	// Use last path if input is empty and last path exists
 	if path == "" && lastOutputPath != "" {
@@ -224,7 +289,7 @@ func promptSpeechModel(lastModel string) string {
 
 	// Determine default choice based on last used model
 	defaultChoice := ""
-	defaultModel := "slam-1"
+	defaultModel := ""
 
 	switch lastModel {
 	case "slam-1":
@@ -246,16 +311,11 @@ func promptSpeechModel(lastModel string) string {
 		fmt.Print("\nEnter your choice (1-3, or leave blank for default): ")
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	choice := strings.TrimSpace(scanner.Text())
+	choice := readLine()
 
-	// Use default if empty
+	// Use the last model, or leave it to the preset or defaults.model
 	if choice == "" {
-		if defaultChoice != "" {
-			return defaultModel
-		}
-		return "slam-1"
+		return defaultModel
 	}
 
 	switch choice {
@@ -266,13 +326,70 @@ func promptSpeechModel(lastModel string) string {
 	case "3":
 		return "nano"
 	default:
-		fmt.Println("Invalid choice. Using default model (slam-1).")
-		return "slam-1"
+		fmt.Println("Invalid choice. Using the default model.")
+		return ""
 	}
 }
 
-// confirmSettings shows a summary and asks user to confirm
-func confirmSettings(sourceType, source, outputPath, speechModel string) bool {
+// promptFormats asks for the output formats. Blank leaves them to a preset
+// or defaults.formats.
+func promptFormats() string {
+	defaults := strings.Join(config.GetDefaultFormats(), ",")
+	fmt.Printf("\nOutput formats, comma-separated, e.g. txt,md,lrc (press Enter for the default [%s]): ", defaults)
+	return strings.ReplaceAll(readLine(), " ", "")
+}
+
+// promptLanguage asks for the language of the audio (optional)
+func promptLanguage() string {
+	fmt.Print("\nLanguage code, e.g. en or hi (leave blank to detect): ")
+	return readLine()
+}
+
+// promptOptions asks for further transcribe flags, checking that they parse
+func promptOptions() []string {
+	for {
+		fmt.Print("\nOther options, e.g. --preset meeting --tag work (? to list them, blank for none): ")
+		line := readLine()
+		if line == "?" {
+			fmt.Print(transcriber.TranscribeCmd.LocalFlags().FlagUsages())
+			continue
+		}
+		options, err := config.SplitArgs(line)
+		if err == nil {
+			err = checkOptions(options)
+		}
+		if err != nil {
+			fmt.Printf("%v. Please try again.\n", err)
+			continue
+		}
+		return options
+	}
+}
+
+// checkOptions rejects flags transcribe does not know or values they do not
+// take, and sources, which are asked for separately. Parsing sets the flag
+// variables of transcribe, which is harmless since it runs in a new process.
+func checkOptions(options []string) error {
+	flags := pflag.NewFlagSet("transcribe", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.AddFlagSet(transcriber.TranscribeCmd.LocalFlags())
+	if err := flags.Parse(options); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected %q: give only flags here", flags.Arg(0))
+	}
+	return nil
+}
+
+// isURL reports whether a source is a web address
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// confirmSettings shows a summary and the equivalent command line and asks
+// user to confirm
+func confirmSettings(sourceType, source, outputPath, speechModel, formats, language string, args []string) bool {
 	fmt.Println("\nSummary of settings:")
 	fmt.Printf("Source type: %s\n", sourceType)
 	fmt.Printf("Source: %s\n", source)
@@ -283,10 +400,30 @@ func confirmSettings(sourceType, source, outputPath, speechModel string) bool {
 		fmt.Println("Output path: [default]")
 	}
 
-	fmt.Printf("Speech model: %s\n", speechModel)
+	if speechModel != "" {
+		fmt.Printf("Speech model: %s\n", speechModel)
+	} else {
+		fmt.Println("Speech model: [default]")
+	}
+	if formats != "" {
+		fmt.Printf("Formats: %s\n", formats)
+	} else {
+		fmt.Println("Formats: [default]")
+	}
+	if language != "" {
+		fmt.Printf("Language: %s\n", language)
+	} else {
+		fmt.Println("Language: [detected]")
+	}
+	fmt.Printf("Command: %s\n", history.Entry{Args: args}.CommandLine())
 
 	fmt.Print("\nProceed with these settings? (y/n): ")
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Scan()
-	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+	return strings.ToLower(readLine()) == "y"
+}
+
+// readLine reads a line of input without surrounding whitespace. All prompts
+// share one scanner, so answers piped in ahead are not lost between them.
+func readLine() string {
+	input.Scan()
+	return strings.TrimSpace(input.Text())
 }
@@ -0,0 +1,122 @@
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// keepEntries is how many finished jobs are kept; older ones are dropped
+const keepEntries = 500
+
+// mu keeps jobs finishing in parallel from dropping each other's entries
+var mu sync.Mutex
+
+// Throughput is learned from the most recent jobs of a model, once there
+// are enough of them. Clips shorter than minimumAudio are left out, since
+// fixed overhead dominates their processing time.
+const (
+	recentJobs     = 20
+	minimumSamples = 3
+	minimumAudio   = 30 * time.Second
+)
+
+// Entry records how long AssemblyAI took to process one transcript
+type Entry struct {
+	Model string `json:"model"`
+	// Key is the masked API key that served the job
+	Key        string    `json:"key,omitempty"`
+	Audio      float64   `json:"audio_seconds"`
+	Processing float64   `json:"processing_seconds"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Path returns the location of the jobs ledger (~/.sona/ledger.json)
+func Path() (string, error) {
+	return datadir.Path("ledger.json")
+}
+
+// Load returns the recorded jobs, oldest first
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger: %v", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("ledger file %s is corrupted: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Append records a finished job, keeping the newest keepEntries
+func Append(entry Entry) error {
+	mu.Lock()
+	defer mu.Unlock()
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+	entries = append(entries, entry)
+	if len(entries) > keepEntries {
+		entries = entries[len(entries)-keepEntries:]
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ledger: %v", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write ledger: %v", err)
+	}
+	return nil
+}
+
+// Throughput returns the typical processing time per second of audio for
+// the model, the median over its recent jobs, and how many jobs it is
+// based on. It returns 0 until the model has minimumSamples jobs.
+func Throughput(entries []Entry, model string) (float64, int) {
+	var ratios []float64
+	for i := len(entries) - 1; i >= 0 && len(ratios) < recentJobs; i-- {
+		entry := entries[i]
+		if entry.Model != model || entry.Audio < minimumAudio.Seconds() || entry.Processing <= 0 {
+			continue
+		}
+		ratios = append(ratios, entry.Processing/entry.Audio)
+	}
+	if len(ratios) < minimumSamples {
+		return 0, len(ratios)
+	}
+
+	sort.Float64s(ratios)
+	middle := len(ratios) / 2
+	if len(ratios)%2 == 0 {
+		return (ratios[middle-1] + ratios[middle]) / 2, len(ratios)
+	}
+	return ratios[middle], len(ratios)
+}
@@ -0,0 +1,69 @@
+package library
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+func init() {
+	workspace.AddCleanup("audio", "Remove archived audio that archive.audio no longer keeps", cleanArchivedAudio)
+}
+
+// ExpiredAudio returns the records whose archived audio the policy no
+// longer keeps
+func ExpiredAudio(archive config.AudioArchive) ([]Record, error) {
+	records, err := List()
+	if err != nil {
+		return nil, err
+	}
+	var expired []Record
+	for _, record := range records {
+		if record.Audio != "" && archive.Expired(record.CreatedAt) {
+			expired = append(expired, record)
+		}
+	}
+	return expired, nil
+}
+
+// DropAudio removes the archived audio of a record and forgets it
+func DropAudio(record Record) error {
+	if err := os.Remove(record.Audio); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %v", record.Audio, err)
+	}
+	record.Audio = ""
+	return Save(record)
+}
+
+func cleanArchivedAudio(dryRun bool) int64 {
+	fmt.Println("\nArchived audio:")
+
+	expired, err := ExpiredAudio(config.GetAudioArchive())
+	if err != nil {
+		fmt.Println("   " + style.Warning("%v", err))
+		return 0
+	}
+	if len(expired) == 0 {
+		fmt.Println("   Nothing to clean")
+		return 0
+	}
+
+	var freed int64
+	for _, record := range expired {
+		size := workspace.Size(record.Audio)
+		fmt.Printf("   %s (%s)\n", record.Audio, workspace.FormatSize(size))
+		if dryRun {
+			freed += size
+			continue
+		}
+		if err := DropAudio(record); err != nil {
+			fmt.Println("   " + style.Warning("%v", err))
+			continue
+		}
+		freed += size
+	}
+	return freed
+}
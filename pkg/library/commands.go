@@ -0,0 +1,251 @@
+package library
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listSort    string
+	listReverse bool
+	listSearch  string
+	listLimit   int
+	listTags    []string
+	showNoPager bool
+)
+
+var ListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved transcripts",
+	Long:  `List transcripts saved by sona with their date, source, duration and size.`,
+	Example: `  sona list
+  sona list --sort duration --limit 10
+  sona list --search standup
+  sona list --tag clientX --tag meeting`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		records, err := List()
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		records = filterRecords(records, listSearch, listTags)
+		if err := sortRecords(records, listSort, listReverse); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if listLimit > 0 && len(records) > listLimit {
+			records = records[:listLimit]
+		}
+
+		if len(records) == 0 {
+			fmt.Println("No transcripts found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tDATE\tSOURCE\tDURATION\tSIZE\tTAGS")
+		for _, record := range records {
+			duration := "-"
+			if record.Duration > 0 {
+				duration = progress.FormatDuration(record.DurationTime())
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				record.Name,
+				record.CreatedAt.Format("2006-01-02 15:04"),
+				shorten(record.Source, 50),
+				duration,
+				workspace.FormatSize(record.Size()),
+				strings.Join(record.Tags, ","))
+		}
+		w.Flush()
+	},
+}
+
+var ShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a saved transcript",
+	Long: `Print a saved transcript with its details. Long transcripts are shown
+through $PAGER (or less) when the output is a terminal.
+
+The name is the one shown by 'sona list'; a unique prefix is enough.`,
+	Example: `  sona show interview
+  sona show interview --no-pager > interview.txt`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		record, err := Find(args[0])
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		var b bytes.Buffer
+		writeRecord(&b, record)
+		page(b.Bytes())
+	},
+}
+
+func init() {
+	ListCmd.Flags().StringVar(&listSort, "sort", "date", "Sort by date, name, duration or size")
+	ListCmd.Flags().BoolVarP(&listReverse, "reverse", "r", false, "Reverse the sort order")
+	ListCmd.Flags().StringVarP(&listSearch, "search", "s", "", "Only show transcripts whose name or source contains this text")
+	ListCmd.Flags().StringArrayVarP(&listTags, "tag", "t", nil, "Only show transcripts with this tag (repeat to require several)")
+	ListCmd.Flags().IntVarP(&listLimit, "limit", "n", 0, "Show at most this many transcripts")
+
+	ShowCmd.Flags().BoolVar(&showNoPager, "no-pager", false, "Print directly instead of using a pager")
+}
+
+// filterRecords keeps records whose name or source contains the search text
+// and that carry every one of the tags
+func filterRecords(records []Record, search string, tags []string) []Record {
+	search = strings.ToLower(strings.TrimSpace(search))
+	tags = NormalizeTags(tags)
+
+	var filtered []Record
+	for _, record := range records {
+		if search != "" && !strings.Contains(strings.ToLower(record.Name), search) && !strings.Contains(strings.ToLower(record.Source), search) {
+			continue
+		}
+		if !hasAllTags(record, tags) {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
+
+func hasAllTags(record Record, tags []string) bool {
+	for _, tag := range tags {
+		if !record.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortRecords orders records by the given field. Dates, durations and sizes
+// sort largest first; names sort alphabetically.
+func sortRecords(records []Record, field string, reverse bool) error {
+	var less func(a, b Record) bool
+	switch field {
+	case "date":
+		less = func(a, b Record) bool { return a.CreatedAt.After(b.CreatedAt) }
+	case "name":
+		less = func(a, b Record) bool { return a.Name < b.Name }
+	case "duration":
+		less = func(a, b Record) bool { return a.Duration > b.Duration }
+	case "size":
+		less = func(a, b Record) bool { return a.Size() > b.Size() }
+	default:
+		return fmt.Errorf("unknown sort field %q (use date, name, duration or size)", field)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if reverse {
+			return less(records[j], records[i])
+		}
+		return less(records[i], records[j])
+	})
+	return nil
+}
+
+// writeRecord renders a record's details followed by its text
+func writeRecord(b *bytes.Buffer, record Record) {
+	fmt.Fprintf(b, "%s\n", record.Name)
+	fmt.Fprintf(b, "%s\n", strings.Repeat("=", len(record.Name)))
+	fmt.Fprintf(b, "Source:   %s\n", record.Source)
+	fmt.Fprintf(b, "Date:     %s\n", record.CreatedAt.Format("2006-01-02 15:04"))
+	if record.Duration > 0 {
+		fmt.Fprintf(b, "Duration: %s\n", progress.FormatDuration(record.DurationTime()))
+	}
+	if record.SpeechModel != "" {
+		fmt.Fprintf(b, "Model:    %s\n", record.SpeechModel)
+	}
+	if record.APIKey != "" {
+		fmt.Fprintf(b, "API key:  %s\n", record.APIKey)
+	}
+	if record.Region != "" {
+		fmt.Fprintf(b, "Region:   %s\n", strings.ToUpper(record.Region))
+	}
+	if record.Cost > 0 {
+		fmt.Fprintf(b, "Cost:     %.2f\n", record.Cost)
+	}
+	if record.Quality != nil {
+		fmt.Fprintf(b, "Quality:  %s\n", record.Quality.Summary())
+	}
+	if len(record.Tags) > 0 {
+		fmt.Fprintf(b, "Tags:     %s\n", strings.Join(record.Tags, ", "))
+	}
+	for _, file := range record.Files {
+		fmt.Fprintf(b, "File:     %s\n", file)
+	}
+	if record.Audio != "" {
+		fmt.Fprintf(b, "Audio:    %s\n", record.Audio)
+	}
+	b.WriteString("\n")
+
+	text := record.Text
+	if text == "" && len(record.Files) > 0 {
+		if data, err := os.ReadFile(record.Files[0]); err == nil {
+			text = string(data)
+		}
+	}
+	b.WriteString(strings.TrimRight(text, "\n"))
+	b.WriteString("\n")
+}
+
+// page shows content through a pager when stdout is a terminal
+func page(content []byte) {
+	if showNoPager || !isTerminal(os.Stdout) {
+		os.Stdout.Write(content)
+		return
+	}
+
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err != nil {
+			os.Stdout.Write(content)
+			return
+		}
+		// Quit immediately when the text fits on one screen
+		pager = "less -FRX"
+	}
+
+	fields := strings.Fields(pager)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Stdout.Write(content)
+	}
+}
+
+// shorten truncates s to at most max characters
+func shorten(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// isTerminal reports whether the file is attached to a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
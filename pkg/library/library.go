@@ -0,0 +1,248 @@
+package library
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Record describes a saved transcript: where it came from, the files it was
+// written to, and the word-level data needed to re-render it later
+type Record struct {
+	Name         string    `json:"name"`
+	Source       string    `json:"source"`
+	SourceType   string    `json:"source_type"`
+	CreatedAt    time.Time `json:"created_at"`
+	Duration     float64   `json:"duration_seconds,omitempty"`
+	SpeechModel  string    `json:"speech_model,omitempty"`
+	LanguageCode string    `json:"language_code,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	Files        []string  `json:"files"`
+	Tags         []string  `json:"tags,omitempty"`
+	// SourceHash is the SHA-256 of the transcribed file, for spotting exact copies
+	SourceHash string   `json:"source_hash,omitempty"`
+	Quality    *Quality `json:"quality,omitempty"`
+	// APIKey is the masked AssemblyAI key that served the transcript
+	APIKey string `json:"api_key,omitempty"`
+	// TranscriptID is the AssemblyAI transcript, when it came from one
+	TranscriptID string `json:"transcript_id,omitempty"`
+	// Region is the AssemblyAI region the transcript was made in
+	Region string `json:"region,omitempty"`
+	// Cost is what the transcript cost at the prices configured when it was made
+	Cost float64 `json:"cost,omitempty"`
+	// Audio is the source audio kept beside the transcript by archive.audio
+	Audio string `json:"audio,omitempty"`
+	// Text is the final transcript text as written to the txt output
+	Text     string               `json:"text"`
+	Words    []transcript.Word    `json:"words,omitempty"`
+	Segments []transcript.Segment `json:"utterances,omitempty"`
+}
+
+// Quality scores how reliable a transcript is, to help decide whether to
+// re-run it with a better model
+type Quality struct {
+	// Score is the mean word confidence as a percentage
+	Score  int    `json:"score"`
+	Rating string `json:"rating"`
+	// MeanConfidence is the average word confidence (0-1)
+	MeanConfidence float64 `json:"mean_confidence"`
+	// LowConfidence is the share of words with confidence below 0.5
+	LowConfidence float64 `json:"low_confidence_share"`
+	// SNR is the audio's signal-to-noise ratio in dB, when it could be measured
+	SNR *float64 `json:"snr_db,omitempty"`
+}
+
+// Summary renders the quality on one line, e.g.
+// "91/100 (good), 3.2% low-confidence words, SNR 24 dB"
+func (q Quality) Summary() string {
+	summary := fmt.Sprintf("%d/100 (%s), %.1f%% low-confidence words", q.Score, q.Rating, 100*q.LowConfidence)
+	if q.SNR != nil {
+		summary += fmt.Sprintf(", SNR %.0f dB", *q.SNR)
+	}
+	return summary
+}
+
+// Size returns the combined size of the record's files and archived audio
+// that still exist
+func (r Record) Size() int64 {
+	var total int64
+	for _, file := range r.Files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	if info, err := os.Stat(r.Audio); r.Audio != "" && err == nil {
+		total += info.Size()
+	}
+	return total
+}
+
+// HasTag reports whether the record carries the tag, ignoring case
+func (r Record) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags trims tags, drops empty ones and removes duplicates
+func NormalizeTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// DurationTime returns the audio duration as a time.Duration
+func (r Record) DurationTime() time.Duration {
+	return time.Duration(r.Duration * float64(time.Second))
+}
+
+// Dir returns the directory holding transcript records (~/.sona/library)
+func Dir() (string, error) {
+	return datadir.Path("library")
+}
+
+// NameFor derives a record name from a transcript path, e.g. "talk-20250101"
+func NameFor(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// Save writes the record atomically, replacing any record with the same name
+func Save(record Record) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create library directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %v", err)
+	}
+	path := filepath.Join(dir, record.Name+".json")
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write record: %v", err)
+	}
+	return nil
+}
+
+// Delete removes the record with the given name. The files it lists are
+// left alone.
+func Delete(name string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name+".json")); err != nil {
+		return fmt.Errorf("failed to remove record %s: %v", name, err)
+	}
+	return nil
+}
+
+// Load reads the record with the given name
+func Load(name string) (Record, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Record{}, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if err != nil {
+		return Record{}, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, fmt.Errorf("record %s is corrupted: %v", name, err)
+	}
+	return record, nil
+}
+
+// List returns every record, newest first. Unreadable records are skipped.
+func List() ([]Record, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read library: %v", err)
+	}
+
+	var records []Record
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		record, err := Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// Find resolves a name to a record. Exact names win; otherwise the query
+// must match the start of exactly one record name.
+func Find(query string) (Record, error) {
+	query = NameFor(query)
+	if record, err := Load(query); err == nil {
+		return record, nil
+	}
+
+	records, err := List()
+	if err != nil {
+		return Record{}, err
+	}
+
+	var matches []Record
+	for _, record := range records {
+		if strings.HasPrefix(record.Name, query) {
+			matches = append(matches, record)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Record{}, fmt.Errorf("no transcript named %q (see 'sona list')", query)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, match := range matches {
+			names[i] = match.Name
+		}
+		return Record{}, fmt.Errorf("%q matches several transcripts: %s", query, strings.Join(names, ", "))
+	}
+}
@@ -0,0 +1,149 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var statsMonth string
+
+var StatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show transcripts, hours and cost per tag and profile",
+	Long: `Show how many transcripts sona made, the hours of audio and what they
+cost, in total and per tag and output profile. A transcript with several
+tags counts towards each of them.
+
+Costs are recorded with each transcript at the prices configured when it
+was made (usage.price_per_hour and usage.model_prices).`,
+	Example: `  sona stats
+  sona stats --month 2025-06`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showStats(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	StatsCmd.Flags().StringVar(&statsMonth, "month", "", "Only count transcripts made in this month, e.g. 2025-06")
+}
+
+// stat sums the transcripts of a group
+type stat struct {
+	Count int
+	Hours float64
+	Cost  float64
+}
+
+func (s *stat) add(record Record) {
+	s.Count++
+	s.Hours += record.Duration / 3600
+	s.Cost += record.Cost
+}
+
+// MonthCost returns the cost of the transcripts made in the calendar month of t
+func MonthCost(t time.Time) (float64, error) {
+	records, err := List()
+	if err != nil {
+		return 0, err
+	}
+
+	month := t.Format("2006-01")
+	var cost float64
+	for _, record := range records {
+		if record.CreatedAt.Format("2006-01") == month {
+			cost += record.Cost
+		}
+	}
+	return cost, nil
+}
+
+func showStats() error {
+	if statsMonth != "" {
+		if _, err := time.Parse("2006-01", statsMonth); err != nil {
+			return fmt.Errorf("invalid month %q (use e.g. 2025-06)", statsMonth)
+		}
+	}
+
+	records, err := List()
+	if err != nil {
+		return err
+	}
+
+	var total stat
+	byTag := make(map[string]*stat)
+	byProfile := make(map[string]*stat)
+	group := func(groups map[string]*stat, name string, record Record) {
+		if groups[name] == nil {
+			groups[name] = &stat{}
+		}
+		groups[name].add(record)
+	}
+	for _, record := range records {
+		if statsMonth != "" && record.CreatedAt.Format("2006-01") != statsMonth {
+			continue
+		}
+		total.add(record)
+		if len(record.Tags) == 0 {
+			group(byTag, "(untagged)", record)
+		}
+		for _, tag := range record.Tags {
+			group(byTag, tag, record)
+		}
+		profile := record.Profile
+		if profile == "" {
+			profile = "(none)"
+		}
+		group(byProfile, profile, record)
+	}
+
+	if total.Count == 0 {
+		fmt.Println("No transcripts found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tTRANSCRIPTS\tHOURS\tCOST")
+	writeStat(w, "Total", total)
+	fmt.Fprintln(w, "\t\t\t")
+	fmt.Fprintln(w, "By tag\t\t\t")
+	writeGroups(w, byTag)
+	fmt.Fprintln(w, "\t\t\t")
+	fmt.Fprintln(w, "By profile\t\t\t")
+	writeGroups(w, byProfile)
+	return w.Flush()
+}
+
+// writeGroups writes the groups, most expensive first, then by hours
+func writeGroups(w *tabwriter.Writer, groups map[string]*stat) {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := groups[names[i]], groups[names[j]]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.Hours != b.Hours {
+			return a.Hours > b.Hours
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		writeStat(w, "  "+name, *groups[name])
+	}
+}
+
+func writeStat(w *tabwriter.Writer, name string, s stat) {
+	fmt.Fprintf(w, "%s\t%d\t%.1f\t%.2f\n", name, s.Count, s.Hours, s.Cost)
+}
@@ -1,25 +1,36 @@
 package logger
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
 )
 
 var (
 	logFile *os.File
 	logger  *log.Logger
+	// verbosity is the number of -v flags: 1 echoes log messages to the
+	// terminal, 2 also streams the output of external commands
+	verbosity int
 )
 
+// SetVerbosity sets how much is shown on the terminal besides the log file
+func SetVerbosity(level int) {
+	verbosity = level
+}
+
 // InitLogger initializes the logger with a file in .sona folder
 func InitLogger() error {
-	homeDir, err := os.UserHomeDir()
+	sonaDir, err := datadir.Dir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %v", err)
+		return err
 	}
-
-	sonaDir := filepath.Join(homeDir, ".sona")
 	if err := os.MkdirAll(sonaDir, 0755); err != nil {
 		return fmt.Errorf("failed to create .sona directory: %v", err)
 	}
@@ -46,6 +57,7 @@ func LogInfo(format string, args ...interface{}) {
 	if logger != nil {
 		logger.Printf("[INFO] "+format, args...)
 	}
+	echo("[INFO] "+format, args...)
 }
 
 // LogError logs an error message
@@ -53,6 +65,7 @@ func LogError(format string, args ...interface{}) {
 	if logger != nil {
 		logger.Printf("[ERROR] "+format, args...)
 	}
+	echo("[ERROR] "+format, args...)
 }
 
 // LogDebug logs a debug message
@@ -60,6 +73,7 @@ func LogDebug(format string, args ...interface{}) {
 	if logger != nil {
 		logger.Printf("[DEBUG] "+format, args...)
 	}
+	echo("[DEBUG] "+format, args...)
 }
 
 // LogWarning logs a warning message
@@ -67,16 +81,57 @@ func LogWarning(format string, args ...interface{}) {
 	if logger != nil {
 		logger.Printf("[WARNING] "+format, args...)
 	}
+	echo("[WARNING] "+format, args...)
+}
+
+// echo prints a log message to stderr under -v
+func echo(format string, args ...interface{}) {
+	if verbosity >= 1 {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
 }
 
 // GetLogPath returns the path to the log file
 func GetLogPath() string {
-	homeDir, _ := os.UserHomeDir()
-	return filepath.Join(homeDir, ".sona", "sona.log")
+	path, _ := datadir.Path("sona.log")
+	return path
+}
+
+// Truncate empties the log file, keeping it open for further logging
+func Truncate() error {
+	if logFile != nil {
+		return logFile.Truncate(0)
+	}
+	return os.Truncate(GetLogPath(), 0)
+}
+
+// CommandOutput returns a writer for an external command's stdout or stderr
+// that captures into buf for LogCommand and, under -vv, also streams live to
+// the terminal
+func CommandOutput(buf *bytes.Buffer) io.Writer {
+	if verbosity >= 2 {
+		return io.MultiWriter(buf, os.Stderr)
+	}
+	return buf
+}
+
+// RunCommand runs cmd with its stdout and stderr captured and logged
+// through LogCommand (streamed under -vv), returning the combined output
+func RunCommand(cmd *exec.Cmd) (string, error) {
+	var output bytes.Buffer
+	cmd.Stdout = CommandOutput(&output)
+	cmd.Stderr = cmd.Stdout
+	err := cmd.Run()
+	LogCommand(cmd.Path, cmd.Args[1:], output.String(), err)
+	return output.String(), err
 }
 
 // LogCommand logs a command execution
 func LogCommand(cmd string, args []string, output string, err error) {
+	echo("[COMMAND] %s %v", cmd, args)
+	if err != nil {
+		echo("[COMMAND] %s exited: %v", cmd, err)
+	}
 	if logger != nil {
 		logger.Printf("[COMMAND] %s %v", cmd, args)
 		if output != "" {
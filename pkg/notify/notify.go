@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// The title and message are passed through the environment so they never
+// need quoting inside the scripts below
+const (
+	titleEnv   = "SONA_NOTIFY_TITLE"
+	messageEnv = "SONA_NOTIFY_MESSAGE"
+)
+
+const macOSScript = `display notification (system attribute "` + messageEnv + `") with title (system attribute "` + titleEnv + `")`
+
+const windowsScript = `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName('text')
+$texts.Item(0).AppendChild($template.CreateTextNode($env:` + titleEnv + `)) > $null
+$texts.Item(1).AppendChild($template.CreateTextNode($env:` + messageEnv + `)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('Sona').Show($toast)`
+
+// Desktop shows a native notification: osascript on macOS, notify-send on
+// Linux and a toast on Windows
+func Desktop(title string, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", macOSScript)
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsScript)
+	default:
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return fmt.Errorf("notify-send not found (install libnotify to get desktop notifications)")
+		}
+		cmd = exec.Command("notify-send", "--app-name=Sona", title, message)
+	}
+
+	cmd.Env = append(os.Environ(), titleEnv+"="+title, messageEnv+"="+message)
+	output, err := cmd.CombinedOutput()
+	logger.LogCommand(cmd.Path, cmd.Args[1:], string(output), err)
+	if err != nil {
+		return fmt.Errorf("failed to show notification: %v", err)
+	}
+	return nil
+}
+
+// Bell rings the terminal bell when stdout is a terminal
+func Bell() {
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Print("\a")
+	}
+}
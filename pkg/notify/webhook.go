@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// webhookTimeout bounds a webhook call so a slow receiver cannot hold up a batch
+const webhookTimeout = 15 * time.Second
+
+// Event is what a webhook reports about a finished job. It is sent as JSON
+// as-is, or is the data of a payload template.
+type Event struct {
+	// Event is transcript.completed or transcript.failed
+	Event  string `json:"event"`
+	Source string `json:"source"`
+	// Name is the library record of the transcript, for 'sona show'
+	Name        string   `json:"name,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	Duration    float64  `json:"duration_seconds,omitempty"`
+	SpeechModel string   `json:"speech_model,omitempty"`
+	// Region is the AssemblyAI region the transcript was made in
+	Region       string    `json:"region,omitempty"`
+	LanguageCode string    `json:"language_code,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Cost         float64   `json:"cost,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	FinishedAt   time.Time `json:"finished_at"`
+}
+
+// Webhook event names
+const (
+	EventCompleted = "transcript.completed"
+	EventFailed    = "transcript.failed"
+)
+
+// templateFuncs help templates produce valid JSON: json encodes any value,
+// e.g. {{json .Text}} gives a quoted, escaped string
+var templateFuncs = template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+	"join": strings.Join,
+}
+
+// ParseTemplate reads a payload template: a Go text/template executed with
+// an Event that must render to JSON
+func ParseTemplate(path string) (*template.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook template: %v", err)
+	}
+	tmpl, err := template.New(path).Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template %s: %v", path, err)
+	}
+	return tmpl, nil
+}
+
+// Payload renders the event with the template, or as plain JSON when tmpl
+// is nil. A template must render to valid JSON.
+func Payload(tmpl *template.Template, event Event) ([]byte, error) {
+	if tmpl == nil {
+		return json.Marshal(event)
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, event); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %v", err)
+	}
+	if !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("webhook template %s did not render valid JSON: %s", tmpl.Name(), strings.TrimSpace(b.String()))
+	}
+	return b.Bytes(), nil
+}
+
+// Webhook posts the event as JSON to url, rendered with tmpl when set
+func Webhook(url string, tmpl *template.Template, event Event) error {
+	payload, err := Payload(tmpl, event)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
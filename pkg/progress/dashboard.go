@@ -0,0 +1,310 @@
+package progress
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// dashboardRows is how many jobs the dashboard lists at once; longer
+// batches scroll so the running job stays in view
+const dashboardRows = 15
+
+// dashboardWidth keeps rows from wrapping on an 80-column terminal, which
+// would throw off redrawing in place
+const dashboardWidth = 79
+
+// Job states shown on the dashboard
+const (
+	JobWaiting = "waiting"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+	JobSkipped = "skipped"
+)
+
+type dashboardJob struct {
+	name       string
+	state      string
+	stage      string
+	estimate   time.Duration
+	started    time.Time
+	stageStart time.Time
+	finished   time.Time
+	note       string
+}
+
+// Dashboard redraws a table of batch jobs in place, with the stage, percent
+// done and elapsed time of each and the error of failed ones. While it
+// runs, whatever the jobs print goes to the handler given to Capture
+// instead of the terminal, so their output cannot scramble the table.
+type Dashboard struct {
+	mu      sync.Mutex
+	out     *os.File
+	jobs    []dashboardJob
+	started time.Time
+	lines   int
+	stop    chan struct{}
+	done    chan struct{}
+	restore func()
+	// parallel is set when several jobs run at once, whose output cannot
+	// be told apart
+	parallel bool
+}
+
+// NewDashboard creates a dashboard for the named jobs, or returns nil when
+// stdout is not a terminal or in accessible mode, and plain output should be
+// used instead
+func NewDashboard(names []string) *Dashboard {
+	if !rewritable() {
+		return nil
+	}
+	jobs := make([]dashboardJob, len(names))
+	for i, name := range names {
+		jobs[i] = dashboardJob{name: name, state: JobWaiting}
+	}
+	return &Dashboard{out: os.Stdout, jobs: jobs}
+}
+
+// Start begins redrawing the dashboard in the background
+func (d *Dashboard) Start() {
+	d.started = time.Now()
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+
+		for frame := 0; ; frame++ {
+			d.render(spinnerFrames[frame%len(spinnerFrames)])
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Capture sends everything printed to stdout to handler, one line at a
+// time, until Stop. The last line of the running job is shown next to it.
+func (d *Dashboard) Capture(handler func(line string)) error {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to capture output: %v", err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			handler(line)
+			d.noteRunning(line)
+		}
+	}()
+
+	d.restore = func() {
+		os.Stdout = stdout
+		writer.Close()
+		<-copied
+		reader.Close()
+	}
+	return nil
+}
+
+// Stop draws the final state and gives stdout back
+func (d *Dashboard) Stop() {
+	if d.stop != nil {
+		close(d.stop)
+		<-d.done
+		d.stop = nil
+	}
+	if d.restore != nil {
+		d.restore()
+		d.restore = nil
+	}
+	d.render(" ")
+}
+
+// Begin marks job i as running
+func (d *Dashboard) Begin(i int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	d.jobs[i] = dashboardJob{name: d.jobs[i].name, state: JobRunning, started: now, stageStart: now}
+}
+
+// Stage shows the stage job i is in, e.g. upload, and how long the stage
+// is expected to take, 0 when unknown. A waiting job shows the stage that
+// was started ahead of its turn.
+func (d *Dashboard) Stage(i int, stage string, estimate time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	job := &d.jobs[i]
+	if job.stage != stage {
+		job.stage = stage
+		job.stageStart = time.Now()
+	}
+	job.estimate = estimate
+}
+
+// Finish marks job i as done, failed or skipped, with a note such as the error
+func (d *Dashboard) Finish(i int, state string, note string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	job := &d.jobs[i]
+	job.state = state
+	job.note = note
+	job.finished = time.Now()
+	if job.started.IsZero() {
+		job.started = job.finished
+	}
+}
+
+// Parallel tells the dashboard that several jobs run at once. Their output
+// cannot be told apart, so it is no longer shown next to the running job.
+func (d *Dashboard) Parallel() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.parallel = true
+}
+
+// noteRunning shows a line of output next to the running job
+func (d *Dashboard) noteRunning(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.parallel {
+		return
+	}
+	for i := range d.jobs {
+		if d.jobs[i].state == JobRunning {
+			d.jobs[i].note = line
+		}
+	}
+}
+
+func (d *Dashboard) render(frame string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counts := make(map[string]int)
+	running := -1
+	for i, job := range d.jobs {
+		counts[job.state]++
+		if job.state == JobRunning {
+			running = i
+		}
+	}
+
+	var lines []string
+	header := fmt.Sprintf("Batch: %d of %d finished", len(d.jobs)-counts[JobWaiting]-counts[JobRunning], len(d.jobs))
+	if counts[JobFailed] > 0 {
+		header += fmt.Sprintf(", %d failed", counts[JobFailed])
+	}
+	header += fmt.Sprintf(" (%s)", FormatDuration(time.Since(d.started)))
+	lines = append(lines, header)
+
+	first, last := dashboardWindow(len(d.jobs), running)
+	if first > 0 {
+		lines = append(lines, fmt.Sprintf("  … %d earlier", first))
+	}
+	for _, job := range d.jobs[first:last] {
+		lines = append(lines, job.line(frame))
+	}
+	if last < len(d.jobs) {
+		lines = append(lines, fmt.Sprintf("  … %d more", len(d.jobs)-last))
+	}
+
+	var b strings.Builder
+	if d.lines > 1 {
+		fmt.Fprintf(&b, "\x1b[%dA", d.lines-1)
+	}
+	b.WriteString("\r")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("\x1b[2K")
+		b.WriteString(fitWidth(line, dashboardWidth))
+	}
+	// Clear lines left over from a longer previous frame
+	for i := len(lines); i < d.lines; i++ {
+		b.WriteString("\n\x1b[2K")
+	}
+	if extra := d.lines - len(lines); extra > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", extra)
+	}
+	d.lines = len(lines)
+	fmt.Fprint(d.out, b.String())
+	if frame == " " {
+		fmt.Fprintln(d.out)
+	}
+}
+
+// dashboardWindow picks the jobs to list so the running one stays in view
+func dashboardWindow(total int, running int) (int, int) {
+	if total <= dashboardRows {
+		return 0, total
+	}
+	first := 0
+	if running >= 0 {
+		first = max(0, min(running-2, total-dashboardRows))
+	}
+	return first, first + dashboardRows
+}
+
+// line renders a job as one row of the dashboard
+func (j dashboardJob) line(frame string) string {
+	// Pad by characters; fmt pads by bytes
+	name := fitWidth(j.name, 28)
+	name += strings.Repeat(" ", 28-utf8.RuneCountInString(name))
+	switch j.state {
+	case JobWaiting:
+		if j.stage != "" {
+			return fmt.Sprintf("  · %s  waiting, %s started", name, j.stage)
+		}
+		return fmt.Sprintf("  · %s  waiting", name)
+	case JobRunning:
+		percent := "    "
+		if j.estimate > 0 {
+			percent = fmt.Sprintf("%3.0f%%", min(99, 100*time.Since(j.stageStart).Seconds()/j.estimate.Seconds()))
+		}
+		stage := j.stage
+		if stage == "" {
+			stage = "starting"
+		}
+		return fmt.Sprintf("  %s %s  %-10s %s  %8s  %s", frame, name, stage, percent, FormatDuration(time.Since(j.started)), j.note)
+	}
+
+	mark, percent, note := "✓", "100%", ""
+	switch j.state {
+	case JobFailed:
+		mark, percent, note = "✗", "    ", j.note
+	case JobSkipped:
+		mark, percent, note = "-", "    ", j.note
+	}
+	elapsed := FormatDuration(j.finished.Sub(j.started))
+	return fmt.Sprintf("  %s %s  %-10s %s  %8s  %s", mark, name, j.state, percent, elapsed, note)
+}
+
+// fitWidth shortens text to at most width characters
+func fitWidth(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,74 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// liveWidth is how much of a partial hypothesis fits on the status line
+const liveWidth = 78
+
+// LiveLine shows a partial hypothesis on a single line that is rewritten
+// in place, and prints finalized text above it. When stdout is not a
+// terminal only finalized text is printed.
+type LiveLine struct {
+	mu          sync.Mutex
+	interactive bool
+	lastWidth   int
+}
+
+// NewLiveLine creates a live line that writes to stdout
+func NewLiveLine() *LiveLine {
+	return &LiveLine{interactive: rewritable()}
+}
+
+// Update replaces the current partial text
+func (l *LiveLine) Update(text string) {
+	if !l.interactive {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Keep the tail, which is what changes as speech continues
+	text = strings.Join(strings.Fields(text), " ")
+	if n := utf8.RuneCountInString(text); n > liveWidth {
+		runes := []rune(text)
+		text = "…" + string(runes[n-liveWidth+1:])
+	}
+
+	width := utf8.RuneCountInString(text)
+	padding := ""
+	if l.lastWidth > width {
+		padding = strings.Repeat(" ", l.lastWidth-width)
+	}
+	l.lastWidth = width
+
+	fmt.Printf("\r%s%s", text, padding)
+}
+
+// Commit prints finalized text and clears the partial line
+func (l *LiveLine) Commit(text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.clearLocked()
+	fmt.Println(text)
+}
+
+// Clear removes the partial line
+func (l *LiveLine) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clearLocked()
+}
+
+func (l *LiveLine) clearLocked() {
+	if l.interactive && l.lastWidth > 0 {
+		fmt.Printf("\r%s\r", strings.Repeat(" ", l.lastWidth))
+		l.lastWidth = 0
+	}
+}
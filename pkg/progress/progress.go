@@ -0,0 +1,179 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner renders a single, continuously updated status line with a phase
+// label, the time spent in the current phase and an optional estimate.
+// When stdout is not a terminal it falls back to printing one line per phase.
+type Spinner struct {
+	mu          sync.Mutex
+	label       string
+	estimate    time.Duration
+	phaseStart  time.Time
+	interactive bool
+	stop        chan struct{}
+	done        chan struct{}
+	lastWidth   int
+	prefix      string
+}
+
+// NewSpinner creates a spinner that writes to stdout
+func NewSpinner() *Spinner {
+	return &Spinner{interactive: rewritable()}
+}
+
+// NewJobSpinner creates a spinner for one of several jobs running at once.
+// It prints one line per phase, starting with label, since status lines
+// redrawn in place by several jobs would overwrite each other.
+func NewJobSpinner(label string) *Spinner {
+	s := &Spinner{}
+	if label != "" {
+		s.prefix = label + ": "
+	}
+	return s
+}
+
+// Start begins rendering the spinner in the background
+func (s *Spinner) Start() {
+	if !s.interactive {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for frame := 0; ; frame++ {
+			select {
+			case <-s.stop:
+				s.clear()
+				return
+			case <-ticker.C:
+				s.render(spinnerFrames[frame%len(spinnerFrames)])
+			}
+		}
+	}()
+}
+
+// SetPhase switches the spinner to a new phase. A zero estimate hides the
+// expected duration.
+func (s *Spinner) SetPhase(label string, estimate time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.label == label {
+		return
+	}
+
+	s.label = label
+	s.estimate = estimate
+	s.phaseStart = time.Now()
+
+	if !s.interactive {
+		if estimate > 0 {
+			fmt.Printf("%s%s (estimated %s)...\n", s.prefix, label, FormatDuration(estimate))
+		} else {
+			fmt.Printf("%s%s...\n", s.prefix, label)
+		}
+	}
+}
+
+// Update relabels the current phase without restarting its timer, e.g. to
+// show a count. Unlike SetPhase it prints nothing when not on a terminal.
+func (s *Spinner) Update(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.label = label
+}
+
+// Stop halts rendering and clears the status line
+func (s *Spinner) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+func (s *Spinner) render(frame string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.label == "" {
+		return
+	}
+
+	elapsed := time.Since(s.phaseStart)
+	line := fmt.Sprintf("%s %s %s", frame, s.label, FormatDuration(elapsed))
+	if s.estimate > 0 {
+		line += " / ~" + FormatDuration(s.estimate)
+		if elapsed > s.estimate {
+			line += " (taking longer than usual)"
+		} else {
+			line += fmt.Sprintf(" (ETA %s)", time.Now().Add(s.estimate-elapsed).Format("15:04"))
+		}
+	}
+
+	padding := ""
+	if s.lastWidth > len(line) {
+		padding = strings.Repeat(" ", s.lastWidth-len(line))
+	}
+	s.lastWidth = len(line)
+
+	fmt.Printf("\r%s%s", line, padding)
+}
+
+func (s *Spinner) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastWidth > 0 {
+		fmt.Printf("\r%s\r", strings.Repeat(" ", s.lastWidth))
+		s.lastWidth = 0
+	}
+}
+
+// rewritable reports whether status lines can be redrawn in place: stdout
+// is a terminal and accessible mode is off, since screen readers cannot
+// follow a line that changes under them
+func rewritable() bool {
+	return isTerminal(os.Stdout) && !style.Accessible()
+}
+
+// isTerminal reports whether the file is attached to a character device
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// FormatDuration renders a duration compactly, e.g. "1m05s" or "3.2s"
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	d = d.Round(time.Second)
+	minutes := int(d / time.Minute)
+	seconds := int((d % time.Minute) / time.Second)
+	if minutes >= 60 {
+		return fmt.Sprintf("%dh%02dm%02ds", minutes/60, minutes%60, seconds)
+	}
+	return fmt.Sprintf("%dm%02ds", minutes, seconds)
+}
@@ -0,0 +1,115 @@
+package progress
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// Timings records how long each named phase of a job took
+type Timings struct {
+	// Job names the job being timed, e.g. its source
+	Job string
+
+	mu      sync.Mutex
+	phases  []phaseTiming
+	current string
+	started time.Time
+	observe func(phase string, estimate time.Duration)
+}
+
+// Observe calls fn whenever a phase begins, and again when its expected
+// duration becomes known, e.g. to show the phase on a dashboard
+func (t *Timings) Observe(fn func(phase string, estimate time.Duration)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observe = fn
+}
+
+// Begin ends the running phase (if any) and starts timing a new one.
+// Beginning the phase that is already running is a no-op.
+func (t *Timings) Begin(phase string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.current == phase {
+		return
+	}
+	t.endLocked()
+	t.current = phase
+	t.started = time.Now()
+	if t.observe != nil {
+		t.observe(phase, 0)
+	}
+}
+
+// Expect reports how long the running phase is expected to take in total
+func (t *Timings) Expect(estimate time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.observe != nil && t.current != "" {
+		t.observe(t.current, estimate)
+	}
+}
+
+// End stops timing the running phase
+func (t *Timings) End() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endLocked()
+}
+
+func (t *Timings) endLocked() {
+	if t.current == "" {
+		return
+	}
+	// Phases that run more than once, e.g. per chunk, add up
+	elapsed := time.Since(t.started)
+	for i := range t.phases {
+		if t.phases[i].name == t.current {
+			t.phases[i].duration += elapsed
+			t.current = ""
+			return
+		}
+	}
+	t.phases = append(t.phases, phaseTiming{name: t.current, duration: elapsed})
+	t.current = ""
+}
+
+// Total returns the combined duration of all finished phases
+func (t *Timings) Total() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var total time.Duration
+	for _, p := range t.phases {
+		total += p.duration
+	}
+	return total
+}
+
+// Summary renders the finished phases, e.g. "download 3.2s, upload 1.0s (total 4.2s)"
+func (t *Timings) Summary() string {
+	t.mu.Lock()
+	phases := append([]phaseTiming(nil), t.phases...)
+	t.mu.Unlock()
+
+	if len(phases) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(phases))
+	var total time.Duration
+	for _, p := range phases {
+		parts = append(parts, fmt.Sprintf("%s %s", p.name, FormatDuration(p.duration)))
+		total += p.duration
+	}
+
+	return fmt.Sprintf("%s (total %s)", strings.Join(parts, ", "), FormatDuration(total))
+}
@@ -0,0 +1,432 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectDescription string
+	projectTags        []string
+	vocabRemove        bool
+	speakerTranscript  string
+	searchContext      int
+	reportOutput       string
+)
+
+var ProjectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Group related transcripts, e.g. the interviews of a study",
+	Long: `Group related transcripts into a project with a shared vocabulary and
+consistent speaker names, search them all at once and summarize them in a
+report.
+
+Transcripts are added by their 'sona list' name, or as they are made with
+'sona transcribe --project <name>', which also corrects near misses of the
+project's vocabulary like the terms of the corrections glossary.
+
+Speaker names replace diarization labels in project searches and reports.
+Names set without --transcript apply to every transcript, e.g. when the
+interviewer always speaks first; names for one transcript take precedence.`,
+	Example: `  sona project create study --description "Onboarding interviews, Q3"
+  sona project add study interview-01 interview-02
+  sona project add study --tag onboarding
+  sona project vocab study Kubernetes "Dr. Okafor"
+  sona project speaker study A=Interviewer
+  sona project speaker study B="Participant 7" --transcript interview-07
+  sona project search study "pricing"
+  sona project report study --output study.md`,
+}
+
+var projectCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an empty project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := Create(args[0], projectDescription); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Println(style.Success("Created project %s", args[0]))
+		fmt.Println(style.Hint("Add transcripts with 'sona project add %s <name>' or 'sona transcribe --project %s'", args[0], args[0]))
+	},
+}
+
+var projectListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List projects",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projects, err := List()
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if len(projects) == 0 {
+			fmt.Println("No projects; create one with 'sona project create <name>'")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tTRANSCRIPTS\tVOCABULARY\tCREATED\tDESCRIPTION")
+		for _, p := range projects {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", p.Name, len(p.Transcripts), len(p.Vocabulary), p.CreatedAt.Format("2006-01-02 15:04"), p.Description)
+		}
+		w.Flush()
+	},
+}
+
+var projectShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show the transcripts, vocabulary and speaker names of a project",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := Load(args[0])
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		showProject(p)
+	},
+}
+
+var projectDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a project, keeping its transcripts",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := Delete(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Project %s deleted; its transcripts are still in the library\n", args[0])
+	},
+}
+
+var projectAddCmd = &cobra.Command{
+	Use:   "add <project> [transcript...]",
+	Short: "Add saved transcripts to a project",
+	Long: `Add transcripts to a project by their 'sona list' name; a unique prefix is
+enough. With --tag, every transcript carrying the tag is added too.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := addTranscripts(args[0], args[1:], projectTags); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+var projectRemoveCmd = &cobra.Command{
+	Use:   "remove <project> <transcript...>",
+	Short: "Take transcripts out of a project, keeping them in the library",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := Update(args[0], func(p *Project) error {
+			for _, name := range args[1:] {
+				if !p.remove(library.NameFor(name)) {
+					return fmt.Errorf("%s is not in project %s", name, p.Name)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %d transcripts from %s\n", len(args)-1, args[0])
+	},
+}
+
+var projectVocabCmd = &cobra.Command{
+	Use:   "vocab <project> [term...]",
+	Short: "Add to, remove from or list the vocabulary of a project",
+	Long: `Add names and terms to the vocabulary of a project, or remove them with
+--remove. Without terms, the vocabulary is listed.
+
+'sona transcribe --project' corrects near misses of these terms, and
+project reports count how often each one comes up.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			p, err := Load(args[0])
+			if err != nil {
+				fmt.Println(style.Error("%v", err))
+				os.Exit(1)
+			}
+			if len(p.Vocabulary) == 0 {
+				fmt.Printf("Project %s has no vocabulary; add terms with 'sona project vocab %s <term...>'\n", p.Name, p.Name)
+				return
+			}
+			for _, term := range p.Vocabulary {
+				fmt.Println(term)
+			}
+			return
+		}
+
+		changed := 0
+		err := Update(args[0], func(p *Project) error {
+			for _, term := range args[1:] {
+				term = strings.TrimSpace(term)
+				if term == "" {
+					continue
+				}
+				if vocabRemove {
+					if p.removeTerm(term) {
+						changed++
+					}
+				} else if p.addTerm(term) {
+					changed++
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if vocabRemove {
+			fmt.Printf("Removed %d terms from %s\n", changed, args[0])
+		} else {
+			fmt.Printf("Added %d terms to %s\n", changed, args[0])
+		}
+	},
+}
+
+var projectSpeakerCmd = &cobra.Command{
+	Use:   "speaker <project> <label=name...>",
+	Short: "Name the speakers of a project's transcripts",
+	Long: `Name diarization labels, e.g. A=Interviewer. Without --transcript the
+names apply to every transcript of the project; with it, to that one only,
+taking precedence. An empty name (A=) removes a name.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := Update(args[0], func(p *Project) error {
+			record := ""
+			if speakerTranscript != "" {
+				record = library.NameFor(speakerTranscript)
+				if !p.Has(record) {
+					return fmt.Errorf("%s is not in project %s", speakerTranscript, p.Name)
+				}
+			}
+			for _, assignment := range args[1:] {
+				label, name, ok := strings.Cut(assignment, "=")
+				label = strings.TrimSpace(label)
+				if !ok || label == "" {
+					return fmt.Errorf("invalid speaker name %q, expected label=name, e.g. A=Interviewer", assignment)
+				}
+				p.setSpeaker(record, label, strings.TrimSpace(name))
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Updated speaker names of %s\n", args[0])
+	},
+}
+
+var projectSearchCmd = &cobra.Command{
+	Use:   "search <project> <phrase>",
+	Short: "Find a word or phrase in every transcript of a project",
+	Long: `List every occurrence of a word or phrase in the transcripts of a project
+with the transcript, timestamp, speaker name and the words around it.
+Matching ignores case and punctuation.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSearch(args[0], args[1]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+var projectReportCmd = &cobra.Command{
+	Use:   "report <project>",
+	Short: "Write a Markdown summary of a project",
+	Long: `Summarize a project in Markdown: its transcripts with their duration,
+words and speakers, the talk time of each speaker across the project, and
+how often each vocabulary term is mentioned.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := Load(args[0])
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		records, missing := p.Records()
+		report := p.Report(records, missing)
+		if reportOutput == "" {
+			fmt.Print(report)
+			return
+		}
+		if err := atomicfile.WriteFile(reportOutput, []byte(report), 0644); err != nil {
+			fmt.Println(style.Error("failed to write %s: %v", reportOutput, err))
+			os.Exit(1)
+		}
+		fmt.Println(style.Success("Report saved to %s", reportOutput))
+	},
+}
+
+func init() {
+	projectCreateCmd.Flags().StringVarP(&projectDescription, "description", "d", "", "What the project is about, shown in lists and reports")
+	projectAddCmd.Flags().StringArrayVarP(&projectTags, "tag", "t", nil, "Also add every transcript with this tag (repeatable)")
+	projectVocabCmd.Flags().BoolVar(&vocabRemove, "remove", false, "Remove the terms instead of adding them")
+	projectSpeakerCmd.Flags().StringVar(&speakerTranscript, "transcript", "", "Name the speakers of this transcript only")
+	projectSearchCmd.Flags().IntVarP(&searchContext, "context", "c", 8, "Number of words to show before and after each match")
+	projectReportCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "Write the report to this file instead of printing it")
+
+	ProjectCmd.AddCommand(projectCreateCmd)
+	ProjectCmd.AddCommand(projectListCmd)
+	ProjectCmd.AddCommand(projectShowCmd)
+	ProjectCmd.AddCommand(projectDeleteCmd)
+	ProjectCmd.AddCommand(projectAddCmd)
+	ProjectCmd.AddCommand(projectRemoveCmd)
+	ProjectCmd.AddCommand(projectVocabCmd)
+	ProjectCmd.AddCommand(projectSpeakerCmd)
+	ProjectCmd.AddCommand(projectSearchCmd)
+	ProjectCmd.AddCommand(projectReportCmd)
+}
+
+// addTranscripts adds the named transcripts and those carrying any of the
+// tags to a project
+func addTranscripts(name string, queries []string, tags []string) error {
+	if len(queries) == 0 && len(tags) == 0 {
+		return fmt.Errorf("name the transcripts to add, or use --tag")
+	}
+
+	var names []string
+	for _, query := range queries {
+		record, err := library.Find(query)
+		if err != nil {
+			return err
+		}
+		names = append(names, record.Name)
+	}
+	if len(tags) > 0 {
+		records, err := library.List()
+		if err != nil {
+			return err
+		}
+		// Oldest first, so tagged transcripts keep the order they were made in
+		for i := len(records) - 1; i >= 0; i-- {
+			for _, tag := range tags {
+				if records[i].HasTag(tag) {
+					names = append(names, records[i].Name)
+					break
+				}
+			}
+		}
+	}
+
+	added := 0
+	err := Update(name, func(p *Project) error {
+		for _, record := range names {
+			if p.add(record) {
+				added++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %d transcripts to %s\n", added, name)
+	return nil
+}
+
+// showProject prints the transcripts, vocabulary and speaker names of a project
+func showProject(p *Project) {
+	fmt.Println(p.Name)
+	fmt.Println(strings.Repeat("=", len(p.Name)))
+	if p.Description != "" {
+		fmt.Println(p.Description)
+	}
+	fmt.Printf("Created: %s\n", p.CreatedAt.Format("2006-01-02 15:04"))
+	if len(p.Vocabulary) > 0 {
+		fmt.Printf("Vocabulary: %s\n", strings.Join(p.Vocabulary, ", "))
+	}
+	if names := p.Speakers[allTranscripts]; len(names) > 0 {
+		fmt.Printf("Speakers: %s\n", formatSpeakers(names))
+	}
+
+	fmt.Println()
+	if len(p.Transcripts) == 0 {
+		fmt.Println("No transcripts yet")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSCRIPT\tDATE\tDURATION\tSPEAKERS")
+	for _, name := range p.Transcripts {
+		record, err := library.Load(name)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t(not in the library)\t\t\n", name)
+			continue
+		}
+		duration := "-"
+		if record.Duration > 0 {
+			duration = progress.FormatDuration(record.DurationTime())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, record.CreatedAt.Format("2006-01-02 15:04"), duration, strings.Join(p.speakers(record), ", "))
+	}
+	w.Flush()
+}
+
+// formatSpeakers renders speaker names as "A=Interviewer, B=Participant"
+func formatSpeakers(names map[string]string) string {
+	labels := make([]string, 0, len(names))
+	for label := range names {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	parts := make([]string, len(labels))
+	for i, label := range labels {
+		parts[i] = label + "=" + names[label]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runSearch prints the matches of a phrase across a project
+func runSearch(name string, phrase string) error {
+	p, err := Load(name)
+	if err != nil {
+		return err
+	}
+	records, missing := p.Records()
+	for _, record := range missing {
+		fmt.Println(style.Warning("Skipping %s, no longer in the library", record))
+	}
+	matches, err := p.Search(records, phrase, searchContext)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Printf("No matches for %q in project %s\n", phrase, p.Name)
+		return nil
+	}
+
+	transcripts := make(map[string]bool)
+	for _, m := range matches {
+		transcripts[m.Transcript] = true
+		timestamp := "--:--:--"
+		if m.Timed {
+			timestamp = formatTimestamp(m.Start)
+		}
+		speaker := ""
+		if m.Speaker != "" {
+			speaker = m.Speaker + ": "
+		}
+		fmt.Printf("%s  %s  %s%s\n", m.Transcript, timestamp, speaker, m.Context)
+	}
+	fmt.Printf("\n%d matches for %q in %d of %d transcripts\n", len(matches), phrase, len(transcripts), len(records))
+	return nil
+}
@@ -0,0 +1,295 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/library"
+)
+
+// allTranscripts keys the speaker names that apply to every transcript
+const allTranscripts = "*"
+
+// namePattern keeps project names usable as file names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// mu serializes updates, which parallel jobs of a batch make to one project
+var mu sync.Mutex
+
+// Project groups related transcripts, e.g. the interviews of a study, with
+// the vocabulary and speaker names they share
+type Project struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Transcripts are the names of library records, in the order added
+	Transcripts []string `json:"transcripts"`
+	// Vocabulary are the names and terms transcripts made for the project
+	// are corrected to, like the terms of the corrections glossary
+	Vocabulary []string `json:"vocabulary,omitempty"`
+	// Speakers names speaker labels by transcript; the "*" entry applies
+	// to every transcript without a name of its own
+	Speakers map[string]map[string]string `json:"speakers,omitempty"`
+}
+
+// Dir returns the directory holding projects (~/.sona/projects)
+func Dir() (string, error) {
+	return datadir.Path("projects")
+}
+
+// validateName rejects names that cannot be used as a file name
+func validateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid project name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// Create saves a new, empty project
+func Create(name string, description string) (*Project, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, err := Load(name); err == nil {
+		return nil, fmt.Errorf("project %s already exists", name)
+	}
+	p := &Project{Name: name, Description: description, CreatedAt: time.Now()}
+	if err := p.Save(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads the project with the given name
+func Load(name string) (*Project, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no project named %q (see 'sona project list')", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p Project
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("project %s is corrupted: %v", name, err)
+	}
+	return &p, nil
+}
+
+// Save writes the project atomically
+func (p *Project) Save() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create projects directory: %v", err)
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode project: %v", err)
+	}
+	if err := atomicfile.WriteFile(filepath.Join(dir, p.Name+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write project: %v", err)
+	}
+	return nil
+}
+
+// Delete removes a project. Its transcripts stay in the library.
+func Delete(name string) error {
+	if _, err := Load(name); err != nil {
+		return err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, name+".json")); err != nil {
+		return fmt.Errorf("failed to remove project %s: %v", name, err)
+	}
+	return nil
+}
+
+// List returns every project by name. Unreadable projects are skipped.
+func List() ([]*Project, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read projects: %v", err)
+	}
+
+	var projects []*Project
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		p, err := Load(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+	return projects, nil
+}
+
+// Update loads a project, applies change and saves it, holding off other
+// updates in between
+func Update(name string, change func(p *Project) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	p, err := Load(name)
+	if err != nil {
+		return err
+	}
+	if err := change(p); err != nil {
+		return err
+	}
+	return p.Save()
+}
+
+// AddTranscript adds a library record to a project, as transcribe
+// --project does once the transcript is saved
+func AddTranscript(name string, record string) error {
+	return Update(name, func(p *Project) error {
+		p.add(record)
+		return nil
+	})
+}
+
+// Has reports whether the transcript is in the project
+func (p *Project) Has(record string) bool {
+	for _, name := range p.Transcripts {
+		if name == record {
+			return true
+		}
+	}
+	return false
+}
+
+// add adds a transcript unless the project has it already, and reports
+// whether it did
+func (p *Project) add(record string) bool {
+	if p.Has(record) {
+		return false
+	}
+	p.Transcripts = append(p.Transcripts, record)
+	return true
+}
+
+// remove takes a transcript out of the project and drops its speaker names
+func (p *Project) remove(record string) bool {
+	for i, name := range p.Transcripts {
+		if name == record {
+			p.Transcripts = append(p.Transcripts[:i], p.Transcripts[i+1:]...)
+			delete(p.Speakers, record)
+			return true
+		}
+	}
+	return false
+}
+
+// addTerm adds a vocabulary term, ignoring case when looking for one the
+// project has already
+func (p *Project) addTerm(term string) bool {
+	for _, existing := range p.Vocabulary {
+		if strings.EqualFold(existing, term) {
+			return false
+		}
+	}
+	p.Vocabulary = append(p.Vocabulary, term)
+	return true
+}
+
+// removeTerm removes a vocabulary term, ignoring case
+func (p *Project) removeTerm(term string) bool {
+	for i, existing := range p.Vocabulary {
+		if strings.EqualFold(existing, term) {
+			p.Vocabulary = append(p.Vocabulary[:i], p.Vocabulary[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// setSpeaker names a speaker label in one transcript, or in all of them
+// when record is empty. An empty name removes the name.
+func (p *Project) setSpeaker(record string, label string, name string) {
+	if record == "" {
+		record = allTranscripts
+	}
+	label = strings.ToUpper(label)
+	if name == "" {
+		delete(p.Speakers[record], label)
+		if len(p.Speakers[record]) == 0 {
+			delete(p.Speakers, record)
+		}
+		return
+	}
+	if p.Speakers == nil {
+		p.Speakers = make(map[string]map[string]string)
+	}
+	if p.Speakers[record] == nil {
+		p.Speakers[record] = make(map[string]string)
+	}
+	p.Speakers[record][label] = name
+}
+
+// SpeakerName returns the name of a speaker label in a transcript, falling
+// back to the project-wide name and then to "Speaker A"
+func (p *Project) SpeakerName(record string, label string) string {
+	if label == "" {
+		return ""
+	}
+	upper := strings.ToUpper(label)
+	if name, ok := p.Speakers[record][upper]; ok {
+		return name
+	}
+	if name, ok := p.Speakers[allTranscripts][upper]; ok {
+		return name
+	}
+	return "Speaker " + upper
+}
+
+// Records loads the library records of the project in order, and returns
+// the names of those no longer in the library
+func (p *Project) Records() ([]library.Record, []string) {
+	var records []library.Record
+	var missing []string
+	for _, name := range p.Transcripts {
+		record, err := library.Load(name)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, missing
+}
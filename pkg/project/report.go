@@ -0,0 +1,191 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// speakerStat is the talk time of one speaker across the project. Named
+// speakers are counted together in every transcript they speak in; unnamed
+// labels only within their transcript, as "A" in one interview is not "A"
+// in the next.
+type speakerStat struct {
+	name        string
+	talk        time.Duration
+	transcripts []string
+}
+
+// termStat counts the mentions of a vocabulary term
+type termStat struct {
+	mentions    int
+	transcripts int
+}
+
+// Report renders a Markdown summary of the project: its transcripts, who
+// speaks how much across them and how often the vocabulary comes up
+func (p *Project) Report(records []library.Record, missing []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", p.Name)
+	if p.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", p.Description)
+	}
+
+	var total time.Duration
+	totalWords := 0
+	for _, record := range records {
+		total += record.DurationTime()
+		totalWords += len(strings.Fields(record.Text))
+	}
+	fmt.Fprintf(&b, "- Transcripts: %d\n", len(records))
+	fmt.Fprintf(&b, "- Total duration: %s\n", progress.FormatDuration(total))
+	fmt.Fprintf(&b, "- Words: %d\n", totalWords)
+	fmt.Fprintf(&b, "- Created: %s\n\n", p.CreatedAt.Format("2006-01-02"))
+
+	if len(records) > 0 {
+		b.WriteString("## Transcripts\n\n")
+		b.WriteString("| Transcript | Date | Duration | Words | Speakers |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, record := range records {
+			duration := "-"
+			if record.Duration > 0 {
+				duration = progress.FormatDuration(record.DurationTime())
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n",
+				cell(record.Name),
+				record.CreatedAt.Format("2006-01-02"),
+				duration,
+				len(strings.Fields(record.Text)),
+				cell(strings.Join(p.speakers(record), ", ")))
+		}
+		b.WriteString("\n")
+	}
+
+	if stats := p.speakerStats(records); len(stats) > 0 {
+		var talk time.Duration
+		for _, s := range stats {
+			talk += s.talk
+		}
+		b.WriteString("## Speakers\n\n")
+		b.WriteString("| Speaker | Transcripts | Talk time | Share |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, s := range stats {
+			fmt.Fprintf(&b, "| %s | %d | %s | %.0f%% |\n",
+				cell(s.name), len(s.transcripts), progress.FormatDuration(s.talk), 100*s.talk.Seconds()/talk.Seconds())
+		}
+		b.WriteString("\n")
+	}
+
+	if len(p.Vocabulary) > 0 {
+		b.WriteString("## Vocabulary\n\n")
+		b.WriteString("| Term | Mentions | Transcripts |\n")
+		b.WriteString("|---|---|---|\n")
+		for _, term := range p.Vocabulary {
+			s := termStats(records, term)
+			fmt.Fprintf(&b, "| %s | %d | %d |\n", cell(term), s.mentions, s.transcripts)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(missing) > 0 {
+		b.WriteString("## Missing transcripts\n\n")
+		b.WriteString("These are in the project but no longer in the library:\n\n")
+		for _, name := range missing {
+			fmt.Fprintf(&b, "- %s\n", name)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// speakers returns the names of the speakers of a transcript in the order
+// they first speak
+func (p *Project) speakers(record library.Record) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, segment := range record.Segments {
+		if segment.Speaker == "" || seen[segment.Speaker] {
+			continue
+		}
+		seen[segment.Speaker] = true
+		names = append(names, p.SpeakerName(record.Name, segment.Speaker))
+	}
+	return names
+}
+
+// speakerStats sums the talk time of each speaker, most talkative first
+func (p *Project) speakerStats(records []library.Record) []*speakerStat {
+	byKey := make(map[string]*speakerStat)
+	for _, record := range records {
+		for _, segment := range record.Segments {
+			if segment.Speaker == "" {
+				continue
+			}
+			name := p.SpeakerName(record.Name, segment.Speaker)
+			key := name
+			if p.unnamed(record.Name, segment.Speaker) {
+				name = fmt.Sprintf("%s (%s)", name, record.Name)
+				key = record.Name + "\x00" + segment.Speaker
+			}
+			s, ok := byKey[key]
+			if !ok {
+				s = &speakerStat{name: name}
+				byKey[key] = s
+			}
+			s.talk += time.Duration(segment.End-segment.Start) * time.Millisecond
+			if len(s.transcripts) == 0 || s.transcripts[len(s.transcripts)-1] != record.Name {
+				s.transcripts = append(s.transcripts, record.Name)
+			}
+		}
+	}
+
+	stats := make([]*speakerStat, 0, len(byKey))
+	for _, s := range byKey {
+		if s.talk > 0 {
+			stats = append(stats, s)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].talk != stats[j].talk {
+			return stats[i].talk > stats[j].talk
+		}
+		return stats[i].name < stats[j].name
+	})
+	return stats
+}
+
+// unnamed reports whether a speaker label has no name in the project
+func (p *Project) unnamed(record string, label string) bool {
+	upper := strings.ToUpper(label)
+	_, named := p.Speakers[record][upper]
+	_, namedAll := p.Speakers[allTranscripts][upper]
+	return !named && !namedAll
+}
+
+// termStats counts how often a vocabulary term is said, and in how many
+// transcripts
+func termStats(records []library.Record, term string) termStat {
+	var s termStat
+	terms := transcript.SearchTerms(term)
+	if len(terms) == 0 {
+		return s
+	}
+	for _, record := range records {
+		words, _ := recordWords(record)
+		if count := len(transcript.FindPhrase(words, terms, 0)); count > 0 {
+			s.mentions += count
+			s.transcripts++
+		}
+	}
+	return s
+}
+
+// cell escapes the pipes of a Markdown table cell
+func cell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
@@ -0,0 +1,57 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Match is an occurrence of a phrase in one of the project's transcripts
+type Match struct {
+	Transcript string
+	transcript.Occurrence
+	// Timed is false for transcripts saved without word timings, whose
+	// matches are found in the text and have no timestamp
+	Timed bool
+}
+
+// Search finds a phrase in every transcript of the project, in project
+// order, with speaker labels replaced by the project's speaker names
+func (p *Project) Search(records []library.Record, phrase string, context int) ([]Match, error) {
+	terms := transcript.SearchTerms(phrase)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("nothing to search for in %q", phrase)
+	}
+
+	var matches []Match
+	for _, record := range records {
+		words, timed := recordWords(record)
+		for _, o := range transcript.FindPhrase(words, terms, context) {
+			o.Speaker = p.SpeakerName(record.Name, o.Speaker)
+			matches = append(matches, Match{Transcript: record.Name, Occurrence: o, Timed: timed})
+		}
+	}
+	return matches, nil
+}
+
+// recordWords returns the timed words of a record, or the words of its text
+// without timings when it was saved before words were kept
+func recordWords(record library.Record) ([]transcript.Word, bool) {
+	if len(record.Words) > 0 {
+		return record.Words, true
+	}
+	var words []transcript.Word
+	for _, field := range strings.Fields(record.Text) {
+		words = append(words, transcript.Word{Text: field})
+	}
+	return words, false
+}
+
+// formatTimestamp renders an offset as HH:MM:SS
+func formatTimestamp(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total/60)%60, total%60)
+}
@@ -0,0 +1,95 @@
+package proofread
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// languageTool proofreads through a LanguageTool server's /v2/check endpoint
+type languageTool struct {
+	url        string
+	language   string
+	httpClient *http.Client
+}
+
+type languageToolResponse struct {
+	Matches []struct {
+		Offset       int `json:"offset"`
+		Length       int `json:"length"`
+		Replacements []struct {
+			Value string `json:"value"`
+		} `json:"replacements"`
+	} `json:"matches"`
+}
+
+// Proofread applies the first suggestion of every match outside protected prefixes
+func (lt *languageTool) Proofread(text string) (string, error) {
+	form := url.Values{}
+	form.Set("text", text)
+	form.Set("language", lt.language)
+
+	resp, err := lt.httpClient.PostForm(lt.url, form)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach LanguageTool: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("LanguageTool returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result languageToolResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode LanguageTool response: %v", err)
+	}
+
+	// LanguageTool reports offsets in UTF-16 code units
+	units := utf16.Encode([]rune(text))
+	protected := protectedUnitRanges(text)
+
+	// Apply from the end so earlier offsets stay valid
+	sort.Slice(result.Matches, func(i, j int) bool {
+		return result.Matches[i].Offset > result.Matches[j].Offset
+	})
+
+	for _, match := range result.Matches {
+		if len(match.Replacements) == 0 {
+			continue
+		}
+		start, end := match.Offset, match.Offset+match.Length
+		if start < 0 || end > len(units) || overlaps(start, end, protected) {
+			continue
+		}
+		replacement := utf16.Encode([]rune(match.Replacements[0].Value))
+		units = append(units[:start], append(replacement, units[end:]...)...)
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+// protectedUnitRanges converts the protected byte ranges to UTF-16 offsets
+func protectedUnitRanges(text string) [][2]int {
+	var ranges [][2]int
+	for _, r := range protectedRanges(text) {
+		start := len(utf16.Encode([]rune(text[:r[0]])))
+		end := start + len(utf16.Encode([]rune(text[r[0]:r[1]])))
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+func overlaps(start int, end int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if start < r[1] && end > r[0] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package proofread
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const llmInstructions = `You are a proofreader for speech transcripts. Correct spelling, grammar and punctuation only.
+Do not summarize, reword or translate. Keep every line break exactly as it is, and never change
+timestamps in square brackets or speaker labels such as "Speaker A:" at the start of a line.
+Reply with the corrected transcript only.`
+
+// llm proofreads through an OpenAI-compatible chat completions endpoint
+type llm struct {
+	url        string
+	model      string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Temperature float64       `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// Proofread sends the transcript to the model and restores the original line prefixes
+func (l *llm) Proofread(text string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: l.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: llmInstructions},
+			{Role: "user", Content: text},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", l.url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+l.apiKey)
+	}
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach proofreading model: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("proofreading model returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode model response: %v", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("model returned no choices")
+	}
+
+	return restorePrefixes(text, result.Choices[0].Message.Content)
+}
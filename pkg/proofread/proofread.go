@@ -0,0 +1,109 @@
+package proofread
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Supported proofreading backends
+const (
+	ProviderLanguageTool = "languagetool"
+	ProviderLLM          = "llm"
+)
+
+// Options configures a proofreading pass
+type Options struct {
+	// Provider is "languagetool" or "llm"
+	Provider string
+	// URL is the LanguageTool check endpoint or an OpenAI-compatible chat completions endpoint
+	URL string
+	// Language is the LanguageTool language code ("auto" to detect)
+	Language string
+	// Model is the LLM model name
+	Model string
+	// APIKey is sent as a bearer token to the LLM endpoint, if set
+	APIKey string
+}
+
+// Proofreader corrects spelling and grammar in transcript text
+type Proofreader interface {
+	Proofread(text string) (string, error)
+}
+
+// New returns the proofreader for the configured provider
+func New(opts Options) (Proofreader, error) {
+	if opts.URL == "" {
+		return nil, fmt.Errorf("proofread.url is not configured. Set it with: sona config set proofread.url <url>")
+	}
+
+	httpClient := &http.Client{Timeout: 2 * time.Minute}
+
+	switch opts.Provider {
+	case ProviderLanguageTool, "":
+		language := opts.Language
+		if language == "" {
+			language = "auto"
+		}
+		return &languageTool{url: opts.URL, language: language, httpClient: httpClient}, nil
+	case ProviderLLM:
+		if opts.Model == "" {
+			return nil, fmt.Errorf("proofread.model is required for the llm provider")
+		}
+		return &llm{url: opts.URL, model: opts.Model, apiKey: opts.APIKey, httpClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proofread provider %q (supported: %s, %s)", opts.Provider, ProviderLanguageTool, ProviderLLM)
+	}
+}
+
+// prefixPattern matches the parts of a transcript line that must never be
+// altered: a leading timestamp like "[00:01:02]" and/or a speaker label like "Speaker A:"
+var prefixPattern = regexp.MustCompile(`^\s*(\[\d{1,2}:\d{2}(?::\d{2})?(?:[.,]\d+)?\]\s*)?([\p{L}\p{N} ._-]{1,40}:\s+)?`)
+
+// splitPrefix separates a line into its protected prefix and the prose after it
+func splitPrefix(line string) (string, string) {
+	match := prefixPattern.FindStringIndex(line)
+	if match == nil {
+		return "", line
+	}
+	return line[:match[1]], line[match[1]:]
+}
+
+// protectedRanges returns the byte ranges of all line prefixes in text
+func protectedRanges(text string) [][2]int {
+	var ranges [][2]int
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		prefix, _ := splitPrefix(line)
+		if prefix != "" {
+			ranges = append(ranges, [2]int{offset, offset + len(prefix)})
+		}
+		offset += len(line)
+	}
+	return ranges
+}
+
+// restorePrefixes puts the original prefixes back onto corrected lines.
+// It fails when the corrected text no longer has the same line structure.
+func restorePrefixes(original string, corrected string) (string, error) {
+	originalLines := strings.Split(strings.TrimRight(original, "\n"), "\n")
+	correctedLines := strings.Split(strings.TrimRight(corrected, "\n"), "\n")
+
+	if len(originalLines) != len(correctedLines) {
+		return "", fmt.Errorf("proofreader changed the line structure (%d lines became %d)", len(originalLines), len(correctedLines))
+	}
+
+	for i, line := range originalLines {
+		prefix, _ := splitPrefix(line)
+		_, body := splitPrefix(correctedLines[i])
+		correctedLines[i] = prefix + body
+	}
+
+	result := strings.Join(correctedLines, "\n")
+	if strings.HasSuffix(original, "\n") {
+		result += "\n"
+	}
+	return result, nil
+}
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// Job is a transcription recorded for later submission
+type Job struct {
+	ID            string   `json:"id"`
+	Source        string   `json:"source"`
+	LanguageCode  string   `json:"language_code,omitempty"`
+	SpeechModel   string   `json:"speech_model"`
+	OutputPath    string   `json:"output_path,omitempty"`
+	Formats       []string `json:"formats,omitempty"`
+	Profile       string   `json:"profile,omitempty"`
+	Numbers       string   `json:"numbers,omitempty"`
+	MarkUncertain float64  `json:"mark_uncertain,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	Speakers      int      `json:"speakers_expected,omitempty"`
+	LRCWords      bool     `json:"lrc_words,omitempty"`
+	ChunkTokens   int      `json:"chunk_tokens,omitempty"`
+	ChunkOverlap  int      `json:"chunk_overlap,omitempty"`
+	ShowNotes     bool     `json:"show_notes,omitempty"`
+	Music         string   `json:"music,omitempty"`
+	Multilingual  bool     `json:"multilingual,omitempty"`
+	SpeechThresh  float64  `json:"speech_threshold,omitempty"`
+	Provider      string   `json:"provider,omitempty"`
+	UploadCodec   string   `json:"upload_codec,omitempty"`
+	Proofread     bool     `json:"proofread,omitempty"`
+	Corrections   string   `json:"corrections,omitempty"`
+	NoCorrections bool     `json:"no_corrections,omitempty"`
+	Project       string   `json:"project,omitempty"`
+	AllowEmpty    bool     `json:"allow_empty,omitempty"`
+	Duplicates    bool     `json:"allow_duplicate,omitempty"`
+	AutoUpgrade   bool     `json:"auto_upgrade,omitempty"`
+	NoTimestamp   bool     `json:"no_timestamp,omitempty"`
+	NameTemplate  string   `json:"name_template,omitempty"`
+	Priority      string   `json:"priority,omitempty"`
+	Budget        float64  `json:"budget,omitempty"`
+	// Webhook receives an event when the job finishes, rendered with the
+	// payload template at WebhookTemplate when set
+	Webhook         string    `json:"webhook,omitempty"`
+	WebhookTemplate string    `json:"webhook_template,omitempty"`
+	QueuedAt        time.Time `json:"queued_at"`
+	// AudioStart and AudioEnd limit transcription to part of the audio
+	AudioStart time.Duration `json:"audio_start,omitempty"`
+	AudioEnd   time.Duration `json:"audio_end,omitempty"`
+	// NotBefore holds back a job deferred by the daily budget until the next day
+	NotBefore time.Time `json:"not_before,omitempty"`
+	// LastError is the failure of the most recent flush attempt, if any
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Job priorities; jobs without one are normal
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// Rank orders priorities, highest first
+func Rank(priority string) int {
+	switch priority {
+	case PriorityHigh:
+		return 0
+	case PriorityLow:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// SortByPriority orders jobs by priority, keeping the order of jobs with the same priority
+func SortByPriority(jobs []Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return Rank(jobs[i].Priority) < Rank(jobs[j].Priority)
+	})
+}
+
+// Path returns the location of the queue file (~/.sona/queue.json)
+func Path() (string, error) {
+	return datadir.Path("queue.json")
+}
+
+// Load returns the queued jobs in the order they were added
+func Load() ([]Job, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue: %v", err)
+	}
+
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("queue file %s is corrupted: %v", path, err)
+	}
+	return jobs, nil
+}
+
+// Save replaces the queue with jobs. The file is written atomically so an
+// interrupted flush never loses the queue.
+func Save(jobs []Job) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	if len(jobs) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear queue: %v", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode queue: %v", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write queue: %v", err)
+	}
+	return nil
+}
+
+// Add appends a job and returns it with its ID and timestamp filled in
+func Add(job Job) (Job, error) {
+	jobs, err := Load()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job.QueuedAt = time.Now()
+	job.ID = nextID(jobs)
+	jobs = append(jobs, job)
+
+	if err := Save(jobs); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Remove deletes the job with the given ID
+func Remove(id string) error {
+	jobs, err := Load()
+	if err != nil {
+		return err
+	}
+
+	for i, job := range jobs {
+		if job.ID == id {
+			return Save(append(jobs[:i], jobs[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no queued job with ID %s", id)
+}
+
+// nextID returns a short numeric ID one higher than any in use
+func nextID(jobs []Job) string {
+	highest := 0
+	for _, job := range jobs {
+		if n, err := strconv.Atoi(job.ID); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return strconv.Itoa(highest + 1)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serviceMode   string
+	serviceDir    string
+	servicePreset string
+	serviceListen string
+	servicePrint  bool
+)
+
+var ServiceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Run sona watch or sona serve in the background, across reboots",
+	Long: `Install sona watch or sona serve as a background service of your user
+account, started at login and restarted if it fails:
+
+  Linux    a systemd user unit in ~/.config/systemd/user
+  macOS    a launchd agent in ~/Library/LaunchAgents
+  Windows  a Scheduled Task run at logon
+
+One service per mode can be installed; installing again replaces it. Flags
+after -- are passed on to the daemon.`,
+	Example: `  sona service install --mode watch --dir ~/Recordings
+  sona service install --mode serve --listen 127.0.0.1:9000 --preset meeting
+  sona service install --mode watch --dir ~/Dropbox/Calls -- --settle 1m
+  sona service install --mode watch --dir ~/Recordings --print
+  sona service uninstall --mode watch`,
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install [-- daemon flags]",
+	Short: "Install and start a background service",
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := newService(serviceMode, serviceDir, servicePreset, serviceListen, args)
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if servicePrint {
+			definition, err := s.definition()
+			if err != nil {
+				fmt.Println(style.Error("%v", err))
+				os.Exit(1)
+			}
+			fmt.Print(definition)
+			return
+		}
+		if err := s.install(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Stop and remove a background service",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateMode(serviceMode); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if err := (&service{mode: serviceMode}).uninstall(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	serviceInstallCmd.Flags().StringVar(&serviceMode, "mode", "", "Daemon to run: watch or serve")
+	serviceInstallCmd.Flags().StringVar(&serviceDir, "dir", "", "Directory for --mode watch to watch")
+	serviceInstallCmd.Flags().StringVar(&servicePreset, "preset", "", "Transcribe with the flags of a saved preset")
+	serviceInstallCmd.Flags().StringVar(&serviceListen, "listen", "", "Address the daemon listens on (serve) or serves health checks on (watch)")
+	serviceInstallCmd.Flags().BoolVar(&servicePrint, "print", false, "Print the service definition instead of installing it")
+	serviceInstallCmd.MarkFlagRequired("mode")
+	serviceUninstallCmd.Flags().StringVar(&serviceMode, "mode", "", "Daemon whose service to remove: watch or serve")
+	serviceUninstallCmd.MarkFlagRequired("mode")
+	ServiceCmd.AddCommand(serviceInstallCmd)
+	ServiceCmd.AddCommand(serviceUninstallCmd)
+}
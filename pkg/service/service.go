@@ -0,0 +1,344 @@
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+// maxTaskCommand is the longest command line schtasks accepts for /TR
+const maxTaskCommand = 261
+
+// service is a sona daemon run by the service manager of the platform
+type service struct {
+	mode string
+	// args is the command line, starting with the sona executable
+	args []string
+	// env is passed on as KEY=value, since service managers start with a
+	// bare environment
+	env []string
+}
+
+// validateMode rejects daemons sona cannot run as a service
+func validateMode(mode string) error {
+	if mode != "watch" && mode != "serve" {
+		return fmt.Errorf("--mode must be watch or serve")
+	}
+	return nil
+}
+
+// newService builds the command line of the daemon from the install flags
+// and the flags after --
+func newService(mode string, dir string, preset string, listen string, extra []string) (*service, error) {
+	if err := validateMode(mode); err != nil {
+		return nil, err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the sona executable: %v", err)
+	}
+	// A service must keep working when the symlink it was installed through moves
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	args := []string{executable, mode}
+	switch {
+	case mode == "watch" && dir == "":
+		return nil, fmt.Errorf("--mode watch needs --dir")
+	case mode == "watch":
+		dir, err = filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", dir)
+		}
+		args = append(args, dir)
+	case dir != "":
+		return nil, fmt.Errorf("--dir only applies to --mode watch")
+	}
+	if preset != "" {
+		args = append(args, "--preset", preset)
+	}
+	if listen != "" {
+		args = append(args, "--listen", listen)
+	}
+	args = append(args, extra...)
+
+	// PATH lets the daemon find FFmpeg and yt-dlp where this shell does
+	env := []string{"PATH=" + os.Getenv("PATH")}
+	if home := os.Getenv(datadir.EnvVar); home != "" {
+		env = append(env, datadir.EnvVar+"="+home)
+	}
+	return &service{mode: mode, args: args, env: env}, nil
+}
+
+// name is the systemd unit and Scheduled Task name of the service
+func (s *service) name() string {
+	return "sona-" + s.mode
+}
+
+// label is the launchd label of the service
+func (s *service) label() string {
+	return "com.github.harsh-2002.sona." + s.mode
+}
+
+// path returns where the service definition is installed; Scheduled Tasks
+// have no file
+func (s *service) path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", s.label()+".plist"), nil
+	case "windows":
+		return "", nil
+	}
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "systemd", "user", s.name()+".service"), nil
+}
+
+// definition returns the systemd unit, launchd plist or schtasks command
+// that installs the service
+func (s *service) definition() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return s.launchdPlist()
+	case "windows":
+		command, err := s.taskCommand()
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("schtasks /Create /F /TN %s /SC ONLOGON /RL LIMITED /TR %s\n", s.name(), windowsQuote(command)), nil
+	}
+	return s.systemdUnit(), nil
+}
+
+// systemdUnit returns a user unit that restarts the daemon when it fails
+// and gives it the time --shutdown-timeout allows to finish a job
+func (s *service) systemdUnit() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=Sona %s\nAfter=network-online.target\n\n", s.mode)
+	b.WriteString("[Service]\nType=simple\n")
+	quoted := make([]string, len(s.args))
+	for i, arg := range s.args {
+		quoted[i] = systemdQuote(arg)
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(quoted, " "))
+	for _, variable := range s.env {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote(variable))
+	}
+	b.WriteString("Restart=on-failure\nRestartSec=10\nTimeoutStopSec=60\n\n")
+	b.WriteString("[Install]\nWantedBy=default.target\n")
+	return b.String()
+}
+
+// launchdPlist returns an agent started at login and restarted when it
+// exits with an error, writing its output to ~/.sona/service-<mode>.log
+func (s *service) launchdPlist() (string, error) {
+	logPath, err := datadir.Path("service-" + s.mode + ".log")
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	fmt.Fprintf(&b, "  <key>Label</key>\n  <string>%s</string>\n", xmlEscape(s.label()))
+	b.WriteString("  <key>ProgramArguments</key>\n  <array>\n")
+	for _, arg := range s.args {
+		fmt.Fprintf(&b, "    <string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("  </array>\n  <key>EnvironmentVariables</key>\n  <dict>\n")
+	for _, variable := range s.env {
+		key, value, _ := strings.Cut(variable, "=")
+		fmt.Fprintf(&b, "    <key>%s</key>\n    <string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+	}
+	b.WriteString("  </dict>\n  <key>RunAtLoad</key>\n  <true/>\n")
+	b.WriteString("  <key>KeepAlive</key>\n  <dict>\n    <key>SuccessfulExit</key>\n    <false/>\n  </dict>\n")
+	fmt.Fprintf(&b, "  <key>StandardOutPath</key>\n  <string>%s</string>\n", xmlEscape(logPath))
+	fmt.Fprintf(&b, "  <key>StandardErrorPath</key>\n  <string>%s</string>\n", xmlEscape(logPath))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String(), nil
+}
+
+// taskCommand returns the command line a Scheduled Task runs
+func (s *service) taskCommand() (string, error) {
+	quoted := make([]string, len(s.args))
+	for i, arg := range s.args {
+		quoted[i] = windowsQuote(arg)
+	}
+	command := strings.Join(quoted, " ")
+	if len(command) > maxTaskCommand {
+		return "", fmt.Errorf("the command line is %d characters, more than the %d a Scheduled Task allows; save the options as a --preset", len(command), maxTaskCommand)
+	}
+	return command, nil
+}
+
+// install writes the service definition, then enables and starts it
+func (s *service) install() error {
+	if runtime.GOOS == "windows" {
+		command, err := s.taskCommand()
+		if err != nil {
+			return err
+		}
+		if err := run("schtasks", "/Create", "/F", "/TN", s.name(), "/SC", "ONLOGON", "/RL", "LIMITED", "/TR", command); err != nil {
+			return err
+		}
+		if err := run("schtasks", "/Run", "/TN", s.name()); err != nil {
+			return err
+		}
+		fmt.Println(style.Success("Scheduled Task %s runs now and at every logon", s.name()))
+		return nil
+	}
+
+	manager := "systemctl"
+	if runtime.GOOS == "darwin" {
+		manager = "launchctl"
+	}
+	if _, err := exec.LookPath(manager); err != nil {
+		return fmt.Errorf("%s not found; use --print to get the service definition for your init system", manager)
+	}
+
+	definition, err := s.definition()
+	if err != nil {
+		return err
+	}
+	path, err := s.path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := atomicfile.WriteFile(path, []byte(definition), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	logger.LogInfo("Wrote service definition %s", path)
+
+	if runtime.GOOS == "darwin" {
+		// An agent loaded before keeps its old definition until unloaded
+		run("launchctl", "unload", path)
+		if err := run("launchctl", "load", "-w", path); err != nil {
+			return err
+		}
+		fmt.Println(style.Success("Installed %s; it runs now and at every login", path))
+		return nil
+	}
+
+	unit := s.name() + ".service"
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", unit}, {"restart", unit}} {
+		if err := run("systemctl", append([]string{"--user"}, args...)...); err != nil {
+			return err
+		}
+	}
+	fmt.Println(style.Success("Installed %s; it runs now and at every login", path))
+	fmt.Printf("Follow its output with: journalctl --user -u %s -f\n", unit)
+	if !lingering() {
+		fmt.Println(style.Hint("Run 'loginctl enable-linger' to start it at boot, before you log in"))
+	}
+	return nil
+}
+
+// uninstall stops the service and removes its definition
+func (s *service) uninstall() error {
+	if runtime.GOOS == "windows" {
+		// Ending fails when the task is not running, which is fine
+		run("schtasks", "/End", "/TN", s.name())
+		if err := run("schtasks", "/Delete", "/F", "/TN", s.name()); err != nil {
+			return err
+		}
+		fmt.Println(style.Success("Removed Scheduled Task %s", s.name()))
+		return nil
+	}
+
+	path, err := s.path()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no %s service is installed (%s not found)", s.mode, path)
+	}
+	if runtime.GOOS == "darwin" {
+		if err := run("launchctl", "unload", "-w", path); err != nil {
+			return err
+		}
+	} else if err := run("systemctl", "--user", "disable", "--now", s.name()+".service"); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %v", path, err)
+	}
+	if runtime.GOOS != "darwin" {
+		run("systemctl", "--user", "daemon-reload")
+	}
+	fmt.Println(style.Success("Removed %s", path))
+	return nil
+}
+
+// lingering reports whether systemd starts the user's services at boot
+// rather than at login
+func lingering() bool {
+	user := os.Getenv("USER")
+	if user == "" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join("/var/lib/systemd/linger", user))
+	return err == nil
+}
+
+// run runs a service manager command, logging its output
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	output, err := cmd.CombinedOutput()
+	logger.LogCommand(cmd.Path, cmd.Args[1:], string(output), err)
+	if err != nil {
+		message := strings.TrimSpace(string(output))
+		if message == "" {
+			message = err.Error()
+		}
+		return fmt.Errorf("%s %s failed: %s", name, strings.Join(args, " "), message)
+	}
+	return nil
+}
+
+// systemdQuote quotes a word of a unit file, escaping the specifiers and
+// variables systemd would otherwise expand
+func systemdQuote(word string) string {
+	word = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$").Replace(word)
+	if word == "" || strings.ContainsAny(word, " \t'\"\\") {
+		return `"` + word + `"`
+	}
+	return word
+}
+
+// windowsQuote quotes an argument of a Windows command line
+func windowsQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+func xmlEscape(text string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
@@ -0,0 +1,125 @@
+// Package style renders status messages the same way everywhere: a symbol
+// for successes, warnings, failures, hints and notes, and a color for each
+// when stdout is a terminal that wants one. In accessible mode symbols are
+// replaced by words a screen reader reads naturally.
+//
+// It writes plain ANSI escapes rather than using lipgloss: sona only needs a
+// handful of colors and bold, and this keeps it free of a dependency tree
+// for the terminal detection it already does itself.
+package style
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI colors
+const (
+	reset  = "\x1b[0m"
+	bold   = "\x1b[1m"
+	dim    = "\x1b[2m"
+	red    = "\x1b[31m"
+	green  = "\x1b[32m"
+	yellow = "\x1b[33m"
+	blue   = "\x1b[34m"
+	cyan   = "\x1b[36m"
+)
+
+// disabled is set by --no-color
+var disabled bool
+
+// accessible is set by ui.accessible
+var accessible bool
+
+// Disable turns colors off, e.g. for --no-color
+func Disable() {
+	disabled = true
+}
+
+// SetAccessible switches to output for screen readers: words instead of
+// symbols and emoji, no colors, and no lines redrawn in place
+func SetAccessible() {
+	accessible = true
+}
+
+// Accessible reports whether output is meant for a screen reader
+func Accessible() bool {
+	return accessible
+}
+
+// Enabled reports whether output is colored: stdout is a terminal, colors
+// were not turned off with --no-color, NO_COLOR or accessible mode, and
+// TERM is not dumb
+func Enabled() bool {
+	if disabled || accessible || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Icon returns a decorative symbol to put before a message, or nothing in
+// accessible mode, e.g. style.Icon("🔍 ") + "Checking dependencies..."
+func Icon(icon string) string {
+	if accessible {
+		return ""
+	}
+	return icon
+}
+
+// prefix returns the symbol of a kind of message, or its word in
+// accessible mode
+func prefix(icon string, word string) string {
+	if accessible {
+		return word
+	}
+	return icon
+}
+
+func paint(color string, text string) string {
+	if !Enabled() {
+		return text
+	}
+	return color + text + reset
+}
+
+// Success renders something that worked, e.g. "✅ Backup written"
+func Success(format string, args ...interface{}) string {
+	return paint(green, prefix("✅ ", "")+fmt.Sprintf(format, args...))
+}
+
+// Warning renders a problem sona works around
+func Warning(format string, args ...interface{}) string {
+	return paint(yellow, prefix("⚠️  ", "Warning: ")+fmt.Sprintf(format, args...))
+}
+
+// Failure renders something that failed while the command goes on, such
+// as one job of a batch
+func Failure(format string, args ...interface{}) string {
+	return paint(red, prefix("❌ ", "Failed: ")+fmt.Sprintf(format, args...))
+}
+
+// Error renders the error a command stops with
+func Error(format string, args ...interface{}) string {
+	return paint(bold+red, "Error: "+fmt.Sprintf(format, args...))
+}
+
+// Hint renders advice on what to do next
+func Hint(format string, args ...interface{}) string {
+	return paint(cyan, prefix("💡 ", "Tip: ")+fmt.Sprintf(format, args...))
+}
+
+// Info renders a note worth the user's attention
+func Info(format string, args ...interface{}) string {
+	return paint(blue, prefix("ℹ️  ", "Note: ")+fmt.Sprintf(format, args...))
+}
+
+// Bold renders text in bold, e.g. headings
+func Bold(text string) string {
+	return paint(bold, text)
+}
+
+// Dim renders text of secondary interest
+func Dim(text string) string {
+	return paint(dim, text)
+}
@@ -0,0 +1,84 @@
+package tokens
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var tokenScope string
+
+var TokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for sona serve",
+	Long: `Manage the API tokens 'sona serve' accepts. Clients send a token as
+"Authorization: Bearer <token>". Each token has a scope:
+
+  submit   submit jobs and follow the jobs submitted with it
+  read     list and read every job and transcript, but not submit
+  admin    all of the above, and remove jobs`,
+	Example: `  sona token create ci --scope submit
+  sona token create dashboard --scope read
+  sona token list
+  sona token revoke ci`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a token and print it",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		secret, err := Create(args[0], tokenScope)
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Token %s (%s) created. It is not shown again:\n\n%s\n", args[0], tokenScope, secret)
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tokens and their scopes",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens, err := Load()
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if len(tokens) == 0 {
+			fmt.Println("No tokens; create one with 'sona token create <name> --scope <scope>'")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSCOPE\tCREATED")
+		for _, token := range tokens {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", token.Name, token.Scope, token.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a token",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := Revoke(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Token %s revoked\n", args[0])
+	},
+}
+
+func init() {
+	tokenCreateCmd.Flags().StringVar(&tokenScope, "scope", ScopeSubmit, "What the token may do: submit, read or admin")
+	TokenCmd.AddCommand(tokenCreateCmd)
+	TokenCmd.AddCommand(tokenListCmd)
+	TokenCmd.AddCommand(tokenRevokeCmd)
+}
@@ -0,0 +1,183 @@
+package tokens
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// Scopes of an API token
+const (
+	// ScopeSubmit may submit jobs and follow the jobs it submitted
+	ScopeSubmit = "submit"
+	// ScopeRead may list and read every job and transcript, but not submit
+	ScopeRead = "read"
+	// ScopeAdmin may do everything, including removing jobs
+	ScopeAdmin = "admin"
+)
+
+// Permissions a scope grants for the sona serve API
+const (
+	// PermSubmit submits jobs
+	PermSubmit = "submit jobs"
+	// PermView lists and reads jobs and transcripts submitted with the token
+	PermView = "read jobs"
+	// PermViewAll extends PermView to every job
+	PermViewAll = "read every job"
+	// PermRemove removes jobs
+	PermRemove = "remove jobs"
+)
+
+// scopePermissions lists what each scope allows. Read and submit are
+// separate, so a dashboard token cannot spend on transcription and a
+// client's token cannot read other clients' transcripts.
+var scopePermissions = map[string][]string{
+	ScopeSubmit: {PermSubmit, PermView},
+	ScopeRead:   {PermView, PermViewAll},
+	ScopeAdmin:  {PermSubmit, PermView, PermViewAll, PermRemove},
+}
+
+// tokenPrefix makes sona tokens recognizable, e.g. to secret scanners
+const tokenPrefix = "sona_"
+
+// namePattern keeps token names short and printable
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.@-]*$`)
+
+// Token is an API token for sona serve. Only a hash of the secret is kept,
+// so the token itself is shown once, when it is created.
+type Token struct {
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Allows reports whether the token's scope grants permission
+func (t Token) Allows(permission string) bool {
+	return slices.Contains(scopePermissions[t.Scope], permission)
+}
+
+// ValidateScope checks a scope given on the command line
+func ValidateScope(scope string) error {
+	if _, ok := scopePermissions[scope]; !ok {
+		return fmt.Errorf("invalid scope %q (use %s, %s or %s)", scope, ScopeSubmit, ScopeRead, ScopeAdmin)
+	}
+	return nil
+}
+
+// Path returns the location of the token file (~/.sona/tokens.json)
+func Path() (string, error) {
+	return datadir.Path("tokens.json")
+}
+
+// Load returns the API tokens in the order they were created
+func Load() ([]Token, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokens: %v", err)
+	}
+
+	var tokens []Token
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("token file %s is corrupted: %v", path, err)
+	}
+	return tokens, nil
+}
+
+func save(tokens []Token) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tokens: %v", err)
+	}
+	// Hashes only, but still nobody else's business
+	if err := atomicfile.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write tokens: %v", err)
+	}
+	return nil
+}
+
+// Create issues a token with a scope and returns its secret, which is not
+// stored and cannot be shown again
+func Create(name string, scope string) (string, error) {
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid token name %q (use letters, digits, _ . @ and -)", name)
+	}
+	if err := ValidateScope(scope); err != nil {
+		return "", err
+	}
+	tokens, err := Load()
+	if err != nil {
+		return "", err
+	}
+	for _, token := range tokens {
+		if token.Name == name {
+			return "", fmt.Errorf("a token named %s already exists; revoke it first", name)
+		}
+	}
+
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	secret := tokenPrefix + hex.EncodeToString(random)
+	tokens = append(tokens, Token{Name: name, Scope: scope, Hash: hash(secret), CreatedAt: time.Now()})
+	if err := save(tokens); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Revoke removes the token with the given name
+func Revoke(name string) error {
+	tokens, err := Load()
+	if err != nil {
+		return err
+	}
+	for i, token := range tokens {
+		if token.Name == name {
+			return save(append(tokens[:i], tokens[i+1:]...))
+		}
+	}
+	return fmt.Errorf("no token named %s", name)
+}
+
+// Find returns the token a secret belongs to
+func Find(tokens []Token, secret string) (Token, bool) {
+	secretHash := hash(secret)
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(secretHash)) == 1 {
+			return token, true
+		}
+	}
+	return Token{}, false
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,34 @@
+package tokens
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	permissions := []string{PermSubmit, PermView, PermViewAll, PermRemove}
+	tests := []struct {
+		scope string
+		want  []bool
+	}{
+		{ScopeSubmit, []bool{true, true, false, false}},
+		{ScopeRead, []bool{false, true, true, false}},
+		{ScopeAdmin, []bool{true, true, true, true}},
+		{"owner", []bool{false, false, false, false}},
+	}
+	for _, tt := range tests {
+		for i, permission := range permissions {
+			if got := (Token{Scope: tt.scope}).Allows(permission); got != tt.want[i] {
+				t.Errorf("%s token Allows(%q) = %v, want %v", tt.scope, permission, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestValidateScope(t *testing.T) {
+	for _, scope := range []string{ScopeSubmit, ScopeRead, ScopeAdmin} {
+		if err := ValidateScope(scope); err != nil {
+			t.Errorf("ValidateScope(%q) = %v", scope, err)
+		}
+	}
+	if err := ValidateScope("owner"); err == nil {
+		t.Error(`ValidateScope("owner") succeeded, want an error`)
+	}
+}
@@ -0,0 +1,396 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/spf13/cobra"
+)
+
+// snapWindow is how far a cue boundary may move to land on a pause
+const snapWindow = 2 * time.Second
+
+// matchWindow is how many recognized words ahead a script word is looked
+// for, so words the recognizer missed or added do not throw off the rest
+const matchWindow = 20
+
+var (
+	alignFormat   string
+	alignOutput   string
+	alignMaxChars int
+	alignNoiseDB  float64
+	alignMinPause time.Duration
+	alignEstimate bool
+	alignModel    string
+	alignLanguage string
+)
+
+var AlignCmd = &cobra.Command{
+	Use:   "align [audio] [text]",
+	Short: "Time an existing script to its audio",
+	Long: `Produce subtitles or timestamps for a script you already have.
+
+The audio is transcribed once to hear when each word is spoken, and the
+script's words are matched to the words heard, so the subtitles keep the
+script's text with the timings of the audio. Words the recognizer got wrong
+take their timing from the matched words around them.
+
+With --estimate nothing is transcribed: the script is spread over the speech
+in proportion to its length and each cue boundary moved onto the nearest
+pause. These timings are approximate, and vtt output says so in a NOTE.`,
+	Example: `  sona align narration.mp3 script.txt
+  sona align narration.mp3 script.txt --format vtt --output narration.vtt
+  sona align lecture.wav notes.txt --estimate --format txt --min-pause 500ms`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runAlign(args[0], args[1]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	AlignCmd.Flags().StringVarP(&alignFormat, "format", "f", "srt", "Output format: srt, vtt or txt")
+	AlignCmd.Flags().StringVarP(&alignOutput, "output", "o", "", "Output file (default: the text file with the format's extension)")
+	AlignCmd.Flags().IntVar(&alignMaxChars, "max-chars", 84, "Maximum characters per cue")
+	AlignCmd.Flags().StringVar(&alignModel, "model", "", "Speech model to hear the words with (default: defaults.model)")
+	AlignCmd.Flags().StringVar(&alignLanguage, "language", "", "Language of the audio (default: detected)")
+	AlignCmd.Flags().BoolVar(&alignEstimate, "estimate", false, "Estimate the timings from pauses instead of transcribing the audio")
+	AlignCmd.Flags().Float64Var(&alignNoiseDB, "noise", -35, "With --estimate, the level in dB below which audio counts as a pause")
+	AlignCmd.Flags().DurationVar(&alignMinPause, "min-pause", 300*time.Millisecond, "With --estimate, the shortest silence that counts as a pause")
+}
+
+func runAlign(audioPath string, textPath string) error {
+	write, ok := subtitleFormats[alignFormat]
+	if !ok {
+		return fmt.Errorf("unsupported format %q (use srt, vtt or txt)", alignFormat)
+	}
+	if alignMaxChars < 10 {
+		return fmt.Errorf("--max-chars must be at least 10")
+	}
+
+	data, err := os.ReadFile(textPath)
+	if err != nil {
+		return fmt.Errorf("failed to read text: %v", err)
+	}
+	chunks := splitCues(string(data), alignMaxChars)
+	if len(chunks) == 0 {
+		return fmt.Errorf("%s contains no text", textPath)
+	}
+
+	var cues []cue
+	matched, total := 0, 0
+	if alignEstimate {
+		duration, err := probeAudioDuration(audioPath)
+		if err != nil {
+			return fmt.Errorf("failed to read audio duration: %v", err)
+		}
+		pauses, err := detectPauses(audioPath, alignNoiseDB, alignMinPause)
+		if err != nil {
+			return err
+		}
+		logger.LogInfo("Estimating %d cues over %s of audio with %d pauses", len(chunks), duration, len(pauses))
+		cues = alignCues(chunks, duration, pauses)
+	} else {
+		words, err := recognizeWords(audioPath)
+		if err != nil {
+			return err
+		}
+		cues, matched, total = matchCues(chunks, words)
+		logger.LogInfo("Matched %d of %d script words to %d recognized words", matched, total, len(words))
+	}
+
+	output := alignOutput
+	if output == "" {
+		output = strings.TrimSuffix(textPath, filepath.Ext(textPath)) + "." + alignFormat
+	}
+	if output == textPath {
+		return fmt.Errorf("refusing to overwrite the input text; pass --output")
+	}
+	content := write(cues)
+	if alignEstimate && alignFormat == "vtt" {
+		content = strings.Replace(content, "WEBVTT\n\n", "WEBVTT\n\nNOTE Timings estimated by sona align from pauses in the audio, not matched word by word\n\n", 1)
+	}
+	if err := writeOutput(output, []byte(content)); err != nil {
+		return fmt.Errorf("failed to write %s: %v", output, err)
+	}
+
+	if alignEstimate {
+		fmt.Printf("Estimated timings for %d cues (approximate: spread over the speech and snapped to pauses)\n", len(cues))
+	} else {
+		fmt.Printf("Aligned %d cues, %d of %d script words matched to the audio\n", len(cues), matched, total)
+		if matched < total/2 {
+			fmt.Println(style.Warning("Most of the script was not heard in the audio; check that they belong together"))
+		}
+	}
+	fmt.Printf("Saved to: %s\n", output)
+	return nil
+}
+
+// recognizeWords transcribes the audio for the timings of its words
+func recognizeWords(audioPath string) ([]transcript.Word, error) {
+	model := alignModel
+	if model == "" {
+		model = config.GetDefaultModel()
+	}
+	result, err := batchTranscription(audioPath, model, alignLanguage, outputProfile{}, newTimings(audioPath))
+	if err != nil {
+		return nil, err
+	}
+	recordUsage(result, audioPath)
+	if len(result.Words) == 0 {
+		return nil, fmt.Errorf("no speech was recognized in %s", audioPath)
+	}
+	return result.Words, nil
+}
+
+// matchCues times the chunks by the recognized words their script words
+// match. Script words without a match share the time between the matched
+// words around them. It returns the cues and how many script words matched.
+func matchCues(chunks []string, words []transcript.Word) ([]cue, int, int) {
+	var script []string
+	// first is the index of each chunk's first word in script
+	first := make([]int, len(chunks)+1)
+	for i, chunk := range chunks {
+		first[i] = len(script)
+		for _, word := range strings.Fields(chunk) {
+			script = append(script, wordKey(word))
+		}
+	}
+	first[len(chunks)] = len(script)
+
+	heard := make([]string, len(words))
+	for i, word := range words {
+		heard[i] = wordKey(word.Text)
+	}
+	match := matchWords(script, heard)
+
+	starts := make([]time.Duration, len(script))
+	ends := make([]time.Duration, len(script))
+	matched := 0
+	prev := -1
+	for i := 0; i <= len(script); i++ {
+		if i < len(script) && match[i] < 0 {
+			continue
+		}
+		// Spread the unmatched words since prev evenly over the gap
+		from := time.Duration(words[0].Start) * time.Millisecond
+		if prev >= 0 {
+			from = ends[prev]
+		}
+		to := time.Duration(words[len(words)-1].End) * time.Millisecond
+		if i < len(script) {
+			to = time.Duration(words[match[i]].Start) * time.Millisecond
+			starts[i] = to
+			ends[i] = time.Duration(words[match[i]].End) * time.Millisecond
+			matched++
+		}
+		to = max(to, from)
+		if gap := i - prev - 1; gap > 0 {
+			for k := 1; k <= gap; k++ {
+				starts[prev+k] = from + (to-from)*time.Duration(k-1)/time.Duration(gap)
+				ends[prev+k] = from + (to-from)*time.Duration(k)/time.Duration(gap)
+			}
+		}
+		prev = i
+	}
+
+	cues := make([]cue, len(chunks))
+	for i, chunk := range chunks {
+		cues[i].Text = chunk
+		if first[i] == first[i+1] {
+			if i > 0 {
+				cues[i].Start, cues[i].End = cues[i-1].End, cues[i-1].End
+			}
+			continue
+		}
+		cues[i].Start = starts[first[i]]
+		cues[i].End = ends[first[i+1]-1]
+	}
+	return cues, matched, len(script)
+}
+
+// matchWords finds each script word among the recognized words, in order,
+// and returns the index of its match or -1. Skipping ahead needs the next
+// script word to agree, so a common word further on does not pull the
+// match away from where the reading is.
+func matchWords(script []string, heard []string) []int {
+	match := make([]int, len(script))
+	next := 0
+	for i, key := range script {
+		match[i] = -1
+		if key == "" {
+			continue
+		}
+		for k := next; k < len(heard) && k < next+matchWindow; k++ {
+			if heard[k] != key || (k > next && !followedBy(script[i+1:], heard[k+1:])) {
+				continue
+			}
+			match[i] = k
+			next = k + 1
+			break
+		}
+	}
+	return match
+}
+
+// followedBy reports whether the next script word is among the first few
+// heard words, or no script word follows
+func followedBy(script []string, heard []string) bool {
+	for _, key := range script {
+		if key == "" {
+			continue
+		}
+		for _, word := range heard[:min(3, len(heard))] {
+			if word == key {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// wordKey reduces a word to its lowercase letters and digits for matching
+func wordKey(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+// splitCues breaks a script into sentences, wrapping long sentences so no
+// cue exceeds maxChars
+func splitCues(text string, maxChars int) []string {
+	var chunks []string
+	for _, line := range strings.Split(text, "\n") {
+		for _, sentence := range splitSentences(line) {
+			chunks = append(chunks, wrapCaption(sentence, maxChars)...)
+		}
+	}
+	return chunks
+}
+
+// alignCues spreads the chunks over the speech (the audio minus its pauses)
+// in proportion to their length, then snaps each boundary to a nearby pause
+func alignCues(chunks []string, duration time.Duration, pauses []pause) []cue {
+	speech := speechSpans(duration, pauses)
+	var totalSpeech time.Duration
+	for _, span := range speech {
+		totalSpeech += span.End - span.Start
+	}
+
+	weights := make([]int, len(chunks))
+	totalWeight := 0
+	for i, chunk := range chunks {
+		weights[i] = spokenLength(chunk)
+		totalWeight += weights[i]
+	}
+
+	cues := make([]cue, len(chunks))
+	cues[0].Start = speechToAudioTime(speech, 0)
+
+	cumulative := 0
+	nextPause := 0
+	for i, chunk := range chunks {
+		cues[i].Text = chunk
+		cumulative += weights[i]
+		if i == len(chunks)-1 {
+			cues[i].End = speechToAudioTime(speech, totalSpeech)
+			break
+		}
+
+		boundary := speechToAudioTime(speech, time.Duration(float64(totalSpeech)*float64(cumulative)/float64(totalWeight)))
+		end, start := boundary, boundary
+
+		// Land on the closest pause that keeps the cues in order
+		best := -1
+		for j := nextPause; j < len(pauses); j++ {
+			middle := (pauses[j].Start + pauses[j].End) / 2
+			if middle < cues[i].Start {
+				continue
+			}
+			if middle > boundary+snapWindow {
+				break
+			}
+			if absDuration(middle-boundary) <= snapWindow && (best < 0 || absDuration(middle-boundary) < absDuration((pauses[best].Start+pauses[best].End)/2-boundary)) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			end, start = pauses[best].Start, pauses[best].End
+			nextPause = best + 1
+		}
+
+		cues[i].End = end
+		cues[i+1].Start = start
+	}
+
+	return cues
+}
+
+// speechSpans returns the parts of the audio between pauses
+func speechSpans(duration time.Duration, pauses []pause) []pause {
+	var spans []pause
+	position := time.Duration(0)
+	for _, p := range pauses {
+		if p.Start > position {
+			spans = append(spans, pause{Start: position, End: p.Start})
+		}
+		if p.End > position {
+			position = p.End
+		}
+	}
+	if position < duration {
+		spans = append(spans, pause{Start: position, End: duration})
+	}
+	if len(spans) == 0 {
+		spans = append(spans, pause{Start: 0, End: duration})
+	}
+	return spans
+}
+
+// speechToAudioTime maps an offset into the speech onto the audio timeline
+func speechToAudioTime(spans []pause, offset time.Duration) time.Duration {
+	for _, span := range spans {
+		length := span.End - span.Start
+		if offset <= length {
+			return span.Start + offset
+		}
+		offset -= length
+	}
+	return spans[len(spans)-1].End
+}
+
+// spokenLength approximates how long text takes to say by counting letters and digits
+func spokenLength(text string) int {
+	n := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	if n == 0 {
+		return utf8.RuneCountInString(text)
+	}
+	return n
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
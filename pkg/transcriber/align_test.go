@@ -0,0 +1,56 @@
+package transcriber
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+func TestMatchWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		heard  string
+		want   []int
+	}{
+		{"identical", "the cat sat", "the cat sat", []int{0, 1, 2}},
+		{"misheard word", "the cat sat down", "the hat sat down", []int{0, -1, 2, 3}},
+		{"extra words heard", "the cat sat", "the um cat uh sat", []int{0, 2, 4}},
+		{"word not read", "the big cat sat", "the cat sat", []int{0, -1, 1, 2}},
+		// "the" further on is not taken for the unheard first word
+		{"common word ahead", "the dog barked", "a dog barked and the cat", []int{-1, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchWords(strings.Fields(tt.script), strings.Fields(tt.heard))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matchWords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchCues(t *testing.T) {
+	words := []transcript.Word{
+		{Text: "Hello", Start: 1000, End: 1400},
+		{Text: "world.", Start: 1500, End: 2000},
+		{Text: "Sona", Start: 3000, End: 3400},
+		{Text: "times", Start: 3500, End: 3800},
+		{Text: "scripts.", Start: 3900, End: 4400},
+	}
+	cues, matched, total := matchCues([]string{"Hello, world!", "Sauna times scripts."}, words)
+
+	if matched != 4 || total != 5 {
+		t.Errorf("matched %d of %d words, want 4 of 5", matched, total)
+	}
+	want := []cue{
+		{Start: 1000 * time.Millisecond, End: 2000 * time.Millisecond, Text: "Hello, world!"},
+		{Start: 2000 * time.Millisecond, End: 4400 * time.Millisecond, Text: "Sauna times scripts."},
+	}
+	if !slices.Equal(cues, want) {
+		t.Errorf("matchCues() = %v, want %v", cues, want)
+	}
+}
@@ -0,0 +1,75 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// assCueChars keeps karaoke captions short, as on vertical short-form video
+const assCueChars = 32
+
+// assHeader sets up a vertical 1080x1920 canvas with large, outlined
+// captions in the lower third. Words turn from white (SecondaryColour) to
+// yellow (PrimaryColour) as they are spoken.
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+PlayResX: 1080
+PlayResY: 1920
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,72,&H0000FFFF,&H00FFFFFF,&H00000000,&H80000000,-1,0,0,0,100,100,0,0,1,4,0,2,60,60,480,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+// formatASS renders word-timed karaoke captions. Each word gets a \k tag
+// lasting until the next word starts, so players highlight it as it is spoken.
+func formatASS(transcript string, source string, result *transcript.Transcript) string {
+	var b strings.Builder
+	b.WriteString(assHeader)
+
+	if result == nil || len(result.Words) == 0 {
+		b.WriteString("; No word timings were available for karaoke captions\n")
+		return b.String()
+	}
+
+	for _, c := range cuesFromWords(result.Words, assCueChars) {
+		words := wordsBetween(result.Words, c.Start, c.End)
+		var line strings.Builder
+		for i, word := range words {
+			end := time.Duration(word.End) * time.Millisecond
+			if i < len(words)-1 {
+				end = time.Duration(words[i+1].Start) * time.Millisecond
+			}
+			length := end - time.Duration(word.Start)*time.Millisecond
+			if i > 0 {
+				line.WriteString(" ")
+			}
+			fmt.Fprintf(&line, "{\\k%d}%s", length/(10*time.Millisecond), assEscape(word.Text))
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,Default,,0,0,0,,%s\n", assTime(c.Start), assTime(c.End), line.String())
+	}
+
+	return b.String()
+}
+
+// assEscape keeps text from being read as override tags or line breaks
+func assEscape(text string) string {
+	return strings.NewReplacer("{", "(", "}", ")", `\`, "/").Replace(text)
+}
+
+// assTime renders an offset as H:MM:SS.cc
+func assTime(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := int64(d / (10 * time.Millisecond))
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, (cs/6000)%60, (cs/100)%60, cs%100)
+}
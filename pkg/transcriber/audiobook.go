@@ -0,0 +1,238 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// audiobookExtension marks audiobooks, whose chapters are transcribed one by one
+const audiobookExtension = ".m4b"
+
+// bookChapter is a chapter of an audiobook as listed in its metadata
+type bookChapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// isAudiobook reports whether the file is an .m4b audiobook
+func isAudiobook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), audiobookExtension)
+}
+
+// probeChapters lists the chapters of a media file with ffprobe
+func probeChapters(path string) ([]bookChapter, error) {
+	ffprobePath, err := FindBinary("ffprobe")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(ffprobePath, "-v", "error", "-show_chapters", "-of", "json", path)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chapters: %v", err)
+	}
+	return parseChapters(output)
+}
+
+// parseChapters reads ffprobe's JSON chapter list. Chapters without a title
+// are named by their number.
+func parseChapters(output []byte) ([]bookChapter, error) {
+	var probe struct {
+		Chapters []struct {
+			StartTime string            `json:"start_time"`
+			EndTime   string            `json:"end_time"`
+			Tags      map[string]string `json:"tags"`
+		} `json:"chapters"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %v", err)
+	}
+
+	var chapters []bookChapter
+	for i, entry := range probe.Chapters {
+		start, err := strconv.ParseFloat(entry.StartTime, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start of chapter %d: %q", i+1, entry.StartTime)
+		}
+		end, err := strconv.ParseFloat(entry.EndTime, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end of chapter %d: %q", i+1, entry.EndTime)
+		}
+		if end <= start {
+			continue
+		}
+
+		title := strings.TrimSpace(entry.Tags["title"])
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		chapters = append(chapters, bookChapter{
+			Title: title,
+			Start: time.Duration(start * float64(time.Second)),
+			End:   time.Duration(end * float64(time.Second)),
+		})
+	}
+	return chapters, nil
+}
+
+// audiobookChapters returns the chapters to transcribe one by one, or nil
+// when the file is transcribed as a whole: it is not an audiobook, has a
+// single chapter, or only part of it was requested
+func audiobookChapters(path string) []bookChapter {
+	if !isAudiobook(path) {
+		return nil
+	}
+	if audioStart > 0 || audioEnd > 0 {
+		logger.LogInfo("Transcribing %s as one file: --start and --end apply to the whole book", path)
+		return nil
+	}
+
+	chapters, err := probeChapters(path)
+	if err != nil {
+		fmt.Println(style.Warning("Could not read the chapters, transcribing the book as one file: %v", err))
+		logger.LogWarning("Could not read chapters of %s: %v", path, err)
+		return nil
+	}
+	if len(chapters) < 2 {
+		return nil
+	}
+	return chapters
+}
+
+// chapterPath names the transcript of a chapter after its number, e.g.
+// book.chapter-03.txt, so the files sort in reading order
+func chapterPath(basePath string, n int, total int) string {
+	width := len(strconv.Itoa(total))
+	return variantPath(basePath, fmt.Sprintf("chapter-%0*d", width, n))
+}
+
+// transcribeAudiobook transcribes each chapter of the converted audio on its
+// own and saves it next to basePath as soon as it is done, then saves the
+// whole book at basePath with a table of contents
+func transcribeAudiobook(filePath string, convertedPath string, basePath string, opts Options, chapters []bookChapter, identity sourceIdentity, timings *progress.Timings) error {
+	fmt.Printf("Audiobook with %d chapters\n", len(chapters))
+	explicit := opts.OutputPath != ""
+
+	var files []string
+	texts := make([]string, len(chapters))
+	results := make([]*transcript.Transcript, len(chapters))
+	for i, chapter := range chapters {
+		fmt.Printf("\n[%d/%d] %s (%s)\n", i+1, len(chapters), chapter.Title, formatTimestamp(chapter.End-chapter.Start))
+
+		timings.Begin("convert")
+		audioPath, err := cutAudio(convertedPath, fmt.Sprintf("chapter-%03d.mp3", i), chapter.Start, chapter.End)
+		timings.End()
+		if err != nil {
+			return fmt.Errorf("failed to cut chapter %d: %v", i+1, err)
+		}
+
+		chapterBase := chapterPath(basePath, i+1, len(chapters))
+		livePath := liveTranscriptPath(chapterBase)
+		text, result, err := transcribeAudio(audioPath, opts.SpeechModel, opts.LanguageCode, livePath, timings)
+		if err != nil {
+			if text, err = placeholderForEmpty(err); err != nil {
+				return fmt.Errorf("chapter %d (%s): %w", i+1, chapter.Title, err)
+			}
+		}
+
+		written, err := saveTranscript(text, filePath, chapterBase, explicit, result)
+		files = append(files, written...)
+		if err != nil {
+			return fmt.Errorf("failed to save chapter %d: %v", i+1, err)
+		}
+		discardLiveTranscript(livePath)
+
+		texts[i] = text
+		if result == nil {
+			result = &transcript.Transcript{}
+		}
+		results[i] = result
+	}
+
+	// The book's result is on the timeline of the whole audio, so timed
+	// formats of the book stay in sync with it
+	result := mergeChapterResults(chapters, results)
+	transcript := bookTranscript(chapters, texts)
+
+	fmt.Println()
+	quality := assessQuality(result, convertedPath)
+	printQuality(quality, opts.SpeechModel)
+
+	written, err := saveTranscript(transcript, filePath, basePath, explicit, result)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	files = append(written, files...)
+	recordTranscript(basePath, filePath, "local", convertedPath, opts, opts.SpeechModel, transcript, result, files, identity, quality)
+
+	printTimingSummary(timings)
+	return nil
+}
+
+// bookTranscript joins the chapter transcripts under numbered headings,
+// after a table of contents with the start of each chapter
+func bookTranscript(chapters []bookChapter, texts []string) string {
+	var b strings.Builder
+	b.WriteString("Contents\n\n")
+	for i, chapter := range chapters {
+		fmt.Fprintf(&b, "%d. %s [%s]\n", i+1, chapter.Title, formatTimestamp(chapter.Start))
+	}
+	for i, chapter := range chapters {
+		fmt.Fprintf(&b, "\n%d. %s\n\n", i+1, chapter.Title)
+		b.WriteString(strings.TrimSpace(texts[i]))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// mergeChapterResults joins the chapter results into one on the timeline of
+// the whole book
+func mergeChapterResults(chapters []bookChapter, results []*transcript.Transcript) *transcript.Transcript {
+	merged := &transcript.Transcript{}
+	var texts []string
+	for i, result := range results {
+		offset := chapters[i].Start.Milliseconds()
+		if text := strings.TrimSpace(result.Text); text != "" {
+			texts = append(texts, text)
+		}
+		for _, word := range result.Words {
+			word.Start += offset
+			word.End += offset
+			merged.Words = append(merged.Words, word)
+		}
+		for _, utterance := range result.Segments {
+			utterance.Start += offset
+			utterance.End += offset
+			merged.Segments = append(merged.Segments, utterance)
+		}
+		for _, chapter := range result.Chapters {
+			chapter.Start += offset
+			chapter.End += offset
+			merged.Chapters = append(merged.Chapters, chapter)
+		}
+		for _, entity := range result.Entities {
+			entity.Start += offset
+			entity.End += offset
+			merged.Entities = append(merged.Entities, entity)
+		}
+		merged.LanguageCode = result.LanguageCode
+		merged.LocalSeconds += result.LocalSeconds
+		if result.APIKey != "" {
+			merged.APIKey = result.APIKey
+		}
+	}
+	merged.Text = strings.Join(texts, "\n\n")
+	merged.Duration = chapters[len(chapters)-1].End.Seconds()
+	return merged
+}
@@ -0,0 +1,291 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/batch"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+	"github.com/spf13/cobra"
+)
+
+var retryList bool
+
+var RetryCmd = &cobra.Command{
+	Use:   "retry [batch-id]",
+	Short: "Re-run the failed sources of a batch",
+	Long: `Re-run only the sources that failed in a multi-source transcription, with
+the options the batch was started with.
+
+Every run of 'sona transcribe' with several sources is recorded in
+~/.sona/batches. Without an ID, the newest batch with failures is retried.`,
+	Example: `  sona retry
+  sona retry 20250101-093000
+  sona retry --list`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if retryList {
+			if err := listBatches(); err != nil {
+				fmt.Println(style.Error("%v", err))
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := validateParallel(parallelJobs); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		id := ""
+		if len(args) == 1 {
+			id = args[0]
+		}
+		failed, err := retryBatch(id)
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RetryCmd.Flags().BoolVar(&retryList, "list", false, "List recent batches and their failures")
+	RetryCmd.Flags().BoolVar(&noDashboard, "no-dashboard", false, "Print the output of every source instead of the live batch dashboard")
+	RetryCmd.Flags().IntVar(&prefetchDepth, "prefetch", 1, "Download or convert this many sources ahead while one transcribes (0 = one at a time)")
+	RetryCmd.Flags().IntVar(&parallelJobs, "parallel", 1, fmt.Sprintf("Retry this many sources at once (up to %d)", maxParallelJobs))
+}
+
+// processSource transcribes one source with the given options and the
+// language of the source
+func processSource(spec sourceSpec, opts Options) error {
+	opts.LanguageCode = spec.LanguageCode
+	if err := checkMonthlyBudget(spec.Source, opts.withDefaults().SpeechModel); err != nil {
+		return err
+	}
+	var err error
+	if youtube.IsYouTubeURL(spec.Source) {
+		fmt.Println("Processing YouTube URL...")
+		err = processYouTubeVideo(spec.Source, opts)
+	} else if isURL(spec.Source) {
+		fmt.Println("Processing audio URL...")
+		err = processRemoteAudio(spec.Source, opts)
+	} else {
+		fmt.Println("Processing local audio file...")
+		err = processLocalAudio(spec.Source, opts)
+	}
+	noteLimitFailure(spec.Source, err)
+	webhookFailed(spec.Source, err)
+	return err
+}
+
+// runBatch transcribes several sources, highest priority first, carrying on
+// past failures, and records the outcome of each for 'sona retry'. Sources
+// over the daily budget move to the queue. It returns the failure count.
+func runBatch(sources []sourceSpec) int {
+	var jobs []queue.Job
+	for _, spec := range sources {
+		jobs = append(jobs, jobFor(spec))
+	}
+	queue.SortByPriority(jobs)
+	b := batch.New(jobs)
+	saveBatch(b)
+
+	startDashboard(jobs)
+	runEntries(len(jobs), func(i int) {
+		job := jobs[i]
+		fmt.Printf("\n[%d/%d] Source: %s\n", i+1, len(jobs), job.Source)
+		if job.LanguageCode != "" {
+			fmt.Printf("Language: %s\n", job.LanguageCode)
+		}
+		// Jobs in parallel prepare their own audio
+		if !runningParallel {
+			onAudioReady = func() { prefetchAfter(jobs, i, i) }
+		}
+		runEntry(b, i, i, func() error {
+			return processSource(sourceSpec{Source: job.Source, LanguageCode: job.LanguageCode}, transcribeOptions)
+		})
+	})
+	onAudioReady = nil
+	discardPrefetched()
+	stopDashboard()
+
+	return summarizeBatch(b)
+}
+
+// retryBatch re-runs the failed entries of the batch (the newest one with
+// failures when id is empty) and returns how many still fail
+func retryBatch(id string) (int, error) {
+	var b *batch.Batch
+	var err error
+	if id == "" {
+		b, err = batch.LatestRetryable()
+	} else {
+		b, err = batch.Load(id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	retry := b.Retryable()
+	if len(retry) == 0 {
+		fmt.Printf("Batch %s has no failed sources\n", b.ID)
+		return 0, nil
+	}
+	fmt.Printf("Retrying %d of %d sources from batch %s\n", len(retry), len(b.Entries), b.ID)
+
+	jobs := make([]queue.Job, len(retry))
+	for n, i := range retry {
+		jobs[n] = b.Entries[i].Job
+	}
+	if err := checkAndInstallDependencies(jobSources(jobs)); err != nil {
+		return 0, fmt.Errorf("dependency check failed: %v", err)
+	}
+	startDashboard(jobs)
+	// The options are shared by the running jobs, so only runs of entries
+	// started with the same options go in parallel
+	for start := 0; start < len(jobs); {
+		end := start + 1
+		for end < len(jobs) && sameOptions(jobs[start], jobs[end]) {
+			end++
+		}
+		restoreJobOptions(jobs[start])
+		runEntries(end-start, func(k int) {
+			n := start + k
+			i, job := retry[n], jobs[n]
+			fmt.Printf("\n[%d/%d] Source: %s\n", n+1, len(retry), job.Source)
+			if !runningParallel {
+				onAudioReady = func() { prefetchAfter(jobs, n, n) }
+			}
+			runEntry(b, i, n, func() error { return processJob(job) })
+		})
+		start = end
+	}
+	onAudioReady = nil
+	discardPrefetched()
+	stopDashboard()
+
+	return summarizeBatch(b), nil
+}
+
+// sameOptions reports whether two jobs were started with the same options,
+// apart from those processJob passes with each job
+func sameOptions(a queue.Job, b queue.Job) bool {
+	return reflect.DeepEqual(jobOptions(a), jobOptions(b))
+}
+
+// jobOptions clears the fields of job that are not set by restoreJobOptions
+func jobOptions(job queue.Job) queue.Job {
+	job.ID = ""
+	job.Source = ""
+	job.LanguageCode = ""
+	job.SpeechModel = ""
+	job.OutputPath = ""
+	job.QueuedAt = time.Time{}
+	job.NotBefore = time.Time{}
+	job.LastError = ""
+	return job
+}
+
+// deferEntry moves entry i to the queue when it does not fit the daily
+// budget and reports whether the entry is done for this run
+func deferEntry(b *batch.Batch, i int) bool {
+	deferred, err := deferJob(b.Entries[i].Job)
+	switch {
+	case err != nil:
+		finishEntry(b, i, err)
+		return true
+	case deferred:
+		b.Finish(i, batch.StatusDeferred, nil)
+		saveBatch(b)
+		return true
+	}
+	return false
+}
+
+// finishEntry records the outcome of a batch entry and saves the batch
+func finishEntry(b *batch.Batch, i int, err error) {
+	source := b.Entries[i].Job.Source
+	switch {
+	case err == nil:
+		b.Finish(i, batch.StatusDone, nil)
+	case errors.Is(err, ErrNoSpeech) || errors.Is(err, ErrSilentAudio):
+		fmt.Println(style.Warning("%s: %v", source, err))
+		logger.LogWarning("Batch %s: %s: %v", b.ID, source, err)
+		b.Finish(i, batch.StatusEmpty, err)
+	default:
+		fmt.Println(style.Failure("%s failed: %v", source, err))
+		logger.LogError("Batch %s: %s failed: %v", b.ID, source, err)
+		if hint, _ := providerHint(err); hint != "" {
+			fmt.Println(style.Hint("%s", hint))
+		}
+		b.Finish(i, batch.StatusFailed, err)
+	}
+	saveBatch(b)
+}
+
+// saveBatch stores the batch; a failure only costs the ability to retry
+func saveBatch(b *batch.Batch) {
+	if err := batch.Save(b); err != nil {
+		fmt.Println(style.Warning("Could not record batch for 'sona retry': %v", err))
+		logger.LogWarning("Failed to save batch %s: %v", b.ID, err)
+	}
+}
+
+// summarizeBatch prints the outcome of the batch and returns the failure count
+func summarizeBatch(b *batch.Batch) int {
+	failed := b.Count(batch.StatusFailed)
+	fmt.Printf("\n%d succeeded, %d failed", b.Count(batch.StatusDone), failed)
+	if empty := b.Count(batch.StatusEmpty); empty > 0 {
+		fmt.Printf(", %d without speech", empty)
+	}
+	deferred := b.Count(batch.StatusDeferred)
+	if deferred > 0 {
+		fmt.Printf(", %d deferred", deferred)
+	}
+	fmt.Println()
+	if deferred > 0 {
+		fmt.Println(style.Hint("Deferred sources are queued for tomorrow; run 'sona queue flush --wait' to resume them automatically"))
+	}
+
+	if failed > 0 {
+		for _, entry := range b.Entries {
+			if entry.Status == batch.StatusFailed {
+				fmt.Println("  " + style.Failure("%s: %s", entry.Job.Source, entry.Error))
+			}
+		}
+		fmt.Println(style.Hint("Run 'sona retry %s' to re-run only the failed sources", b.ID))
+	}
+	return failed
+}
+
+// listBatches prints the recorded batches, newest first
+func listBatches() error {
+	ids, err := batch.IDs()
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Println("No batches recorded")
+		return nil
+	}
+
+	for _, id := range ids {
+		b, err := batch.Load(id)
+		if err != nil {
+			fmt.Printf("%s  %v\n", id, err)
+			continue
+		}
+		fmt.Printf("%s  %s  %d sources, %d done, %d failed\n", b.ID, b.StartedAt.Format("2006-01-02 15:04"),
+			len(b.Entries), b.Count(batch.StatusDone), b.Count(batch.StatusFailed))
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package transcriber
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/usage"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// jobPriority orders sources in batches and the queue: high, normal or low
+var jobPriority string
+
+// monthlyBudget is the most to spend on transcripts this calendar month, 0 for no limit
+var monthlyBudget float64
+
+func validatePriority(priority string) error {
+	switch priority {
+	case "", queue.PriorityHigh, queue.PriorityNormal, queue.PriorityLow:
+		return nil
+	default:
+		return fmt.Errorf("unsupported priority %q (use %s, %s or %s)", priority, queue.PriorityHigh, queue.PriorityNormal, queue.PriorityLow)
+	}
+}
+
+// jobMinutes estimates the audio length of a job before it runs, or 0 when
+// it cannot be determined
+func jobMinutes(job queue.Job) float64 {
+	var duration time.Duration
+	var err error
+	if youtube.IsYouTubeURL(job.Source) {
+		duration, err = youtube.ProbeDuration(job.Source)
+	} else {
+		duration, err = probeAudioDuration(job.Source)
+	}
+	if err != nil {
+		logger.LogWarning("Could not estimate the length of %s for the budget: %v", job.Source, err)
+		return 0
+	}
+	return duration.Minutes()
+}
+
+// overBudget reports whether the job would take today's usage past
+// budget.daily_minutes. A job longer than the whole budget still runs as the
+// first of a day, or it would never run.
+func overBudget(job queue.Job) bool {
+	limit := float64(config.GetDailyMinutes())
+	if limit <= 0 {
+		return false
+	}
+
+	used, err := usage.Today()
+	if err != nil {
+		logger.LogWarning("Could not read today's usage: %v", err)
+		return false
+	}
+	if used == 0 {
+		return false
+	}
+
+	minutes := jobMinutes(job)
+	if used < limit && used+minutes <= limit {
+		return false
+	}
+	if minutes > 0 {
+		fmt.Printf("%sDaily budget: %.0f of %.0f minutes used today, %s needs about %.0f more\n", style.Icon("⏸  "), used, limit, job.Source, minutes)
+	} else {
+		fmt.Printf("%sDaily budget: %.0f of %.0f minutes used today\n", style.Icon("⏸  "), used, limit)
+	}
+	logger.LogInfo("Job %s over the daily budget: %.1f used, %.1f needed, limit %.0f", job.Source, used, minutes, limit)
+	return true
+}
+
+// validateMonthlyBudget checks --budget, which needs a price for the model
+// to tell what a job costs
+func validateMonthlyBudget(budget float64, model string) error {
+	if budget < 0 {
+		return fmt.Errorf("--budget cannot be negative")
+	}
+	if budget > 0 && config.GetModelPrice(model) == 0 {
+		return fmt.Errorf("--budget needs the price of %s; set usage.price_per_hour or usage.model_prices", model)
+	}
+	return nil
+}
+
+// checkMonthlyBudget refuses a job whose estimated cost would take this
+// month's spending, the cost of the transcripts in the library, past
+// --budget. A job of unknown length only runs while budget is left.
+func checkMonthlyBudget(source string, model string) error {
+	if monthlyBudget <= 0 {
+		return nil
+	}
+
+	spent, err := library.MonthCost(time.Now())
+	if err != nil {
+		return fmt.Errorf("could not read this month's spending: %v", err)
+	}
+	cost := jobMinutes(queue.Job{Source: source}) / 60 * config.GetModelPrice(model)
+	if spent < monthlyBudget && spent+cost <= monthlyBudget {
+		return nil
+	}
+	logger.LogInfo("Job %s over the monthly budget: %.2f spent, %.2f needed, budget %.2f", source, spent, cost, monthlyBudget)
+	return fmt.Errorf("%w: %.2f of %.2f spent this month, this job would cost about %.2f more", ErrOverBudget, spent, monthlyBudget, cost)
+}
+
+// jobCost is what AssemblyAI charged for the transcript at the configured
+// price of the model, 0 when no price is set
+func jobCost(model string, result *transcript.Transcript) float64 {
+	billed := max(0, result.Duration-result.LocalSeconds)
+	return billed / 3600 * config.GetModelPrice(model)
+}
+
+// deferJob queues the job for tomorrow when it does not fit today's budget
+// and reports whether it was deferred
+func deferJob(job queue.Job) (bool, error) {
+	if !overBudget(job) {
+		return false, nil
+	}
+
+	job.NotBefore = usage.NextDay(time.Now())
+	queued, err := queue.Add(job)
+	if err != nil {
+		return false, fmt.Errorf("over the daily budget and could not be queued: %v", err)
+	}
+	fmt.Printf("Deferred to tomorrow as job %s\n", queued.ID)
+	logger.LogInfo("Deferred %s as job %s until %s", job.Source, queued.ID, job.NotBefore.Format(time.RFC3339))
+	return true, nil
+}
+
+// noteLimitFailure logs a source refused because of an account limit for 'sona usage'
+func noteLimitFailure(source string, err error) {
+	if !assemblyai.IsLimitError(err) {
+		return
+	}
+	fmt.Println(style.Hint("AssemblyAI refused the job because of an account limit; see 'sona usage'"))
+	if err := usage.RecordLimitFailure(source, err); err != nil {
+		logger.LogWarning("Failed to record limit failure: %v", err)
+	}
+}
+
+// recordUsage adds the transcribed audio to today's usage for the daily budget
+func recordUsage(result *transcript.Transcript, audioPath string) {
+	duration := time.Duration(result.Duration * float64(time.Second))
+	if duration <= 0 {
+		duration = audioDurationOrZero(audioPath)
+	}
+	if err := usage.Add(duration); err != nil {
+		logger.LogWarning("Failed to record usage: %v", err)
+	}
+}
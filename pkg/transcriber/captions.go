@@ -0,0 +1,96 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// captionFile keeps a small text file holding the latest caption lines,
+// for OBS's "Read from file" text source and similar overlays
+type captionFile struct {
+	mu       sync.Mutex
+	path     string
+	maxLines int
+	width    int
+	// committed holds recent finalized text, already wrapped
+	committed []string
+}
+
+// newCaptionFile creates the caption file, empty
+func newCaptionFile(path string, maxLines int, width int) (*captionFile, error) {
+	if maxLines < 1 {
+		return nil, fmt.Errorf("--caption-lines must be at least 1")
+	}
+	if width < 10 {
+		return nil, fmt.Errorf("--caption-width must be at least 10")
+	}
+	c := &captionFile{path: path, maxLines: maxLines, width: width}
+	if err := c.write(nil); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update shows the partial text after the committed lines
+func (c *captionFile) Update(partial string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(wrapCaption(partial, c.width))
+}
+
+// Commit appends finalized text and drops lines that scrolled out of view
+func (c *captionFile) Commit(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.committed = append(c.committed, wrapCaption(text, c.width)...)
+	if len(c.committed) > c.maxLines {
+		c.committed = c.committed[len(c.committed)-c.maxLines:]
+	}
+	return c.write(nil)
+}
+
+// Clear empties the caption file, e.g. when the stream ends
+func (c *captionFile) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.committed = nil
+	return c.write(nil)
+}
+
+// write replaces the file atomically so the overlay never reads half an update
+func (c *captionFile) write(partial []string) error {
+	lines := append(append([]string{}, c.committed...), partial...)
+	if len(lines) > c.maxLines {
+		lines = lines[len(lines)-c.maxLines:]
+	}
+
+	if err := writeOutput(c.path, []byte(strings.Join(lines, "\n"))); err != nil {
+		return fmt.Errorf("failed to write captions: %v", err)
+	}
+	return nil
+}
+
+// wrapCaption breaks text into lines of at most width characters on word boundaries
+func wrapCaption(text string, width int) []string {
+	var lines []string
+	var line strings.Builder
+
+	for _, word := range strings.Fields(text) {
+		lineLen := utf8.RuneCountInString(line.String())
+		if lineLen > 0 && lineLen+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteString(" ")
+		}
+		line.WriteString(word)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
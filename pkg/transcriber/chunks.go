@@ -0,0 +1,125 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// maxConcurrentChunks bounds how many chunks are uploaded and polled at
+// once; a lower usage.concurrency_limit takes precedence
+const maxConcurrentChunks = 8
+
+// errChunkSkipped marks chunks not started because an earlier one failed
+var errChunkSkipped = errors.New("skipped after an earlier chunk failed")
+
+// audioChunk is a piece of the audio transcribed as a job of its own
+type audioChunk struct {
+	// Name identifies the chunk in messages, e.g. "segment 3"
+	Name     string
+	Path     string
+	Start    time.Duration
+	End      time.Duration
+	Model    string
+	Language string
+}
+
+// transcribeChunks transcribes the chunks concurrently, starting them in
+// order. done is called for each chunk in order as soon as it and every
+// chunk before it have finished, so the caller can assemble and write the
+// output while later chunks are still processing. After a failure no new
+// chunks are started and the first failure is returned.
+func transcribeChunks(chunks []audioChunk, profile outputProfile, timings *progress.Timings, done func(i int, result *transcript.Transcript)) ([]*transcript.Transcript, error) {
+	results := make([]*transcript.Transcript, len(chunks))
+	errs := make([]error, len(chunks))
+	if len(chunks) == 0 {
+		return results, nil
+	}
+
+	concurrency := maxConcurrentChunks
+	if limit := config.GetConcurrencyLimit(); limit > 0 && limit < concurrency {
+		concurrency = limit
+	}
+	logger.LogInfo("Transcribing %d chunks, %d at a time", len(chunks), concurrency)
+
+	timings.Begin("transcribe")
+	defer timings.End()
+	spinner := newSpinner(timings)
+	spinner.Start()
+	defer spinner.Stop()
+	spinner.SetPhase(fmt.Sprintf("Transcribing %d chunks", len(chunks)), 0)
+
+	finished := make(chan int, len(chunks))
+	slots := make(chan struct{}, concurrency)
+	var failed atomic.Bool
+	go func() {
+		for i := range chunks {
+			slots <- struct{}{}
+			if failed.Load() {
+				errs[i] = errChunkSkipped
+				<-slots
+				finished <- i
+				continue
+			}
+			go func(i int) {
+				defer func() { <-slots }()
+				results[i], errs[i] = transcribeChunk(chunks[i], profile)
+				if errs[i] != nil {
+					failed.Store(true)
+				}
+				finished <- i
+			}(i)
+		}
+	}()
+
+	complete := make([]bool, len(chunks))
+	next := 0
+	for n := 1; n <= len(chunks); n++ {
+		i := <-finished
+		complete[i] = true
+		if errs[i] == nil {
+			logger.LogInfo("Chunk %s finished (%d/%d)", chunks[i].Name, n, len(chunks))
+		}
+		spinner.Update(fmt.Sprintf("Transcribing %d chunks: %d done", len(chunks), n))
+
+		for next < len(chunks) && complete[next] && errs[next] == nil {
+			if done != nil {
+				done(next, results[next])
+			}
+			next++
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, errChunkSkipped) {
+			return nil, fmt.Errorf("%s: %w", chunks[i].Name, err)
+		}
+	}
+	return results, nil
+}
+
+// transcribeChunk uploads one chunk and waits for its transcript. Progress is
+// shown for all chunks together, so the client reports nothing itself.
+func transcribeChunk(chunk audioChunk, profile outputProfile) (*transcript.Transcript, error) {
+	client := newClient()
+	client.Polling = assemblyai.PollPolicy{
+		Expected:    estimateProcessingTime(chunk.End-chunk.Start, chunk.Model),
+		MinInterval: config.GetPollingDuration("polling.min_interval"),
+		MaxInterval: config.GetPollingDuration("polling.max_interval"),
+		Timeout:     config.GetPollingDuration("polling.timeout"),
+	}
+	client.Progress = func(string) {}
+
+	result, err := client.TranscribeAudio(audioForUpload(chunk.Path), transcriptionRequest(chunk.Model, chunk.Language, profile))
+	if err != nil {
+		return nil, err
+	}
+	return result.Normalize(), nil
+}
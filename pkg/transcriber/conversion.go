@@ -0,0 +1,165 @@
+package transcriber
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+// audioStream describes the first audio stream of a media file
+type audioStream struct {
+	Codec      string
+	SampleRate int
+	Channels   int
+}
+
+// conversion holds the ffmpeg parameters for the MP3 sent for transcription
+type conversion struct {
+	SampleRate int
+	Channels   int
+	// Bitrate is in kbps
+	Bitrate int
+}
+
+// defaultConversion is used when the source cannot be probed
+var defaultConversion = conversion{SampleRate: 44100, Channels: 2, Bitrate: 192}
+
+// narrowbandRate is the highest sample rate treated as telephone quality;
+// such audio carries nothing above 4 kHz
+const narrowbandRate = 8000
+
+// telephonyCodecs are speech codecs used by phones, voicemail and VoIP
+// (amr in .amr and .3gp files, G.711 in call recordings)
+var telephonyCodecs = map[string]bool{
+	"amr_nb": true, "amr_wb": true, "gsm": true, "gsm_ms": true,
+	"pcm_mulaw": true, "pcm_alaw": true, "g722": true, "g723_1": true,
+	"g729": true, "adpcm_g726": true, "adpcm_g726le": true,
+}
+
+// mp3SampleRates are the sample rates the MP3 encoder accepts
+var mp3SampleRates = []int{8000, 11025, 12000, 16000, 22050, 24000, 32000, 44100}
+
+var audioStreamPattern = regexp.MustCompile(`Stream #\d+:\d+\S*: Audio: (\w+)[^\n]*?, (\d+) Hz, ([^,\n]+)`)
+
+var channelCountPattern = regexp.MustCompile(`^(\d+) channels`)
+
+// probeAudioStream reads the codec, sample rate and channels of a media file
+// from ffmpeg's stream info
+func probeAudioStream(path string) (audioStream, error) {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return audioStream{}, err
+	}
+
+	// ffmpeg exits non-zero without an output file, but still prints the stream info
+	output, _ := logger.RunCommand(exec.Command(ffmpegPath, "-hide_banner", "-i", path))
+
+	return parseAudioStream(output)
+}
+
+// parseAudioStream extracts the first "Audio: codec, rate Hz, layout" line from ffmpeg output
+func parseAudioStream(output string) (audioStream, error) {
+	match := audioStreamPattern.FindStringSubmatch(output)
+	if match == nil {
+		return audioStream{}, fmt.Errorf("no audio stream found in ffmpeg output")
+	}
+
+	stream := audioStream{Codec: match[1]}
+	stream.SampleRate, _ = strconv.Atoi(match[2])
+
+	layout := strings.TrimSpace(match[3])
+	switch {
+	case layout == "mono":
+		stream.Channels = 1
+	case layout == "stereo":
+		stream.Channels = 2
+	case channelCountPattern.MatchString(layout):
+		stream.Channels, _ = strconv.Atoi(channelCountPattern.FindStringSubmatch(layout)[1])
+	default:
+		// Surround layouts such as 5.1 are downmixed to stereo anyway
+		stream.Channels = 2
+	}
+	return stream, nil
+}
+
+// isNarrowband reports whether the stream is telephone-quality audio
+func (s audioStream) isNarrowband() bool {
+	return s.SampleRate > 0 && s.SampleRate <= narrowbandRate
+}
+
+// isTelephony reports whether the stream uses a phone or VoIP speech codec
+func (s audioStream) isTelephony() bool {
+	return telephonyCodecs[s.Codec]
+}
+
+// conversionFor picks MP3 parameters that keep what the source has without
+// inflating it: the source sample rate up to 44.1 kHz, at most two
+// channels, and a bitrate that fits the bandwidth
+func conversionFor(stream audioStream) conversion {
+	if stream.SampleRate <= 0 {
+		return defaultConversion
+	}
+
+	c := conversion{SampleRate: 44100, Channels: stream.Channels}
+	for _, rate := range mp3SampleRates {
+		if rate >= stream.SampleRate {
+			c.SampleRate = rate
+			break
+		}
+	}
+	if c.Channels < 1 || c.Channels > 2 {
+		c.Channels = 2
+	}
+
+	// Per-channel bitrates that are transparent for speech at each bandwidth
+	perChannel := 96
+	switch {
+	case c.SampleRate <= narrowbandRate:
+		perChannel = 24
+	case c.SampleRate <= 16000:
+		perChannel = 48
+	case c.SampleRate <= 24000:
+		perChannel = 64
+	}
+	c.Bitrate = perChannel * c.Channels
+	return c
+}
+
+// ffmpegArgs returns the encoder arguments for the conversion
+func (c conversion) ffmpegArgs() []string {
+	return []string{
+		"-ar", strconv.Itoa(c.SampleRate),
+		"-ac", strconv.Itoa(c.Channels),
+		"-b:a", fmt.Sprintf("%dk", c.Bitrate),
+	}
+}
+
+// describe summarizes the stream for progress output, e.g. "amr_nb, 8 kHz mono"
+func (s audioStream) describe() string {
+	channels := fmt.Sprintf("%d channels", s.Channels)
+	switch s.Channels {
+	case 1:
+		channels = "mono"
+	case 2:
+		channels = "stereo"
+	}
+	return fmt.Sprintf("%s, %s kHz %s", s.Codec, strconv.FormatFloat(float64(s.SampleRate)/1000, 'f', -1, 64), channels)
+}
+
+// warnNarrowband tells the user what to expect from telephone-quality audio
+func warnNarrowband(stream audioStream) {
+	if !stream.isNarrowband() {
+		return
+	}
+	source := "Narrowband"
+	if stream.isTelephony() {
+		source = "Telephone"
+	}
+	fmt.Println(style.Warning("%s audio (%s): expect lower accuracy than for studio recordings, especially for names, numbers and crosstalk", source, stream.describe()))
+	logger.LogWarning("Narrowband source audio (%s)", stream.describe())
+}
@@ -0,0 +1,205 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// runningDaemon is set while sona serve or sona watch runs, when nobody is
+// there to answer questions
+var runningDaemon bool
+
+// daemon is what sona serve and sona watch share: health and readiness
+// endpoints, running jobs, and a graceful stop on SIGTERM that lets the
+// running jobs finish
+type daemon struct {
+	mu              sync.Mutex
+	stopping        bool
+	running         sync.WaitGroup
+	stop            chan struct{}
+	shutdownTimeout time.Duration
+	server          *http.Server
+}
+
+// newDaemon prepares a daemon after checking the settings it runs with, so
+// a misconfigured container fails at start rather than on its first job
+func newDaemon(cmd *cobra.Command, shutdownTimeout time.Duration) (*daemon, error) {
+	if err := flagsFromEnv(cmd.Flags()); err != nil {
+		return nil, err
+	}
+	if problems := config.ValidateEnv(); len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.String()
+		}
+		return nil, fmt.Errorf("invalid environment: %s", strings.Join(messages, "; "))
+	}
+	if err := applyConfigDefaults(TranscribeCmd); err != nil {
+		return nil, err
+	}
+
+	runningDaemon = true
+	d := &daemon{stop: make(chan struct{}), shutdownTimeout: shutdownTimeout}
+	workspace.OnShutdown(d.shutdown)
+	return d, nil
+}
+
+// flagsFromEnv sets the flags not given on the command line from SONA_*
+// environment variables named after them, e.g. --listen from SONA_LISTEN
+func flagsFromEnv(flags *pflag.FlagSet) error {
+	var err error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		name := "SONA_" + strings.ToUpper(strings.ReplaceAll(flag.Name, "-", "_"))
+		value, ok := os.LookupEnv(name)
+		if !ok || flag.Changed || err != nil {
+			return
+		}
+		if setErr := flags.Set(flag.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s: %v", name, setErr)
+		}
+	})
+	return err
+}
+
+// handleHealth adds /healthz, which answers while the process is up, and
+// /readyz, which answers only while jobs can be run
+func (d *daemon) handleHealth(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if err := d.ready(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "unavailable", "error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+}
+
+// ready reports why jobs cannot be run, nil when they can
+func (d *daemon) ready() error {
+	d.mu.Lock()
+	stopping := d.stopping
+	d.mu.Unlock()
+	if stopping {
+		return errors.New("shutting down")
+	}
+	if provider == "assemblyai" && config.GetAPIKeyNoExit() == "" {
+		return errors.New("no AssemblyAI API key; set ASSEMBLYAI_API_KEY")
+	}
+	if _, err := deps.FindBinary("ffmpeg"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// listen serves mux on addr in the background
+func (d *daemon) listen(addr string, mux *http.ServeMux) {
+	d.server = &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := d.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	}()
+	fmt.Printf("Listening on %s\n", addr)
+}
+
+// run runs a job unless the daemon is stopping, and reports whether it
+// did. Several jobs may run at once.
+func (d *daemon) run(job func()) bool {
+	d.mu.Lock()
+	if d.stopping {
+		d.mu.Unlock()
+		return false
+	}
+	d.running.Add(1)
+	d.mu.Unlock()
+
+	defer d.running.Done()
+	job()
+	return true
+}
+
+// stopped is closed when the daemon begins to shut down
+func (d *daemon) stopped() <-chan struct{} {
+	return d.stop
+}
+
+// shutdown stops taking jobs; wait then lets the running ones finish
+func (d *daemon) shutdown() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopping {
+		d.stopping = true
+		close(d.stop)
+	}
+}
+
+// wait blocks until the daemon is shut down, lets the running jobs finish
+// within the shutdown timeout and exits
+func (d *daemon) wait() {
+	<-d.stop
+	fmt.Println("Shutting down, waiting for running jobs to finish")
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.shutdownTimeout)
+	defer cancel()
+	if d.server != nil {
+		d.server.Shutdown(ctx)
+	}
+
+	// No job starts once stopping is set, so the count only goes down
+	idle := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		logger.LogWarning("Running jobs did not finish within %v", d.shutdownTimeout)
+		workspace.RemoveAll()
+		fmt.Printf("Running jobs did not finish within %v, stopped\n", d.shutdownTimeout)
+		os.Exit(1)
+	}
+	logger.LogInfo("Daemon stopped")
+	fmt.Println("Stopped")
+	os.Exit(0)
+}
+
+// runDaemonJob runs a job created from the daemon's own options and returns
+// the transcript it added to the library, if any. The shared options are
+// left alone, so jobs may run at once.
+func runDaemonJob(job queue.Job) (*library.Record, error) {
+	var record *library.Record
+	opts := optionsFor(job)
+	opts.onRecord = func(saved library.Record) { record = &saved }
+	err := processSource(sourceSpec{Source: job.Source, LanguageCode: job.LanguageCode}, opts)
+	return record, err
+}
+
+// writeJSON answers a request with value as JSON
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
@@ -0,0 +1,141 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/batch"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// noDashboard prints the output of every batch job as it happens instead
+// of showing the batch on a dashboard
+var noDashboard bool
+
+// dashboard shows the running batch, nil when output is printed as usual.
+// dashboardRows holds the row of each running job by its source.
+var (
+	dashboard     *progress.Dashboard
+	dashboardRows = make(map[string]int)
+	dashboardMu   sync.Mutex
+)
+
+// startDashboard shows the jobs on a live dashboard when stdout is a
+// terminal. What the jobs print goes to the log file meanwhile.
+func startDashboard(jobs []queue.Job) {
+	if noDashboard {
+		return
+	}
+	names := make([]string, len(jobs))
+	for i, job := range jobs {
+		names[i] = job.Source
+		if !youtube.IsYouTubeURL(job.Source) {
+			names[i] = filepath.Base(job.Source)
+		}
+	}
+
+	d := progress.NewDashboard(names)
+	if d == nil {
+		return
+	}
+	if err := d.Capture(func(line string) { logger.LogInfo("%s", line) }); err != nil {
+		logger.LogWarning("Batch dashboard unavailable: %v", err)
+		return
+	}
+	if parallelJobs > 1 {
+		d.Parallel()
+	}
+	d.Start()
+	dashboard = d
+}
+
+// stopDashboard draws the final state of the batch and restores normal output
+func stopDashboard() {
+	if dashboard == nil {
+		return
+	}
+	dashboard.Stop()
+	dashboard = nil
+	fmt.Printf("Details of each job are in %s\n", logger.GetLogPath())
+}
+
+// newTimings times the phases of the job for source, showing them on the
+// dashboard when there is one
+func newTimings(source string) *progress.Timings {
+	timings := &progress.Timings{Job: source}
+	dashboardMu.Lock()
+	row, ok := dashboardRows[source]
+	dashboardMu.Unlock()
+	if dashboard != nil && ok {
+		d := dashboard
+		timings.Observe(func(phase string, estimate time.Duration) {
+			d.Stage(row, phase, estimate)
+		})
+	}
+	return timings
+}
+
+// newSpinner creates the status line of the job timed by timings. Jobs
+// running in parallel or in a daemon, which may run several, each print
+// plain lines that start with their source.
+func newSpinner(timings *progress.Timings) *progress.Spinner {
+	if runningParallel || runningDaemon {
+		return progress.NewJobSpinner(timings.Job)
+	}
+	return progress.NewSpinner()
+}
+
+// runEntry runs batch entry i, shown as row of the dashboard, unless it
+// does not fit the daily budget, and records the outcome. Entries may run
+// in parallel; the batch is only touched under batchMu.
+func runEntry(b *batch.Batch, i int, row int, run func() error) {
+	if dashboard != nil {
+		dashboardMu.Lock()
+		dashboardRows[b.Entries[i].Job.Source] = row
+		dashboardMu.Unlock()
+		dashboard.Begin(row)
+	}
+	batchMu.Lock()
+	deferred := deferEntry(b, i)
+	batchMu.Unlock()
+	if !deferred {
+		err := run()
+		batchMu.Lock()
+		finishEntry(b, i, err)
+		batchMu.Unlock()
+	}
+	if dashboard == nil {
+		return
+	}
+
+	batchMu.Lock()
+	entry := b.Entries[i]
+	batchMu.Unlock()
+	switch entry.Status {
+	case batch.StatusDone:
+		dashboard.Finish(row, progress.JobDone, "")
+	case batch.StatusFailed:
+		dashboard.Finish(row, progress.JobFailed, entry.Error)
+	case batch.StatusDeferred:
+		dashboard.Finish(row, progress.JobSkipped, "deferred by the daily budget")
+	default:
+		dashboard.Finish(row, progress.JobSkipped, entry.Error)
+	}
+}
+
+// canPrompt reports whether the user can be asked a question: stdin is a
+// terminal, no dashboard hides the question, no daemon is running and no
+// other job is asking at the same time
+func canPrompt() bool {
+	if dashboard != nil || runningDaemon || runningParallel {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,80 @@
+package transcriber
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// lineTimestampPattern matches the [HH:MM:SS] a profile puts at the start of
+// a turn
+var lineTimestampPattern = regexp.MustCompile(`^\[(\d{2}):(\d{2}):(\d{2})\]`)
+
+// audioLink returns a link that opens the source at offset: a YouTube URL
+// with ?t=, or a file:// URL with a #t= media fragment, which browsers and
+// most players seek to. Other web sources and audiobooks get no link.
+func audioLink(source string, offset time.Duration) string {
+	seconds := int(offset.Seconds())
+	if youtube.IsYouTubeURL(source) {
+		u, err := url.Parse(source)
+		if err != nil {
+			return ""
+		}
+		query := u.Query()
+		query.Set("t", fmt.Sprintf("%ds", seconds))
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	// Audiobook chapters are timed from the start of the chapter, not the book
+	if isURL(source) || isAudiobook(source) {
+		return ""
+	}
+
+	path, err := filepath.Abs(source)
+	if err != nil {
+		return ""
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path), Fragment: fmt.Sprintf("t=%d", seconds)}
+	// Windows paths start with the drive letter, which needs a leading slash
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	return u.String()
+}
+
+// markdownTimestamp renders an offset as HH:MM:SS linked to that moment of
+// the source, or as plain text when the source cannot be linked
+func markdownTimestamp(source string, offset time.Duration) string {
+	link := audioLink(source, offset)
+	if link == "" {
+		return formatTimestamp(offset)
+	}
+	// Parentheses would end the link early
+	link = strings.NewReplacer("(", "%28", ")", "%29").Replace(link)
+	return fmt.Sprintf("[%s](%s)", formatTimestamp(offset), link)
+}
+
+// linkTimestamp turns the timestamp starting a line of the transcript into a
+// link to that moment of the source
+func linkTimestamp(line string, source string) string {
+	match := lineTimestampPattern.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+
+	link := audioLink(source, offset)
+	if link == "" {
+		return line
+	}
+	return markdownTimestamp(source, offset) + line[len(match[0]):]
+}
@@ -0,0 +1,180 @@
+package transcriber
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/fingerprint"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// Audio counts as a copy of an earlier transcript when their fingerprints
+// agree this well (unrelated audio scores about 0.5) over at least half of
+// the shorter recording and at least minimumDuplicateOverlap
+const (
+	duplicateSimilarity     = 0.65
+	minimumDuplicateOverlap = 10 * time.Second
+)
+
+// allowDuplicate transcribes without checking the library for earlier copies
+var allowDuplicate bool
+
+// sourceIdentity identifies the audio of a source: the file's hash for
+// exact copies and an acoustic fingerprint for re-encoded or trimmed ones
+type sourceIdentity struct {
+	Hash        string
+	Fingerprint fingerprint.Fingerprint
+}
+
+// duplicate is an earlier transcript of the same audio
+type duplicate struct {
+	Record library.Record
+	Reason string
+}
+
+// identifyAudio hashes and fingerprints the file. Failures only cost
+// duplicate detection, so they are logged and leave the field empty.
+func identifyAudio(path string) sourceIdentity {
+	var identity sourceIdentity
+
+	hash, err := fileHash(path)
+	if err != nil {
+		logger.LogWarning("Could not hash %s: %v", path, err)
+	}
+	identity.Hash = hash
+
+	samples, err := decodePCM(path, fingerprint.SampleRate)
+	if err != nil {
+		logger.LogWarning("Could not fingerprint %s: %v", path, err)
+		return identity
+	}
+	identity.Fingerprint = fingerprint.Compute(samples)
+	return identity
+}
+
+// fileHash returns the SHA-256 of the file's contents
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// findDuplicate looks for an earlier transcript of the same source. Without
+// an identity it only looks for the same YouTube video, which is checked
+// before downloading; with one it looks for an identical file or audio whose
+// fingerprint matches, skipping transcripts of the same video that were
+// already offered.
+func findDuplicate(source string, identity sourceIdentity) (*duplicate, error) {
+	records, err := library.List()
+	if err != nil {
+		return nil, err
+	}
+
+	identified := identity.Hash != "" || len(identity.Fingerprint) > 0
+	var candidates []library.Record
+	for _, record := range records {
+		if youtube.IsYouTubeURL(source) && record.Source == source {
+			if !identified {
+				return &duplicate{Record: record, Reason: "same video"}, nil
+			}
+			continue
+		}
+		if identity.Hash != "" && record.SourceHash == identity.Hash {
+			return &duplicate{Record: record, Reason: "identical file"}, nil
+		}
+		candidates = append(candidates, record)
+	}
+	if len(identity.Fingerprint) == 0 {
+		return nil, nil
+	}
+
+	minimumFrames := int(minimumDuplicateOverlap.Seconds() / fingerprint.FrameDuration)
+	var best *duplicate
+	bestSimilarity := duplicateSimilarity
+	for _, record := range candidates {
+		stored, err := fingerprint.Load(record.Name)
+		if err != nil {
+			continue
+		}
+
+		match := fingerprint.Compare(identity.Fingerprint, stored)
+		shorter := min(len(identity.Fingerprint), len(stored))
+		if match.Overlap < minimumFrames || 2*match.Overlap < shorter || match.Similarity < bestSimilarity {
+			continue
+		}
+
+		reason := fmt.Sprintf("%.0f%% acoustic match", 100*match.Similarity)
+		if offset := time.Duration(float64(match.Offset) * fingerprint.FrameDuration * float64(time.Second)); absDuration(offset) >= time.Second {
+			reason += fmt.Sprintf(", offset %s", formatTimestamp(absDuration(offset).Round(time.Second)))
+		}
+		best = &duplicate{Record: record, Reason: reason}
+		bestSimilarity = match.Similarity
+	}
+	return best, nil
+}
+
+// offerExisting tells the user about an earlier transcript of the same audio
+// and, on a terminal, asks whether to use it. It returns true when the
+// earlier transcript should be used instead of transcribing again.
+func offerExisting(d *duplicate) bool {
+	record := d.Record
+	fmt.Printf("%sThis looks like %s, transcribed %s (%s)\n", style.Icon("♻️  "), record.Name, record.CreatedAt.Format("2006-01-02 15:04"), d.Reason)
+	for _, file := range record.Files {
+		fmt.Printf("   %s\n", file)
+	}
+	logger.LogInfo("Possible duplicate of %s: %s", record.Name, d.Reason)
+
+	if !canPrompt() {
+		fmt.Println("   Transcribing again (use --allow-duplicate to skip this check)")
+		return false
+	}
+
+	fmt.Print("Use the existing transcript instead of transcribing again? (y/n): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+}
+
+// useExisting offers an earlier transcript of the source and returns true
+// when the user chose it over transcribing again
+func useExisting(source string, identity sourceIdentity) bool {
+	if allowDuplicate {
+		return false
+	}
+
+	d, err := findDuplicate(source, identity)
+	if err != nil {
+		logger.LogWarning("Duplicate check failed: %v", err)
+		return false
+	}
+	if d == nil || !offerExisting(d) {
+		return false
+	}
+	fmt.Printf("Using existing transcript %s\n", d.Record.Name)
+	return true
+}
+
+// checkDuplicate identifies the audio and offers an earlier transcript of it.
+// It returns the identity to record with the new transcript, and true when
+// the user chose the existing transcript.
+func checkDuplicate(source string, audioPath string) (sourceIdentity, bool) {
+	identity := identifyAudio(audioPath)
+	return identity, useExisting(source, identity)
+}
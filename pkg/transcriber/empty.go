@@ -0,0 +1,87 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+var (
+	// ErrNoSpeech is returned when the provider finished but found no speech in the audio
+	ErrNoSpeech = errors.New("no speech detected in audio")
+	// ErrSilentAudio is returned when the audio itself is silent
+	ErrSilentAudio = errors.New("audio is silent")
+)
+
+// Exit codes for results that completed without usable speech
+const (
+	ExitNoSpeech    = 3
+	ExitSilentAudio = 4
+)
+
+// checkEmptyTranscript classifies an empty transcript as silent audio or
+// audio without recognizable speech. Non-empty transcripts pass through.
+func checkEmptyTranscript(transcript string, audioPath string) error {
+	if strings.TrimSpace(transcript) != "" {
+		return nil
+	}
+	// Audio AssemblyAI downloaded from a URL is not here to analyze
+	if audioPath == "" {
+		return ErrNoSpeech
+	}
+
+	silent, err := isSilentAudio(audioPath)
+	if err != nil {
+		logger.LogWarning("Could not analyze volume of %s: %v", audioPath, err)
+	}
+	if silent {
+		return ErrSilentAudio
+	}
+	return ErrNoSpeech
+}
+
+// emptyPlaceholder returns the text written instead of an empty transcript
+// when --allow-empty is set, and whether err is an empty-audio error at all
+func emptyPlaceholder(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrSilentAudio):
+		return "[silent audio]\n", true
+	case errors.Is(err, ErrNoSpeech):
+		return "[no speech detected]\n", true
+	default:
+		return "", false
+	}
+}
+
+// exitWithError prints the error and exits with a code that reflects its cause
+func exitWithError(prefix string, err error) {
+	// os.Exit skips deferred cleanups, so remove temp files first
+	workspace.RemoveAll()
+	finishRun(false, fmt.Sprintf("%s: %v", prefix, err))
+	notifyFinished(false, fmt.Sprintf("%s: %v", prefix, err))
+
+	switch {
+	case errors.Is(err, ErrSilentAudio):
+		fmt.Println(style.Error("The audio is silent. No transcript was written."))
+		fmt.Println(style.Hint("Use --allow-empty to write a placeholder transcript anyway"))
+		logger.LogError("%s: %v", prefix, err)
+		os.Exit(ExitSilentAudio)
+	case errors.Is(err, ErrNoSpeech):
+		fmt.Println(style.Error("No speech was detected in the audio. No transcript was written."))
+		fmt.Println(style.Hint("Use --allow-empty to write a placeholder transcript anyway"))
+		logger.LogError("%s: %v", prefix, err)
+		os.Exit(ExitNoSpeech)
+	default:
+		fmt.Println(style.Error("%s: %v", prefix, err))
+		hint, code := providerHint(err)
+		if hint != "" {
+			fmt.Println(style.Hint("%s", hint))
+		}
+		os.Exit(code)
+	}
+}
@@ -0,0 +1,80 @@
+package transcriber
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+)
+
+// Causes of failure that applications embedding sona can test for with
+// errors.Is on the errors of ProcessYouTubeVideo and ProcessLocalAudio
+var (
+	// ErrUnsupportedSource means the source is neither a YouTube URL nor a local file
+	ErrUnsupportedSource = errors.New("unsupported source")
+	// ErrDependencyMissing means ffmpeg, ffprobe or yt-dlp is not installed
+	ErrDependencyMissing = deps.ErrDependencyMissing
+	// ErrQuotaExceeded means AssemblyAI refused the job because of an account limit
+	ErrQuotaExceeded = assemblyai.ErrQuotaExceeded
+	// ErrProvider means AssemblyAI rejected the job or failed the transcript
+	ErrProvider = assemblyai.ErrProvider
+	// ErrOverBudget means the job would take this month's spending past --budget
+	ErrOverBudget = errors.New("over the monthly budget")
+)
+
+// Exit codes for jobs AssemblyAI refused, so scripts can tell a bad key from
+// a bad file; other failures exit with 1
+const (
+	ExitAuthentication      = 5
+	ExitAccountLimit        = 6
+	ExitUnsupportedAudio    = 7
+	ExitRejected            = 8
+	ExitProviderUnavailable = 9
+)
+
+// providerGuidance says what to do about each cause of an AssemblyAI error,
+// and the exit code it ends the run with
+var providerGuidance = map[string]struct {
+	hint string
+	code int
+}{
+	assemblyai.CauseAuthentication:    {"AssemblyAI did not accept the API key; set the right one with 'sona config set api_key <key>'", ExitAuthentication},
+	assemblyai.CauseInsufficientFunds: {"The AssemblyAI account is out of balance; top it up in the AssemblyAI dashboard, or add the keys of other accounts with 'sona config set api_keys'", ExitAccountLimit},
+	assemblyai.CauseRateLimit:         {"The AssemblyAI account is sending too many requests; wait a minute and run the command again, or transcribe fewer files at once", ExitAccountLimit},
+	assemblyai.CauseUnsupportedFile:   {"AssemblyAI could not read the file as audio; check that it plays, and that it is a recording rather than a playlist or web page", ExitUnsupportedAudio},
+	assemblyai.CauseInvalidAudio:      {"The audio cannot be transcribed; check that it is not empty, corrupt or too short, and that the file has an audio track", ExitUnsupportedAudio},
+	assemblyai.CauseInvalidRequest:    {"AssemblyAI rejected an option of the job; check that --model supports the language and options of the job", ExitRejected},
+	assemblyai.CauseUnavailable:       {"AssemblyAI failed on its side; try again in a few minutes", ExitProviderUnavailable},
+}
+
+// providerHint returns the advice for an error from AssemblyAI and the exit
+// code for it, or "" and 1 for other errors
+func providerHint(err error) (string, int) {
+	guidance, ok := providerGuidance[assemblyai.Cause(err)]
+	if !ok {
+		return "", 1
+	}
+	return guidance.hint, guidance.code
+}
+
+// SourceError is a source that cannot be transcribed
+type SourceError struct {
+	Source  string
+	Message string
+}
+
+func (e *SourceError) Error() string {
+	return e.Message
+}
+
+// Is lets errors.Is match ErrUnsupportedSource
+func (e *SourceError) Is(target error) bool {
+	return target == ErrUnsupportedSource
+}
+
+// isURL reports whether the source is a web address rather than a path
+func isURL(source string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
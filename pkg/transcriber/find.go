@@ -0,0 +1,100 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/spf13/cobra"
+)
+
+var findContext int
+
+// transcriptIDPattern matches AssemblyAI transcript IDs, which are searched
+// through the API when no saved transcript has the name
+var transcriptIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var FindCmd = &cobra.Command{
+	Use:   "find [name|transcript-id] [phrase]",
+	Short: "Find a word or phrase in a transcript with timestamps",
+	Long: `List every occurrence of a word or phrase in a saved transcript with its
+timestamp and the words around it, so you can jump straight to that moment
+in the audio. Matching ignores case and punctuation.
+
+An AssemblyAI transcript ID can be given instead of a saved transcript; it
+is searched through AssemblyAI's word search.`,
+	Example: `  sona find board-meeting "pricing model"
+  sona find interview kubernetes --context 15
+  sona find 5551722f-f677-48a6-9287-39c0aafd9ac1 "road map"`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runFind(args[0], args[1]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	FindCmd.Flags().IntVarP(&findContext, "context", "c", 8, "Number of words to show before and after each match")
+}
+
+func runFind(name string, phrase string) error {
+	terms := transcript.SearchTerms(phrase)
+	if len(terms) == 0 {
+		return fmt.Errorf("nothing to search for in %q", phrase)
+	}
+
+	record, err := library.Find(name)
+	if err != nil {
+		if transcriptIDPattern.MatchString(name) {
+			return findRemote(name, phrase)
+		}
+		return err
+	}
+	if len(record.Words) == 0 {
+		return fmt.Errorf("transcript %s has no word timings; find needs a transcript saved by this version of sona", record.Name)
+	}
+
+	occurrences := transcript.FindPhrase(record.Words, terms, findContext)
+	if len(occurrences) == 0 {
+		fmt.Printf("No matches for %q in %s\n", phrase, record.Name)
+		return nil
+	}
+
+	for _, o := range occurrences {
+		speaker := ""
+		if o.Speaker != "" {
+			speaker = o.Speaker + ": "
+		}
+		fmt.Printf("%s  %s%s\n", formatTimestamp(o.Start), speaker, o.Context)
+	}
+	fmt.Printf("\n%d matches for %q in %s\n", len(occurrences), phrase, record.Name)
+	return nil
+}
+
+// findRemote searches a transcript stored at AssemblyAI, which only returns
+// the timestamps of the matches
+func findRemote(transcriptID string, phrase string) error {
+	client := newClient()
+	result, err := client.WordSearch(transcriptID, []string{phrase})
+	if err != nil {
+		return err
+	}
+	if result.TotalCount == 0 {
+		fmt.Printf("No matches for %q in transcript %s\n", phrase, transcriptID)
+		return nil
+	}
+
+	for _, match := range result.Matches {
+		for _, timestamp := range match.Timestamps {
+			fmt.Printf("%s  %s\n", formatTimestamp(time.Duration(timestamp[0])*time.Millisecond), match.Text)
+		}
+	}
+	fmt.Printf("\n%d matches for %q in transcript %s\n", result.TotalCount, phrase, transcriptID)
+	return nil
+}
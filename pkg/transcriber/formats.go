@@ -0,0 +1,159 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// supportedProviders lists the transcription providers sona can talk to
+var supportedProviders = []string{"assemblyai", providerStreaming, providerHybrid}
+
+// formatter renders a transcript for a given source into a file body.
+// result carries the word and segment timings, when known.
+type formatter func(transcript string, source string, result *transcript.Transcript) string
+
+// outputFormats maps a format name (also used as file extension, unless
+// formatExtensions says otherwise) to its formatter
+var outputFormats = map[string]formatter{
+	"txt":          formatText,
+	"md":           formatMarkdown,
+	"lrc":          formatLRC,
+	"ass":          formatASS,
+	"chunks-jsonl": formatChunksJSONL,
+	"json":         formatJSON,
+}
+
+// formatExtensions are the file extensions of formats not named after theirs
+var formatExtensions = map[string]string{
+	"chunks-jsonl": "chunks.jsonl",
+}
+
+// timedFormats are built from the timings rather than the transcript text,
+// so edits to the text (proofreading, review) do not carry over to them
+var timedFormats = map[string]bool{
+	"lrc":          true,
+	"ass":          true,
+	"chunks-jsonl": true,
+	"json":         true,
+}
+
+func formatText(transcript string, source string, result *transcript.Transcript) string {
+	return transcript
+}
+
+func formatMarkdown(transcript string, source string, result *transcript.Transcript) string {
+	var b strings.Builder
+	b.WriteString("# Transcript\n\n")
+	fmt.Fprintf(&b, "- **Source:** %s\n", source)
+	fmt.Fprintf(&b, "- **Date:** %s\n\n", time.Now().Format("2006-01-02 15:04"))
+
+	for _, paragraph := range strings.Split(transcript, "\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph != "" {
+			b.WriteString(linkTimestamp(paragraph, source))
+			b.WriteString("\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+// formatNames returns the supported format names in a stable order
+func formatNames() []string {
+	names := make([]string, 0, len(outputFormats))
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateFormats normalizes the requested formats and rejects unknown ones
+func validateFormats(formats []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+
+	for _, format := range formats {
+		format = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(format, ".")))
+		if format == "" || seen[format] {
+			continue
+		}
+		if _, ok := outputFormats[format]; !ok {
+			return nil, fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(formatNames(), ", "))
+		}
+		seen[format] = true
+		result = append(result, format)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("at least one output format is required")
+	}
+
+	return result, nil
+}
+
+// validateProvider rejects providers sona does not support
+func validateProvider(provider string) error {
+	for _, supported := range supportedProviders {
+		if provider == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported provider %q (supported: %s)", provider, strings.Join(supportedProviders, ", "))
+}
+
+// validateRegion checks that the configured AssemblyAI region exists and
+// offers the speech model. Streaming picks its own model.
+func validateRegion(provider string, speechModel string) error {
+	region := config.GetRegion()
+	if err := assemblyai.CheckRegion(region); err != nil {
+		return err
+	}
+	if provider == providerStreaming {
+		return nil
+	}
+	if err := assemblyai.CheckModel(region, speechModel); err != nil {
+		return fmt.Errorf("%v (--model, or 'sona config set defaults.model')", err)
+	}
+	return nil
+}
+
+// outputPathsFor maps each format to the file it is written to.
+// An explicit path is used as-is for a single format; with several formats
+// its extension is replaced per format.
+func outputPathsFor(basePath string, explicit bool, formats []string) map[string]string {
+	paths := make(map[string]string, len(formats))
+
+	if explicit && len(formats) == 1 {
+		paths[formats[0]] = basePath
+		return paths
+	}
+
+	ext := filepath.Ext(basePath)
+	if _, known := outputFormats[strings.TrimPrefix(strings.ToLower(ext), ".")]; known {
+		basePath = strings.TrimSuffix(basePath, ext)
+	}
+
+	for _, format := range formats {
+		ext, ok := formatExtensions[format]
+		if !ok {
+			ext = format
+		}
+		paths[format] = basePath + "." + ext
+	}
+	return paths
+}
+
+// variantPath inserts a variant name before the extension, e.g.
+// "talk.txt" with "corrected" becomes "talk.corrected.txt"
+func variantPath(path string, variant string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + variant + ext
+}
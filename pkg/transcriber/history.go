@@ -0,0 +1,46 @@
+package transcriber
+
+import (
+	"os"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/history"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// currentRun is the history entry of the running transcribe command,
+// recorded once it is known how it ended
+var currentRun *history.Entry
+
+// startRun begins the history entry of this transcribe command with its
+// command line as typed, before aliases were expanded
+func startRun(sources []sourceSpec) {
+	dir, _ := os.Getwd()
+	currentRun = &history.Entry{
+		Time:   time.Now(),
+		Dir:    dir,
+		Args:   os.Args[1:],
+		Model:  transcribeOptions.SpeechModel,
+		Output: transcribeOptions.OutputPath,
+	}
+	for _, spec := range sources {
+		currentRun.Sources = append(currentRun.Sources, spec.Source)
+	}
+}
+
+// finishRun records the running transcribe command in the history
+func finishRun(ok bool, result string) {
+	if currentRun == nil {
+		return
+	}
+	currentRun.OK, currentRun.Result = ok, result
+	addToHistory(*currentRun)
+	currentRun = nil
+}
+
+func addToHistory(entry history.Entry) {
+	if err := history.Add(entry, config.GetHistorySize()); err != nil {
+		logger.LogWarning("Failed to record run in history: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/usage"
+)
+
+// providerHybrid transcribes locally with whisper.cpp and sends only the
+// segments it is unsure about to AssemblyAI
+const providerHybrid = "hybrid"
+
+// hybridStretch is a run of consecutive low-confidence local segments,
+// transcribed again by AssemblyAI
+type hybridStretch struct {
+	// First and Last index the segments the stretch replaces
+	First int
+	Last  int
+	Start time.Duration
+	End   time.Duration
+}
+
+// hybridTranscription transcribes the audio with whisper.cpp, then sends
+// every stretch of segments below hybrid.min_confidence to AssemblyAI and
+// puts its transcript in their place. Only the audio sent to AssemblyAI
+// counts towards usage.
+func hybridTranscription(audioPath string, speechModel string, languageCode string, profile outputProfile, timings *progress.Timings) (*transcript.Transcript, error) {
+	fmt.Println("Transcribing locally with whisper.cpp...")
+	timings.Begin("local")
+	spinner := newSpinner(timings)
+	spinner.Start()
+	spinner.SetPhase("Transcribing locally", 0)
+	segments, detected, err := localTranscription(audioPath, languageCode)
+	spinner.Stop()
+	timings.End()
+	if err != nil {
+		return nil, err
+	}
+
+	threshold := config.GetHybridConfidence()
+	stretches := lowConfidenceStretches(segments, threshold)
+	var chunks []audioChunk
+	var sent time.Duration
+	for i, stretch := range stretches {
+		path, err := cutAudio(audioPath, fmt.Sprintf("stretch-%03d.mp3", i), stretch.Start, stretch.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cut stretch %d: %v", i+1, err)
+		}
+		chunks = append(chunks, audioChunk{
+			Name:     fmt.Sprintf("stretch %d", i+1),
+			Path:     path,
+			Start:    stretch.Start,
+			End:      stretch.End,
+			Model:    speechModel,
+			Language: detected,
+		})
+		sent += stretch.End - stretch.Start
+	}
+
+	duration := audioDurationOrZero(audioPath)
+	if len(chunks) == 0 {
+		fmt.Printf("Every segment is above confidence %g; nothing sent to AssemblyAI\n", threshold)
+	} else {
+		fmt.Printf("Sending %d low-confidence stretches (%s) to AssemblyAI...\n", len(chunks), formatTimestamp(sent))
+	}
+	results, err := transcribeChunks(chunks, profile, timings, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if duration > 0 {
+		fmt.Printf("AssemblyAI transcribed %s of %s (%.0f%%)\n", formatTimestamp(sent), formatTimestamp(duration), 100*sent.Seconds()/duration.Seconds())
+	}
+	logger.LogInfo("Hybrid transcription of %s: %d of %d segments sent to AssemblyAI", audioPath, countReplaced(stretches), len(segments))
+	if err := usage.Add(sent); err != nil {
+		logger.LogWarning("Failed to record usage: %v", err)
+	}
+
+	merged := mergeHybrid(segments, stretches, results)
+	merged.LanguageCode = detected
+	merged.Duration = duration.Seconds()
+	merged.LocalSeconds = max(0, (duration - sent).Seconds())
+	return merged, nil
+}
+
+// lowConfidenceStretches groups consecutive segments below the threshold
+func lowConfidenceStretches(segments []whisperSegment, threshold float64) []hybridStretch {
+	var stretches []hybridStretch
+	for i, segment := range segments {
+		if segment.confidence() >= threshold {
+			continue
+		}
+		start := time.Duration(segment.Start) * time.Millisecond
+		end := time.Duration(segment.End) * time.Millisecond
+		if n := len(stretches); n > 0 && stretches[n-1].Last == i-1 {
+			stretches[n-1].Last = i
+			stretches[n-1].End = end
+			continue
+		}
+		stretches = append(stretches, hybridStretch{First: i, Last: i, Start: start, End: end})
+	}
+	return stretches
+}
+
+// countReplaced counts the segments the stretches replace
+func countReplaced(stretches []hybridStretch) int {
+	var n int
+	for _, stretch := range stretches {
+		n += stretch.Last - stretch.First + 1
+	}
+	return n
+}
+
+// mergeHybrid keeps the confident local segments and puts AssemblyAI's
+// transcript of each stretch in place of the segments it covers. Each
+// segment and stretch becomes an utterance without a speaker, so timestamps
+// still work while speaker labels are left out.
+func mergeHybrid(segments []whisperSegment, stretches []hybridStretch, results []*transcript.Transcript) *transcript.Transcript {
+	merged := &transcript.Transcript{}
+	var texts []string
+	add := func(text string, start int64, end int64, words []transcript.Word) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		texts = append(texts, text)
+		merged.Segments = append(merged.Segments, transcript.Segment{Text: text, Start: start, End: end})
+		merged.Words = append(merged.Words, words...)
+	}
+
+	next := 0
+	for i := 0; i < len(segments); i++ {
+		if next < len(stretches) && stretches[next].First == i {
+			stretch := stretches[next]
+			offset := stretch.Start.Milliseconds()
+			words := make([]transcript.Word, len(results[next].Words))
+			for j, word := range results[next].Words {
+				word.Start += offset
+				word.End += offset
+				word.Speaker = ""
+				words[j] = word
+			}
+			add(results[next].Text, offset, stretch.End.Milliseconds(), words)
+			merged.APIKey = results[next].APIKey
+			i = stretch.Last
+			next++
+			continue
+		}
+		add(segments[i].Text, segments[i].Start, segments[i].End, segments[i].Words)
+	}
+
+	merged.Text = strings.Join(texts, " ")
+	return merged
+}
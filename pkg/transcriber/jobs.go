@@ -0,0 +1,202 @@
+package transcriber
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/fingerprint"
+	"github.com/Harsh-2002/Sona/pkg/history"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeJob     bool
+	deleteRemote bool
+	deleteYes    bool
+)
+
+var JobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Manage finished and queued transcription jobs",
+}
+
+var jobsDeleteCmd = &cobra.Command{
+	Use:   "delete <name|queue-id|transcript-id>",
+	Short: "Delete a transcription job and, with --purge, every trace of it",
+	Long: `Delete a transcript from the library, as listed by 'sona list', or a job
+still waiting in the queue. The transcript files are kept unless --purge is
+given, which also removes them, the audio kept by archive.audio, the audio
+fingerprint and the runs in 'sona history' that transcribed the source.
+
+With --remote the transcript and its uploaded audio are also deleted from
+AssemblyAI. An AssemblyAI transcript ID can be given instead of a name to
+delete only the remote transcript.`,
+	Example: `  sona jobs delete wrong-file-20250101 --purge
+  sona jobs delete wrong-file --purge --remote --yes
+  sona jobs delete 5551722f-f677-48a6-9287-39c0aafd9ac1 --remote`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := deleteJob(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	JobsCmd.AddCommand(jobsDeleteCmd)
+	jobsDeleteCmd.Flags().BoolVar(&purgeJob, "purge", false, "Also remove the transcript files, kept audio, fingerprint and history")
+	jobsDeleteCmd.Flags().BoolVar(&deleteRemote, "remote", false, "Also delete the transcript and uploaded audio from AssemblyAI")
+	jobsDeleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Do not ask for confirmation")
+}
+
+func deleteJob(query string) error {
+	record, err := library.Find(query)
+	if err != nil {
+		if removed, queueErr := removeQueued(query); removed || queueErr != nil {
+			return queueErr
+		}
+		if transcriptIDPattern.MatchString(query) && deleteRemote {
+			return deleteRemoteTranscript(query, "", config.GetRegion())
+		}
+		return err
+	}
+
+	// Say exactly what goes before anything does
+	var paths []string
+	if purgeJob {
+		for _, file := range record.Files {
+			if _, err := os.Stat(file); err == nil {
+				paths = append(paths, file)
+			}
+		}
+		if _, err := os.Stat(record.Audio); record.Audio != "" && err == nil {
+			paths = append(paths, record.Audio)
+		}
+	}
+	fmt.Printf("Deleting %s (%s)\n", record.Name, record.Source)
+	for _, path := range paths {
+		fmt.Printf("  %s\n", path)
+	}
+	if deleteRemote {
+		if record.TranscriptID == "" {
+			fmt.Println(style.Warning("No AssemblyAI transcript ID was saved with %s; it cannot be deleted remotely", record.Name))
+		} else {
+			fmt.Printf("  AssemblyAI transcript %s\n", record.TranscriptID)
+		}
+	}
+	if !deleteYes && !confirmDelete() {
+		fmt.Println("Nothing deleted")
+		return nil
+	}
+
+	// The remote transcript goes first: without the record its ID is lost
+	if deleteRemote && record.TranscriptID != "" {
+		if err := deleteRemoteTranscript(record.TranscriptID, record.APIKey, record.Region); err != nil {
+			return err
+		}
+	}
+
+	var failed []string
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			failed = append(failed, path)
+			logger.LogError("Failed to remove %s: %v", path, err)
+		}
+	}
+	if purgeJob {
+		if err := fingerprint.Delete(record.Name); err != nil {
+			logger.LogWarning("%v", err)
+		}
+		if forgotten, err := history.Forget(record.Source); err != nil {
+			logger.LogWarning("Failed to update history: %v", err)
+		} else if forgotten > 0 {
+			fmt.Printf("Removed %d runs from the history\n", forgotten)
+		}
+	}
+	if err := library.Delete(record.Name); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s was deleted from the library, but these files could not be removed: %s", record.Name, strings.Join(failed, ", "))
+	}
+
+	fmt.Println(style.Success("Deleted %s", record.Name))
+	if !purgeJob && len(record.Files) > 0 {
+		fmt.Println(style.Hint("The transcript files were kept; use --purge to remove them too"))
+	}
+	return nil
+}
+
+// removeQueued removes a job waiting in the queue, reporting whether there
+// was one with the ID
+func removeQueued(id string) (bool, error) {
+	jobs, err := queue.Load()
+	if err != nil {
+		return false, err
+	}
+	for _, job := range jobs {
+		if job.ID == id {
+			if err := queue.Remove(id); err != nil {
+				return true, err
+			}
+			fmt.Println(style.Success("Removed queued job %s (%s)", id, job.Source))
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// deleteRemoteTranscript deletes a transcript from AssemblyAI in the region
+// it was made in, with the key that created it, known by its masked form, or
+// the configured keys in turn
+func deleteRemoteTranscript(transcriptID string, maskedKey string, region string) error {
+	client := newClient()
+	if region != "" {
+		client.Region = region
+	}
+	keys := append([]string{client.APIKey}, client.FallbackKeys...)
+	for _, key := range keys {
+		if maskedKey != "" && config.MaskAPIKey(key) == maskedKey {
+			keys = []string{key}
+			break
+		}
+	}
+
+	var err error
+	for _, key := range keys {
+		client.APIKey = key
+		if err = client.DeleteTranscript(transcriptID); err == nil {
+			fmt.Println(style.Success("Deleted AssemblyAI transcript %s", transcriptID))
+			return nil
+		}
+		// Another account's transcript is not found with this key
+		var providerErr *assemblyai.ProviderError
+		if !errors.As(err, &providerErr) || (providerErr.StatusCode != 401 && providerErr.StatusCode != 404) {
+			break
+		}
+	}
+	return err
+}
+
+// confirmDelete asks before deleting. Without a terminal nothing is
+// deleted unless --yes was given.
+func confirmDelete() bool {
+	if !canPrompt() {
+		fmt.Println(style.Hint("Use --yes to delete without confirmation"))
+		return false
+	}
+	fmt.Print("Delete? (y/n): ")
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+}
@@ -0,0 +1,78 @@
+package transcriber
+
+import (
+	"encoding/json"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// transcriptJSON is the json output: the transcript with the timing and
+// confidence of every word. Fields are named as in AssemblyAI's transcript
+// response, so tools written against the API can read it.
+type transcriptJSON struct {
+	ID     string `json:"id,omitempty"`
+	Source string `json:"source"`
+	// Text is the transcript as written to the other formats, after
+	// corrections; RawText is the provider's text when it differs
+	Text    string `json:"text"`
+	RawText string `json:"raw_text,omitempty"`
+	// Confidence is left out when neither the provider nor the words give one
+	Confidence         *float64 `json:"confidence,omitempty"`
+	LanguageCode       string   `json:"language_code,omitempty"`
+	LanguageConfidence float64  `json:"language_confidence,omitempty"`
+	// AudioDuration is in seconds; word and utterance times in milliseconds
+	AudioDuration float64                `json:"audio_duration,omitempty"`
+	Words         []transcript.Word      `json:"words"`
+	Utterances    []transcript.Segment   `json:"utterances,omitempty"`
+	Chapters      []transcript.Chapter   `json:"chapters,omitempty"`
+	Highlights    []transcript.Highlight `json:"highlights,omitempty"`
+	Entities      []transcript.Entity    `json:"entities,omitempty"`
+}
+
+// formatJSON writes the provider's transcript with its words, utterances
+// and confidence scores. Without a result, only the text is known.
+func formatJSON(text string, source string, result *transcript.Transcript) string {
+	out := transcriptJSON{Source: source, Text: text, Words: []transcript.Word{}}
+	if result != nil {
+		out.ID = result.ID
+		if result.Text != text {
+			out.RawText = result.Text
+		}
+		out.LanguageCode = result.LanguageCode
+		out.LanguageConfidence = result.LanguageConfidence
+		out.AudioDuration = result.Duration
+		if len(result.Words) > 0 {
+			out.Words = result.Words
+		}
+		out.Utterances = result.Segments
+		out.Chapters = result.Chapters
+		out.Highlights = result.Highlights
+		out.Entities = result.Entities
+		out.Confidence = transcriptConfidence(result)
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
+
+// transcriptConfidence returns the provider's confidence in the transcript,
+// or the mean word confidence when it reports none, e.g. for transcripts
+// merged from several requests
+func transcriptConfidence(result *transcript.Transcript) *float64 {
+	if result.Confidence > 0 {
+		confidence := result.Confidence
+		return &confidence
+	}
+	if len(result.Words) == 0 {
+		return nil
+	}
+	var total float64
+	for _, word := range result.Words {
+		total += word.Confidence
+	}
+	confidence := total / float64(len(result.Words))
+	return &confidence
+}
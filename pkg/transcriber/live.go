@@ -0,0 +1,191 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	liveInputFormat string
+	liveDevice      string
+	liveOutput      string
+	liveLanguage    string
+	captionsPath    string
+	captionLines    int
+	captionWidth    int
+)
+
+var LiveCmd = &cobra.Command{
+	Use:   "live",
+	Short: "Transcribe microphone audio live",
+	Long: `Transcribe audio from a microphone or other capture device as it is spoken.
+
+Partial results update in place and each finished sentence is appended to
+the transcript file immediately. Press Ctrl+C to stop.
+
+With --captions, the latest lines are continuously written to a plain text
+file that OBS's text source ("Read from file") can display as live captions.
+
+The capture device is read with ffmpeg. Defaults:
+  macOS    --input-format avfoundation --device ":0"
+  Linux    --input-format pulse --device default
+  Windows  --input-format dshow --device "audio=<name>" (required; list names with
+           ffmpeg -list_devices true -f dshow -i dummy)`,
+	Example: `  sona live
+  sona live --captions ~/obs/captions.txt --caption-lines 2
+  sona live --input-format alsa --device hw:1 --output standup.txt`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runLive(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	LiveCmd.Flags().StringVar(&liveInputFormat, "input-format", defaultCaptureFormat(), "ffmpeg input format of the capture device")
+	LiveCmd.Flags().StringVar(&liveDevice, "device", defaultCaptureDevice(), "Capture device to record from")
+	LiveCmd.Flags().StringVarP(&liveOutput, "output", "o", "", "Transcript file (default: live-<timestamp>.txt in the output directory)")
+	LiveCmd.Flags().StringVarP(&liveLanguage, "language", "l", "", "Language code, e.g. en, es (default: English)")
+	LiveCmd.Flags().StringVar(&captionsPath, "captions", "", "Continuously write the latest caption lines to this file")
+	LiveCmd.Flags().IntVar(&captionLines, "caption-lines", 2, "Number of caption lines kept in the captions file")
+	LiveCmd.Flags().IntVar(&captionWidth, "caption-width", 42, "Maximum characters per caption line")
+}
+
+// defaultCaptureFormat returns the ffmpeg capture input for the platform
+func defaultCaptureFormat() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "avfoundation"
+	case "windows":
+		return "dshow"
+	default:
+		return "pulse"
+	}
+}
+
+// defaultCaptureDevice returns the default microphone for the platform.
+// DirectShow has no default device, so Windows users must name one.
+func defaultCaptureDevice() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return ":0"
+	case "windows":
+		return ""
+	default:
+		return "default"
+	}
+}
+
+func runLive() error {
+	if liveDevice == "" {
+		return fmt.Errorf("no capture device given; pass --device (see 'sona live --help')")
+	}
+	if _, err := FindBinary("ffmpeg"); err != nil {
+		return &deps.MissingError{Binary: "ffmpeg", Message: "FFmpeg not found. Run 'sona install' to install dependencies"}
+	}
+
+	glossary, err := loadGlossary()
+	if err != nil {
+		return err
+	}
+
+	path := liveOutput
+	if path == "" {
+		dir := config.GetOutputPath()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %v", err)
+		}
+		path = filepath.Join(dir, "live-"+formatFilenameTimestamp(time.Now(), "20060102-150405")+".txt")
+	}
+	transcriptFile, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, config.GetOutputFileMode())
+	if err != nil {
+		return fmt.Errorf("failed to open transcript file: %v", err)
+	}
+	defer transcriptFile.Close()
+	if err := applyOutputPermissions(path); err != nil {
+		return err
+	}
+
+	var captions *captionFile
+	if captionsPath != "" {
+		if captions, err = newCaptionFile(captionsPath, captionLines, captionWidth); err != nil {
+			return err
+		}
+		fmt.Printf("Writing captions to: %s\n", captionsPath)
+	}
+
+	// Finished turns are already on disk, so an interrupt loses nothing
+	workspace.OnInterrupt(func() {
+		if captions != nil {
+			captions.Clear()
+		}
+		fmt.Printf("\nTranscript saved to: %s\n", path)
+	})
+
+	fmt.Printf("Listening on %s %s (Ctrl+C to stop)\n", liveInputFormat, liveDevice)
+	fmt.Printf("Transcript: %s\n\n", path)
+	logger.LogInfo("Live transcription from %s %s to %s", liveInputFormat, liveDevice, path)
+
+	display := progress.NewLiveLine()
+	err = streamFromFFmpeg([]string{"-f", liveInputFormat, "-i", liveDevice}, liveLanguage, func(turn assemblyai.Turn) {
+		text := strings.TrimSpace(turn.Transcript)
+		if glossary != nil {
+			text, _ = glossary.Apply(text)
+		}
+
+		if !turn.IsFinal() {
+			display.Update(text)
+			updateCaptions(captions, text, false)
+			return
+		}
+		if text == "" {
+			return
+		}
+		display.Commit(text)
+		updateCaptions(captions, text, true)
+		if _, err := fmt.Fprintln(transcriptFile, text); err != nil {
+			logger.LogWarning("Failed to write live transcript: %v", err)
+		}
+	})
+	display.Clear()
+	if captions != nil {
+		captions.Clear()
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Transcript saved to: %s\n", path)
+	return nil
+}
+
+// updateCaptions forwards text to the caption file, if one is in use
+func updateCaptions(captions *captionFile, text string, final bool) {
+	if captions == nil {
+		return
+	}
+	var err error
+	if final {
+		err = captions.Commit(text)
+	} else {
+		err = captions.Update(text)
+	}
+	if err != nil {
+		logger.LogWarning("%v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// lrcWordSync selects enhanced LRC with a timestamp before every word
+var lrcWordSync bool
+
+// formatLRC renders an LRC file with one timed line per utterance or
+// sentence. Transcripts without word timings (e.g. from the streaming
+// provider) are written as unsynced lyrics.
+func formatLRC(transcript string, source string, result *transcript.Transcript) string {
+	var b strings.Builder
+	title := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	fmt.Fprintf(&b, "[ti:%s]\n", title)
+	if result != nil && result.Duration > 0 {
+		length := time.Duration(result.Duration * float64(time.Second))
+		fmt.Fprintf(&b, "[length:%02d:%02d]\n", int(length.Minutes()), int(length.Seconds())%60)
+	}
+	b.WriteString("[re:sona]\n\n")
+
+	if result == nil || len(result.Words) == 0 {
+		for _, line := range strings.Split(strings.TrimSpace(transcript), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				b.WriteString(line)
+				b.WriteString("\n")
+			}
+		}
+		return b.String()
+	}
+
+	passages := timedPassages(result.Words, result.Segments)
+	for _, p := range passages {
+		fmt.Fprintf(&b, "[%s]", lrcTime(p.Start))
+		if lrcWordSync {
+			for _, word := range wordsBetween(result.Words, p.Start, p.End) {
+				fmt.Fprintf(&b, " <%s> %s", lrcTime(time.Duration(word.Start)*time.Millisecond), word.Text)
+			}
+			fmt.Fprintf(&b, " <%s>\n", lrcTime(p.End))
+		} else {
+			b.WriteString(strings.TrimSpace(p.Text))
+			b.WriteString("\n")
+		}
+	}
+	// Clear the last line once speech ends
+	if len(passages) > 0 && !lrcWordSync {
+		fmt.Fprintf(&b, "[%s]\n", lrcTime(passages[len(passages)-1].End))
+	}
+
+	return b.String()
+}
+
+// wordsBetween returns the words that lie within start and end
+func wordsBetween(words []transcript.Word, start time.Duration, end time.Duration) []transcript.Word {
+	var result []transcript.Word
+	for _, word := range words {
+		wordStart := time.Duration(word.Start) * time.Millisecond
+		if wordStart >= start && wordStart < end {
+			result = append(result, word)
+		}
+	}
+	return result
+}
+
+// lrcTime renders an offset as mm:ss.xx
+func lrcTime(d time.Duration) string {
+	hundredths := int(d / (10 * time.Millisecond))
+	return fmt.Sprintf("%02d:%02d.%02d", hundredths/6000, (hundredths/100)%60, hundredths%100)
+}
@@ -0,0 +1,252 @@
+package transcriber
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/ledger"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// processingRatio is the typical share of the audio duration AssemblyAI
+// needs to finish a transcript once processing has started, used until the
+// jobs ledger has enough history for the model
+const processingRatio = 0.3
+
+// minimumProcessingEstimate keeps estimates for short clips realistic
+const minimumProcessingEstimate = 15 * time.Second
+
+var durationPattern = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// probeAudioDuration reads the duration of a media file from ffmpeg's stream info
+func probeAudioDuration(path string) (time.Duration, error) {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return 0, err
+	}
+
+	// ffmpeg exits non-zero without an output file, but still prints the stream info
+	output, _ := logger.RunCommand(exec.Command(ffmpegPath, "-hide_banner", "-i", path))
+
+	return parseFFmpegDuration(output)
+}
+
+// parseFFmpegDuration extracts the "Duration: HH:MM:SS.ss" value from ffmpeg output
+func parseFFmpegDuration(output string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("duration not found in ffmpeg output")
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), nil
+}
+
+// estimateProcessingTime guesses how long the model takes to transcribe
+// audio of the given length, from the speed of its past jobs when known
+func estimateProcessingTime(audioDuration time.Duration, model string) time.Duration {
+	if audioDuration <= 0 {
+		return 0
+	}
+
+	ratio := processingRatio
+	entries, err := ledger.Load()
+	if err != nil {
+		logger.LogWarning("Could not read the jobs ledger: %v", err)
+	}
+	if learned, samples := ledger.Throughput(entries, model); learned > 0 {
+		logger.LogInfo("Estimating with %s's speed over %d past jobs: %.2fx the audio duration", model, samples, learned)
+		ratio = learned
+	}
+
+	estimate := time.Duration(float64(audioDuration) * ratio)
+	if estimate < minimumProcessingEstimate {
+		estimate = minimumProcessingEstimate
+	}
+	return estimate.Round(time.Second)
+}
+
+// audioDurationOrZero probes the duration and logs instead of failing,
+// since the duration only feeds progress estimates
+func audioDurationOrZero(path string) time.Duration {
+	duration, err := probeAudioDuration(path)
+	if err != nil {
+		logger.LogWarning("Could not determine audio duration for %s: %v", path, err)
+		return 0
+	}
+	logger.LogInfo("Audio duration: %s", duration)
+	return duration
+}
+
+// Byte rates used to estimate disk usage from audio duration
+const (
+	// downloadBytesPerSecond covers yt-dlp's source audio stream plus the extracted MP3
+	downloadBytesPerSecond = 2 * 320 * 1000 / 8
+	// unknownDownloadSize is assumed when the duration of a video cannot be determined
+	unknownDownloadSize = 500 * 1024 * 1024
+	// transcriptSpace is reserved in the output location for transcript files
+	transcriptSpace = 10 * 1024 * 1024
+)
+
+// estimateConversionSize estimates the size of the converted copy of a local file
+func estimateConversionSize(path string) int64 {
+	if duration := audioDurationOrZero(path); duration > 0 {
+		// Match the bitrate convertAudioToMP3 will choose for the source
+		params := defaultConversion
+		if stream, err := probeAudioStream(path); err == nil {
+			params = conversionFor(stream)
+		}
+		return int64(duration.Seconds() * float64(params.Bitrate*1000/8))
+	}
+
+	// Without a duration, assume the converted file is about as large as the input
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// estimateDownloadSize estimates the disk space needed to download a video's audio
+func estimateDownloadSize(url string) int64 {
+	duration, err := youtube.ProbeDuration(url)
+	if err != nil || duration <= 0 {
+		logger.LogWarning("Could not determine video duration, assuming %d bytes: %v", unknownDownloadSize, err)
+		return unknownDownloadSize
+	}
+	return int64(duration.Seconds() * downloadBytesPerSecond)
+}
+
+// outputDirFor returns the directory transcripts will be written to
+func outputDirFor(outputPath string) string {
+	if outputPath != "" {
+		return filepath.Dir(outputPath)
+	}
+	return config.GetOutputPath()
+}
+
+// silenceThresholdDB is the peak level below which audio is treated as silent
+const silenceThresholdDB = -60.0
+
+var maxVolumePattern = regexp.MustCompile(`max_volume: (-?\d+(?:\.\d+)?|-inf) dB`)
+
+// isSilentAudio reports whether the loudest sample of the file stays below the silence threshold
+func isSilentAudio(path string) (bool, error) {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return false, err
+	}
+
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-i", path,
+		"-af", "volumedetect", "-vn", "-sn", "-dn", "-f", "null", "-")
+	output, err := logger.RunCommand(cmd)
+	if err != nil {
+		return false, fmt.Errorf("volume analysis failed: %v", err)
+	}
+
+	match := maxVolumePattern.FindStringSubmatch(output)
+	if match == nil {
+		return false, fmt.Errorf("max_volume not found in ffmpeg output")
+	}
+	if match[1] == "-inf" {
+		return true, nil
+	}
+
+	maxVolume, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid max_volume %q: %v", match[1], err)
+	}
+
+	logger.LogInfo("Peak volume of %s: %.1f dB", path, maxVolume)
+	return maxVolume <= silenceThresholdDB, nil
+}
+
+// pause is a stretch of silence in the audio
+type pause struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+var (
+	silenceStartPattern = regexp.MustCompile(`silence_start: (-?\d+(?:\.\d+)?)`)
+	silenceEndPattern   = regexp.MustCompile(`silence_end: (\d+(?:\.\d+)?)`)
+)
+
+// detectPauses lists the pauses quieter than noiseDB and longer than minLength
+// using ffmpeg's silencedetect filter
+func detectPauses(path string, noiseDB float64, minLength time.Duration) ([]pause, error) {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	filter := fmt.Sprintf("silencedetect=noise=%gdB:d=%g", noiseDB, minLength.Seconds())
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-i", path, "-af", filter, "-vn", "-sn", "-dn", "-f", "null", "-")
+	output, err := logger.RunCommand(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("silence detection failed: %v", err)
+	}
+
+	return parsePauses(output), nil
+}
+
+// parsePauses pairs silencedetect's start and end lines. A pause still open
+// at the end of the file is dropped.
+func parsePauses(output string) []pause {
+	starts := silenceStartPattern.FindAllStringSubmatch(output, -1)
+	ends := silenceEndPattern.FindAllStringSubmatch(output, -1)
+
+	var pauses []pause
+	for i := 0; i < len(starts) && i < len(ends); i++ {
+		start, _ := strconv.ParseFloat(starts[i][1], 64)
+		end, _ := strconv.ParseFloat(ends[i][1], 64)
+		if start < 0 {
+			start = 0
+		}
+		pauses = append(pauses, pause{
+			Start: time.Duration(start * float64(time.Second)),
+			End:   time.Duration(end * float64(time.Second)),
+		})
+	}
+	return pauses
+}
+
+// decodePCM decodes the audio of a media file to 16-bit mono samples at the given rate
+func decodePCM(path string, sampleRate int) ([]int16, error) {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-hide_banner", "-loglevel", "error", "-i", path,
+		"-f", "s16le", "-acodec", "pcm_s16le", "-ar", strconv.Itoa(sampleRate), "-ac", "1", "pipe:1"}
+	cmd := exec.Command(ffmpegPath, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = logger.CommandOutput(&stderr)
+	err = cmd.Run()
+	logger.LogCommand(ffmpegPath, args, stderr.String(), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %v", err)
+	}
+
+	samples := make([]int16, stdout.Len()/2)
+	if err := binary.Read(&stdout, binary.LittleEndian, samples); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode audio: %v", err)
+	}
+	return samples, nil
+}
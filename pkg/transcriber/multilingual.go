@@ -0,0 +1,340 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Code-switched audio is first cut into windows of about languageWindowLength,
+// at the pause nearest to each boundary, and the language of each window is
+// detected. Consecutive windows in the same language are then joined and
+// transcribed as one segment in that language.
+const (
+	languageWindowLength = 15 * time.Second
+	languageWindowSlack  = 5 * time.Second
+	// languageDetectionModel is the model used to detect every language;
+	// slam-1 only understands English
+	languageDetectionModel = "best"
+	// minimumLanguageConfidence is the detection confidence below which a
+	// window takes the language of its neighbours, so a stray loanword or
+	// a short noisy window does not split a segment
+	minimumLanguageConfidence = 0.6
+)
+
+// multilingual transcribes code-switched audio segment by segment, tagging each with its language
+var multilingual bool
+
+// languageSegment is a transcribed stretch of the audio
+type languageSegment struct {
+	Start  time.Duration
+	Result *transcript.Transcript
+}
+
+// languageWindow is a short stretch of the audio with its detected language
+type languageWindow struct {
+	Start      time.Duration
+	End        time.Duration
+	Language   string
+	Confidence float64
+	// Result is the detection transcript, reused when the window forms a segment on its own
+	Result *transcript.Transcript
+}
+
+// multilingualTranscription detects the language of short windows of the
+// audio, splits it where the language changes and transcribes every
+// segment in its own language, with the model configured for that
+// language in multilingual.models when there is one. Windows and segments
+// are transcribed concurrently, and finished segments are written to
+// livePath in order while the rest are still processing.
+func multilingualTranscription(audioPath string, profile outputProfile, livePath string, timings *progress.Timings) (*transcript.Transcript, error) {
+	duration, err := probeAudioDuration(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio duration: %v", err)
+	}
+	pauses, err := detectPauses(audioPath, -35, 300*time.Millisecond)
+	if err != nil {
+		return nil, err
+	}
+	bounds := chunkBounds(duration, pauses, languageWindowLength, languageWindowSlack)
+
+	fmt.Printf("Detecting languages in %d windows...\n", len(bounds))
+	windows, err := detectWindowLanguages(audioPath, bounds, profile, timings)
+	if err != nil {
+		return nil, err
+	}
+	smoothLanguages(windows)
+
+	models := config.GetLanguageModels()
+	runs := languageRuns(windows)
+	segments := make([]languageSegment, len(runs))
+	var chunks []audioChunk
+	var chunkSegments []int
+	for i, run := range runs {
+		first, last := run[0], run[len(run)-1]
+		language := first.Language
+		model := languageDetectionModel
+		if mapped, ok := models[language]; ok {
+			model = mapped
+		}
+		logger.LogInfo("Language segment %d: %s from %s to %s", i+1, language, formatTimestamp(first.Start), formatTimestamp(last.End))
+		segments[i].Start = first.Start
+
+		// A lone window was already transcribed in its language by the detection pass
+		if len(run) == 1 && model == languageDetectionModel && first.Result != nil {
+			segments[i].Result = first.Result
+			continue
+		}
+
+		path, err := cutAudio(audioPath, fmt.Sprintf("segment-%03d.mp3", i), first.Start, last.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cut segment %d: %v", i+1, err)
+		}
+		chunks = append(chunks, audioChunk{
+			Name:     fmt.Sprintf("segment %d", i+1),
+			Path:     path,
+			Start:    first.Start,
+			End:      last.End,
+			Model:    model,
+			Language: language,
+		})
+		chunkSegments = append(chunkSegments, i)
+	}
+
+	live, err := newPartialTranscript(livePath)
+	if err != nil {
+		return nil, err
+	}
+	defer live.Close()
+	live.writeReady(segments)
+
+	fmt.Printf("Transcribing %d language segments...\n", len(runs))
+	_, err = transcribeChunks(chunks, profile, timings, func(n int, result *transcript.Transcript) {
+		result.LanguageCode = chunks[n].Language
+		segments[chunkSegments[n]].Result = result
+		live.writeReady(segments)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeLanguageSegments(segments)
+	merged.Duration = duration.Seconds()
+	return merged, nil
+}
+
+// detectWindowLanguages transcribes each window with language detection.
+// Silent windows are left out.
+func detectWindowLanguages(audioPath string, bounds []pause, profile outputProfile, timings *progress.Timings) ([]languageWindow, error) {
+	var chunks []audioChunk
+	for i, bound := range bounds {
+		path, err := cutAudio(audioPath, fmt.Sprintf("window-%03d.mp3", i), bound.Start, bound.End)
+		if err != nil {
+			return nil, fmt.Errorf("failed to cut window %d: %v", i+1, err)
+		}
+		if silent, err := isSilentAudio(path); err == nil && silent {
+			logger.LogInfo("Skipping silent window %d at %s", i+1, formatTimestamp(bound.Start))
+			continue
+		}
+		chunks = append(chunks, audioChunk{
+			Name:  fmt.Sprintf("window %d", i+1),
+			Path:  path,
+			Start: bound.Start,
+			End:   bound.End,
+			Model: languageDetectionModel,
+		})
+	}
+
+	results, err := transcribeChunks(chunks, profile, timings, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]languageWindow, len(chunks))
+	for i, chunk := range chunks {
+		result := results[i]
+		logger.LogInfo("Window %s detected as %s (confidence %.2f)", formatTimestamp(chunk.Start), result.LanguageCode, result.LanguageConfidence)
+		windows[i] = languageWindow{
+			Start:      chunk.Start,
+			End:        chunk.End,
+			Language:   result.LanguageCode,
+			Confidence: result.LanguageConfidence,
+			Result:     result,
+		}
+	}
+	return windows, nil
+}
+
+// partialTranscript writes finished segments to the live transcript in
+// order, so a long job leaves usable output behind as it goes
+type partialTranscript struct {
+	file    *os.File
+	written int
+}
+
+// newPartialTranscript creates the live transcript; an empty path writes nothing
+func newPartialTranscript(path string) (*partialTranscript, error) {
+	if path == "" {
+		return &partialTranscript{}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create live transcript: %v", err)
+	}
+	if err := applyOutputPermissions(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	fmt.Printf("Partial transcript: %s\n", path)
+	return &partialTranscript{file: file}, nil
+}
+
+// writeReady appends the segments finished since the last call, up to the
+// first one still processing
+func (p *partialTranscript) writeReady(segments []languageSegment) {
+	for p.written < len(segments) && segments[p.written].Result != nil {
+		result := segments[p.written].Result
+		p.written++
+		text := strings.TrimSpace(result.Text)
+		if p.file == nil || text == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(p.file, languageTag(result.LanguageCode)+text); err != nil {
+			logger.LogWarning("Failed to write live transcript: %v", err)
+		}
+	}
+}
+
+// Close closes the live transcript file
+func (p *partialTranscript) Close() {
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+// smoothLanguages gives uncertain windows the language of their neighbours
+// when those agree, or of the only neighbour at either end
+func smoothLanguages(windows []languageWindow) {
+	for i := range windows {
+		if windows[i].Confidence >= minimumLanguageConfidence {
+			continue
+		}
+		var neighbour string
+		switch {
+		case i > 0 && i < len(windows)-1:
+			if windows[i-1].Language == windows[i+1].Language {
+				neighbour = windows[i-1].Language
+			}
+		case i > 0:
+			neighbour = windows[i-1].Language
+		case i < len(windows)-1:
+			neighbour = windows[i+1].Language
+		}
+		if neighbour != "" && neighbour != windows[i].Language {
+			logger.LogInfo("Window at %s: %s detected with confidence %.2f, using %s", formatTimestamp(windows[i].Start), windows[i].Language, windows[i].Confidence, neighbour)
+			windows[i].Language = neighbour
+			// The detection transcript was in the wrong language
+			windows[i].Result = nil
+		}
+	}
+}
+
+// languageRuns groups consecutive windows in the same language
+func languageRuns(windows []languageWindow) [][]languageWindow {
+	var runs [][]languageWindow
+	for i, window := range windows {
+		if i > 0 && window.Language == windows[i-1].Language {
+			runs[len(runs)-1] = append(runs[len(runs)-1], window)
+			continue
+		}
+		runs = append(runs, []languageWindow{window})
+	}
+	return runs
+}
+
+// cutAudio writes the stretch from start to end of the audio to name next to it
+func cutAudio(audioPath string, name string, start time.Duration, end time.Duration) (string, error) {
+	path := filepath.Join(filepath.Dir(audioPath), name)
+	args := []string{"-hide_banner", "-y", "-ss", fmt.Sprintf("%.3f", start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", (end - start).Seconds()), "-i", audioPath, "-f", "mp3", path}
+	if err := runFFmpeg(args, ""); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// chunkBounds splits the audio into chunks of about length, moving each cut
+// to the middle of the nearest pause within slack
+func chunkBounds(duration time.Duration, pauses []pause, length time.Duration, slack time.Duration) []pause {
+	var chunks []pause
+	start := time.Duration(0)
+
+	for duration-start > length+slack {
+		target := start + length
+		cut := target
+		best := slack + 1
+		for _, p := range pauses {
+			middle := (p.Start + p.End) / 2
+			if distance := absDuration(middle - target); distance <= slack && distance < best {
+				cut, best = middle, distance
+			}
+		}
+		chunks = append(chunks, pause{Start: start, End: cut})
+		start = cut
+	}
+
+	return append(chunks, pause{Start: start, End: duration})
+}
+
+// mergeLanguageSegments joins the segment results into one on the original
+// timeline. Each segment forms one "[xx]" tagged line of text, and every
+// utterance is tagged with its segment's language. Speaker labels are
+// assigned per segment, so the same letter may not mean the same person
+// across segments.
+func mergeLanguageSegments(segments []languageSegment) *transcript.Transcript {
+	merged := &transcript.Transcript{}
+	var lines []string
+
+	for _, segment := range segments {
+		result := segment.Result
+		offset := segment.Start.Milliseconds()
+		if result.APIKey != "" {
+			merged.APIKey = result.APIKey
+		}
+		tag := languageTag(result.LanguageCode)
+
+		if text := strings.TrimSpace(result.Text); text != "" {
+			lines = append(lines, tag+text)
+		}
+
+		for _, word := range result.Words {
+			word.Start += offset
+			word.End += offset
+			merged.Words = append(merged.Words, word)
+		}
+		for _, utterance := range result.Segments {
+			utterance.Start += offset
+			utterance.End += offset
+			utterance.Text = tag + utterance.Text
+			merged.Segments = append(merged.Segments, utterance)
+		}
+	}
+
+	merged.Text = strings.Join(lines, "\n")
+	return merged
+}
+
+// languageTag renders the prefix for text in a detected language
+func languageTag(language string) string {
+	if language == "" {
+		return ""
+	}
+	return "[" + language + "] "
+}
@@ -0,0 +1,299 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Ways of handling music-only stretches of the audio. trim is skip limited
+// to the intro and outro.
+const (
+	musicMark   = "mark"
+	musicRemove = "remove"
+	musicSkip   = "skip"
+	musicTrim   = "trim"
+)
+
+// musicMarker stands in for music in marked transcripts
+const musicMarker = "[music]"
+
+// Music detection settings. Audio is analysed in 1s windows of 20ms
+// frames; speech leaves many frames well below the window's average energy
+// (between syllables and words), while music keeps a steady level.
+const (
+	musicSampleRate     = 8000
+	musicFrameSamples   = musicSampleRate / 50
+	musicWindowFrames   = 50
+	musicLowEnergyRatio = 0.2
+	// musicSilenceRMS (about -50 dBFS) keeps silence from counting as music
+	musicSilenceRMS = 100
+	// minMusicLength drops short stretches such as jingles between sentences
+	minMusicLength = 10 * time.Second
+	// musicEdgeWindow is how close to the start or end music must begin or
+	// finish to count as an intro or outro, leaving room for a cold open
+	musicEdgeWindow = 2 * time.Minute
+)
+
+// musicMode is how detected music is handled; empty leaves the audio as is
+var musicMode string
+
+// musicSegment is a stretch of the audio that holds music without speech
+type musicSegment struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// validateMusicMode rejects unknown --music values
+func validateMusicMode(mode string) error {
+	switch mode {
+	case "", musicMark, musicRemove, musicSkip, musicTrim:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --music %q (use %s, %s, %s or %s)", mode, musicMark, musicRemove, musicSkip, musicTrim)
+	}
+}
+
+// cutsMusic reports whether the mode cuts music out before upload, which
+// shortens the uploaded audio
+func cutsMusic(mode string) bool {
+	return mode == musicSkip || mode == musicTrim
+}
+
+// edgeMusic keeps the intro and outro: the first segment when it starts
+// within musicEdgeWindow of the beginning and the last when it ends within
+// musicEdgeWindow of the end
+func edgeMusic(segments []musicSegment, duration time.Duration) []musicSegment {
+	var edges []musicSegment
+	for i, segment := range segments {
+		intro := i == 0 && segment.Start <= musicEdgeWindow
+		outro := i == len(segments)-1 && duration-segment.End <= musicEdgeWindow
+		if intro || outro {
+			edges = append(edges, segment)
+		}
+	}
+	return edges
+}
+
+// detectMusic decodes the audio and returns the music segments the mode
+// handles, only the intro and outro for trim, and the audio's length
+func detectMusic(path string, mode string) ([]musicSegment, time.Duration, error) {
+	samples, err := decodePCM(path, musicSampleRate)
+	if err != nil {
+		return nil, 0, fmt.Errorf("music detection failed: %w", err)
+	}
+
+	duration := time.Duration(len(samples)) * time.Second / musicSampleRate
+	segments := classifyMusic(samples)
+	if mode == musicTrim {
+		segments = edgeMusic(segments, duration)
+	}
+	return segments, duration, nil
+}
+
+// classifyMusic finds the music segments in 8kHz mono samples
+func classifyMusic(samples []int16) []musicSegment {
+	windowSamples := musicFrameSamples * musicWindowFrames
+	windows := len(samples) / windowSamples
+	music := make([]bool, windows)
+
+	for w := 0; w < windows; w++ {
+		energies := make([]float64, musicWindowFrames)
+		var total float64
+		for f := range energies {
+			frame := samples[w*windowSamples+f*musicFrameSamples : w*windowSamples+(f+1)*musicFrameSamples]
+			for _, s := range frame {
+				energies[f] += float64(s) * float64(s)
+			}
+			energies[f] /= float64(len(frame))
+			total += energies[f]
+		}
+		mean := total / musicWindowFrames
+		if mean < musicSilenceRMS*musicSilenceRMS {
+			continue
+		}
+
+		low := 0
+		for _, energy := range energies {
+			if energy < mean/2 {
+				low++
+			}
+		}
+		music[w] = float64(low)/musicWindowFrames < musicLowEnergyRatio
+	}
+
+	// Smooth over five windows so single frames of speech-like audio in a
+	// song (or sustained notes in speech) do not split segments
+	smoothed := make([]bool, windows)
+	for w := range music {
+		votes, count := 0, 0
+		for n := w - 2; n <= w+2; n++ {
+			if n >= 0 && n < windows {
+				count++
+				if music[n] {
+					votes++
+				}
+			}
+		}
+		smoothed[w] = votes*2 > count
+	}
+
+	var segments []musicSegment
+	for w := 0; w < windows; {
+		if !smoothed[w] {
+			w++
+			continue
+		}
+		start := w
+		for w < windows && smoothed[w] {
+			w++
+		}
+		segment := musicSegment{Start: time.Duration(start) * time.Second, End: time.Duration(w) * time.Second}
+		if segment.End-segment.Start >= minMusicLength {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// totalMusic returns the combined length of the segments
+func totalMusic(segments []musicSegment) time.Duration {
+	var total time.Duration
+	for _, segment := range segments {
+		total += segment.End - segment.Start
+	}
+	return total
+}
+
+// cutMusic writes a copy of the audio without the music segments next to
+// it and returns the copy with the kept stretches of the original
+func cutMusic(audioPath string, segments []musicSegment, duration time.Duration) (string, []musicSegment, error) {
+	var kept []musicSegment
+	var conditions []string
+	cursor := time.Duration(0)
+	bounds := append(append([]musicSegment(nil), segments...), musicSegment{Start: duration, End: duration})
+	for _, segment := range bounds {
+		if segment.Start > cursor {
+			kept = append(kept, musicSegment{Start: cursor, End: segment.Start})
+			conditions = append(conditions, fmt.Sprintf("between(t,%.3f,%.3f)", cursor.Seconds(), segment.Start.Seconds()))
+		}
+		cursor = segment.End
+	}
+	if len(kept) == 0 {
+		return "", nil, fmt.Errorf("the audio is all music; nothing left to transcribe")
+	}
+
+	output := filepath.Join(filepath.Dir(audioPath), "without-music.mp3")
+	filter := fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", strings.Join(conditions, "+"))
+	if err := runFFmpeg([]string{"-hide_banner", "-y", "-i", audioPath, "-af", filter, "-f", "mp3", output}, ""); err != nil {
+		return "", nil, fmt.Errorf("failed to cut music: %w", err)
+	}
+	return output, kept, nil
+}
+
+// restoreTimings maps word and utterance times from the cut audio back onto
+// the original, given the stretches that were kept
+func restoreTimings(result *transcript.Transcript, kept []musicSegment) {
+	toOriginal := func(ms int64) int64 {
+		offset := time.Duration(ms) * time.Millisecond
+		for i, span := range kept {
+			length := span.End - span.Start
+			if offset < length || i == len(kept)-1 {
+				return (span.Start + offset).Milliseconds()
+			}
+			offset -= length
+		}
+		return ms
+	}
+
+	for i := range result.Words {
+		result.Words[i].Start = toOriginal(result.Words[i].Start)
+		result.Words[i].End = toOriginal(result.Words[i].End)
+	}
+	for i := range result.Segments {
+		result.Segments[i].Start = toOriginal(result.Segments[i].Start)
+		result.Segments[i].End = toOriginal(result.Segments[i].End)
+	}
+	for i := range result.Chapters {
+		result.Chapters[i].Start = toOriginal(result.Chapters[i].Start)
+		result.Chapters[i].End = toOriginal(result.Chapters[i].End)
+	}
+	for i := range result.Entities {
+		result.Entities[i].Start = toOriginal(result.Entities[i].Start)
+		result.Entities[i].End = toOriginal(result.Entities[i].End)
+	}
+}
+
+// applyMusic drops the words and utterances inside music segments, putting
+// a [music] marker in their place unless mode is remove. Transcripts
+// without word timings are left unchanged.
+func applyMusic(result *transcript.Transcript, segments []musicSegment, mode string) {
+	if len(segments) == 0 || len(result.Words) == 0 {
+		return
+	}
+	mark := mode != musicRemove
+
+	inMusic := func(start int64, end int64) bool {
+		middle := time.Duration((start+end)/2) * time.Millisecond
+		for _, segment := range segments {
+			if middle >= segment.Start && middle < segment.End {
+				return true
+			}
+		}
+		return false
+	}
+
+	var words []transcript.Word
+	var parts []string
+	next := 0
+	for _, word := range result.Words {
+		if inMusic(word.Start, word.End) {
+			continue
+		}
+		for ; next < len(segments) && segments[next].Start <= time.Duration(word.Start)*time.Millisecond; next++ {
+			if mark {
+				parts = append(parts, musicMarker)
+			}
+		}
+		words = append(words, word)
+		parts = append(parts, word.Text)
+	}
+	for ; next < len(segments) && mark; next++ {
+		parts = append(parts, musicMarker)
+	}
+	result.Words = words
+	result.Text = strings.Join(parts, " ")
+
+	if len(result.Segments) == 0 {
+		return
+	}
+	var utterances []transcript.Segment
+	next = 0
+	for _, utterance := range result.Segments {
+		if inMusic(utterance.Start, utterance.End) {
+			continue
+		}
+		for ; next < len(segments) && segments[next].Start <= time.Duration(utterance.Start)*time.Millisecond; next++ {
+			if mark {
+				utterances = append(utterances, musicUtterance(segments[next]))
+			}
+		}
+		utterances = append(utterances, utterance)
+	}
+	for ; next < len(segments) && mark; next++ {
+		utterances = append(utterances, musicUtterance(segments[next]))
+	}
+	result.Segments = utterances
+}
+
+// musicUtterance is the unattributed [music] line for a segment
+func musicUtterance(segment musicSegment) transcript.Segment {
+	return transcript.Segment{
+		Text:  musicMarker,
+		Start: segment.Start.Milliseconds(),
+		End:   segment.End.Milliseconds(),
+	}
+}
@@ -0,0 +1,71 @@
+package transcriber
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+)
+
+// defaultNameTemplate names transcripts as sona always has: title-date
+const defaultNameTemplate = "{title}-{date}"
+
+// nameTemplate lays out generated transcript filenames, from --name-template
+var nameTemplate string
+
+var (
+	namePlaceholderPattern = regexp.MustCompile(`\{[^{}]*\}`)
+	// repeatedSeparators are left behind by placeholders that came out empty
+	repeatedSeparators = regexp.MustCompile(`([-_.])[-_.]+`)
+)
+
+// namePlaceholders are the placeholders --name-template understands
+var namePlaceholders = map[string]bool{
+	"{title}":  true,
+	"{date}":   true,
+	"{preset}": true,
+}
+
+// generatedName names a transcript after its title in the layout of
+// --name-template. {date} is empty with --no-timestamp and {preset} without
+// --preset.
+func generatedName(title string) string {
+	template := nameTemplate
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	date := ""
+	if !noTimestamp {
+		date = formatFilenameTimestamp(time.Now(), config.GetTimestampFormat())
+	}
+
+	name := strings.NewReplacer("{title}", title, "{date}", date, "{preset}", presetName).Replace(template)
+	name = repeatedSeparators.ReplaceAllString(name, "$1")
+	name = strings.Trim(name, "-_. ")
+	if name == "" {
+		return title
+	}
+	return name
+}
+
+// validateNameTemplate rejects templates that could name every transcript
+// the same or write outside the output directory
+func validateNameTemplate(template string) error {
+	if template == "" {
+		return nil
+	}
+	for _, placeholder := range namePlaceholderPattern.FindAllString(template, -1) {
+		if !namePlaceholders[placeholder] {
+			return fmt.Errorf("unknown placeholder %s in --name-template (supported: {title}, {date}, {preset})", placeholder)
+		}
+	}
+	if !strings.Contains(template, "{title}") && !strings.Contains(template, "{date}") {
+		return fmt.Errorf("--name-template needs {title} or {date}, or every transcript gets the same name")
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("--name-template names a file; use --output or output.default_path for the directory")
+	}
+	return nil
+}
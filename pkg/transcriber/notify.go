@@ -0,0 +1,135 @@
+package transcriber
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/notify"
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+// webhookURL receives a JSON event when each job finishes, rendered with
+// the Go template at webhookTemplate when set
+var (
+	webhookURL      string
+	webhookTemplate string
+)
+
+// validateWebhook checks the webhook URL and parses its template, so a
+// broken template fails before any audio is transcribed. The template path
+// is made absolute so queued jobs find it from any directory.
+func validateWebhook() error {
+	if webhookURL == "" {
+		if webhookTemplate != "" {
+			return fmt.Errorf("--webhook-template needs --webhook")
+		}
+		return nil
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q (use an http or https URL)", webhookURL)
+	}
+	if webhookTemplate == "" {
+		return nil
+	}
+	if absPath, err := filepath.Abs(webhookTemplate); err == nil {
+		webhookTemplate = absPath
+	}
+	tmpl, err := notify.ParseTemplate(webhookTemplate)
+	if err != nil {
+		return err
+	}
+	// Rendering a sample catches unknown fields and output that is not JSON
+	_, err = notify.Payload(tmpl, notify.Event{Event: notify.EventCompleted, FinishedAt: time.Now()})
+	return err
+}
+
+// sendWebhook posts the event to --webhook. The job is done either way, so
+// failures are only reported.
+func sendWebhook(event notify.Event) {
+	if webhookURL == "" {
+		return
+	}
+
+	var tmpl *template.Template
+	if webhookTemplate != "" {
+		var err error
+		if tmpl, err = notify.ParseTemplate(webhookTemplate); err != nil {
+			fmt.Println(style.Warning("Webhook not sent: %v", err))
+			logger.LogWarning("Webhook for %s not sent: %v", event.Source, err)
+			return
+		}
+	}
+	if err := notify.Webhook(webhookURL, tmpl, event); err != nil {
+		fmt.Println(style.Warning("Webhook failed: %v", err))
+		logger.LogWarning("Webhook for %s failed: %v", event.Source, err)
+		return
+	}
+	logger.LogInfo("Sent %s webhook for %s", event.Event, event.Source)
+}
+
+// webhookCompleted reports a saved transcript to --webhook
+func webhookCompleted(record library.Record) {
+	sendWebhook(notify.Event{
+		Event:        notify.EventCompleted,
+		Source:       record.Source,
+		Name:         record.Name,
+		Files:        record.Files,
+		Duration:     record.Duration,
+		SpeechModel:  record.SpeechModel,
+		Region:       record.Region,
+		LanguageCode: record.LanguageCode,
+		Profile:      record.Profile,
+		Tags:         record.Tags,
+		Cost:         record.Cost,
+		Text:         record.Text,
+		FinishedAt:   time.Now(),
+	})
+}
+
+// webhookFailed reports a job that failed to --webhook
+func webhookFailed(source string, err error) {
+	if err == nil {
+		return
+	}
+	sendWebhook(notify.Event{
+		Event:      notify.EventFailed,
+		Source:     source,
+		Error:      err.Error(),
+		Profile:    profileName,
+		Tags:       library.NormalizeTags(tags),
+		FinishedAt: time.Now(),
+	})
+}
+
+// notifyFinished tells the user a run ended, through a desktop notification
+// and/or the terminal bell as requested. Failures to notify are only logged.
+func notifyFinished(success bool, message string) {
+	if ringBell {
+		notify.Bell()
+	}
+	if !notifyDesktop {
+		return
+	}
+
+	title := "Sona: transcription finished"
+	if !success {
+		title = "Sona: transcription failed"
+	}
+	if err := notify.Desktop(title, message); err != nil {
+		logger.LogWarning("Desktop notification failed: %v", err)
+	}
+}
+
+// completionMessage summarizes a successful run for the notification
+func completionMessage(sources []sourceSpec) string {
+	if len(sources) == 1 {
+		return fmt.Sprintf("Finished %s", sources[0].Source)
+	}
+	return fmt.Sprintf("Finished %d sources", len(sources))
+}
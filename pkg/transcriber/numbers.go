@@ -0,0 +1,139 @@
+package transcriber
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Number styles accepted by --numbers
+const (
+	numbersDefault = ""
+	numbersWords   = "words"
+	numbersDigits  = "digits"
+)
+
+var (
+	onesWords = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	tensWords  = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	scaleWords = []struct {
+		value int64
+		name  string
+	}{
+		{1_000_000_000_000, "trillion"},
+		{1_000_000_000, "billion"},
+		{1_000_000, "million"},
+		{1_000, "thousand"},
+	}
+
+	// numberPattern matches integers (optionally with thousands separators),
+	// decimals and a trailing percent sign
+	numberPattern = regexp.MustCompile(`\d{1,3}(?:,\d{3})+(?:\.\d+)?%?|\d+(?:\.\d+)?%?`)
+
+	sentenceStartPattern = regexp.MustCompile(`(^|[.!?]\s+)(\p{Ll})`)
+	pronounIPattern      = regexp.MustCompile(`\bi\b`)
+)
+
+// validateNumberStyle rejects unknown --numbers values
+func validateNumberStyle(style string) error {
+	switch style {
+	case numbersDefault, numbersWords, numbersDigits:
+		return nil
+	default:
+		return fmt.Errorf("unsupported number style %q (supported: %s, %s)", style, numbersWords, numbersDigits)
+	}
+}
+
+// withNumberStyle overrides the profile's number handling when a style is given
+func (p outputProfile) withNumberStyle(style string) outputProfile {
+	switch style {
+	case numbersWords:
+		p.VerbatimNumbers = true
+	case numbersDigits:
+		p.VerbatimNumbers = false
+	}
+	return p
+}
+
+// applyVerbatimNumbers makes a transcript requested without text formatting
+// consistent: numbers the provider still returned as digits are spelled out,
+// and sentence casing, which the provider drops along with formatting, is restored
+func applyVerbatimNumbers(result *transcript.Transcript) {
+	result.Text = verbatimText(result.Text)
+	for i := range result.Segments {
+		result.Segments[i].Text = verbatimText(result.Segments[i].Text)
+	}
+}
+
+func verbatimText(text string) string {
+	text = numberPattern.ReplaceAllStringFunc(text, spellNumberToken)
+	text = pronounIPattern.ReplaceAllString(text, "I")
+	return sentenceStartPattern.ReplaceAllStringFunc(text, func(match string) string {
+		r, size := utf8.DecodeLastRuneInString(match)
+		return match[:len(match)-size] + string(unicode.ToUpper(r))
+	})
+}
+
+// spellNumberToken spells out a matched number such as "1,250", "3.5" or "40%"
+func spellNumberToken(token string) string {
+	percent := strings.HasSuffix(token, "%")
+	token = strings.TrimSuffix(token, "%")
+	whole, fraction, _ := strings.Cut(strings.ReplaceAll(token, ",", ""), ".")
+
+	var words string
+	if n, err := strconv.ParseInt(whole, 10, 64); err == nil && n < 1_000_000_000_000_000 {
+		words = spellInteger(n)
+	} else {
+		words = spellDigits(whole)
+	}
+	if fraction != "" {
+		words += " point " + spellDigits(fraction)
+	}
+	if percent {
+		words += " percent"
+	}
+	return words
+}
+
+// spellInteger spells out a non-negative integer, e.g. 1205 -> "one thousand two hundred five"
+func spellInteger(n int64) string {
+	if n < 20 {
+		return onesWords[n]
+	}
+
+	var parts []string
+	for _, scale := range scaleWords {
+		if n >= scale.value {
+			parts = append(parts, spellInteger(n/scale.value), scale.name)
+			n %= scale.value
+		}
+	}
+	if n >= 100 {
+		parts = append(parts, onesWords[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n >= 20 && n%10 != 0:
+		parts = append(parts, tensWords[n/10]+"-"+onesWords[n%10])
+	case n >= 20:
+		parts = append(parts, tensWords[n/10])
+	case n > 0:
+		parts = append(parts, onesWords[n])
+	}
+	return strings.Join(parts, " ")
+}
+
+// spellDigits reads a digit string one digit at a time
+func spellDigits(digits string) string {
+	words := make([]string, 0, len(digits))
+	for _, d := range digits {
+		words = append(words, onesWords[d-'0'])
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,61 @@
+package transcriber
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+)
+
+// audioStart and audioEnd limit transcription to part of the audio. The
+// whole file is still uploaded; AssemblyAI skips the rest, and word timings
+// stay relative to the start of the file. Zero means the start or the end.
+var (
+	audioStart time.Duration
+	audioEnd   time.Duration
+)
+
+// speechThreshold makes AssemblyAI reject audio in which less than this
+// share (0-1) is speech, such as music or noise; 0 accepts everything
+var speechThreshold float64
+
+// validateAudioRange checks --start and --end
+func validateAudioRange(start time.Duration, end time.Duration) error {
+	if start < 0 || end < 0 {
+		return fmt.Errorf("--start and --end cannot be negative")
+	}
+	if end > 0 && end <= start {
+		return fmt.Errorf("--end (%s) must be after --start (%s)", end, start)
+	}
+	return nil
+}
+
+// validateSpeechThreshold checks --speech-threshold
+func validateSpeechThreshold(threshold float64) error {
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("--speech-threshold must be between 0 and 1, got %g", threshold)
+	}
+	return nil
+}
+
+// applyAudioRange adds the requested range and speech threshold to the request
+func applyAudioRange(request *assemblyai.TranscriptionRequest) {
+	request.AudioStartFrom = audioStart.Milliseconds()
+	request.AudioEndAt = audioEnd.Milliseconds()
+	request.SpeechThreshold = speechThreshold
+}
+
+// requestedAudio returns how much of audio of the given length falls in
+// the requested range, for estimates. It fails when --start is past the end.
+func requestedAudio(duration time.Duration) (time.Duration, error) {
+	if duration <= 0 {
+		return 0, nil
+	}
+	if audioStart >= duration {
+		return 0, fmt.Errorf("--start %s is past the end of the audio (%s)", audioStart, formatTimestamp(duration))
+	}
+	if audioEnd > 0 && audioEnd < duration {
+		duration = audioEnd
+	}
+	return duration - audioStart, nil
+}
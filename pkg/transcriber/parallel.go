@@ -0,0 +1,61 @@
+package transcriber
+
+import (
+	"fmt"
+	"sync"
+)
+
+// maxParallelJobs keeps --parallel within what AssemblyAI accepts from one
+// account without throttling uploads
+const maxParallelJobs = 16
+
+// parallelJobs is how many sources of a batch are processed at once
+var parallelJobs int
+
+// runningParallel is set while a batch runs several jobs at once, so no job
+// stops to ask a question another job's output would bury
+var runningParallel bool
+
+// batchMu guards the batch record, which every running job updates
+var batchMu sync.Mutex
+
+// validateParallel rejects job counts the worker pool cannot run
+func validateParallel(jobs int) error {
+	if jobs < 1 || jobs > maxParallelJobs {
+		return fmt.Errorf("--parallel must be between 1 and %d", maxParallelJobs)
+	}
+	return nil
+}
+
+// runEntries calls run for 0..count-1 in order, with up to --parallel calls
+// running at once. Later entries start as earlier ones finish, so the
+// priority order of the batch still decides what runs first.
+func runEntries(count int, run func(n int)) {
+	workers := min(parallelJobs, count)
+	if workers <= 1 {
+		for n := 0; n < count; n++ {
+			run(n)
+		}
+		return
+	}
+
+	runningParallel = true
+	defer func() { runningParallel = false }()
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				run(n)
+			}
+		}()
+	}
+	for n := 0; n < count; n++ {
+		next <- n
+	}
+	close(next)
+	wg.Wait()
+}
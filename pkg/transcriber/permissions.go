@@ -0,0 +1,42 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/config"
+)
+
+// writeOutput writes a file the user asked for, such as a transcript or
+// subtitles, with the permissions and group from the output.* config. The
+// file is replaced atomically, so a crash or a full disk never leaves a
+// truncated transcript behind.
+func writeOutput(path string, data []byte) error {
+	if err := atomicfile.WriteFile(path, data, config.GetOutputFileMode()); err != nil {
+		return err
+	}
+	return applyOutputPermissions(path)
+}
+
+// applyOutputPermissions sets output.file_mode and output.group on an output
+// file. The mode is set explicitly because the umask would strip group write
+// from files created directly.
+func applyOutputPermissions(path string) error {
+	if err := os.Chmod(path, config.GetOutputFileMode()); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %v", path, err)
+	}
+
+	group := config.GetOutputGroup()
+	gid, err := config.LookupGroupID(group)
+	if err != nil {
+		return err
+	}
+	if gid < 0 {
+		return nil
+	}
+	if err := os.Chown(path, -1, gid); err != nil {
+		return fmt.Errorf("failed to give %s to group %s: %v", path, group, err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+)
+
+// prefetchDepth is how many sources of a batch are downloaded or converted
+// ahead while the current one uploads and transcribes; 0 runs each source
+// from start to finish before the next
+var prefetchDepth int
+
+// preparedAudio is the download or conversion of a source started before
+// its turn
+type preparedAudio struct {
+	done chan struct{}
+	path string
+	// ws holds a converted local file until its job takes it over
+	ws  *workspace.Workspace
+	err error
+}
+
+var (
+	prefetchMu sync.Mutex
+	prefetched = make(map[string]*preparedAudio)
+)
+
+// onAudioReady is called once the running job has its audio, the point
+// from which it only needs the network to AssemblyAI; batches use it to
+// start preparing the next sources
+var onAudioReady func()
+
+// audioReady tells the batch that the running job has its audio
+func audioReady() {
+	if onAudioReady != nil {
+		onAudioReady()
+	}
+}
+
+// prefetchAfter starts preparing the prefetchDepth jobs after job i, shown
+// on the dashboard rows after row
+func prefetchAfter(jobs []queue.Job, i int, row int) {
+	for ahead := 1; ahead <= prefetchDepth && i+ahead < len(jobs); ahead++ {
+		prefetch(jobs[i+ahead].Source, row+ahead)
+	}
+}
+
+// prefetch downloads a YouTube source or converts a local one in the
+// background so it is ready when its turn comes. Sources that cannot be
+// prepared are left for their turn to report.
+func prefetch(source string, row int) {
+	isYouTube := youtube.IsYouTubeURL(source)
+	if !isYouTube {
+		if isURL(source) {
+			return
+		}
+		if _, err := os.Stat(source); err != nil {
+			return
+		}
+	}
+
+	prefetchMu.Lock()
+	defer prefetchMu.Unlock()
+	if _, ok := prefetched[source]; ok {
+		return
+	}
+	p := &preparedAudio{done: make(chan struct{})}
+	prefetched[source] = p
+
+	stage := "convert"
+	if isYouTube {
+		stage = "download"
+	}
+	if dashboard != nil {
+		dashboard.Stage(row, stage, 0)
+	}
+	logger.LogInfo("Preparing %s ahead of its turn", source)
+
+	go func() {
+		defer close(p.done)
+		if isYouTube {
+			p.path, p.err = youtube.DownloadAudio(source)
+			return
+		}
+		p.ws, p.err = workspace.New()
+		if p.err != nil {
+			return
+		}
+		if p.err = workspace.CheckFreeSpace(p.ws.Dir, estimateConversionSize(source), "audio conversion"); p.err == nil {
+			p.path, p.err = convertAudioToMP3(source, p.ws.Dir)
+		}
+	}()
+}
+
+// takePrefetched returns what was prepared for a source, waiting for it
+// when it is still being prepared, and whether anything was
+func takePrefetched(source string) (*preparedAudio, bool) {
+	prefetchMu.Lock()
+	p, ok := prefetched[source]
+	delete(prefetched, source)
+	prefetchMu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	<-p.done
+	return p, true
+}
+
+// downloadYouTubeAudio returns the downloaded audio of a video, fetched
+// ahead of time when it was prefetched. A failed prefetch is tried again,
+// resuming what it got.
+func downloadYouTubeAudio(url string) (string, error) {
+	if p, ok := takePrefetched(url); ok {
+		if p.err == nil {
+			return p.path, nil
+		}
+		logger.LogWarning("Prefetching %s failed, downloading again: %v", url, p.err)
+	}
+	return youtube.DownloadAudio(url)
+}
+
+// convertLocalAudio converts a local file to MP3 in ws, taking over the
+// conversion when it was prefetched
+func convertLocalAudio(filePath string, ws *workspace.Workspace) (string, error) {
+	if p, ok := takePrefetched(filePath); ok {
+		if p.ws != nil {
+			defer p.ws.Remove()
+		}
+		if p.err == nil && p.path == filePath {
+			// Uploaded unconverted, without FFmpeg
+			return filePath, nil
+		}
+		if p.err == nil {
+			// Move it into the job's workspace so it is removed with it
+			path := ws.Path(filepath.Base(p.path))
+			if err := os.Rename(p.path, path); err == nil {
+				return path, nil
+			}
+		}
+		logger.LogWarning("Using prefetched conversion of %s failed, converting again: %v", filePath, p.err)
+	}
+	return convertAudioToMP3(filePath, ws.Dir)
+}
+
+// discardPrefetched drops whatever was prepared for sources that never got
+// their turn, e.g. because they were deferred. Downloads stay in the cache
+// for a later run.
+func discardPrefetched() {
+	prefetchMu.Lock()
+	sources := make([]string, 0, len(prefetched))
+	for source := range prefetched {
+		sources = append(sources, source)
+	}
+	prefetchMu.Unlock()
+
+	for _, source := range sources {
+		if p, ok := takePrefetched(source); ok && p.ws != nil {
+			p.ws.Remove()
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/corrections"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/project"
+	"github.com/Harsh-2002/Sona/pkg/proofread"
+)
+
+// loadGlossary loads the corrections glossary, adding the vocabulary of the
+// --project to its terms
+func loadGlossary() (*corrections.Glossary, error) {
+	if noCorrections {
+		return nil, nil
+	}
+	glossary, err := loadGlossaryFile()
+	if err != nil || projectName == "" {
+		return glossary, err
+	}
+
+	p, err := project.Load(projectName)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Vocabulary) == 0 {
+		return glossary, nil
+	}
+	if glossary == nil {
+		glossary = &corrections.Glossary{}
+	}
+	glossary.AddTerms(p.Vocabulary)
+	logger.LogInfo("Added %d vocabulary terms of project %s", len(p.Vocabulary), p.Name)
+	return glossary, nil
+}
+
+// loadGlossaryFile loads the corrections glossary file. An explicitly chosen
+// file must exist; the default ~/.sona/corrections.yaml is optional.
+func loadGlossaryFile() (*corrections.Glossary, error) {
+	path := correctionsPath
+	explicit := path != ""
+	if !explicit {
+		path = config.GetCorrectionsFile()
+		explicit = path != ""
+	}
+	if !explicit {
+		defaultPath, err := corrections.DefaultPath()
+		if err != nil {
+			return nil, nil
+		}
+		path = defaultPath
+	}
+
+	glossary, err := corrections.Load(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load corrections from %s: %v", path, err)
+	}
+
+	logger.LogInfo("Loaded %d correction rules and %d terms from %s", len(glossary.Rules), len(glossary.Terms), path)
+	return glossary, nil
+}
+
+// glossaryChanges are the glossary corrections made to the last transcript,
+// until saveTranscript writes them to its change log
+var glossaryChanges []corrections.Change
+
+// postProcess applies the configured post-processing steps to a finished transcript
+func postProcess(transcript string) (string, error) {
+	glossaryChanges = nil
+	glossary, err := loadGlossary()
+	if err != nil {
+		return "", err
+	}
+
+	if glossary != nil {
+		transcript, glossaryChanges = glossary.Apply(transcript)
+		count := 0
+		for _, change := range glossaryChanges {
+			count += change.Count
+			logger.LogInfo("Corrected %q to %q %d times", change.From, change.To, change.Count)
+		}
+		if count > 0 {
+			fmt.Printf("Applied %d glossary corrections\n", count)
+		}
+	}
+
+	return transcript, nil
+}
+
+// changeLogPath returns where the glossary corrections made to a transcript
+// are listed
+func changeLogPath(transcriptPath string) string {
+	return strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".corrections.log"
+}
+
+// formatChangeLog lists each kind of glossary correction on its own line
+func formatChangeLog(changes []corrections.Change) string {
+	var b strings.Builder
+	for _, change := range changes {
+		fmt.Fprintf(&b, "%s -> %s (%dx)\n", change.From, change.To, change.Count)
+	}
+	return b.String()
+}
+
+// proofreadTranscript runs the transcript through the configured proofreading backend
+func proofreadTranscript(transcript string) (string, error) {
+	proofreader, err := proofread.New(proofread.Options{
+		Provider: config.GetProofreadProvider(),
+		URL:      config.GetProofreadURL(),
+		Language: config.GetProofreadLanguage(),
+		Model:    config.GetProofreadModel(),
+		APIKey:   config.GetProofreadAPIKey(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Println("Proofreading transcript...")
+	logger.LogInfo("Proofreading transcript with %s", config.GetProofreadProvider())
+	return proofreader.Proofread(transcript)
+}
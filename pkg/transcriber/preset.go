@@ -0,0 +1,176 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// presetName is the preset whose flags fill in those not given to transcribe
+var presetName string
+
+// unpresettable are flags that name a single run's sources or output, so a
+// preset cannot hold them
+var unpresettable = map[string]bool{
+	"preset":            true,
+	"output":            true,
+	"manifest":          true,
+	"batch-voice-notes": true,
+}
+
+var PresetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "Save and manage named sets of transcribe flags",
+	Long: `Presets save a set of 'sona transcribe' flags under a name, for workflows
+you run again and again. Flags given on the command line win over those of
+the preset, which win over the defaults.* config keys.
+
+The built-in presets meeting, podcast, lecture, call and interview are ready
+to use; saving a preset under one of their names replaces it.`,
+	Example: `  sona transcribe standup.mp3 --preset meeting
+  sona preset save meeting --model best --speakers-expected 4 --show-notes --format md
+  sona preset list
+  sona preset delete meeting`,
+}
+
+var presetSaveCmd = &cobra.Command{
+	Use:   "save <name> [transcribe flags]",
+	Short: "Save transcribe flags as a preset",
+	Long: `Save the given 'sona transcribe' flags under a name, replacing any preset
+with that name. Presets are stored in the config file under [presets.<name>].`,
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+			cmd.Help()
+			return
+		}
+		preset, err := parsePreset(args[1:])
+		if err == nil {
+			err = savePreset(args[0], preset)
+		}
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+var presetListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved and built-in presets with their flags",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, name := range config.PresetNames() {
+			preset, _ := config.GetPreset(name)
+			line := fmt.Sprintf("%-16s %s", name, config.FormatPreset(preset))
+			if config.IsBuiltinPreset(name) {
+				line += " (built-in)"
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+var presetDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a preset",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.DeletePreset(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if config.IsBuiltinPreset(args[0]) {
+			fmt.Printf("Preset %s deleted; the built-in %s preset applies again\n", args[0], args[0])
+		} else {
+			fmt.Printf("Preset %s deleted\n", args[0])
+		}
+	},
+}
+
+func init() {
+	PresetCmd.AddCommand(presetSaveCmd)
+	PresetCmd.AddCommand(presetListCmd)
+	PresetCmd.AddCommand(presetDeleteCmd)
+}
+
+// parsePreset reads transcribe flags into a preset. The flags are parsed
+// by the transcribe command itself, so a preset accepts exactly what
+// transcribe does.
+func parsePreset(args []string) (config.Preset, error) {
+	flags := TranscribeCmd.Flags()
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("a preset holds flags only, not sources: %v", flags.Args())
+	}
+
+	preset := make(config.Preset)
+	var err error
+	flags.Visit(func(flag *pflag.Flag) {
+		if unpresettable[flag.Name] {
+			err = fmt.Errorf("--%s cannot be saved in a preset", flag.Name)
+			return
+		}
+		if values, ok := flag.Value.(pflag.SliceValue); ok {
+			preset[flag.Name] = values.GetSlice()
+			return
+		}
+		preset[flag.Name] = []string{flag.Value.String()}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(preset) == 0 {
+		return nil, fmt.Errorf("no flags given; e.g. sona preset save meeting --model best --format md")
+	}
+	return preset, nil
+}
+
+// savePreset stores the preset after checking that the flags make sense together
+func savePreset(name string, preset config.Preset) error {
+	if err := config.ValidatePresetName(name); err != nil {
+		return err
+	}
+	if err := applyConfigDefaults(TranscribeCmd); err != nil {
+		return err
+	}
+	if err := config.SavePreset(name, preset); err != nil {
+		return err
+	}
+	fmt.Printf("Preset %s saved: %s\n", name, config.FormatPreset(preset))
+	return nil
+}
+
+// applyPreset sets the flags of --preset that were not given on the
+// command line, as if they had been
+func applyPreset(flags *pflag.FlagSet) error {
+	if presetName == "" {
+		return nil
+	}
+	preset, ok := config.GetPreset(presetName)
+	if !ok {
+		return fmt.Errorf("no preset named %s (see 'sona preset list')", presetName)
+	}
+
+	for name, values := range preset {
+		flag := flags.Lookup(name)
+		if flag == nil || unpresettable[name] {
+			return fmt.Errorf("preset %s has an unknown flag --%s", presetName, name)
+		}
+		if flag.Changed {
+			continue
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("preset %s: invalid --%s: %v", presetName, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package transcriber
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Speaker label styles used by profiles
+const (
+	labelNone  = ""
+	labelShort = "short" // "A: ..."
+	labelFull  = "full"  // "Speaker A: ..."
+	labelUpper = "upper" // "SPEAKER A: ..."
+)
+
+// timestampEveryUtterance stamps every utterance instead of at an interval
+const timestampEveryUtterance = -1
+
+// outputProfile bundles transcript options for a kind of work
+type outputProfile struct {
+	Description string
+	// Disfluencies keeps filler words like "um" and false starts
+	Disfluencies bool
+	// VerbatimNumbers keeps numbers as spoken instead of formatting them as digits
+	VerbatimNumbers bool
+	// LabelStyle selects how speakers are labeled; labelNone disables diarization
+	LabelStyle string
+	// TimestampInterval is the minimum gap between timestamps, 0 for none,
+	// or timestampEveryUtterance
+	TimestampInterval time.Duration
+	// SpeakersExpected is the number of speakers diarization should find, 0 to let it decide
+	SpeakersExpected int
+}
+
+// outputProfiles maps a profile name to its options
+var outputProfiles = map[string]outputProfile{
+	"legal": {
+		Description:       "verbatim record: filler words, spoken numbers, SPEAKER labels, a timestamp on every turn",
+		Disfluencies:      true,
+		VerbatimNumbers:   true,
+		LabelStyle:        labelUpper,
+		TimestampInterval: timestampEveryUtterance,
+	},
+	"broadcast": {
+		Description:       "clean read: formatted numbers, Speaker labels, a timestamp every 30 seconds",
+		LabelStyle:        labelFull,
+		TimestampInterval: 30 * time.Second,
+	},
+	"casual": {
+		Description: "light touch: formatted text with short speaker labels, no timestamps",
+		LabelStyle:  labelShort,
+	},
+}
+
+// profileNames returns the supported profile names in a stable order
+func profileNames() []string {
+	names := make([]string, 0, len(outputProfiles))
+	for name := range outputProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupProfile returns the named profile. An empty name selects the
+// plain default: formatted text without labels or timestamps.
+func lookupProfile(name string) (outputProfile, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return outputProfile{}, nil
+	}
+	p, ok := outputProfiles[name]
+	if !ok {
+		return outputProfile{}, fmt.Errorf("unknown profile %q (supported: %s)", name, strings.Join(profileNames(), ", "))
+	}
+	return p, nil
+}
+
+// apply sets the provider options the profile depends on
+func (p outputProfile) apply(request *assemblyai.TranscriptionRequest) {
+	request.Disfluencies = p.Disfluencies
+	request.SpeakerLabels = p.LabelStyle != labelNone
+	if request.SpeakerLabels {
+		request.SpeakersExpected = p.SpeakersExpected
+	}
+	if p.VerbatimNumbers {
+		formatText := false
+		request.FormatText = &formatText
+	}
+}
+
+// withSpeakers sets the expected number of speakers. Profiles without
+// speaker labels switch to full labels, since a count implies diarization.
+func (p outputProfile) withSpeakers(count int) outputProfile {
+	if count <= 0 {
+		return p
+	}
+	p.SpeakersExpected = count
+	if p.LabelStyle == labelNone {
+		p.LabelStyle = labelFull
+	}
+	return p
+}
+
+// validateSpeakersExpected checks the --speakers-expected value
+func validateSpeakersExpected(count int) error {
+	if count < 0 {
+		return fmt.Errorf("--speakers-expected must be a positive number of speakers")
+	}
+	return nil
+}
+
+// render turns a finished transcript into text in the profile's layout.
+// Without utterances (or without labels and timestamps) the plain text is used.
+func (p outputProfile) render(result *transcript.Transcript) string {
+	if len(result.Segments) == 0 || (p.LabelStyle == labelNone && p.TimestampInterval == 0) {
+		return result.Text
+	}
+
+	var b strings.Builder
+	lastStamp := time.Duration(-1)
+
+	for _, utterance := range result.Segments {
+		start := time.Duration(utterance.Start) * time.Millisecond
+
+		switch {
+		case p.TimestampInterval == timestampEveryUtterance:
+			fmt.Fprintf(&b, "[%s] ", formatTimestamp(start))
+		case p.TimestampInterval > 0 && (lastStamp < 0 || start-lastStamp >= p.TimestampInterval):
+			fmt.Fprintf(&b, "[%s] ", formatTimestamp(start))
+			lastStamp = start
+		}
+
+		// Lines such as [music] belong to no speaker
+		if label := speakerLabel(p.LabelStyle, utterance.Speaker); label != "" && utterance.Speaker != "" {
+			b.WriteString(label)
+			b.WriteString(": ")
+		}
+
+		b.WriteString(strings.TrimSpace(utterance.Text))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// speakerLabel renders a speaker name in the given style
+func speakerLabel(style string, speaker string) string {
+	switch style {
+	case labelShort:
+		return speaker
+	case labelFull:
+		return "Speaker " + speaker
+	case labelUpper:
+		return "SPEAKER " + strings.ToUpper(speaker)
+	default:
+		return ""
+	}
+}
+
+// formatTimestamp renders an offset as HH:MM:SS
+func formatTimestamp(d time.Duration) string {
+	total := int(d.Seconds())
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, (total/60)%60, total%60)
+}
@@ -0,0 +1,126 @@
+package transcriber
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Words below lowConfidence count as low-confidence words in the quality score
+const lowConfidence = 0.5
+
+// Ratings need a high mean confidence and few low-confidence words
+const (
+	goodConfidence = 0.85
+	goodLowShare   = 0.05
+	fairConfidence = 0.7
+	fairLowShare   = 0.15
+)
+
+// mostAccurateModel is suggested for transcripts that did not rate good
+const mostAccurateModel = "best"
+
+// The signal-to-noise ratio is measured over 50ms frames. Below noisySNR dB
+// the audio itself limits accuracy.
+const (
+	snrSampleRate   = 8000
+	snrFrameSamples = snrSampleRate / 20
+	noisySNR        = 10.0
+	// minimumNoisePower stands in for digitally silent frames, about -90 dBFS
+	minimumNoisePower = 1.0
+)
+
+// assessQuality scores a finished transcript from its word confidences and
+// the signal-to-noise ratio of the audio. It returns nil when the result has
+// no word confidences to score.
+func assessQuality(result *transcript.Transcript, audioPath string) *library.Quality {
+	if result == nil || len(result.Words) == 0 {
+		return nil
+	}
+
+	var total float64
+	var low int
+	for _, word := range result.Words {
+		total += word.Confidence
+		if word.Confidence < lowConfidence {
+			low++
+		}
+	}
+	quality := &library.Quality{
+		MeanConfidence: total / float64(len(result.Words)),
+		LowConfidence:  float64(low) / float64(len(result.Words)),
+	}
+	quality.Score = int(math.Round(100 * quality.MeanConfidence))
+
+	switch {
+	case quality.MeanConfidence >= goodConfidence && quality.LowConfidence <= goodLowShare:
+		quality.Rating = "good"
+	case quality.MeanConfidence >= fairConfidence && quality.LowConfidence <= fairLowShare:
+		quality.Rating = "fair"
+	default:
+		quality.Rating = "poor"
+	}
+
+	if audioPath == "" {
+		return quality
+	}
+	if snr, err := estimateSNR(audioPath); err != nil {
+		logger.LogWarning("Could not measure the signal-to-noise ratio of %s: %v", audioPath, err)
+	} else {
+		quality.SNR = &snr
+	}
+	return quality
+}
+
+// estimateSNR compares the loud and quiet stretches of the audio: the 90th
+// percentile of the power of 50ms frames stands for speech and the 10th for
+// the noise floor between words
+func estimateSNR(path string) (float64, error) {
+	samples, err := decodePCM(path, snrSampleRate)
+	if err != nil {
+		return 0, err
+	}
+
+	var powers []float64
+	for start := 0; start+snrFrameSamples <= len(samples); start += snrFrameSamples {
+		var sum float64
+		for _, sample := range samples[start : start+snrFrameSamples] {
+			sum += float64(sample) * float64(sample)
+		}
+		powers = append(powers, sum/snrFrameSamples)
+	}
+	if len(powers) < 10 {
+		return 0, fmt.Errorf("audio too short")
+	}
+
+	sort.Float64s(powers)
+	noise := math.Max(powers[len(powers)/10], minimumNoisePower)
+	signal := powers[len(powers)*9/10]
+	if signal <= noise {
+		return 0, nil
+	}
+	return 10 * math.Log10(signal/noise), nil
+}
+
+// printQuality shows the quality score and, for weak transcripts, whether a
+// re-run is worth it
+func printQuality(quality *library.Quality, model string) {
+	if quality == nil {
+		return
+	}
+
+	fmt.Printf("Quality: %s\n", quality.Summary())
+	if quality.Rating == "good" {
+		return
+	}
+	if quality.SNR != nil && *quality.SNR < noisySNR {
+		fmt.Println(style.Hint("The audio is noisy; cleaning it up will help more than another model"))
+	} else if model != mostAccurateModel {
+		fmt.Println(style.Hint("Re-running with --model %s may give a more accurate transcript", mostAccurateModel))
+	}
+}
@@ -0,0 +1,368 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/usage"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+	"github.com/spf13/cobra"
+)
+
+// connectivityTimeout bounds the check for whether the API is reachable
+const connectivityTimeout = 5 * time.Second
+
+var (
+	flushWait     bool
+	flushInterval time.Duration
+)
+
+var QueueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Manage transcriptions queued while offline",
+	Long: `Manage transcriptions queued with 'sona transcribe --queue'.
+
+Jobs are stored in ~/.sona/queue.json and submitted with 'sona queue flush',
+high priority jobs first. Use 'sona queue flush --wait' to keep waiting until
+the connection returns and to run jobs deferred by budget.daily_minutes when
+the next day begins.`,
+	Example: `  sona queue list
+  sona queue remove 3
+  sona queue flush
+  sona queue flush --wait --interval 5m`,
+}
+
+var queueListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List queued transcriptions",
+	Run: func(cmd *cobra.Command, args []string) {
+		jobs, err := queue.Load()
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		if len(jobs) == 0 {
+			fmt.Println("No queued transcriptions")
+			return
+		}
+
+		queue.SortByPriority(jobs)
+		for _, job := range jobs {
+			priority := ""
+			if job.Priority != "" && job.Priority != queue.PriorityNormal {
+				priority = "  [" + job.Priority + "]"
+			}
+			fmt.Printf("%s  %s  %s%s\n", job.ID, job.QueuedAt.Format("2006-01-02 15:04"), job.Source, priority)
+			if job.NotBefore.After(time.Now()) {
+				fmt.Printf("    deferred by the daily budget until %s\n", job.NotBefore.Format("2006-01-02 15:04"))
+			}
+			if job.LastError != "" {
+				fmt.Printf("    last attempt failed: %s\n", job.LastError)
+			}
+		}
+		if limit := config.GetDailyMinutes(); limit > 0 {
+			if used, err := usage.Today(); err == nil {
+				fmt.Printf("\nDaily budget: %.0f of %d minutes used today\n", used, limit)
+			}
+		}
+	},
+}
+
+var queueRemoveCmd = &cobra.Command{
+	Use:   "remove [id]",
+	Short: "Remove a queued transcription",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := queue.Remove(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+		fmt.Printf("Removed job %s\n", args[0])
+	},
+}
+
+var queueFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Submit queued transcriptions",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := flushQueue(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	QueueCmd.AddCommand(queueListCmd)
+	QueueCmd.AddCommand(queueRemoveCmd)
+	QueueCmd.AddCommand(queueFlushCmd)
+
+	queueFlushCmd.Flags().BoolVar(&flushWait, "wait", false, "Wait for connectivity instead of giving up when offline")
+	queueFlushCmd.Flags().DurationVar(&flushInterval, "interval", time.Minute, "How often to check connectivity with --wait")
+}
+
+// enqueueSources records the sources for later submission
+func enqueueSources(sources []sourceSpec) error {
+	for _, spec := range sources {
+		job, err := queue.Add(jobFor(spec))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Queued %s (job %s)\n", job.Source, job.ID)
+		logger.LogInfo("Queued job %s: %s", job.ID, job.Source)
+	}
+
+	fmt.Println("Run 'sona queue flush' once you are back online")
+	return nil
+}
+
+// jobFor captures a source with the current options so it can be run later
+func jobFor(spec sourceSpec) queue.Job {
+	source := spec.Source
+	// Local paths must still resolve when run from another directory
+	if !youtube.IsYouTubeURL(source) {
+		if absPath, err := filepath.Abs(source); err == nil {
+			source = absPath
+		}
+	}
+
+	priority := spec.Priority
+	if priority == "" {
+		priority = jobPriority
+	}
+
+	// The preset is not kept with the job, so its name goes into the template
+	return queue.Job{
+		Source:          source,
+		LanguageCode:    spec.LanguageCode,
+		SpeechModel:     transcribeOptions.SpeechModel,
+		OutputPath:      transcribeOptions.OutputPath,
+		Formats:         formats,
+		Profile:         profileName,
+		Numbers:         numberStyle,
+		MarkUncertain:   markThreshold,
+		Tags:            tags,
+		Speakers:        speakerCount,
+		LRCWords:        lrcWordSync,
+		ChunkTokens:     chunkTokens,
+		ChunkOverlap:    chunkOverlap,
+		ShowNotes:       showNotes,
+		Music:           musicMode,
+		Multilingual:    multilingual,
+		AudioStart:      audioStart,
+		AudioEnd:        audioEnd,
+		SpeechThresh:    speechThreshold,
+		Provider:        provider,
+		UploadCodec:     uploadCodec,
+		Proofread:       proofreadOutput,
+		Corrections:     correctionsPath,
+		NoCorrections:   noCorrections,
+		Project:         projectName,
+		AllowEmpty:      allowEmpty,
+		Duplicates:      allowDuplicate,
+		AutoUpgrade:     autoUpgrade,
+		NoTimestamp:     noTimestamp,
+		NameTemplate:    strings.ReplaceAll(nameTemplate, "{preset}", presetName),
+		Priority:        priority,
+		Budget:          monthlyBudget,
+		Webhook:         webhookURL,
+		WebhookTemplate: webhookTemplate,
+	}
+}
+
+// jobSources returns the source of each job
+func jobSources(jobs []queue.Job) []string {
+	sources := make([]string, len(jobs))
+	for i, job := range jobs {
+		sources[i] = job.Source
+	}
+	return sources
+}
+
+// flushQueue submits every queued job, highest priority first. Finished
+// jobs leave the queue; failed ones stay with their error so they can be
+// retried, and jobs over the daily budget stay until the next day. With
+// --wait it keeps running until only failed jobs remain.
+func flushQueue() error {
+	if err := assemblyai.CheckRegion(config.GetRegion()); err != nil {
+		return err
+	}
+	for {
+		jobs, err := queue.Load()
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			fmt.Println("No queued transcriptions")
+			return nil
+		}
+
+		for !reachable() {
+			if !flushWait {
+				return fmt.Errorf("AssemblyAI is not reachable; %d jobs remain queued (use --wait to retry until online)", len(jobs))
+			}
+			fmt.Printf("Offline, checking again in %s...\n", flushInterval)
+			time.Sleep(flushInterval)
+		}
+
+		if err := checkAndInstallDependencies(jobSources(jobs)); err != nil {
+			return fmt.Errorf("dependency check failed: %v", err)
+		}
+
+		remaining, interrupted := flushJobs(jobs)
+		if err := saveRemaining(remaining); err != nil {
+			return err
+		}
+		if !flushWait {
+			return nil
+		}
+		if interrupted {
+			continue
+		}
+
+		resume := nextResume(remaining)
+		if resume.IsZero() {
+			return nil
+		}
+		fmt.Printf("Waiting until %s to resume deferred jobs...\n", resume.Format("2006-01-02 15:04"))
+		time.Sleep(time.Until(resume))
+	}
+}
+
+// flushJobs runs the jobs that are due and returns those left in the queue.
+// It stops early, reporting interrupted, when the connection drops.
+func flushJobs(jobs []queue.Job) (remaining []queue.Job, interrupted bool) {
+	queue.SortByPriority(jobs)
+	for i, job := range jobs {
+		if job.NotBefore.After(time.Now()) {
+			remaining = append(remaining, job)
+			continue
+		}
+
+		fmt.Printf("\n[%d/%d] Job %s: %s\n", i+1, len(jobs), job.ID, job.Source)
+		if overBudget(job) {
+			job.NotBefore = usage.NextDay(time.Now())
+			remaining = append(remaining, job)
+			continue
+		}
+
+		err := runQueuedJob(job)
+		switch {
+		case err == nil:
+			logger.LogInfo("Queued job %s completed", job.ID)
+		case errors.Is(err, ErrNoSpeech) || errors.Is(err, ErrSilentAudio):
+			// Retrying cannot produce speech, so drop the job
+			fmt.Println(style.Warning("Job %s removed: %v", job.ID, err))
+			logger.LogWarning("Queued job %s removed: %v", job.ID, err)
+		default:
+			fmt.Println(style.Failure("Job %s failed: %v", job.ID, err))
+			logger.LogError("Queued job %s failed: %v", job.ID, err)
+			job.LastError = err.Error()
+			remaining = append(remaining, job)
+
+			// Stop early if the connection dropped again
+			if !reachable() {
+				fmt.Println("Connection lost, keeping the remaining jobs queued")
+				return append(remaining, jobs[i+1:]...), true
+			}
+		}
+	}
+	return remaining, false
+}
+
+// nextResume returns when the earliest deferred job becomes due, or the
+// zero time when no job is deferred
+func nextResume(jobs []queue.Job) time.Time {
+	var resume time.Time
+	for _, job := range jobs {
+		if job.NotBefore.After(time.Now()) && (resume.IsZero() || job.NotBefore.Before(resume)) {
+			resume = job.NotBefore
+		}
+	}
+	return resume
+}
+
+// runQueuedJob processes a job with the options it was queued (or first run) with
+func runQueuedJob(job queue.Job) error {
+	restoreJobOptions(job)
+	return processJob(job)
+}
+
+// restoreJobOptions sets the options the job was queued (or first run) with
+func restoreJobOptions(job queue.Job) {
+	formats = job.Formats
+	profileName = job.Profile
+	numberStyle = job.Numbers
+	markThreshold = job.MarkUncertain
+	tags = job.Tags
+	speakerCount = job.Speakers
+	lrcWordSync = job.LRCWords
+	chunkTokens = job.ChunkTokens
+	chunkOverlap = job.ChunkOverlap
+	showNotes = job.ShowNotes
+	musicMode = job.Music
+	multilingual = job.Multilingual
+	audioStart = job.AudioStart
+	audioEnd = job.AudioEnd
+	speechThreshold = job.SpeechThresh
+	uploadCodec = job.UploadCodec
+	proofreadOutput = job.Proofread
+	correctionsPath = job.Corrections
+	noCorrections = job.NoCorrections
+	projectName = job.Project
+	allowEmpty = job.AllowEmpty
+	allowDuplicate = job.Duplicates
+	autoUpgrade = job.AutoUpgrade
+	noTimestamp = job.NoTimestamp
+	nameTemplate = job.NameTemplate
+	jobPriority = job.Priority
+	monthlyBudget = job.Budget
+	webhookURL = job.Webhook
+	webhookTemplate = job.WebhookTemplate
+	// Jobs queued before the provider was recorded used the default
+	provider = job.Provider
+	if provider == "" {
+		provider = "assemblyai"
+	}
+}
+
+// processJob transcribes the source of a job with the options already set
+func processJob(job queue.Job) error {
+	return processSource(sourceSpec{Source: job.Source, LanguageCode: job.LanguageCode}, optionsFor(job))
+}
+
+// optionsFor returns the per-call options of a job
+func optionsFor(job queue.Job) Options {
+	return Options{
+		OutputPath:  job.OutputPath,
+		SpeechModel: job.SpeechModel,
+	}
+}
+
+func saveRemaining(remaining []queue.Job) error {
+	if err := queue.Save(remaining); err != nil {
+		return err
+	}
+	if len(remaining) > 0 {
+		fmt.Printf("\n%d jobs remain queued\n", len(remaining))
+	} else {
+		fmt.Println("\nQueue flushed")
+	}
+	return nil
+}
+
+// pendingQueueNotice reminds the user of jobs waiting in the queue
+func pendingQueueNotice() {
+	if jobs, err := queue.Load(); err == nil && len(jobs) > 0 {
+		fmt.Println(style.Info("%d queued transcriptions are waiting; run 'sona queue flush' to submit them", len(jobs)))
+	}
+}
@@ -0,0 +1,231 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/spf13/cobra"
+)
+
+var (
+	quoteKeywords []string
+	quoteContext  time.Duration
+	quoteFormat   string
+)
+
+var QuotesCmd = &cobra.Command{
+	Use:   "quotes [name]",
+	Short: "Extract quotes mentioning a keyword",
+	Long: `Find every passage of a saved transcript that mentions a keyword and print
+it with timestamps and the surrounding context, ready to paste into a report.`,
+	Example: `  sona quotes board-meeting --keyword pricing
+  sona quotes interview --keyword "road map" --keyword roadmap --context 20s
+  sona quotes call --keyword churn --format text`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runQuotes(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	QuotesCmd.Flags().StringArrayVarP(&quoteKeywords, "keyword", "k", nil, "Keyword or phrase to look for (repeatable)")
+	QuotesCmd.Flags().DurationVarP(&quoteContext, "context", "c", 10*time.Second, "Context to include before and after each match")
+	QuotesCmd.Flags().StringVarP(&quoteFormat, "format", "f", "md", "Output format: md or text")
+	QuotesCmd.MarkFlagRequired("keyword")
+}
+
+// passage is a timed piece of a transcript, one utterance or sentence
+type passage struct {
+	Speaker string
+	Text    string
+	Start   time.Duration
+	End     time.Duration
+}
+
+// quote is a run of passages around one or more matches
+type quote struct {
+	Passages []passage
+	// Matches marks the passages that contain a keyword
+	Matches map[int]bool
+}
+
+func runQuotes(name string) error {
+	if quoteFormat != "md" && quoteFormat != "text" {
+		return fmt.Errorf("unsupported format %q (use md or text)", quoteFormat)
+	}
+
+	record, err := library.Find(name)
+	if err != nil {
+		return err
+	}
+
+	passages := timedPassages(record.Words, record.Segments)
+	if len(passages) == 0 {
+		return fmt.Errorf("transcript %s has no timing data; quotes need a transcript saved by this version of sona", record.Name)
+	}
+
+	pattern, err := keywordPattern(quoteKeywords)
+	if err != nil {
+		return err
+	}
+
+	quotes := findQuotes(passages, pattern, quoteContext)
+	if len(quotes) == 0 {
+		fmt.Printf("No mentions of %s in %s\n", strings.Join(quoteKeywords, ", "), record.Name)
+		return nil
+	}
+
+	for i, q := range quotes {
+		if i > 0 {
+			fmt.Println()
+		}
+		if quoteFormat == "md" {
+			fmt.Print(formatQuoteMarkdown(q, pattern, record))
+		} else {
+			fmt.Print(formatQuoteText(q))
+		}
+	}
+	return nil
+}
+
+// timedPassages returns the utterances, or sentences built from the words
+// when the transcript was made without speaker labels
+func timedPassages(words []transcript.Word, utterances []transcript.Segment) []passage {
+	var passages []passage
+	if len(utterances) > 0 {
+		for _, u := range utterances {
+			passages = append(passages, passage{
+				Speaker: u.Speaker,
+				Text:    u.Text,
+				Start:   time.Duration(u.Start) * time.Millisecond,
+				End:     time.Duration(u.End) * time.Millisecond,
+			})
+		}
+		return passages
+	}
+	return sentencesFromWords(words)
+}
+
+// sentencesFromWords groups words into sentences at sentence punctuation
+func sentencesFromWords(words []transcript.Word) []passage {
+	var passages []passage
+	var current []string
+	var start int64
+
+	for i, word := range words {
+		if len(current) == 0 {
+			start = word.Start
+		}
+		current = append(current, word.Text)
+
+		if strings.HasSuffix(word.Text, ".") || strings.HasSuffix(word.Text, "?") || strings.HasSuffix(word.Text, "!") || len(current) >= maxSegmentWords || i == len(words)-1 {
+			passages = append(passages, passage{
+				Speaker: word.Speaker,
+				Text:    strings.Join(current, " "),
+				Start:   time.Duration(start) * time.Millisecond,
+				End:     time.Duration(word.End) * time.Millisecond,
+			})
+			current = nil
+		}
+	}
+	return passages
+}
+
+// keywordPattern matches any of the keywords case-insensitively on word boundaries
+func keywordPattern(keywords []string) (*regexp.Regexp, error) {
+	var alternatives []string
+	for _, keyword := range keywords {
+		keyword = strings.TrimSpace(keyword)
+		if keyword != "" {
+			alternatives = append(alternatives, regexp.QuoteMeta(keyword))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil, fmt.Errorf("at least one --keyword is required")
+	}
+	return regexp.Compile(`(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+}
+
+// findQuotes collects each matching passage with the passages within
+// context of it, merging quotes that overlap
+func findQuotes(passages []passage, pattern *regexp.Regexp, context time.Duration) []quote {
+	var quotes []quote
+	// offset is the index in passages of the current quote's first passage
+	offset := 0
+
+	for i, p := range passages {
+		if !pattern.MatchString(p.Text) {
+			continue
+		}
+
+		first := i
+		for first > 0 && passages[first-1].End >= p.Start-context {
+			first--
+		}
+		last := i
+		for last < len(passages)-1 && passages[last+1].Start <= p.End+context {
+			last++
+		}
+
+		// Extend the previous quote when the windows overlap
+		if n := len(quotes); n > 0 && first < offset+len(quotes[n-1].Passages) {
+			q := &quotes[n-1]
+			if end := offset + len(q.Passages); last >= end {
+				q.Passages = append(q.Passages, passages[end:last+1]...)
+			}
+			q.Matches[i-offset] = true
+			continue
+		}
+
+		quotes = append(quotes, quote{
+			Passages: append([]passage(nil), passages[first:last+1]...),
+			Matches:  map[int]bool{i - first: true},
+		})
+		offset = first
+	}
+	return quotes
+}
+
+func formatQuoteText(q quote) string {
+	var b strings.Builder
+	first, last := q.Passages[0], q.Passages[len(q.Passages)-1]
+	fmt.Fprintf(&b, "[%s - %s]\n", formatTimestamp(first.Start), formatTimestamp(last.End))
+	for _, p := range q.Passages {
+		b.WriteString("  ")
+		if p.Speaker != "" {
+			fmt.Fprintf(&b, "Speaker %s: ", p.Speaker)
+		}
+		b.WriteString(p.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func formatQuoteMarkdown(q quote, pattern *regexp.Regexp, record library.Record) string {
+	var b strings.Builder
+	first, last := q.Passages[0], q.Passages[len(q.Passages)-1]
+	fmt.Fprintf(&b, "### %s – %s\n\n", formatTimestamp(first.Start), formatTimestamp(last.End))
+	for i, p := range q.Passages {
+		text := p.Text
+		if q.Matches[i] {
+			text = pattern.ReplaceAllString(text, "**$0**")
+		}
+		b.WriteString("> ")
+		if p.Speaker != "" {
+			fmt.Fprintf(&b, "**Speaker %s:** ", p.Speaker)
+		}
+		b.WriteString(text)
+		b.WriteString("\n>\n")
+	}
+	fmt.Fprintf(&b, "> — *%s*, %s\n", record.Name, record.Source)
+	return b.String()
+}
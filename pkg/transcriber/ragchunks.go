@@ -0,0 +1,178 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// Defaults of --chunk-tokens and --chunk-overlap, sized for common
+// embedding models
+const (
+	defaultChunkTokens  = 512
+	defaultChunkOverlap = 64
+)
+
+var (
+	chunkTokens  int
+	chunkOverlap int
+)
+
+// textChunk is one line of chunks-jsonl output
+type textChunk struct {
+	// ID is stable across runs, so re-indexing a transcript replaces its chunks
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Chunk  int    `json:"chunk"`
+	Text   string `json:"text"`
+	// Start and End are in seconds; they are left out without word timings
+	Start        *float64 `json:"start,omitempty"`
+	End          *float64 `json:"end,omitempty"`
+	Speakers     []string `json:"speakers,omitempty"`
+	Tokens       int      `json:"tokens"`
+	LanguageCode string   `json:"language_code,omitempty"`
+	TranscriptID string   `json:"transcript_id,omitempty"`
+}
+
+// formatChunksJSONL splits the transcript into overlapping chunks of at most
+// --chunk-tokens tokens, one JSON object per line, ready to embed into a
+// vector database. Chunks end at a sentence where one ends in their second
+// half. Transcripts without word timings are chunked without timestamps.
+func formatChunksJSONL(transcript string, source string, result *transcript.Transcript) string {
+	words := chunkWords(transcript, result)
+	timed := result != nil && len(result.Words) > 0
+	name := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+
+	size, overlap := chunkLimits()
+
+	var b strings.Builder
+	for i, span := range chunkSpans(words, size, overlap) {
+		chunk := textChunk{
+			ID:     fmt.Sprintf("%s-%04d", name, i+1),
+			Source: source,
+			Chunk:  i + 1,
+		}
+		if result != nil {
+			chunk.LanguageCode = result.LanguageCode
+			chunk.TranscriptID = result.ID
+		}
+
+		var text []string
+		seen := make(map[string]bool)
+		for _, word := range words[span[0]:span[1]] {
+			text = append(text, word.Text)
+			chunk.Tokens += estimateTokens(word.Text)
+			if word.Speaker != "" && !seen[word.Speaker] {
+				seen[word.Speaker] = true
+				chunk.Speakers = append(chunk.Speakers, word.Speaker)
+			}
+		}
+		chunk.Text = strings.Join(text, " ")
+		if timed {
+			start := float64(words[span[0]].Start) / 1000
+			end := float64(words[span[1]-1].End) / 1000
+			chunk.Start, chunk.End = &start, &end
+		}
+
+		line, err := json.Marshal(chunk)
+		if err != nil {
+			continue
+		}
+		b.Write(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// chunkWords returns the timed words of the result, or the words of the
+// transcript text without timings
+func chunkWords(text string, result *transcript.Transcript) []transcript.Word {
+	if result != nil && len(result.Words) > 0 {
+		return result.Words
+	}
+	var words []transcript.Word
+	for _, field := range strings.Fields(text) {
+		words = append(words, transcript.Word{Text: field})
+	}
+	return words
+}
+
+// chunkLimits returns the chunk size and overlap in tokens, falling back to
+// the defaults for jobs queued before the flags existed
+func chunkLimits() (int, int) {
+	size, overlap := chunkTokens, chunkOverlap
+	if size <= 0 {
+		size = defaultChunkTokens
+	}
+	if overlap < 0 || overlap >= size/2 {
+		overlap = min(defaultChunkOverlap, size/4)
+	}
+	return size, overlap
+}
+
+// chunkSpans returns the [start, end) word ranges of the chunks. Each chunk
+// after the first repeats about overlap tokens of the one before it.
+func chunkSpans(words []transcript.Word, size int, overlap int) [][2]int {
+	var spans [][2]int
+	start := 0
+	for start < len(words) {
+		// Take words while they fit; a single word longer than a chunk
+		// becomes a chunk of its own
+		end, tokens := start, 0
+		for end < len(words) && (end == start || tokens+estimateTokens(words[end].Text) <= size) {
+			tokens += estimateTokens(words[end].Text)
+			end++
+		}
+
+		// End at the last sentence in the second half, if there is one
+		if end < len(words) {
+			for i, used := end, tokens; i > start+1 && used > size/2; i-- {
+				if endsSentence(words[i-1].Text) {
+					end = i
+					break
+				}
+				used -= estimateTokens(words[i-1].Text)
+			}
+		}
+		spans = append(spans, [2]int{start, end})
+		if end == len(words) {
+			break
+		}
+
+		// Step back over the overlap, always moving forward
+		next, repeated := end, 0
+		for next > start+1 && repeated+estimateTokens(words[next-1].Text) <= overlap {
+			next--
+			repeated += estimateTokens(words[next].Text)
+		}
+		start = next
+	}
+	return spans
+}
+
+// endsSentence reports whether a word ends with sentence punctuation
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!")
+}
+
+// estimateTokens approximates the tokens of a word and the space after it
+// at four characters per token, which slightly overestimates English text
+// for common tokenizers, so chunks stay within the embedding model's limit
+func estimateTokens(word string) int {
+	return max(1, (utf8.RuneCountInString(word)+4)/4)
+}
+
+// validateChunking rejects chunk sizes that cannot make useful chunks
+func validateChunking(tokens int, overlap int) error {
+	if tokens < 16 {
+		return fmt.Errorf("--chunk-tokens must be at least 16")
+	}
+	if overlap < 0 || overlap >= tokens/2 {
+		return fmt.Errorf("--chunk-overlap must be between 0 and half of --chunk-tokens")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/fingerprint"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/project"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// recordTranscript adds a saved transcript to the library used by 'sona list',
+// keeping the transcribed audio beside it when archive.audio says so. The
+// transcript is already on disk, so failures are only reported.
+func recordTranscript(basePath string, source string, sourceType string, audioPath string, opts Options, model string, transcript string, result *transcript.Transcript, files []string, identity sourceIdentity, quality *library.Quality) {
+	record := library.Record{
+		Name:         library.NameFor(basePath),
+		Source:       source,
+		SourceType:   sourceType,
+		CreatedAt:    time.Now(),
+		SpeechModel:  model,
+		LanguageCode: opts.LanguageCode,
+		Profile:      profileName,
+		Tags:         library.NormalizeTags(tags),
+		Text:         transcript,
+		SourceHash:   identity.Hash,
+		Quality:      quality,
+	}
+	if sourceType == "local" {
+		if absPath, err := filepath.Abs(source); err == nil {
+			record.Source = absPath
+		}
+	}
+	for _, file := range files {
+		if absPath, err := filepath.Abs(file); err == nil {
+			file = absPath
+		}
+		record.Files = append(record.Files, file)
+	}
+	if result != nil {
+		record.Duration = result.Duration
+		if result.APIKey != "" {
+			record.APIKey = config.MaskAPIKey(result.APIKey)
+		}
+		record.Cost = jobCost(model, result)
+		record.TranscriptID = result.ID
+		record.Region = result.Region
+		record.Words = result.Words
+		record.Segments = result.Segments
+	}
+
+	record.Audio = archiveAudio(audioPath, basePath)
+
+	// The transcript is on disk even when the library cannot be updated
+	defer webhookCompleted(record)
+	if opts.onRecord != nil {
+		defer opts.onRecord(record)
+	}
+
+	if err := library.Save(record); err != nil {
+		fmt.Println(style.Warning("Could not add transcript to the library: %v", err))
+		logger.LogWarning("Failed to record transcript: %v", err)
+		return
+	}
+	if len(identity.Fingerprint) > 0 {
+		if err := fingerprint.Save(record.Name, identity.Fingerprint); err != nil {
+			logger.LogWarning("Failed to save fingerprint: %v", err)
+		}
+	}
+	if projectName != "" {
+		if err := project.AddTranscript(projectName, record.Name); err != nil {
+			fmt.Println(style.Warning("Could not add transcript to project %s: %v", projectName, err))
+			logger.LogWarning("Failed to add %s to project %s: %v", record.Name, projectName, err)
+		}
+	}
+}
+
+// archiveAudio copies the transcribed audio beside the transcript when
+// archive.audio keeps audio, and returns where, "" when it is discarded with
+// the temp files. YouTube audio is kept as downloaded, local files as
+// converted for upload. Audio past the policy is removed on the way.
+func archiveAudio(audioPath string, basePath string) string {
+	archive := config.GetAudioArchive()
+	pruneArchivedAudio(archive)
+	if !archive.Keep || audioPath == "" {
+		return ""
+	}
+
+	dest, err := filepath.Abs(basePath + filepath.Ext(audioPath))
+	if err != nil {
+		dest = basePath + filepath.Ext(audioPath)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		// Never overwrite a file, which may be the source itself
+		logger.LogWarning("Not archiving audio, %s already exists", dest)
+		return ""
+	}
+	if err := deps.CopyFile(audioPath, dest, config.GetOutputFileMode()); err != nil {
+		fmt.Println(style.Warning("Could not keep the audio: %v", err))
+		return ""
+	}
+	if err := applyOutputPermissions(dest); err != nil {
+		logger.LogWarning("%v", err)
+	}
+	fmt.Printf("%sAudio kept: %s\n", style.Icon("🎧 "), dest)
+	return dest
+}
+
+// pruneArchivedAudio removes archived audio the policy no longer keeps, so
+// keep-days does not depend on anyone running 'sona clean'
+func pruneArchivedAudio(archive config.AudioArchive) {
+	if !archive.Keep || archive.Days == 0 {
+		return
+	}
+	expired, err := library.ExpiredAudio(archive)
+	if err != nil {
+		logger.LogWarning("Failed to look for expired audio: %v", err)
+		return
+	}
+	for _, record := range expired {
+		if err := library.DropAudio(record); err != nil {
+			logger.LogWarning("Failed to remove expired audio: %v", err)
+			continue
+		}
+		logger.LogInfo("Removed audio of %s, older than %d days", record.Name, archive.Days)
+	}
+}
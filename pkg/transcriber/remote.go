@@ -0,0 +1,152 @@
+package transcriber
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+// remoteCheckTimeout bounds the check that a URL source can be downloaded
+const remoteCheckTimeout = 15 * time.Second
+
+// processRemoteAudio transcribes audio at a URL other than YouTube, such as
+// a podcast enclosure or a presigned S3 URL. AssemblyAI downloads it itself,
+// which saves downloading the file here and uploading it again.
+func processRemoteAudio(audioURL string, opts Options) error {
+	if err := checkRemoteOptions(); err != nil {
+		return err
+	}
+	fmt.Printf("Processing: %s\n", redactURL(audioURL))
+	if err := checkRemoteAudio(audioURL); err != nil {
+		return err
+	}
+	if err := workspace.CheckFreeSpace(outputDirFor(opts.OutputPath), transcriptSpace, "transcript"); err != nil {
+		return err
+	}
+
+	timings := newTimings(audioURL)
+	basePath, err := transcriptBasePath(audioURL, "url", opts.OutputPath)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+
+	text, result, err := transcribeRemoteAudio(audioURL, opts.SpeechModel, opts.LanguageCode, timings)
+	if err != nil {
+		if text, err = placeholderForEmpty(err); err != nil {
+			return fmt.Errorf("transcription failed: %w", err)
+		}
+	}
+
+	quality := assessQuality(result, "")
+	printQuality(quality, opts.SpeechModel)
+
+	files, err := saveTranscript(text, audioURL, basePath, opts.OutputPath != "", result)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	recordTranscript(basePath, audioURL, "url", "", opts, opts.SpeechModel, text, result, files, sourceIdentity{}, quality)
+
+	logger.LogInfo("Remote audio processing completed successfully")
+	printTimingSummary(timings)
+	return nil
+}
+
+// transcribeRemoteAudio has AssemblyAI transcribe the audio at the URL and
+// renders the transcript like transcribeAudio does a file
+func transcribeRemoteAudio(audioURL string, speechModel string, languageCode string, timings *progress.Timings) (string, *transcript.Transcript, error) {
+	profile, err := lookupProfile(profileName)
+	if err != nil {
+		return "", nil, err
+	}
+	profile = profile.withNumberStyle(numberStyle).withSpeakers(speakerCount)
+
+	result, err := batchTranscription(audioURL, speechModel, languageCode, profile, timings)
+	if err != nil {
+		return "", nil, err
+	}
+	recordUsage(result, "")
+
+	text, err := finishTranscript(result, profile, "")
+	if err != nil {
+		return "", nil, err
+	}
+	return text, result, nil
+}
+
+// checkRemoteOptions rejects the options that work on the audio itself,
+// which is never downloaded for a URL source
+func checkRemoteOptions() error {
+	switch {
+	case provider == providerStreaming || provider == providerHybrid:
+		return fmt.Errorf("--provider %s needs the audio locally; URL sources are transcribed with --provider assemblyai", provider)
+	case musicMode != "":
+		return fmt.Errorf("--music needs the audio locally and cannot be used with URL sources")
+	case multilingual && transcribeOptions.LanguageCode == "":
+		return fmt.Errorf("--multilingual needs the audio locally and cannot be used with URL sources")
+	}
+	return nil
+}
+
+// checkRemoteAudio makes sure the URL serves a file AssemblyAI can download,
+// so a dead link or a web page fails here rather than as a transcript error.
+// Only the first byte is requested: presigned URLs are often signed for GET
+// only, which rules out HEAD.
+func checkRemoteAudio(audioURL string) error {
+	req, err := http.NewRequest("GET", audioURL, nil)
+	if err != nil {
+		return &SourceError{Source: audioURL, Message: fmt.Sprintf("invalid URL %s: %v", redactURL(audioURL), err)}
+	}
+	req.Header.Set("Range", "bytes=0-0")
+
+	client := &http.Client{Timeout: remoteCheckTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not reach %s: %v", redactURL(audioURL), err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &SourceError{Source: audioURL, Message: fmt.Sprintf("%s is not publicly reachable (status %d); a presigned URL may have expired", redactURL(audioURL), resp.StatusCode)}
+	case resp.StatusCode >= http.StatusBadRequest:
+		return &SourceError{Source: audioURL, Message: fmt.Sprintf("%s cannot be downloaded (status %d)", redactURL(audioURL), resp.StatusCode)}
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		return &SourceError{Source: audioURL, Message: fmt.Sprintf("%s is a web page, not an audio or video file; only YouTube pages and direct links to media files can be transcribed", redactURL(audioURL))}
+	}
+	return nil
+}
+
+// redactURL leaves the query off a URL for display, since presigned URLs
+// carry their credentials there
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+	u.RawQuery = ""
+	return u.String() + "?..."
+}
+
+// remoteTitle names the transcript of a URL source after the file in its
+// path, e.g. episode-12 for https://cdn.example.com/feed/episode-12.mp3
+func remoteTitle(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return u.Hostname()
+	}
+	return strings.TrimSuffix(name, path.Ext(name))
+}
@@ -0,0 +1,349 @@
+package transcriber
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+// Segments built from plain text end at a sentence boundary or at this length
+const maxSegmentWords = 40
+
+var reviewAudio string
+
+var ReviewCmd = &cobra.Command{
+	Use:   "review [name]",
+	Short: "Step through a transcript while listening to the audio",
+	Long: `Review a saved transcript segment by segment. Each segment's audio is
+played with ffplay while its text is shown, and any segment can be corrected
+in place. Saving rewrites the text formats of the transcript (txt, md);
+formats built from word timings are listed as not updated.
+
+Commands at the prompt:
+  Enter, n   next segment            p        previous segment
+  r          replay the segment      g <N>    go to segment N
+  e          edit the segment text   s        save all formats
+  q          quit
+
+The audio defaults to the original local file; pass --audio for YouTube
+sources or moved files.`,
+	Example: `  sona review interview
+  sona review talk --audio ~/Downloads/talk.mp3`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runReview(args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ReviewCmd.Flags().StringVar(&reviewAudio, "audio", "", "Audio file to play (default: the transcript's local source)")
+}
+
+// reviewSegment is one unit of text with its position in the audio
+type reviewSegment struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+	Timed bool
+	// LineEnd marks the last segment of a line in the transcript
+	LineEnd bool
+}
+
+// reviewSession holds the state of a review
+type reviewSession struct {
+	record   library.Record
+	segments []reviewSegment
+	audio    string
+	ffplay   string
+	player   *exec.Cmd
+	dirty    bool
+}
+
+func runReview(name string) error {
+	record, err := library.Find(name)
+	if err != nil {
+		return err
+	}
+
+	session := &reviewSession{record: record}
+	session.buildSegments()
+	if len(session.segments) == 0 {
+		return fmt.Errorf("transcript %s is empty", record.Name)
+	}
+
+	session.audio = reviewAudio
+	if session.audio == "" && record.SourceType == "local" {
+		session.audio = record.Source
+	}
+	if session.audio != "" {
+		if _, err := os.Stat(session.audio); err != nil {
+			fmt.Println(style.Warning("Audio not found (%s), reviewing without playback", session.audio))
+			session.audio = ""
+		}
+	} else {
+		fmt.Println(style.Warning("No local audio for this transcript; pass --audio to enable playback"))
+	}
+	if session.audio != "" {
+		if session.ffplay, err = FindBinary("ffplay"); err != nil {
+			fmt.Println(style.Warning("ffplay not found, reviewing without playback"))
+		}
+	}
+
+	defer session.stopPlayback()
+	return session.loop()
+}
+
+// buildSegments splits the transcript into reviewable segments. Lines that
+// correspond one-to-one to utterances take their timings; other text is split
+// into sentences timed by counting words.
+func (s *reviewSession) buildSegments() {
+	text := strings.TrimRight(s.record.Text, "\n")
+	lines := strings.Split(text, "\n")
+
+	if len(s.record.Segments) > 0 && len(lines) == len(s.record.Segments) {
+		for i, line := range lines {
+			utterance := s.record.Segments[i]
+			s.segments = append(s.segments, reviewSegment{
+				Text:    line,
+				Start:   time.Duration(utterance.Start) * time.Millisecond,
+				End:     time.Duration(utterance.End) * time.Millisecond,
+				Timed:   true,
+				LineEnd: true,
+			})
+		}
+		return
+	}
+
+	wordIndex := 0
+	for _, line := range lines {
+		sentences := splitSentences(line)
+		for i, sentence := range sentences {
+			segment := reviewSegment{Text: sentence, LineEnd: i == len(sentences)-1}
+			count := len(strings.Fields(sentence))
+			if wordIndex+count <= len(s.record.Words) {
+				segment.Start = time.Duration(s.record.Words[wordIndex].Start) * time.Millisecond
+				segment.End = time.Duration(s.record.Words[wordIndex+count-1].End) * time.Millisecond
+				segment.Timed = true
+			}
+			wordIndex += count
+			s.segments = append(s.segments, segment)
+		}
+	}
+}
+
+// splitSentences breaks text after sentence punctuation, or every
+// maxSegmentWords words when sentences run long
+func splitSentences(text string) []string {
+	var sentences []string
+	var current []string
+
+	for _, word := range strings.Fields(text) {
+		current = append(current, word)
+		last := word[len(word)-1]
+		if last == '.' || last == '?' || last == '!' || len(current) >= maxSegmentWords {
+			sentences = append(sentences, strings.Join(current, " "))
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		sentences = append(sentences, strings.Join(current, " "))
+	}
+	return sentences
+}
+
+func (s *reviewSession) loop() error {
+	reader := bufio.NewReader(os.Stdin)
+	index := 0
+	s.show(index)
+
+	for {
+		fmt.Print("> ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			// End of input quits, keeping unsaved edits only if saved explicitly
+			fmt.Println()
+			return nil
+		}
+		command := strings.TrimSpace(input)
+
+		switch {
+		case command == "" || command == "n":
+			if index < len(s.segments)-1 {
+				index++
+			} else {
+				fmt.Println("Last segment. Press s to save or q to quit.")
+				continue
+			}
+		case command == "p":
+			if index > 0 {
+				index--
+			}
+		case command == "r":
+		case strings.HasPrefix(command, "g"):
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(command, "g")))
+			if err != nil || n < 1 || n > len(s.segments) {
+				fmt.Printf("Enter a segment number between 1 and %d\n", len(s.segments))
+				continue
+			}
+			index = n - 1
+		case command == "e":
+			s.stopPlayback()
+			fmt.Printf("New text (empty keeps the current one):\n")
+			edited, _ := reader.ReadString('\n')
+			edited = strings.TrimSpace(edited)
+			if edited != "" && edited != s.segments[index].Text {
+				s.segments[index].Text = edited
+				s.dirty = true
+			}
+		case command == "s":
+			if err := s.save(); err != nil {
+				fmt.Println(style.Error("%v", err))
+			}
+			continue
+		case command == "q":
+			if s.dirty {
+				fmt.Print("Discard unsaved changes? (y/n): ")
+				answer, _ := reader.ReadString('\n')
+				if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+					continue
+				}
+			}
+			return nil
+		default:
+			fmt.Println("Commands: Enter/n next, p previous, r replay, g <N> go to, e edit, s save, q quit")
+			continue
+		}
+
+		s.show(index)
+	}
+}
+
+// show renders the segment with its neighbours and starts playback
+func (s *reviewSession) show(index int) {
+	if isInteractiveOutput() && !style.Accessible() {
+		fmt.Print("\033[H\033[2J")
+	}
+
+	segment := s.segments[index]
+	header := fmt.Sprintf("%s  segment %d/%d", s.record.Name, index+1, len(s.segments))
+	if segment.Timed {
+		header += fmt.Sprintf("  [%s - %s]", formatTimestamp(segment.Start), formatTimestamp(segment.End))
+	}
+	if s.dirty {
+		header += "  (unsaved)"
+	}
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("-", len(header)))
+
+	if index > 0 {
+		fmt.Printf("  %s\n\n", s.segments[index-1].Text)
+	}
+	fmt.Printf("%s%s\n\n", style.Icon("▶ "), segment.Text)
+	if index < len(s.segments)-1 {
+		fmt.Printf("  %s\n\n", s.segments[index+1].Text)
+	}
+
+	s.play(segment)
+}
+
+// play starts ffplay for the segment in the background, replacing any
+// segment that is still playing
+func (s *reviewSession) play(segment reviewSegment) {
+	s.stopPlayback()
+	if s.ffplay == "" || !segment.Timed {
+		return
+	}
+
+	length := segment.End - segment.Start
+	args := []string{"-nodisp", "-autoexit", "-loglevel", "quiet",
+		"-ss", fmt.Sprintf("%.3f", segment.Start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", length.Seconds()),
+		s.audio}
+	s.player = exec.Command(s.ffplay, args...)
+	if err := s.player.Start(); err != nil {
+		logger.LogWarning("Failed to start ffplay: %v", err)
+		s.player = nil
+		return
+	}
+	// Reap the process once it finishes on its own
+	player := s.player
+	go player.Wait()
+}
+
+func (s *reviewSession) stopPlayback() {
+	if s.player != nil && s.player.Process != nil {
+		s.player.Process.Kill()
+	}
+	s.player = nil
+}
+
+// save rebuilds the transcript from the segments and rewrites every output
+// format that was saved for it
+func (s *reviewSession) save() error {
+	var b strings.Builder
+	for i, segment := range s.segments {
+		b.WriteString(segment.Text)
+		if segment.LineEnd || i == len(s.segments)-1 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	transcript := b.String()
+
+	// Formats built from word timings (lrc, ass, json, subtitles) cannot
+	// take the edited text, so they are left as they were
+	var stale []string
+	for _, file := range s.record.Files {
+		if derivedOutput(file) {
+			continue
+		}
+		format := strings.TrimPrefix(filepath.Ext(file), ".")
+		render, ok := outputFormats[format]
+		if !ok || timedFormats[format] {
+			stale = append(stale, file)
+			continue
+		}
+		if err := writeOutput(file, []byte(render(transcript, s.record.Source, nil))); err != nil {
+			return fmt.Errorf("failed to write %s: %v", file, err)
+		}
+		fmt.Printf("Saved to: %s\n", file)
+	}
+	for _, file := range stale {
+		fmt.Println(style.Warning("Not updated (built from word timings): %s", file))
+		logger.LogWarning("Review of %s left %s unchanged", s.record.Name, file)
+	}
+
+	s.record.Text = transcript
+	if err := library.Save(s.record); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// derivedOutput reports whether file was made from the transcript rather
+// than being one of its formats: a proofread copy, corrections log or show notes
+func derivedOutput(file string) bool {
+	base := strings.TrimSuffix(file, filepath.Ext(file))
+	return strings.HasSuffix(base, ".corrected") || strings.HasSuffix(base, ".show-notes") || strings.HasSuffix(file, ".corrections.log")
+}
+
+// isInteractiveOutput reports whether stdout is a terminal
+func isInteractiveOutput() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
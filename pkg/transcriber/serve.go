@@ -0,0 +1,550 @@
+package transcriber
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/tokens"
+	"github.com/Harsh-2002/Sona/pkg/youtube"
+	"github.com/spf13/cobra"
+)
+
+// serverQueueSize is how many submitted jobs may wait for a worker
+const serverQueueSize = 100
+
+// States of a job submitted to sona serve
+const (
+	serverQueued  = "queued"
+	serverRunning = "running"
+	serverDone    = "done"
+	serverFailed  = "failed"
+)
+
+var (
+	serveListen          string
+	serveShutdownTimeout time.Duration
+	serveNoAuth          bool
+	serveWorkers         int
+	serveHighWorkers     int
+)
+
+// tokenKey holds the API token of a request in its context
+type tokenKey struct{}
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP API that transcribes submitted audio",
+	Long: `Run an HTTP API that transcribes audio uploads and YouTube URLs with the
+options of --preset and the defaults.* config keys.
+
+Endpoints:
+  POST   /v1/jobs                  submit a multipart "file" upload, or JSON
+                                   {"source": "<YouTube URL>", "language": "en",
+                                   "priority": "high"}
+  GET    /v1/jobs                  list submitted jobs
+  GET    /v1/jobs/{id}             show a job
+  GET    /v1/jobs/{id}/transcript  the transcript text of a finished job
+  DELETE /v1/jobs/{id}             remove a job, cancelling it if it waits
+  GET    /healthz                  200 while the process is up
+  GET    /readyz                   200 while jobs can be run, 503 otherwise
+
+Waiting jobs run highest priority first, in the order they were submitted
+within a priority. --workers jobs of any priority run at once, and
+--high-priority-workers more run only high priority jobs, so an urgent file
+does not wait behind a running backfill job either.
+
+Requests to /v1 need an API token created with 'sona token create', sent as
+"Authorization: Bearer <token>". Submit tokens see only the jobs submitted
+with them, read tokens see every job, and only admin tokens remove jobs.
+Setting security.allow_remote_delete to false stops even admin tokens from
+removing jobs.
+--no-auth turns tokens off, e.g. behind a proxy that authenticates.
+
+Every flag can also be set as an environment variable, e.g. SONA_LISTEN, and
+every config key too, e.g. SONA_DEFAULTS_MODEL. On SIGTERM the server stops
+taking jobs and lets the running ones finish within --shutdown-timeout.`,
+	Example: `  sona serve
+  sona serve --listen 127.0.0.1:9000 --preset meeting
+  curl -F file=@standup.mp3 http://localhost:8080/v1/jobs`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runServer(cmd); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	ServeCmd.Flags().StringVar(&serveListen, "listen", ":8080", "Address to listen on")
+	ServeCmd.Flags().StringVar(&presetName, "preset", "", "Transcribe with the flags of a saved preset")
+	ServeCmd.Flags().DurationVar(&serveShutdownTimeout, "shutdown-timeout", 25*time.Second, "How long to let the running jobs finish on shutdown")
+	ServeCmd.Flags().BoolVar(&serveNoAuth, "no-auth", false, "Accept requests without an API token")
+	ServeCmd.Flags().IntVar(&serveWorkers, "workers", 1, "Jobs of any priority to run at once")
+	ServeCmd.Flags().IntVar(&serveHighWorkers, "high-priority-workers", 1, "Further jobs to run at once that only take high priority jobs")
+}
+
+// serverJob is a job submitted to sona serve
+type serverJob struct {
+	ID         string     `json:"id"`
+	Source     string     `json:"source"`
+	Language   string     `json:"language,omitempty"`
+	Priority   string     `json:"priority"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	Transcript string     `json:"transcript,omitempty"`
+	Files      []string   `json:"files,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// SubmittedBy names the token the job was submitted with
+	SubmittedBy string `json:"submitted_by,omitempty"`
+
+	// upload is the submitted file, removed once the job has run
+	upload string
+	text   string
+}
+
+// server keeps the jobs submitted since it started
+type server struct {
+	daemon *daemon
+	mu     sync.Mutex
+	jobs   map[string]*serverJob
+	// waiting are the jobs not yet started, in the order they were submitted
+	waiting []*serverJob
+	// wake tells the idle workers a job was submitted or the server is stopping
+	wake *sync.Cond
+}
+
+func runServer(cmd *cobra.Command) error {
+	d, err := newDaemon(cmd, serveShutdownTimeout)
+	if err != nil {
+		return err
+	}
+	if !serveNoAuth {
+		issued, err := tokens.Load()
+		if err != nil {
+			return err
+		}
+		if len(issued) == 0 {
+			return fmt.Errorf("no API tokens; create one with 'sona token create <name> --scope admin', or run with --no-auth")
+		}
+	}
+	if serveWorkers < 1 || serveHighWorkers < 0 || serveWorkers+serveHighWorkers > maxParallelJobs {
+		return fmt.Errorf("--workers must be at least 1 and --high-priority-workers at least 0, %d in all at most", maxParallelJobs)
+	}
+	s := &server{daemon: d, jobs: make(map[string]*serverJob)}
+	s.wake = sync.NewCond(&s.mu)
+
+	mux := http.NewServeMux()
+	d.handleHealth(mux)
+	s.routes(mux)
+
+	for w := 0; w < serveWorkers+serveHighWorkers; w++ {
+		go s.work(w >= serveWorkers)
+	}
+	go func() {
+		<-d.stopped()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.wake.Broadcast()
+	}()
+	d.listen(serveListen, mux)
+	logger.LogInfo("Serving on %s", serveListen)
+	d.wait()
+	return nil
+}
+
+// routes adds the job API to mux
+func (s *server) routes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /v1/jobs", authorize(tokens.PermSubmit, s.handleSubmit))
+	mux.HandleFunc("GET /v1/jobs", authorize(tokens.PermView, s.handleList))
+	mux.HandleFunc("GET /v1/jobs/{id}", authorize(tokens.PermView, s.handleJob))
+	mux.HandleFunc("GET /v1/jobs/{id}/transcript", authorize(tokens.PermView, s.handleTranscript))
+	mux.HandleFunc("DELETE /v1/jobs/{id}", authorize(tokens.PermRemove, s.handleRemove))
+}
+
+// work runs submitted jobs one after another, highest priority first,
+// until the server shuts down. A worker for highOnly takes only high
+// priority jobs.
+func (s *server) work(highOnly bool) {
+	for {
+		job := s.next(highOnly)
+		if job == nil {
+			return
+		}
+		if !s.daemon.run(func() { s.runJob(job) }) {
+			// Stopping: the job stays queued with the others
+			s.putBack(job)
+			return
+		}
+	}
+}
+
+// putBack returns a job taken by next to its place among the waiting jobs
+func (s *server) putBack(job *serverJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := 0
+	for i < len(s.waiting) && !s.waiting[i].CreatedAt.After(job.CreatedAt) {
+		i++
+	}
+	s.waiting = slices.Insert(s.waiting, i, job)
+}
+
+// next waits for a job and takes the one to run next: the earliest of the
+// highest priority. It returns nil once the server is stopping.
+func (s *server) next(highOnly bool) *serverJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		select {
+		case <-s.daemon.stopped():
+			return nil
+		default:
+		}
+
+		best := -1
+		for i, job := range s.waiting {
+			if highOnly && job.Priority != queue.PriorityHigh {
+				continue
+			}
+			if best < 0 || queue.Rank(job.Priority) < queue.Rank(s.waiting[best].Priority) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			job := s.waiting[best]
+			s.waiting = append(s.waiting[:best], s.waiting[best+1:]...)
+			return job
+		}
+		s.wake.Wait()
+	}
+}
+
+func (s *server) runJob(job *serverJob) {
+	s.update(job, func() {
+		now := time.Now()
+		job.Status = serverRunning
+		job.StartedAt = &now
+	})
+
+	source := job.Source
+	if job.upload != "" {
+		source = job.upload
+		defer os.Remove(job.upload)
+	}
+	queued := jobFor(sourceSpec{Source: source, LanguageCode: job.Language, Priority: job.Priority})
+	record, err := runDaemonJob(queued)
+
+	s.update(job, func() {
+		now := time.Now()
+		job.FinishedAt = &now
+		job.Status = serverDone
+		if err != nil {
+			job.Status = serverFailed
+			job.Error = err.Error()
+		}
+		if record != nil {
+			job.Transcript = record.Name
+			job.Files = record.Files
+			job.text = record.Text
+		}
+	})
+}
+
+// update changes a job while no request reads it
+func (s *server) update(job *serverJob, change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change()
+}
+
+// handleSubmit accepts a multipart upload or a JSON request with a URL
+func (s *server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	if err := s.daemon.ready(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	token := requestToken(r)
+	job := &serverJob{ID: newJobID(), Status: serverQueued, CreatedAt: time.Now(), SubmittedBy: token.Name}
+	if limit := config.GetMaxUploadSize(); limit > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := receiveUpload(r, job); err != nil {
+			if job.upload != "" {
+				os.Remove(job.upload)
+			}
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("upload is larger than %d bytes (security.max_upload_size)", tooLarge.Limit))
+				return
+			}
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		var request struct {
+			Source   string `json:"source"`
+			Language string `json:"language"`
+			Priority string `json:"priority"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+		if !youtube.IsYouTubeURL(request.Source) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("source must be a YouTube URL; upload files as multipart \"file\""))
+			return
+		}
+		job.Source = request.Source
+		job.Language = request.Language
+		job.Priority = request.Priority
+	}
+
+	job.Priority = strings.ToLower(strings.TrimSpace(job.Priority))
+	if err := validatePriority(job.Priority); err != nil {
+		if job.upload != "" {
+			os.Remove(job.upload)
+		}
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if job.Priority == "" {
+		job.Priority = queue.PriorityNormal
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) >= serverQueueSize {
+		if job.upload != "" {
+			os.Remove(job.upload)
+		}
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("%d jobs are already waiting", serverQueueSize))
+		return
+	}
+	s.waiting = append(s.waiting, job)
+	s.jobs[job.ID] = job
+	s.wake.Broadcast()
+	logger.LogInfo("Job %s submitted: %s", job.ID, job.Source)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// receiveUpload streams the "file" part of a multipart request to disk
+func receiveUpload(r *http.Request, job *serverJob) error {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch part.FormName() {
+		case "language", "priority":
+			value, err := io.ReadAll(io.LimitReader(part, 64))
+			if err != nil {
+				return err
+			}
+			if part.FormName() == "language" {
+				job.Language = strings.TrimSpace(string(value))
+			} else {
+				job.Priority = string(value)
+			}
+		case "file":
+			name := filepath.Base(part.FileName())
+			if name == "." || name == string(filepath.Separator) {
+				return fmt.Errorf("the file part has no file name")
+			}
+			dir, err := datadir.Path("uploads")
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("failed to create upload directory: %v", err)
+			}
+			job.Source = name
+			job.upload = filepath.Join(dir, job.ID+"-"+name)
+			if err := saveUpload(part, job.upload); err != nil {
+				return err
+			}
+		}
+	}
+	if job.upload == "" {
+		return fmt.Errorf("no file uploaded; send it as the multipart \"file\" part")
+	}
+	return nil
+}
+
+func saveUpload(part io.Reader, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to save upload: %v", err)
+	}
+	if _, err := io.Copy(file, part); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to save upload: %w", err)
+	}
+	return file.Close()
+}
+
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	token := requestToken(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*serverJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if canSee(token, job) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// lookup returns the job a request names, answering 404 when there is
+// none the request's token may see. The caller must hold s.mu.
+func (s *server) lookup(w http.ResponseWriter, r *http.Request) (*serverJob, bool) {
+	job, ok := s.jobs[r.PathValue("id")]
+	if !ok || !canSee(requestToken(r), job) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+		return nil, false
+	}
+	return job, true
+}
+
+func (s *server) handleJob(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.lookup(w, r); ok {
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+func (s *server) handleRemove(w http.ResponseWriter, r *http.Request) {
+	if !config.GetAllowRemoteDelete() {
+		writeError(w, http.StatusForbidden, fmt.Errorf("removing jobs is turned off on this server (security.allow_remote_delete)"))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if job.Status == serverRunning {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s is running", job.ID))
+		return
+	}
+	for i, waiting := range s.waiting {
+		if waiting == job {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			if job.upload != "" {
+				os.Remove(job.upload)
+			}
+			break
+		}
+	}
+	delete(s.jobs, job.ID)
+	logger.LogInfo("Job %s removed by %s", job.ID, requestToken(r).Name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) handleTranscript(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if job.Status != serverDone {
+		writeError(w, http.StatusConflict, fmt.Errorf("job %s is %s", job.ID, job.Status))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, job.text)
+}
+
+// authorize lets a request through to handler when it carries a token
+// whose scope grants permission, unless the server runs without tokens
+func authorize(permission string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if serveNoAuth {
+			token := tokens.Token{Scope: tokens.ScopeAdmin}
+			handler(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+			return
+		}
+
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("an API token is required"))
+			return
+		}
+		issued, err := tokens.Load()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		token, ok := tokens.Find(issued, strings.TrimSpace(secret))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("invalid API token"))
+			return
+		}
+		if !token.Allows(permission) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("token %s has the %s scope, which may not %s", token.Name, token.Scope, permission))
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	}
+}
+
+// requestToken returns the token a request was authorized with
+func requestToken(r *http.Request) tokens.Token {
+	token, _ := r.Context().Value(tokenKey{}).(tokens.Token)
+	return token
+}
+
+// canSee reports whether a token may see a job: submit tokens see only the
+// jobs submitted with them
+func canSee(token tokens.Token, job *serverJob) bool {
+	return token.Allows(tokens.PermViewAll) || job.SubmittedBy == token.Name
+}
+
+// writeError answers a request with an error as JSON
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// newJobID returns a random ID for a submitted job
+func newJobID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
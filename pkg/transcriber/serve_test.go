@@ -0,0 +1,93 @@
+package transcriber
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/queue"
+	"github.com/Harsh-2002/Sona/pkg/tokens"
+	"github.com/spf13/viper"
+)
+
+func TestServeScopes(t *testing.T) {
+	t.Setenv(datadir.EnvVar, filepath.Join(t.TempDir(), ".sona"))
+	viper.Reset()
+	viper.Set("security.allow_remote_delete", true)
+	t.Cleanup(viper.Reset)
+
+	secrets := make(map[string]string)
+	for _, scope := range []string{tokens.ScopeSubmit, tokens.ScopeRead, tokens.ScopeAdmin} {
+		secret, err := tokens.Create(scope, scope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secrets[scope] = secret
+	}
+
+	// A stopping daemon refuses submissions after authorization, without
+	// needing an API key or ffmpeg
+	s := &server{daemon: &daemon{stopping: true}, jobs: map[string]*serverJob{
+		"own":   {ID: "own", Status: serverDone, SubmittedBy: tokens.ScopeSubmit},
+		"other": {ID: "other", Status: serverDone, SubmittedBy: "someone"},
+	}}
+	mux := http.NewServeMux()
+	s.routes(mux)
+
+	// Want is the status for the submit, read and admin tokens
+	tests := []struct {
+		method string
+		path   string
+		want   [3]int
+	}{
+		{"POST", "/v1/jobs", [3]int{http.StatusServiceUnavailable, http.StatusForbidden, http.StatusServiceUnavailable}},
+		{"GET", "/v1/jobs", [3]int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"GET", "/v1/jobs/own", [3]int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"GET", "/v1/jobs/other", [3]int{http.StatusNotFound, http.StatusOK, http.StatusOK}},
+		{"GET", "/v1/jobs/own/transcript", [3]int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"GET", "/v1/jobs/other/transcript", [3]int{http.StatusNotFound, http.StatusOK, http.StatusOK}},
+		{"DELETE", "/v1/jobs/missing", [3]int{http.StatusForbidden, http.StatusForbidden, http.StatusNotFound}},
+	}
+	for _, tt := range tests {
+		for i, scope := range []string{tokens.ScopeSubmit, tokens.ScopeRead, tokens.ScopeAdmin} {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+secrets[scope])
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if w.Code != tt.want[i] {
+				t.Errorf("%s %s with a %s token = %d, want %d", tt.method, tt.path, scope, w.Code, tt.want[i])
+			}
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/v1/jobs", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /v1/jobs without a token = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestServeWorkPutsJobBack(t *testing.T) {
+	// The daemon has begun stopping but its stop channel is not closed yet,
+	// so next hands out a job that run then declines
+	s := &server{daemon: &daemon{stopping: true, stop: make(chan struct{})}, jobs: make(map[string]*serverJob)}
+	s.wake = sync.NewCond(&s.mu)
+	now := time.Now()
+	first := &serverJob{ID: "first", Status: serverQueued, Priority: queue.PriorityNormal, CreatedAt: now}
+	second := &serverJob{ID: "second", Status: serverQueued, Priority: queue.PriorityHigh, CreatedAt: now.Add(time.Second)}
+	s.waiting = []*serverJob{first, second}
+
+	s.work(false)
+
+	if len(s.waiting) != 2 || s.waiting[0] != first || s.waiting[1] != second {
+		t.Errorf("waiting = %v, want both jobs back in submission order", s.waiting)
+	}
+	if second.Status != serverQueued {
+		t.Errorf("status = %s, want %s", second.Status, serverQueued)
+	}
+}
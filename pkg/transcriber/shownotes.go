@@ -0,0 +1,181 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// maxKeyTopics caps the key phrases listed in show notes
+const maxKeyTopics = 10
+
+// showNotes requests chapters, key phrases and entities and writes a
+// show notes document next to the transcript
+var showNotes bool
+
+var (
+	// urlPattern matches written links such as "https://sona.dev/docs" or "example.com"
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?://)?(?:[a-z0-9-]+\.)+(?:com|org|net|io|dev|ai|co|fm|app|me|tv|edu|gov|xyz|info|link)\b(?:/[^\s,;)"']*)?`)
+	// spokenURLPattern matches links read out loud, such as "example dot com"
+	spokenURLPattern = regexp.MustCompile(`(?i)\b([a-z0-9-]+(?: dot [a-z0-9-]+)*) dot (com|org|net|io|dev|ai|co|fm|app|me|tv|edu|gov|xyz|info|link)\b`)
+)
+
+// mentionTypes are the entity types listed under "Mentioned", in order
+var mentionTypes = []struct {
+	entityType string
+	label      string
+}{
+	{"person_name", "People"},
+	{"organization", "Organizations"},
+	{"product", "Products"},
+}
+
+// applyShowNotes asks the provider for the data show notes are built from
+func applyShowNotes(request *assemblyai.TranscriptionRequest) {
+	request.AutoChapters = true
+	request.AutoHighlights = true
+	request.EntityDetection = true
+}
+
+// showNotesPath returns where the show notes for a transcript are written,
+// e.g. "talk.txt" becomes "talk.show-notes.md"
+func showNotesPath(transcriptPath string) string {
+	return strings.TrimSuffix(transcriptPath, filepath.Ext(transcriptPath)) + ".show-notes.md"
+}
+
+// formatShowNotes renders a Markdown show notes document with a summary,
+// chapters, key topics and the links and names mentioned
+func formatShowNotes(source string, transcript string, result *transcript.Transcript) string {
+	var b strings.Builder
+	title := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+	fmt.Fprintf(&b, "# %s\n\n", title)
+
+	if summary := showNotesSummary(result.Chapters); summary != "" {
+		b.WriteString("## Summary\n\n")
+		b.WriteString(summary)
+		b.WriteString("\n\n")
+	}
+
+	if len(result.Chapters) > 0 {
+		b.WriteString("## Chapters\n\n")
+		for _, chapter := range result.Chapters {
+			start := time.Duration(chapter.Start) * time.Millisecond
+			fmt.Fprintf(&b, "- %s %s\n", markdownTimestamp(source, start), strings.TrimSpace(chapter.Headline))
+		}
+		b.WriteString("\n")
+	}
+
+	if topics := keyTopics(result.Highlights); len(topics) > 0 {
+		b.WriteString("## Key Topics\n\n")
+		for _, topic := range topics {
+			fmt.Fprintf(&b, "- %s\n", topic)
+		}
+		b.WriteString("\n")
+	}
+
+	if links := mentionedLinks(transcript, result.Entities); len(links) > 0 {
+		b.WriteString("## Links\n\n")
+		for _, link := range links {
+			fmt.Fprintf(&b, "- %s\n", link)
+		}
+		b.WriteString("\n")
+	}
+
+	var mentioned strings.Builder
+	for _, kind := range mentionTypes {
+		if names := entityNames(result.Entities, kind.entityType); len(names) > 0 {
+			fmt.Fprintf(&mentioned, "- **%s:** %s\n", kind.label, strings.Join(names, ", "))
+		}
+	}
+	if mentioned.Len() > 0 {
+		b.WriteString("## Mentioned\n\n")
+		b.WriteString(mentioned.String())
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// showNotesSummary builds a summary paragraph from the chapter summaries.
+// Short episodes use them in full; longer ones keep their first sentences.
+func showNotesSummary(chapters []transcript.Chapter) string {
+	var parts []string
+	for _, chapter := range chapters {
+		summary := strings.TrimSpace(chapter.Summary)
+		if summary == "" {
+			continue
+		}
+		if len(chapters) > 3 {
+			if sentences := splitSentences(summary); len(sentences) > 0 {
+				summary = sentences[0]
+			}
+		}
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, " ")
+}
+
+// keyTopics returns the most relevant key phrases
+func keyTopics(highlights []transcript.Highlight) []string {
+	results := append([]transcript.Highlight(nil), highlights...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+
+	var topics []string
+	for _, highlight := range results {
+		if len(topics) == maxKeyTopics {
+			break
+		}
+		topics = append(topics, highlight.Text)
+	}
+	return topics
+}
+
+// mentionedLinks finds the written and spoken links and email addresses,
+// in order of first mention
+func mentionedLinks(transcript string, entities []transcript.Entity) []string {
+	var links []string
+	seen := make(map[string]bool)
+	add := func(link string) {
+		link = strings.TrimRight(link, ".")
+		key := strings.ToLower(link)
+		if link != "" && !seen[key] {
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+
+	spoken := spokenURLPattern.ReplaceAllStringFunc(transcript, func(match string) string {
+		return strings.ReplaceAll(strings.ToLower(match), " dot ", ".")
+	})
+	for _, match := range urlPattern.FindAllString(spoken, -1) {
+		add(match)
+	}
+	for _, entity := range entities {
+		if entity.EntityType == "email_address" {
+			add(entity.Text)
+		}
+	}
+	return links
+}
+
+// entityNames returns the distinct names of one entity type, in order of first mention
+func entityNames(entities []transcript.Entity, entityType string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, entity := range entities {
+		key := strings.ToLower(entity.Text)
+		if entity.EntityType == entityType && !seen[key] {
+			seen[key] = true
+			names = append(names, entity.Text)
+		}
+	}
+	return names
+}
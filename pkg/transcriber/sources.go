@@ -0,0 +1,187 @@
+package transcriber
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+// languageSeparator separates a source from its language hint, e.g. "talk.mp3|hi"
+const languageSeparator = "|"
+
+// sourceSpec describes a single input together with its language hint
+type sourceSpec struct {
+	Source       string
+	LanguageCode string
+	// Priority overrides --priority for this source
+	Priority string
+}
+
+// parseSourceSpec splits a "source|lang" argument into its parts.
+// Sources without a hint fall back to defaultLanguage. Only a known language
+// code after the last "|" is a hint; otherwise the "|" is part of the path.
+func parseSourceSpec(arg string, defaultLanguage string) sourceSpec {
+	spec := sourceSpec{Source: strings.TrimSpace(arg), LanguageCode: defaultLanguage}
+
+	if idx := strings.LastIndex(arg, languageSeparator); idx != -1 {
+		source := strings.TrimSpace(arg[:idx])
+		lang := strings.TrimSpace(arg[idx+len(languageSeparator):])
+		if source != "" && isLanguageCode(lang) {
+			spec.Source = source
+			spec.LanguageCode = lang
+		}
+	}
+
+	return spec
+}
+
+// readManifest reads sources from a CSV manifest with the columns
+// "source" and optional "language" and "priority". Blank lines and lines starting
+// with '#' are ignored, and a header row is skipped if present.
+func readManifest(path string, defaultLanguage string) ([]sourceSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var specs []sourceSpec
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// The error names its line
+			return nil, fmt.Errorf("failed to parse manifest: %v", err)
+		}
+		// Comments and quoted line breaks make lines and records differ
+		line, _ := reader.FieldPos(0)
+
+		if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		// Skip the header row
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), "source") {
+			continue
+		}
+
+		spec := parseSourceSpec(record[0], defaultLanguage)
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			spec.LanguageCode = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			spec.Priority = strings.ToLower(strings.TrimSpace(record[2]))
+			if err := validatePriority(spec.Priority); err != nil {
+				return nil, fmt.Errorf("manifest line %d: %v", line, err)
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("manifest %s contains no sources", path)
+	}
+
+	return specs, nil
+}
+
+// collectSources builds the list of sources from command arguments and an optional manifest
+func collectSources(args []string, manifestPath string, defaultLanguage string) ([]sourceSpec, error) {
+	var specs []sourceSpec
+
+	for _, arg := range args {
+		specs = append(specs, parseSourceSpec(arg, defaultLanguage))
+	}
+
+	if manifestPath != "" {
+		manifestSpecs, err := readManifest(manifestPath, defaultLanguage)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, manifestSpecs...)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no sources given. Pass a source or use --manifest")
+	}
+
+	return expandDirectories(specs)
+}
+
+// expandDirectories replaces each directory among the sources with the
+// audio and video files in it, which keep its language and priority
+func expandDirectories(specs []sourceSpec) ([]sourceSpec, error) {
+	var expanded []sourceSpec
+	for _, spec := range specs {
+		info, err := os.Stat(spec.Source)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, spec)
+			continue
+		}
+
+		files, err := directoryMedia(spec.Source, recursive)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			if !recursive {
+				return nil, fmt.Errorf("no audio or video files in %s (use --recursive to include its subdirectories)", spec.Source)
+			}
+			return nil, fmt.Errorf("no audio or video files in %s", spec.Source)
+		}
+		fmt.Printf("%s: %d audio and video files\n", spec.Source, len(files))
+		for _, file := range files {
+			entry := spec
+			entry.Source = file
+			expanded = append(expanded, entry)
+		}
+	}
+	return expanded, nil
+}
+
+// directoryMedia lists the audio and video files in dir in name order, and
+// with recurse those of its subdirectories. Hidden files and directories and
+// files still being written by a sync client are skipped, as are
+// subdirectories that cannot be read.
+func directoryMedia(dir string, recurse bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			fmt.Println(style.Warning("Skipping %s: %v", path, err))
+			logger.LogWarning("Skipping %s: %v", path, err)
+			return nil
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != dir && (!recurse || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasPrefix(name, ".") && !isPartialName(name) && mediaExtensions[strings.ToLower(filepath.Ext(name))] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,43 @@
+package transcriber
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSourceSpec(t *testing.T) {
+	tests := []struct {
+		arg      string
+		source   string
+		language string
+	}{
+		{"talk.mp3", "talk.mp3", "en"},
+		{"talk.mp3|hi", "talk.mp3", "hi"},
+		{"talk.mp3 | en_us", "talk.mp3", "en_us"},
+		{"a|b.mp3", "a|b.mp3", "en"},
+		{"talk|final.mp3", "talk|final.mp3", "en"},
+		{"talk.mp3|", "talk.mp3|", "en"},
+		{"|hi", "|hi", "en"},
+	}
+	for _, tt := range tests {
+		spec := parseSourceSpec(tt.arg, "en")
+		if spec.Source != tt.source || spec.LanguageCode != tt.language {
+			t.Errorf("parseSourceSpec(%q) = %q, %q, want %q, %q", tt.arg, spec.Source, spec.LanguageCode, tt.source, tt.language)
+		}
+	}
+}
+
+func TestReadManifestLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sources.csv")
+	manifest := "source,language,priority\n# comment\n\"multi\nline.mp3\",hi\nb.mp3,,urgent\n"
+	if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readManifest(path, "")
+	if err == nil || !strings.Contains(err.Error(), "line 5") {
+		t.Errorf("readManifest() error = %v, want it on line 5", err)
+	}
+}
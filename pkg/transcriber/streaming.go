@@ -0,0 +1,132 @@
+package transcriber
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// providerStreaming transcribes through the realtime API, showing partial
+// results while the audio plays through
+const providerStreaming = "assemblyai-streaming"
+
+// streamingModelFor picks the realtime model for a language
+func streamingModelFor(languageCode string) string {
+	if languageCode == "" || strings.HasPrefix(languageCode, "en") {
+		return ""
+	}
+	return "universal-streaming-multilingual"
+}
+
+// liveTranscriptPath is where a streaming session commits finalized turns,
+// e.g. "talk-20250101.live.txt" for "talk-20250101.txt"
+func liveTranscriptPath(basePath string) string {
+	return strings.TrimSuffix(basePath, filepath.Ext(basePath)) + ".live.txt"
+}
+
+// discardLiveTranscript removes the live file once the full transcript is saved
+func discardLiveTranscript(livePath string) {
+	if err := os.Remove(livePath); err == nil {
+		logger.LogInfo("Removed live transcript: %s", livePath)
+	}
+}
+
+// streamTranscription plays the audio through the realtime API at its natural
+// pace. Partial hypotheses are rewritten in place on the terminal, and each
+// finalized turn is printed and appended to livePath immediately.
+func streamTranscription(audioPath string, languageCode string, livePath string, timings *progress.Timings) (*transcript.Transcript, error) {
+	liveFile, err := os.Create(livePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create live transcript: %v", err)
+	}
+	defer liveFile.Close()
+	if err := applyOutputPermissions(livePath); err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("Streaming audio (live transcript: %s)\n", livePath)
+	timings.Begin("stream")
+	defer timings.End()
+
+	display := progress.NewLiveLine()
+	var turns []string
+	var writeErr error
+
+	// -re reads the input at its native rate, as a live source would arrive
+	err = streamFromFFmpeg([]string{"-re", "-i", audioPath}, languageCode, func(turn assemblyai.Turn) {
+		text := strings.TrimSpace(turn.Transcript)
+		if !turn.IsFinal() {
+			display.Update(text)
+			return
+		}
+		if text == "" {
+			return
+		}
+		display.Commit(text)
+		turns = append(turns, text)
+		if _, err := fmt.Fprintln(liveFile, text); err != nil && writeErr == nil {
+			writeErr = err
+		}
+	})
+	display.Clear()
+	if err != nil {
+		return nil, err
+	}
+	if writeErr != nil {
+		logger.LogWarning("Failed to write live transcript: %v", writeErr)
+	}
+
+	return &transcript.Transcript{
+		Text:     strings.Join(turns, "\n"),
+		Duration: audioDurationOrZero(audioPath).Seconds(),
+	}, nil
+}
+
+// streamFromFFmpeg decodes the given ffmpeg input to PCM and streams it to
+// the realtime API until the input ends
+func streamFromFFmpeg(inputArgs []string, languageCode string, onTurn assemblyai.TurnFunc) error {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"-hide_banner", "-loglevel", "error"}, inputArgs...)
+	args = append(args, "-f", "s16le", "-acodec", "pcm_s16le", "-ar", strconv.Itoa(assemblyai.StreamingSampleRate), "-ac", "1", "pipe:1")
+	cmd := exec.Command(ffmpegPath, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = logger.CommandOutput(&stderr)
+	audio, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start audio decoder: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start audio decoder: %v", err)
+	}
+
+	client := newClient()
+	streamErr := client.Stream(audio, assemblyai.StreamingOptions{SpeechModel: streamingModelFor(languageCode)}, onTurn)
+
+	// Stop the decoder if the session ended early
+	if streamErr != nil {
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	logger.LogCommand(ffmpegPath, args, stderr.String(), waitErr)
+
+	if streamErr != nil {
+		return streamErr
+	}
+	if waitErr != nil {
+		return fmt.Errorf("audio decoding failed: %v: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,321 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+// cueGap is the silence between words that always starts a new cue
+const cueGap = time.Second
+
+var (
+	subtitleSRT      string
+	subtitleOutput   string
+	subtitleBurn     bool
+	subtitleStyle    string
+	subtitleLineSize int
+	subtitleModel    string
+	subtitleLanguage string
+)
+
+// subtitleStyles are named caption styles for burned-in subtitles, as ASS
+// style overrides for ffmpeg's subtitles filter
+var subtitleStyles = map[string]string{
+	"default": "",
+	"bold":    "FontName=Arial,FontSize=24,Bold=1,Outline=2,Shadow=0",
+	"boxed":   "FontName=Arial,FontSize=22,BorderStyle=3,Outline=1,Shadow=0,BackColour=&H80000000",
+	"yellow":  "FontName=Arial,FontSize=22,PrimaryColour=&H0000FFFF,Outline=2,Shadow=0",
+}
+
+// softSubtitleCodecs maps a container extension to the subtitle codec it can carry
+var softSubtitleCodecs = map[string]string{
+	".mp4":  "mov_text",
+	".m4v":  "mov_text",
+	".mov":  "mov_text",
+	".mkv":  "srt",
+	".webm": "webvtt",
+}
+
+var SubtitleCmd = &cobra.Command{
+	Use:   "subtitle [video]",
+	Short: "Add subtitles to a video",
+	Long: `Transcribe a video and add the subtitles to a copy of it.
+
+By default the subtitles are added as a soft subtitle track that viewers can
+turn on and off. With --burn (or --style) they are drawn into the picture
+instead, which works on every player and platform.
+
+An existing SRT file is reused instead of transcribing: pass it with --srt,
+or keep it next to the video with the same name (video.srt).
+
+Styles for burned subtitles: default, bold, boxed, yellow, or your own ASS
+style overrides such as "FontSize=28,PrimaryColour=&H00FFFFFF".`,
+	Example: `  sona subtitle talk.mp4
+  sona subtitle talk.mp4 --burn --style boxed
+  sona subtitle talk.mp4 --srt edited.srt --output talk-final.mp4`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSubtitle(cmd, args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	SubtitleCmd.Flags().StringVar(&subtitleSRT, "srt", "", "Use this SRT file instead of transcribing (default: video.srt when present)")
+	SubtitleCmd.Flags().StringVarP(&subtitleOutput, "output", "o", "", "Output video (default: video.subtitled with the same extension)")
+	SubtitleCmd.Flags().BoolVar(&subtitleBurn, "burn", false, "Burn the subtitles into the picture instead of adding a subtitle track")
+	SubtitleCmd.Flags().StringVar(&subtitleStyle, "style", "", "Caption style for burned subtitles: default, bold, boxed, yellow, or ASS overrides (implies --burn)")
+	SubtitleCmd.Flags().IntVar(&subtitleLineSize, "line-width", 42, "Maximum characters per subtitle line when transcribing")
+	SubtitleCmd.Flags().StringVarP(&subtitleModel, "model", "m", "slam-1", "Speech model to use (slam-1, best, nano) (default: defaults.model)")
+	SubtitleCmd.Flags().StringVarP(&subtitleLanguage, "language", "l", "", "Language code of the audio, e.g. en, hi (default: provider default)")
+}
+
+func runSubtitle(cmd *cobra.Command, videoPath string) error {
+	if _, err := os.Stat(videoPath); err != nil {
+		return fmt.Errorf("video not found: %s", videoPath)
+	}
+	if !cmd.Flags().Changed("model") {
+		subtitleModel = config.GetDefaultModel()
+	}
+
+	style, err := resolveSubtitleStyle(subtitleStyle)
+	if err != nil {
+		return err
+	}
+	burn := subtitleBurn || subtitleStyle != ""
+
+	output := subtitleOutput
+	if output == "" {
+		output = variantPath(videoPath, "subtitled")
+	}
+	codec, soft := softSubtitleCodecs[strings.ToLower(filepath.Ext(output))]
+	if !burn && !soft {
+		return fmt.Errorf("%s files cannot carry a subtitle track; use --burn or an .mp4, .mkv or .webm --output", filepath.Ext(output))
+	}
+	if filepath.Clean(output) == filepath.Clean(videoPath) {
+		return fmt.Errorf("refusing to overwrite the input video; pass a different --output")
+	}
+
+	srtPath, err := subtitlesFor(videoPath)
+	if err != nil {
+		return err
+	}
+
+	ws, err := workspace.New()
+	if err != nil {
+		return err
+	}
+	defer ws.Remove()
+
+	if burn {
+		fmt.Println("Burning subtitles into the video...")
+		err = burnSubtitles(videoPath, srtPath, style, output, ws)
+	} else {
+		fmt.Println("Adding subtitle track...")
+		err = muxSubtitles(videoPath, srtPath, codec, output)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved to: %s\n", output)
+	return nil
+}
+
+// resolveSubtitleStyle returns the ASS overrides for a named style, or the
+// overrides themselves when given directly
+func resolveSubtitleStyle(style string) (string, error) {
+	style = strings.TrimSpace(style)
+	if strings.Contains(style, "=") {
+		return style, nil
+	}
+	if style == "" {
+		return "", nil
+	}
+	overrides, ok := subtitleStyles[strings.ToLower(style)]
+	if !ok {
+		names := make([]string, 0, len(subtitleStyles))
+		for name := range subtitleStyles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown style %q (supported: %s, or ASS overrides like FontSize=28)", style, strings.Join(names, ", "))
+	}
+	return overrides, nil
+}
+
+// subtitlesFor returns the SRT file to use, transcribing the video into
+// video.srt when none exists yet
+func subtitlesFor(videoPath string) (string, error) {
+	if subtitleSRT != "" {
+		if _, err := os.Stat(subtitleSRT); err != nil {
+			return "", fmt.Errorf("subtitle file not found: %s", subtitleSRT)
+		}
+		fmt.Printf("Using subtitles from %s\n", subtitleSRT)
+		return subtitleSRT, nil
+	}
+
+	srtPath := strings.TrimSuffix(videoPath, filepath.Ext(videoPath)) + ".srt"
+	if _, err := os.Stat(srtPath); err == nil {
+		fmt.Printf("Using existing subtitles from %s\n", srtPath)
+		return srtPath, nil
+	}
+
+	if subtitleLineSize < 10 {
+		return "", fmt.Errorf("--line-width must be at least 10")
+	}
+
+	cues, err := transcribeCues(videoPath)
+	if err != nil {
+		return "", err
+	}
+	if err := writeOutput(srtPath, []byte(formatSRT(cues))); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", srtPath, err)
+	}
+	fmt.Printf("Saved subtitles to: %s (%d cues)\n", srtPath, len(cues))
+	return srtPath, nil
+}
+
+// transcribeCues transcribes the video's audio and groups the words into
+// subtitle cues of up to two lines
+func transcribeCues(videoPath string) ([]cue, error) {
+	ws, err := workspace.New()
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Remove()
+
+	audioPath, err := convertAudioToMP3(videoPath, ws.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("audio conversion failed: %v", err)
+	}
+
+	result, err := batchTranscription(audioPath, subtitleModel, subtitleLanguage, outputProfile{}, &progress.Timings{})
+	if err != nil {
+		return nil, fmt.Errorf("transcription failed: %v", err)
+	}
+	if len(result.Words) == 0 {
+		return nil, fmt.Errorf("no speech detected in %s", videoPath)
+	}
+
+	cues := cuesFromWords(result.Words, subtitleLineSize*2)
+
+	glossary, err := loadGlossary()
+	if err != nil {
+		return nil, err
+	}
+	for i := range cues {
+		text := cues[i].Text
+		if glossary != nil {
+			text, _ = glossary.Apply(text)
+		}
+		cues[i].Text = strings.Join(wrapCaption(text, subtitleLineSize), "\n")
+	}
+	return cues, nil
+}
+
+// cuesFromWords groups words into cues of at most maxChars characters,
+// starting a new cue after sentence ends and pauses
+func cuesFromWords(words []transcript.Word, maxChars int) []cue {
+	var cues []cue
+	var current cue
+	var text []string
+	length := 0
+
+	flush := func() {
+		if len(text) > 0 {
+			current.Text = strings.Join(text, " ")
+			cues = append(cues, current)
+		}
+		text, length = nil, 0
+	}
+
+	for _, word := range words {
+		start := time.Duration(word.Start) * time.Millisecond
+		end := time.Duration(word.End) * time.Millisecond
+
+		if len(text) > 0 && (length+1+len(word.Text) > maxChars || start-current.End >= cueGap) {
+			flush()
+		}
+		if len(text) == 0 {
+			current = cue{Start: start}
+		}
+		text = append(text, word.Text)
+		length += len(word.Text) + 1
+		current.End = end
+
+		if strings.HasSuffix(word.Text, ".") || strings.HasSuffix(word.Text, "?") || strings.HasSuffix(word.Text, "!") {
+			flush()
+		}
+	}
+	flush()
+	return cues
+}
+
+// muxSubtitles copies the video and adds the SRT as a subtitle track
+func muxSubtitles(videoPath string, srtPath string, codec string, output string) error {
+	args := []string{"-hide_banner", "-y", "-i", videoPath, "-i", srtPath,
+		"-map", "0:v?", "-map", "0:a?", "-map", "1:0",
+		"-c:v", "copy", "-c:a", "copy", "-c:s", codec}
+	if subtitleLanguage != "" {
+		args = append(args, "-metadata:s:s:0", "language="+subtitleLanguage)
+	}
+	return runFFmpeg(append(args, output), "")
+}
+
+// burnSubtitles draws the subtitles into the picture. The SRT is copied
+// into the workspace and referenced by a plain name, which sidesteps
+// ffmpeg's filter escaping for paths with colons or quotes.
+func burnSubtitles(videoPath string, srtPath string, style string, output string, ws *workspace.Workspace) error {
+	if err := deps.CopyFile(srtPath, ws.Path("subtitles.srt"), 0644); err != nil {
+		return err
+	}
+
+	filter := "subtitles=subtitles.srt"
+	if style != "" {
+		filter += ":force_style='" + strings.ReplaceAll(style, "'", "") + "'"
+	}
+
+	absVideo, err := filepath.Abs(videoPath)
+	if err != nil {
+		return err
+	}
+	absOutput, err := filepath.Abs(output)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"-hide_banner", "-y", "-i", absVideo, "-vf", filter, "-c:a", "copy", absOutput}
+	return runFFmpeg(args, ws.Dir)
+}
+
+// runFFmpeg runs ffmpeg in dir, logging its output
+func runFFmpeg(args []string, dir string) error {
+	ffmpegPath, err := FindBinary("ffmpeg")
+	if err != nil {
+		return &deps.MissingError{Binary: "ffmpeg", Message: "FFmpeg is required. Run 'sona install' to install dependencies"}
+	}
+
+	cmd := exec.Command(ffmpegPath, args...)
+	cmd.Dir = dir
+	if _, err := logger.RunCommand(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed: %v", err)
+	}
+	return nil
+}
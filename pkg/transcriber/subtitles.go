@@ -0,0 +1,56 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cue is a piece of text shown between two points in the audio
+type cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// subtitleFormats maps a subtitle format name (also its file extension) to its writer
+var subtitleFormats = map[string]func(cues []cue) string{
+	"srt": formatSRT,
+	"vtt": formatVTT,
+	"txt": formatTimedText,
+}
+
+func formatSRT(cues []cue) string {
+	var b strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, subtitleTime(c.Start, ","), subtitleTime(c.End, ","), c.Text)
+	}
+	return b.String()
+}
+
+func formatVTT(cues []cue) string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", subtitleTime(c.Start, "."), subtitleTime(c.End, "."), c.Text)
+	}
+	return b.String()
+}
+
+// formatTimedText writes one "[HH:MM:SS] text" line per cue
+func formatTimedText(cues []cue) string {
+	var b strings.Builder
+	for _, c := range cues {
+		fmt.Fprintf(&b, "[%s] %s\n", formatTimestamp(c.Start), c.Text)
+	}
+	return b.String()
+}
+
+// subtitleTime renders HH:MM:SS followed by the separator and milliseconds
+func subtitleTime(d time.Duration, separator string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, separator, ms%1000)
+}
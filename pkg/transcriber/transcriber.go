@@ -5,213 +5,692 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/Harsh-2002/Sona/pkg/assemblyai"
 	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/ledger"
+	"github.com/Harsh-2002/Sona/pkg/library"
 	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/project"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
 	"github.com/Harsh-2002/Sona/pkg/youtube"
 	"github.com/spf13/cobra"
 )
 
+// Options are the settings that differ between calls: the output path,
+// model and language. The other transcribe settings, such as the formats,
+// profile, provider and music or multilingual mode, are kept by the package
+// for the whole process, so calls running at once share them.
+type Options struct {
+	// OutputPath is where the transcript is saved; empty generates a name in
+	// the configured output directory
+	OutputPath string
+	// SpeechModel is the AssemblyAI model, e.g. slam-1, best or nano; empty
+	// uses defaults.model
+	SpeechModel string
+	// LanguageCode is the language of the audio; empty leaves language
+	// selection to the provider
+	LanguageCode string
+
+	// onRecord, when set, is called with the transcript added to the library
+	onRecord func(record library.Record)
+}
+
+// withDefaults fills in the speech model from the config when it is not set
+func (o Options) withDefaults() Options {
+	if o.SpeechModel == "" {
+		o.SpeechModel = config.GetDefaultModel()
+	}
+	return o
+}
+
 var (
-	outputPath  string
-	speechModel string
+	// transcribeOptions holds the --output, --model and --language flags
+	transcribeOptions Options
+
+	manifestPath string
+	provider     string
+	formats      []string
+	allowEmpty   bool
+	noTimestamp  bool
+
+	correctionsPath string
+	noCorrections   bool
+	proofreadOutput bool
+	queueOffline    bool
+	profileName     string
+	numberStyle     string
+	notifyDesktop   bool
+	ringBell        bool
+	markThreshold   float64
+	speakerCount    int
+	tags            []string
+	projectName     string
+	recursive       bool
 )
 
 var TranscribeCmd = &cobra.Command{
-	Use:   "transcribe [source]",
+	Use:   "transcribe [source...]",
 	Short: "Transcribe audio from YouTube video or local file",
 	Long: `Transcribe audio to text using AssemblyAI.
 	
 Sources:
 - YouTube URL: sona transcribe "https://youtube.com/watch?v=..."
 - Local file: sona transcribe "./audio.mp3"
-
-Examples:
-  sona transcribe "https://youtube.com/watch?v=dQw4w9WgXcQ"
+- Audio URL: sona transcribe "https://cdn.example.com/episode.mp3", which
+  AssemblyAI downloads directly instead of sona downloading and uploading it
+
+Several sources can be given at once. Append "|<language>" to a source
+to override the language for that source only, or list sources in a CSV
+manifest with the columns "source,language".
+
+A directory transcribes the audio and video files in it, and with
+--recursive those of its subdirectories, one transcript per file. With
+--parallel, that many sources are uploaded and transcribed at once.
+
+Voice notes of up to a minute are uploaded without conversion. Give a
+folder of them, e.g. from an exported WhatsApp chat, to --batch-voice-notes
+to merge many into one request and save a transcript for each note.
+
+Profiles bundle transcript options for a kind of work:
+  legal      verbatim record: filler words, spoken numbers, SPEAKER labels,
+             a timestamp on every turn
+  broadcast  clean read: formatted numbers, Speaker labels, a timestamp
+             every 30 seconds
+  casual     formatted text with short speaker labels, no timestamps
+
+Defaults for --model, --provider, --format and --profile are read from the
+defaults.* config keys; flags always take precedence.`,
+	Example: `  sona transcribe "https://youtube.com/watch?v=dQw4w9WgXcQ"
   sona transcribe "./audio.mp3"
   sona transcribe "https://youtube.com/watch?v=..." --output ./transcript.txt
-  sona transcribe "./audio.mp3" --model slam-1`,
-	Args: cobra.ExactArgs(1),
+  sona transcribe "./audio.mp3" --model slam-1
+  sona transcribe "./meeting.mp3|en" "./interview.mp3|hi" --model best
+  sona transcribe --manifest ./archive.csv --language en
+  sona transcribe ./podcasts/ --recursive
+  sona transcribe ./podcasts/ --recursive --parallel 4
+  sona transcribe "./audio.mp3" --format txt,md
+  sona transcribe "./audio.mp3" --proofread
+  sona transcribe "./audio.mp3" --queue
+  sona transcribe "./deposition.mp3" --profile legal
+  sona transcribe "./consult.mp3" --numbers words
+  sona transcribe "./lecture.mp3" --notify-desktop --bell
+  sona transcribe "./lecture.mp3" --provider assemblyai-streaming
+  sona transcribe "./interview.mp3" --mark-uncertain 0.6
+  sona transcribe "./call.mp3" --tag meeting --tag clientX
+  sona transcribe ./interviews/ --project study
+  sona transcribe "./panel.mp3" --speakers-expected 5
+  sona transcribe "./town-hall.mp3" --upload-codec opus
+  sona transcribe "./webinar.mp4" --start 5m --end 1h10m
+  sona transcribe --manifest ./archive.csv --speech-threshold 0.2
+  sona transcribe --batch-voice-notes "./WhatsApp Voice Notes"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if voiceNotesDir != "" {
+			if len(args) > 0 || manifestPath != "" {
+				return fmt.Errorf("--batch-voice-notes cannot be combined with sources or --manifest")
+			}
+			return nil
+		}
+		if len(args) == 0 && manifestPath == "" {
+			return fmt.Errorf("requires at least one source, --manifest or --batch-voice-notes")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
-		source := args[0]
-		fmt.Printf("Source: %s\n", source)
+		if err := applyConfigDefaults(cmd); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		if voiceNotesDir != "" {
+			runVoiceNotes(voiceNotesDir)
+			return
+		}
+
+		sources, err := collectSources(args, manifestPath, transcribeOptions.LanguageCode)
+		if err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+
+		if len(sources) > 1 && transcribeOptions.OutputPath != "" {
+			fmt.Println(style.Error("--output can only be used with a single source"))
+			os.Exit(1)
+		}
+
+		startRun(sources)
+
+		// Without a connection, save the jobs for 'sona queue flush'
+		if queueOffline {
+			if !reachable() {
+				fmt.Println("AssemblyAI is not reachable, queueing for later")
+				if err := enqueueSources(sources); err != nil {
+					finishRun(false, err.Error())
+					fmt.Println(style.Error("%v", err))
+					os.Exit(1)
+				}
+				finishRun(true, "queued while offline")
+				return
+			}
+			pendingQueueNotice()
+		}
 
 		// Check and install dependencies
-		if err := checkAndInstallDependencies(); err != nil {
-			fmt.Printf("Error: Dependency check failed: %v\n", err)
+		names := make([]string, len(sources))
+		for i, source := range sources {
+			names[i] = source.Source
+		}
+		if err := checkAndInstallDependencies(names); err != nil {
+			finishRun(false, "dependency check failed: "+err.Error())
+			fmt.Println(style.Error("Dependency check failed: %v", err))
 			os.Exit(1)
 		}
 
-		// Determine source type and process
-		if youtube.IsYouTubeURL(source) {
-			fmt.Println("Processing YouTube URL...")
-			if err := processYouTubeVideo(source, outputPath, speechModel); err != nil {
-				fmt.Printf("Error: YouTube processing failed: %v\n", err)
+		// Batches carry on past failures and can be retried with 'sona retry'
+		if len(sources) > 1 {
+			if failed := runBatch(sources); failed > 0 {
+				finishRun(false, fmt.Sprintf("%d of %d sources failed", failed, len(sources)))
+				notifyFinished(false, fmt.Sprintf("%d of %d sources failed", failed, len(sources)))
 				os.Exit(1)
 			}
 		} else {
-			fmt.Println("Processing local audio file...")
-			if err := processLocalAudio(source, outputPath, speechModel); err != nil {
-				fmt.Printf("Error: Local audio processing failed: %v\n", err)
-				os.Exit(1)
+			spec := sources[0]
+			fmt.Printf("Source: %s\n", redactURL(spec.Source))
+			if spec.LanguageCode != "" {
+				fmt.Printf("Language: %s\n", spec.LanguageCode)
+			}
+			if deferred, err := deferJob(jobFor(spec)); err != nil {
+				exitWithError("Daily budget", err)
+			} else if deferred {
+				finishRun(true, "deferred by the daily budget")
+				fmt.Println(style.Hint("Run 'sona queue flush --wait' to resume deferred jobs automatically"))
+				return
+			}
+			if err := processSource(spec, transcribeOptions); err != nil {
+				if youtube.IsYouTubeURL(spec.Source) {
+					exitWithError("YouTube processing failed", err)
+				}
+				if isURL(spec.Source) {
+					exitWithError("Remote audio processing failed", err)
+				}
+				exitWithError("Local audio processing failed", err)
 			}
 		}
 
 		fmt.Println("Transcription completed successfully")
+		finishRun(true, completionMessage(sources))
+		notifyFinished(true, completionMessage(sources))
 	},
 }
 
 func init() {
-	TranscribeCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output file path (default: auto-generated)")
-	TranscribeCmd.Flags().StringVarP(&speechModel, "model", "m", "slam-1", "Speech model to use (slam-1, best, nano)")
+	TranscribeCmd.Flags().StringVarP(&transcribeOptions.OutputPath, "output", "o", "", "Output file path (default: auto-generated)")
+	TranscribeCmd.Flags().StringVarP(&transcribeOptions.SpeechModel, "model", "m", "slam-1", "Speech model to use (slam-1, best, nano) (default: defaults.model)")
+	TranscribeCmd.Flags().StringVarP(&transcribeOptions.LanguageCode, "language", "l", "", "Default language code for all sources, e.g. en, hi (default: provider default)")
+	TranscribeCmd.Flags().StringVar(&presetName, "preset", "", "Use the flags saved in this preset (see 'sona preset') for those not given")
+	TranscribeCmd.Flags().StringVar(&manifestPath, "manifest", "", "CSV file listing sources with an optional language column")
+	TranscribeCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Also transcribe the files in subdirectories of directory sources")
+	TranscribeCmd.Flags().StringVar(&voiceNotesDir, "batch-voice-notes", "", "Transcribe the audio files in this folder, merging voice notes of up to a minute into as few requests as possible")
+	TranscribeCmd.Flags().StringVar(&provider, "provider", "assemblyai", "Transcription provider: assemblyai, assemblyai-streaming for live partial results, or hybrid to send only unclear parts of a local whisper.cpp transcript (default: defaults.provider)")
+	TranscribeCmd.Flags().StringSliceVarP(&formats, "format", "f", []string{"txt"}, "Output formats, comma-separated (txt, md, lrc, ass, chunks-jsonl, json) (default: defaults.formats)")
+	TranscribeCmd.Flags().BoolVar(&lrcWordSync, "lrc-words", false, "Time every word in lrc output (enhanced LRC) instead of every line")
+	TranscribeCmd.Flags().IntVar(&chunkTokens, "chunk-tokens", defaultChunkTokens, "Largest chunk in chunks-jsonl output, in estimated tokens")
+	TranscribeCmd.Flags().IntVar(&chunkOverlap, "chunk-overlap", defaultChunkOverlap, "Tokens each chunk in chunks-jsonl output repeats from the one before")
+	TranscribeCmd.Flags().StringVar(&profileName, "profile", "", "Output profile: legal, broadcast or casual (default: defaults.profile)")
+	TranscribeCmd.Flags().StringVar(&numberStyle, "numbers", "", "Write numbers as spoken words or as digits (words, digits) (default: from profile, else digits)")
+	TranscribeCmd.Flags().IntVar(&speakerCount, "speakers-expected", 0, "Number of speakers in the audio, to help diarization tell similar voices apart (enables speaker labels)")
+	TranscribeCmd.Flags().Float64Var(&markThreshold, "mark-uncertain", 0, "Mark words below this confidence (0-1), e.g. 0.6 wraps them as [?word?]")
+	TranscribeCmd.Flags().BoolVar(&multilingual, "multilingual", false, "For audio that switches languages: split it where the language changes and transcribe each part in its language, tagged as [xx]")
+	TranscribeCmd.Flags().StringVar(&musicMode, "music", "", "Handle music-only stretches: mark them as [music], remove them, skip uploading them, or trim only the intro and outro (mark, remove, skip, trim)")
+	TranscribeCmd.Flags().DurationVar(&audioStart, "start", 0, "Transcribe from this point of the audio, e.g. 1m30s (the whole file is still uploaded)")
+	TranscribeCmd.Flags().DurationVar(&audioEnd, "end", 0, "Stop transcribing at this point of the audio, e.g. 45m")
+	TranscribeCmd.Flags().Float64Var(&speechThreshold, "speech-threshold", 0, "Reject audio in which less than this share (0-1) is speech, e.g. 0.2 to skip files that are mostly music or noise")
+	TranscribeCmd.Flags().StringArrayVarP(&tags, "tag", "t", nil, "Tag the transcript for 'sona list --tag' (repeatable)")
+	TranscribeCmd.Flags().StringVar(&projectName, "project", "", "Add the transcript to this project and correct near misses of its vocabulary (see 'sona project')")
+	TranscribeCmd.Flags().BoolVar(&noTimestamp, "no-timestamp", false, "Do not append a timestamp to generated filenames")
+	TranscribeCmd.Flags().StringVar(&nameTemplate, "name-template", "", "Layout of generated filenames, from {title}, {date} and {preset} (default \"{title}-{date}\")")
+	TranscribeCmd.Flags().StringVar(&correctionsPath, "corrections", "", "Glossary of corrections to apply (default: ~/.sona/corrections.yaml)")
+	TranscribeCmd.Flags().BoolVar(&noCorrections, "no-corrections", false, "Do not apply the corrections glossary")
+	TranscribeCmd.Flags().BoolVar(&showNotes, "show-notes", false, "Also write Markdown show notes with a summary, chapters, key topics and links")
+	TranscribeCmd.Flags().BoolVar(&proofreadOutput, "proofread", false, "Also save a spell- and grammar-checked .corrected copy (see proofread.* config)")
+	TranscribeCmd.Flags().StringVar(&uploadCodec, "upload-codec", "", "Codec of the uploaded audio: mp3, or opus for a 5-10x smaller upload on slow or metered connections")
+	TranscribeCmd.Flags().StringVar(&jobPriority, "priority", "", "Priority of the sources in batches and the queue: high, normal or low (default: normal)")
+	TranscribeCmd.Flags().Float64Var(&monthlyBudget, "budget", 0, "Refuse jobs that would take this month's transcription cost past this amount (needs usage.price_per_hour or usage.model_prices)")
+	TranscribeCmd.Flags().StringVar(&webhookURL, "webhook", "", "POST a JSON event to this URL when each source finishes or fails")
+	TranscribeCmd.Flags().StringVar(&webhookTemplate, "webhook-template", "", "Go template file that renders the webhook payload from the event, e.g. {\"text\": {{json .Text}}}")
+	TranscribeCmd.Flags().BoolVar(&noDashboard, "no-dashboard", false, "With several sources, print the output of each instead of the live batch dashboard")
+	TranscribeCmd.Flags().IntVar(&prefetchDepth, "prefetch", 1, "With several sources, download or convert this many ahead while one transcribes (0 = one at a time)")
+	TranscribeCmd.Flags().IntVar(&parallelJobs, "parallel", 1, fmt.Sprintf("With several sources, upload and transcribe this many at once (up to %d)", maxParallelJobs))
+	TranscribeCmd.Flags().BoolVar(&queueOffline, "queue", false, "Queue the sources for 'sona queue flush' when offline instead of failing")
+	TranscribeCmd.Flags().BoolVar(&notifyDesktop, "notify-desktop", false, "Show a desktop notification when transcription finishes")
+	TranscribeCmd.Flags().BoolVar(&ringBell, "bell", false, "Ring the terminal bell when transcription finishes")
+	TranscribeCmd.Flags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Transcribe even when the audio matches an earlier transcript in the library")
+	TranscribeCmd.Flags().BoolVar(&autoUpgrade, "auto-upgrade", false, "Re-transcribe poor-quality results with a better model or cleaned-up audio without asking (see quality.rerun_below)")
+	TranscribeCmd.Flags().BoolVar(&allowEmpty, "allow-empty", false, "Write a placeholder transcript when no speech is detected instead of failing")
 }
 
-// checkAndInstallDependencies ensures both yt-dlp and ffmpeg are available
-func checkAndInstallDependencies() error {
-	fmt.Println("🔍 Checking dependencies...")
+// applyConfigDefaults fills in options the user did not pass as flags from
+// the --preset, then the defaults.* config keys, and validates the result
+func applyConfigDefaults(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	if err := applyPreset(flags); err != nil {
+		return err
+	}
+
+	if !flags.Changed("model") {
+		transcribeOptions.SpeechModel = config.GetDefaultModel()
+	}
+	if !flags.Changed("provider") {
+		provider = config.GetDefaultProvider()
+	}
+	if !flags.Changed("format") {
+		formats = config.GetDefaultFormats()
+	}
+	if !flags.Changed("profile") {
+		profileName = config.GetDefaultProfile()
+	}
+
+	if err := validateProvider(provider); err != nil {
+		return err
+	}
+	if err := validateRegion(provider, transcribeOptions.SpeechModel); err != nil {
+		return err
+	}
+	if _, err := lookupProfile(profileName); err != nil {
+		return err
+	}
+	numberStyle = strings.ToLower(strings.TrimSpace(numberStyle))
+	if err := validateNumberStyle(numberStyle); err != nil {
+		return err
+	}
+	if err := validateUncertainThreshold(markThreshold); err != nil {
+		return err
+	}
+	if err := validateSpeakersExpected(speakerCount); err != nil {
+		return err
+	}
+	if err := validateChunking(chunkTokens, chunkOverlap); err != nil {
+		return err
+	}
+	if err := validateNameTemplate(nameTemplate); err != nil {
+		return err
+	}
+	if err := validateParallel(parallelJobs); err != nil {
+		return err
+	}
+	if projectName != "" {
+		if _, err := project.Load(projectName); err != nil {
+			return err
+		}
+	}
+	uploadCodec = strings.ToLower(strings.TrimSpace(uploadCodec))
+	if err := validateUploadCodec(uploadCodec); err != nil {
+		return err
+	}
+	if speakerCount > 0 && (provider == providerStreaming || provider == providerHybrid) {
+		return fmt.Errorf("--speakers-expected is not supported with the %s provider", provider)
+	}
+	if err := validateMonthlyBudget(monthlyBudget, transcribeOptions.SpeechModel); err != nil {
+		return err
+	}
+	if err := validateWebhook(); err != nil {
+		return err
+	}
+	jobPriority = strings.ToLower(strings.TrimSpace(jobPriority))
+	if err := validatePriority(jobPriority); err != nil {
+		return err
+	}
+	musicMode = strings.ToLower(strings.TrimSpace(musicMode))
+	if err := validateMusicMode(musicMode); err != nil {
+		return err
+	}
+	if multilingual && (provider == providerStreaming || provider == providerHybrid) {
+		return fmt.Errorf("--multilingual is not supported with the %s provider", provider)
+	}
+	if err := validateAudioRange(audioStart, audioEnd); err != nil {
+		return err
+	}
+	if err := validateSpeechThreshold(speechThreshold); err != nil {
+		return err
+	}
+	if audioStart > 0 || audioEnd > 0 || speechThreshold > 0 {
+		switch {
+		case provider == providerStreaming || provider == providerHybrid:
+			return fmt.Errorf("--start, --end and --speech-threshold are not supported with the %s provider", provider)
+		case multilingual:
+			return fmt.Errorf("--start, --end and --speech-threshold cannot be combined with --multilingual")
+		case cutsMusic(musicMode) && (audioStart > 0 || audioEnd > 0):
+			return fmt.Errorf("--start and --end cannot be combined with --music %s, which shortens the audio", musicMode)
+		}
+	}
+
+	validFormats, err := validateFormats(formats)
+	if err != nil {
+		return err
+	}
+	formats = validFormats
+
+	return nil
+}
+
+// checkAndInstallDependencies ensures the tools the sources need are
+// available: yt-dlp for YouTube, and FFmpeg for YouTube, videos and formats
+// AssemblyAI does not accept as they are
+func checkAndInstallDependencies(sources []string) error {
+	fmt.Println(style.Icon("🔍 ") + "Checking dependencies...")
 	logger.LogInfo("Checking dependencies")
 
+	var needYtDlp, needFFmpeg bool
+	remote := 0
+	for _, source := range sources {
+		if youtube.IsYouTubeURL(source) {
+			needYtDlp, needFFmpeg = true, true
+		} else if isURL(source) {
+			// AssemblyAI downloads other URLs itself
+			remote++
+		} else if !uploadableFormats[strings.ToLower(filepath.Ext(source))] {
+			needFFmpeg = true
+		}
+	}
+	if remote == len(sources) {
+		return nil
+	}
+
 	// Check yt-dlp
-	ytdlpPath, err := youtube.FindBinary("yt-dlp")
-	if err != nil {
-		fmt.Println("❌ yt-dlp not found")
-		fmt.Println("💡 Run 'sona install' to install dependencies")
-		return fmt.Errorf("yt-dlp not found. Run 'sona install' to install dependencies")
+	if needYtDlp {
+		ytdlpPath, err := youtube.FindBinary("yt-dlp")
+		if err != nil {
+			fmt.Println(style.Failure("yt-dlp not found"))
+			fmt.Println(style.Hint("Run 'sona install' to install dependencies"))
+			return &deps.MissingError{Binary: "yt-dlp", Message: "yt-dlp not found. Run 'sona install' to install dependencies"}
+		}
+		logger.LogInfo("yt-dlp found at: %s", ytdlpPath)
 	}
-	logger.LogInfo("yt-dlp found at: %s", ytdlpPath)
 
 	// Check ffmpeg
 	ffmpegPath, err := FindBinary("ffmpeg")
 	if err != nil {
-		fmt.Println("❌ FFmpeg not found")
-		fmt.Println("💡 Run 'sona install' to install dependencies")
-		return fmt.Errorf("FFmpeg not found. Run 'sona install' to install dependencies")
+		if !needFFmpeg {
+			// Every source can be uploaded as it is
+			fmt.Println(style.Warning("FFmpeg not found, uploading without conversion or audio checks"))
+			logger.LogWarning("FFmpeg not available: %v", err)
+			return nil
+		}
+		fmt.Println(style.Failure("FFmpeg not found"))
+		fmt.Println(style.Hint("Run 'sona install' to install dependencies"))
+		return &deps.MissingError{Binary: "ffmpeg", Message: fmt.Sprintf("FFmpeg not found; it is needed for YouTube, videos and formats other than %s. Run 'sona install' to install dependencies",
+			strings.Join(UnconvertedFormats(), ", "))}
 	}
 	logger.LogInfo("FFmpeg found at: %s", ffmpegPath)
 
 	// On macOS, also check for ffprobe
-	if runtime.GOOS == "darwin" {
+	if runtime.GOOS == "darwin" && needYtDlp {
 		if _, err := FindBinary("ffprobe"); err != nil {
-			fmt.Println("❌ ffprobe not found on macOS")
-			fmt.Println("💡 Run 'sona install' to install dependencies")
-			return fmt.Errorf("ffprobe not found on macOS. Run 'sona install' to install dependencies")
+			fmt.Println(style.Failure("ffprobe not found on macOS"))
+			fmt.Println(style.Hint("Run 'sona install' to install dependencies"))
+			return &deps.MissingError{Binary: "ffprobe", Message: "ffprobe not found on macOS. Run 'sona install' to install dependencies"}
 		} else {
 			logger.LogInfo("ffprobe found")
 		}
 	}
 
-	fmt.Println("🎯 All dependencies are ready!")
+	fmt.Println(style.Icon("🎯 ") + "All dependencies are ready!")
 	return nil
 }
 
-func processYouTubeVideo(url string, outputPath string, speechModel string) error {
+func processYouTubeVideo(url string, opts Options) error {
 	fmt.Println("Processing YouTube URL...")
 	logger.LogInfo("Processing YouTube video: %s", url)
 
+	// A video transcribed before needs no download
+	if useExisting(url, sourceIdentity{}) {
+		return nil
+	}
+
+	timings := newTimings(url)
+
+	// Downloads go to the cache, where a partial download is kept to be
+	// resumed when the connection drops
+	downloadDir, err := youtube.DownloadDir(url)
+	if err != nil {
+		return err
+	}
+
+	// Make sure the download and the transcript fit before starting
+	if err := workspace.CheckFreeSpace(downloadDir, estimateDownloadSize(url), "YouTube download"); err != nil {
+		return err
+	}
+	if err := workspace.CheckFreeSpace(outputDirFor(opts.OutputPath), transcriptSpace, "transcript"); err != nil {
+		return err
+	}
+
 	// Download audio from YouTube
-	audioFile, err := youtube.DownloadAudio(url, filepath.Dir(outputPath))
+	timings.Begin("download")
+	audioFile, err := downloadYouTubeAudio(url)
+	timings.End()
 	if err != nil {
 		logger.LogError("Failed to download YouTube audio: %v", err)
-		return fmt.Errorf("failed to download YouTube audio: %v", err)
+		return fmt.Errorf("failed to download YouTube audio: %w", err)
 	}
+	defer youtube.RemoveDownload(url)
+	audioReady()
 
 	logger.LogInfo("Audio downloaded successfully: %s", audioFile)
 
+	identity, skip := checkDuplicate(url, audioFile)
+	if skip {
+		return nil
+	}
+
+	basePath, err := transcriptBasePath(url, "youtube", opts.OutputPath)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	livePath := liveTranscriptPath(basePath)
+
 	// Transcribe the audio
-	transcript, err := transcribeAudio(audioFile, speechModel)
+	transcript, result, err := transcribeAudio(audioFile, opts.SpeechModel, opts.LanguageCode, livePath, timings)
 	if err != nil {
-		logger.LogError("Failed to transcribe YouTube audio: %v", err)
-		return fmt.Errorf("failed to transcribe audio: %v", err)
+		if transcript, err = placeholderForEmpty(err); err != nil {
+			logger.LogError("Failed to transcribe YouTube audio: %v", err)
+			return fmt.Errorf("failed to transcribe audio: %w", err)
+		}
 	}
 
+	quality := assessQuality(result, audioFile)
+	printQuality(quality, opts.SpeechModel)
+
 	// Save transcript
-	if err := saveTranscript(transcript, url, "youtube"); err != nil {
+	files, err := saveTranscript(transcript, url, basePath, opts.OutputPath != "", result)
+	if err != nil {
 		logger.LogError("Failed to save transcript: %v", err)
 		return fmt.Errorf("failed to save transcript: %v", err)
 	}
+	discardLiveTranscript(livePath)
+	recordTranscript(basePath, url, "youtube", audioFile, opts, opts.SpeechModel, transcript, result, files, identity, quality)
+	offerRerun(rerunSource{
+		AudioPath:  audioFile,
+		Source:     url,
+		SourceType: "youtube",
+		BasePath:   basePath,
+		Options:    opts,
+		Quality:    quality,
+	}, timings)
 
-	// Clean up audio file
-	os.Remove(audioFile)
 	logger.LogInfo("YouTube video processing completed successfully")
+	printTimingSummary(timings)
 
 	return nil
 }
 
-func processLocalAudio(filePath string, outputPath string, speechModel string) error {
+func processLocalAudio(filePath string, opts Options) error {
+	// Other URLs are handed to AssemblyAI by processRemoteAudio
+	if isURL(filePath) {
+		return &SourceError{Source: filePath, Message: fmt.Sprintf("unsupported source %s: not a local file", redactURL(filePath))}
+	}
+
 	// Check if file exists
 	_, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
-		return fmt.Errorf("audio file not found: %s", filePath)
+		return &SourceError{Source: filePath, Message: fmt.Sprintf("audio file not found: %s", filePath)}
 	}
 
 	// Show file info
 	fmt.Printf("Processing: %s\n", filepath.Base(filePath))
 
-	// Create temporary directory for conversion
-	tempDir, err := os.MkdirTemp("", "sona-*")
+	// Create temporary workspace for conversion
+	ws, err := workspace.New()
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %v", err)
+		return err
+	}
+	defer ws.Remove()
+
+	// Make sure the converted audio and the transcript fit before starting
+	if err := workspace.CheckFreeSpace(ws.Dir, estimateConversionSize(filePath), "audio conversion"); err != nil {
+		return err
+	}
+	if err := workspace.CheckFreeSpace(outputDirFor(opts.OutputPath), transcriptSpace, "transcript"); err != nil {
+		return err
+	}
+
+	// Identify the original file: conversion would change its hash
+	identity, skip := checkDuplicate(filePath, filePath)
+	if skip {
+		return nil
 	}
-	defer os.RemoveAll(tempDir)
+
+	timings := newTimings(filePath)
 
 	// Convert audio to MP3 format for better compatibility
-	convertedPath, err := convertAudioToMP3(filePath, tempDir)
+	timings.Begin("convert")
+	convertedPath, err := convertLocalAudio(filePath, ws)
+	timings.End()
+	if err != nil {
+		return fmt.Errorf("audio conversion failed: %w", err)
+	}
+	audioReady()
+
+	basePath, err := transcriptBasePath(filePath, "local", opts.OutputPath)
 	if err != nil {
-		return fmt.Errorf("audio conversion failed: %v", err)
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+
+	// Audiobooks get a transcript per chapter besides the whole book
+	if chapters := audiobookChapters(filePath); chapters != nil {
+		return transcribeAudiobook(filePath, convertedPath, basePath, opts, chapters, identity, timings)
 	}
+	livePath := liveTranscriptPath(basePath)
 
 	// Transcribe the converted audio
-	transcript, err := transcribeAudio(convertedPath, speechModel)
+	transcript, result, err := transcribeAudio(convertedPath, opts.SpeechModel, opts.LanguageCode, livePath, timings)
 	if err != nil {
-		return fmt.Errorf("transcription failed: %v", err)
+		if transcript, err = placeholderForEmpty(err); err != nil {
+			return fmt.Errorf("transcription failed: %w", err)
+		}
 	}
 
+	quality := assessQuality(result, convertedPath)
+	printQuality(quality, opts.SpeechModel)
+
 	// Save transcript
-	if err := saveTranscript(transcript, filePath, "local"); err != nil {
+	files, err := saveTranscript(transcript, filePath, basePath, opts.OutputPath != "", result)
+	if err != nil {
 		return fmt.Errorf("failed to save transcript: %v", err)
 	}
-
+	discardLiveTranscript(livePath)
+	recordTranscript(basePath, filePath, "local", convertedPath, opts, opts.SpeechModel, transcript, result, files, identity, quality)
+	offerRerun(rerunSource{
+		AudioPath:  convertedPath,
+		Source:     filePath,
+		SourceType: "local",
+		BasePath:   basePath,
+		Options:    opts,
+		Quality:    quality,
+	}, timings)
+
+	printTimingSummary(timings)
 	return nil
 }
 
-// convertAudioToMP3 converts audio file to MP3 format for better compatibility
+// uploadableFormats are the audio files AssemblyAI accepts as they are. When
+// FFmpeg is not installed they are uploaded without conversion.
+var uploadableFormats = map[string]bool{
+	".mp3": true, ".wav": true, ".m4a": true, ".flac": true,
+	".ogg": true, ".opus": true, ".aac": true,
+}
+
+// UnconvertedFormats lists the file extensions that can be transcribed
+// without FFmpeg
+func UnconvertedFormats() []string {
+	formats := make([]string, 0, len(uploadableFormats))
+	for ext := range uploadableFormats {
+		formats = append(formats, ext)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// convertAudioToMP3 converts audio file to MP3 format for better compatibility.
+// Without FFmpeg, files AssemblyAI accepts as they are are returned unconverted;
+// voice notes in those formats are copied into outputDir unconverted.
 func convertAudioToMP3(inputPath string, outputDir string) (string, error) {
 	// Check if ffmpeg is installed
 	ffmpegPath, err := FindBinary("ffmpeg")
 	if err != nil {
-		// FFmpeg not found
-		fmt.Println("❌ FFmpeg not found")
-		fmt.Println("💡 Run 'sona install' to install dependencies")
-		return "", fmt.Errorf("FFmpeg is required for audio conversion. Run 'sona install' to install dependencies")
+		ext := strings.ToLower(filepath.Ext(inputPath))
+		if uploadableFormats[ext] {
+			fmt.Println(style.Warning("FFmpeg not found, uploading %s without conversion", filepath.Base(inputPath)))
+			logger.LogWarning("Skipping conversion of %s: %v", inputPath, err)
+			return inputPath, nil
+		}
+		fmt.Println(style.Failure("FFmpeg not found"))
+		fmt.Println(style.Hint("Run 'sona install' to install dependencies"))
+		return "", &deps.MissingError{Binary: "ffmpeg", Message: fmt.Sprintf("FFmpeg is required to convert %s files; without it only %s files can be transcribed. Run 'sona install' to install dependencies",
+			strings.TrimPrefix(ext, "."), strings.Join(UnconvertedFormats(), ", "))}
+	}
+
+	// Voice notes are short enough that converting them costs more than
+	// uploading them as they are
+	if isVoiceNote(inputPath) {
+		return copyVoiceNote(inputPath, outputDir)
 	}
 
 	// Create output path
 	outputPath := filepath.Join(outputDir, "converted.mp3")
 
+	// Match the encoding to the source so phone audio is not upsampled
+	params := defaultConversion
+	if stream, err := probeAudioStream(inputPath); err != nil {
+		logger.LogWarning("Could not read audio stream of %s, using default conversion: %v", inputPath, err)
+	} else {
+		params = conversionFor(stream)
+		logger.LogInfo("Source audio: %s; converting at %d Hz, %d channels, %d kbps",
+			stream.describe(), params.SampleRate, params.Channels, params.Bitrate)
+		warnNarrowband(stream)
+	}
+
 	fmt.Println("Converting audio to MP3 format...")
 
 	// Run ffmpeg to convert the file
-	cmd := exec.Command(ffmpegPath,
-		"-i", inputPath,
-		"-vn",          // No video
-		"-ar", "44100", // Sample rate
-		"-ac", "2", // Stereo
-		"-b:a", "192k", // Bitrate
+	args := []string{"-i", inputPath, "-vn"} // No video
+	args = append(args, params.ffmpegArgs()...)
+	args = append(args,
 		"-f", "mp3", // Format
 		"-y", // Overwrite output
 		outputPath)
-
-	// Hide ffmpeg output
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-
-	if err := cmd.Run(); err != nil {
+	cmd := exec.Command(ffmpegPath, args...)
+	if _, err := logger.RunCommand(cmd); err != nil {
 		return "", fmt.Errorf("failed to convert audio: %v", err)
 	}
 
@@ -224,37 +703,32 @@ func convertAudioToMP3(inputPath string, outputDir string) (string, error) {
 	return outputPath, nil
 }
 
-// FindBinary finds FFmpeg binary in PATH or user's bin directory
+// FindBinary finds FFmpeg binaries in sona's bin directory, PATH or ~/bin
 func FindBinary(binaryName string) (string, error) {
-	// First check if it's in PATH
-	if path, err := exec.LookPath(binaryName); err == nil {
-		return path, nil
-	}
-
-	// For Unix-like systems, check user's bin directory
-	if runtime.GOOS != "windows" {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			userBinPath := filepath.Join(homeDir, "bin", binaryName)
-			if _, err := os.Stat(userBinPath); err == nil {
-				return userBinPath, nil
-			}
-		}
-	}
-
-	// Not found
-	return "", fmt.Errorf("%s not found", binaryName)
+	return deps.FindBinary(binaryName)
 }
 
-// InstallFFmpeg attempts to install FFmpeg
-func InstallFFmpeg() error {
+// InstallFFmpeg attempts to install FFmpeg from the given source
+func InstallFFmpeg(source deps.InstallSource) error {
 	// Direct binary download is more reliable across platforms
-	fmt.Println("Downloading FFmpeg binary directly...")
-	return downloadFFmpegBinary()
+	fmt.Printf("Installing FFmpeg binary from %s...\n", source.Describe())
+	if err := downloadFFmpegBinary(source); err != nil {
+		return err
+	}
+	// Check what was downloaded runs and is FFmpeg before it is ever used
+	if _, err := deps.VerifyManaged("ffmpeg"); err != nil {
+		return err
+	}
+	if _, ok := deps.ManagedPath("ffprobe"); ok {
+		if _, err := deps.VerifyManaged("ffprobe"); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // downloadFFmpegBinary downloads FFmpeg binary directly for the current platform
-func downloadFFmpegBinary() error {
+func downloadFFmpegBinary(source deps.InstallSource) error {
 	fmt.Println("Attempting to download FFmpeg binary...")
 
 	platform := getPlatform()
@@ -262,9 +736,14 @@ func downloadFFmpegBinary() error {
 
 	logger.LogInfo("Detected platform: %s, architecture: %s", platform, arch)
 
+	// Ready binaries in the install directory need no download or extraction
+	if installed, err := copyLocalFFmpeg(source); installed || err != nil {
+		return err
+	}
+
 	if platform == "macos" {
 		// For macOS, download both ffmpeg and ffprobe from evermeet.cx
-		return downloadMacOSFFmpeg()
+		return downloadMacOSFFmpeg(source)
 	}
 
 	// For other platforms, use BtbN builds
@@ -275,15 +754,10 @@ func downloadFFmpegBinary() error {
 
 	logger.LogInfo("Downloading FFmpeg from: %s", downloadURL)
 
-	// Create bin directory if it doesn't exist (consistent path across Unix-like systems)
-	homeDir, err := os.UserHomeDir()
+	// Create sona's bin directory if it doesn't exist
+	binDir, err := deps.EnsureBinDir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %v", err)
-	}
-
-	binDir := filepath.Join(homeDir, "bin")
-	if err := os.MkdirAll(binDir, 0755); err != nil {
-		return fmt.Errorf("failed to create bin directory: %v", err)
+		return err
 	}
 
 	// Change to the bin directory for extraction
@@ -298,8 +772,7 @@ func downloadFFmpegBinary() error {
 	defer os.Chdir(originalDir)
 
 	// Download the archive
-	cmd := exec.Command("curl", "-L", "-o", filename, downloadURL)
-	if err := cmd.Run(); err != nil {
+	if err := source.Fetch(path.Base(downloadURL), downloadURL, filename); err != nil {
 		return fmt.Errorf("failed to download FFmpeg: %v", err)
 	}
 
@@ -312,18 +785,41 @@ func downloadFFmpegBinary() error {
 	return nil
 }
 
-// downloadMacOSFFmpeg downloads ffmpeg and ffprobe for macOS from evermeet.cx
-func downloadMacOSFFmpeg() error {
-	logger.LogInfo("Downloading FFmpeg and ffprobe for macOS from evermeet.cx")
+// copyLocalFFmpeg installs ffmpeg (and ffprobe, when present) straight from
+// the install directory. It reports whether anything was installed.
+func copyLocalFFmpeg(source deps.InstallSource) (bool, error) {
+	ffmpegSrc, ok := source.LocalBinary("ffmpeg")
+	if !ok {
+		return false, nil
+	}
 
-	homeDir, err := os.UserHomeDir()
+	binDir, err := deps.EnsureBinDir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %v", err)
+		return true, err
 	}
 
-	binDir := filepath.Join(homeDir, "bin")
-	if err := os.MkdirAll(binDir, 0755); err != nil {
-		return fmt.Errorf("failed to create bin directory: %v", err)
+	if err := deps.CopyFile(ffmpegSrc, filepath.Join(binDir, "ffmpeg"), 0755); err != nil {
+		return true, fmt.Errorf("failed to copy ffmpeg: %v", err)
+	}
+
+	if ffprobeSrc, ok := source.LocalBinary("ffprobe"); ok {
+		if err := deps.CopyFile(ffprobeSrc, filepath.Join(binDir, "ffprobe"), 0755); err != nil {
+			return true, fmt.Errorf("failed to copy ffprobe: %v", err)
+		}
+	}
+
+	logger.LogInfo("FFmpeg copied from %s to %s", source.Dir, binDir)
+	return true, nil
+}
+
+// downloadMacOSFFmpeg downloads ffmpeg and ffprobe for macOS from evermeet.cx
+// (or the equivalent ffmpeg.zip / ffprobe.zip assets from the install source)
+func downloadMacOSFFmpeg(source deps.InstallSource) error {
+	logger.LogInfo("Downloading FFmpeg and ffprobe for macOS from evermeet.cx")
+
+	binDir, err := deps.EnsureBinDir()
+	if err != nil {
+		return err
 	}
 
 	// Download ffmpeg
@@ -331,14 +827,13 @@ func downloadMacOSFFmpeg() error {
 	ffmpegPath := filepath.Join(binDir, "ffmpeg.zip")
 	logger.LogInfo("Downloading ffmpeg from: %s", ffmpegURL)
 
-	cmd := exec.Command("curl", "-L", "-o", ffmpegPath, ffmpegURL)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		logger.LogError("Failed to download ffmpeg: %v, output: %s", err, string(output))
+	if err := source.Fetch("ffmpeg.zip", ffmpegURL, ffmpegPath); err != nil {
+		logger.LogError("Failed to download ffmpeg: %v", err)
 		return fmt.Errorf("failed to download ffmpeg: %v", err)
 	}
 
 	// Extract ffmpeg
-	cmd = exec.Command("unzip", "-q", "-o", ffmpegPath, "-d", binDir)
+	cmd := exec.Command("unzip", "-q", "-o", ffmpegPath, "-d", binDir)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		logger.LogError("Failed to extract ffmpeg: %v, output: %s", err, string(output))
 		return fmt.Errorf("failed to extract ffmpeg: %v", err)
@@ -349,9 +844,8 @@ func downloadMacOSFFmpeg() error {
 	ffprobePath := filepath.Join(binDir, "ffprobe.zip")
 	logger.LogInfo("Downloading ffprobe from: %s", ffprobeURL)
 
-	cmd = exec.Command("curl", "-L", "-o", ffprobePath, ffprobeURL)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		logger.LogError("Failed to download ffprobe: %v, output: %s", err, string(output))
+	if err := source.Fetch("ffprobe.zip", ffprobeURL, ffprobePath); err != nil {
+		logger.LogError("Failed to download ffprobe: %v", err)
 		return fmt.Errorf("failed to download ffprobe: %v", err)
 	}
 
@@ -537,18 +1031,251 @@ func addToPath(binDir string) error {
 	return os.Setenv("PATH", currentPath)
 }
 
-func transcribeAudio(audioPath string, speechModel string) (string, error) {
+// transcribeAudio transcribes the file with the selected provider and applies
+// the output profile and post-processing. It returns the final text and the
+// transcript with word timings the provider's result was normalized into.
+// Streaming sessions append finalized turns to livePath as they arrive.
+func transcribeAudio(audioPath string, speechModel string, languageCode string, livePath string, timings *progress.Timings) (string, *transcript.Transcript, error) {
 	// Verify file exists
 	_, err := os.Stat(audioPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to open audio file: %v", err)
+		return "", nil, fmt.Errorf("failed to open audio file: %v", err)
+	}
+
+	if languageCode != "" {
+		logger.LogInfo("Using language code: %s", languageCode)
+	}
+
+	profile, err := lookupProfile(profileName)
+	if err != nil {
+		return "", nil, err
+	}
+	profile = profile.withNumberStyle(numberStyle).withSpeakers(speakerCount)
+
+	// Music is found before upload so --music skip and trim can leave it out
+	var music, kept []musicSegment
+	if musicMode != "" {
+		var duration time.Duration
+		music, duration, err = detectMusic(audioPath, musicMode)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(music) > 0 {
+			fmt.Printf("Detected %d music segments (%s)\n", len(music), formatTimestamp(totalMusic(music)))
+			logger.LogInfo("Detected %d music segments in %s", len(music), audioPath)
+		}
+		if cutsMusic(musicMode) && len(music) > 0 {
+			if audioPath, kept, err = cutMusic(audioPath, music, duration); err != nil {
+				return "", nil, err
+			}
+		}
 	}
 
+	var result *transcript.Transcript
+	if provider == providerStreaming {
+		result, err = streamTranscription(audioPath, languageCode, livePath, timings)
+	} else if provider == providerHybrid {
+		result, err = hybridTranscription(audioPath, speechModel, languageCode, profile, timings)
+	} else if multilingual && languageCode == "" {
+		result, err = multilingualTranscription(audioPath, profile, livePath, timings)
+	} else {
+		result, err = batchTranscription(audioPath, speechModel, languageCode, profile, timings)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	// The hybrid provider only counts the audio it sent to AssemblyAI
+	if provider != providerHybrid {
+		recordUsage(result, audioPath)
+	}
+
+	if kept != nil {
+		restoreTimings(result, kept)
+	}
+	applyMusic(result, music, musicMode)
+
+	text, err := finishTranscript(result, profile, audioPath)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, result, nil
+}
+
+// finishTranscript marks uncertain words, renders the transcript with the
+// profile and post-processes the text, failing when no speech was found in
+// the audio
+func finishTranscript(result *transcript.Transcript, profile outputProfile, audioPath string) (string, error) {
+	openMarker, closeMarker := config.GetUncertainMarkers()
+	if marked := markUncertain(result, markThreshold, openMarker, closeMarker); marked > 0 {
+		fmt.Printf("Marked %d uncertain words\n", marked)
+	}
+	if profile.VerbatimNumbers {
+		applyVerbatimNumbers(result)
+	}
+	text := profile.render(result)
+
+	if err := checkEmptyTranscript(text, audioPath); err != nil {
+		return "", err
+	}
+	return postProcess(text)
+}
+
+// newClient returns an AssemblyAI client for the configured key that moves
+// on to the keys in assemblyai.api_keys when an account hits its limit, and
+// connects with the network.* settings and timeouts
+func newClient() *assemblyai.Client {
 	client := assemblyai.NewClient(config.GetAPIKey())
-	return client.TranscribeAudio(audioPath, speechModel)
+	client.FallbackKeys = config.GetFallbackAPIKeys()
+	client.Region = config.GetRegion()
+	client.UseTransport(transportOptions())
+	return client
 }
 
-func saveTranscript(transcript string, source string, sourceType string) error {
+// transportOptions are the network.* settings and timeouts
+func transportOptions() assemblyai.TransportOptions {
+	return assemblyai.TransportOptions{
+		HTTP2:             config.GetHTTP2(),
+		WriteBufferSize:   config.GetUploadBuffer(),
+		IdleTimeout:       config.GetIdleTimeout(),
+		ConnectTimeout:    config.GetConnectTimeout(),
+		RequestTimeout:    config.GetRequestTimeout(),
+		UploadIdleTimeout: config.GetUploadIdleTimeout(),
+	}
+}
+
+// reachable reports whether AssemblyAI can be contacted, over the
+// connections the next job uses
+func reachable() bool {
+	return assemblyai.Reachable(config.GetRegion(), transportOptions(), connectivityTimeout)
+}
+
+// batchTranscription uploads the file and waits for the finished transcript.
+// Audio at a URL is left for AssemblyAI to download.
+func batchTranscription(audioPath string, speechModel string, languageCode string, profile outputProfile, timings *progress.Timings) (*transcript.Transcript, error) {
+	remote := isURL(audioPath)
+	var span time.Duration
+	var err error
+	if !remote {
+		if span, err = requestedAudio(audioDurationOrZero(audioPath)); err != nil {
+			return nil, err
+		}
+	}
+	estimate := estimateProcessingTime(span, speechModel)
+	uploadPath := audioPath
+	if !remote {
+		uploadPath = audioForUpload(audioPath)
+	}
+
+	spinner := newSpinner(timings)
+	spinner.Start()
+	defer spinner.Stop()
+
+	var processingStarted time.Time
+	client := newClient()
+	client.Polling = assemblyai.PollPolicy{
+		Expected:    estimate,
+		MinInterval: config.GetPollingDuration("polling.min_interval"),
+		MaxInterval: config.GetPollingDuration("polling.max_interval"),
+		Timeout:     config.GetPollingDuration("polling.timeout"),
+	}
+	if span > 0 && span <= voiceNoteLength {
+		client.Polling = voiceNotePolling(client.Polling)
+	}
+	client.Progress = func(phase string) {
+		switch phase {
+		case assemblyai.PhaseUploading:
+			timings.Begin("upload")
+			spinner.SetPhase("Uploading audio", 0)
+		case assemblyai.PhaseQueued:
+			timings.Begin("transcribe")
+			spinner.SetPhase("Queued at AssemblyAI", 0)
+		case assemblyai.PhaseProcessing:
+			timings.Begin("transcribe")
+			spinner.SetPhase("Transcribing", estimate)
+			timings.Expect(estimate)
+			if processingStarted.IsZero() {
+				processingStarted = time.Now()
+			}
+		case assemblyai.PhaseCompleted:
+			timings.End()
+		}
+	}
+
+	var result *assemblyai.TranscriptResult
+	if remote {
+		result, err = client.TranscribeURL(audioPath, transcriptionRequest(speechModel, languageCode, profile))
+	} else {
+		result, err = client.TranscribeAudio(uploadPath, transcriptionRequest(speechModel, languageCode, profile))
+	}
+	timings.End()
+	if err != nil {
+		return nil, err
+	}
+	if !processingStarted.IsZero() {
+		recordThroughput(speechModel, result.APIKey, result.AudioDuration, time.Since(processingStarted))
+	}
+	return result.Normalize(), nil
+}
+
+// transcriptionRequest builds the request for the model and language with
+// the options of the profile and the command line
+func transcriptionRequest(speechModel string, languageCode string, profile outputProfile) assemblyai.TranscriptionRequest {
+	request := assemblyai.TranscriptionRequest{
+		SpeechModel:       speechModel,
+		LanguageCode:      languageCode,
+		LanguageDetection: multilingual && languageCode == "",
+	}
+	profile.apply(&request)
+	applyAudioRange(&request)
+	if showNotes {
+		applyShowNotes(&request)
+	}
+	return request
+}
+
+// recordThroughput adds a finished job to the ledger that calibrates
+// processing estimates and splits usage by key; a failure only costs
+// calibration
+func recordThroughput(model string, apiKey string, audioSeconds float64, processing time.Duration) {
+	if audioSeconds <= 0 {
+		return
+	}
+	entry := ledger.Entry{
+		Model:      model,
+		Key:        config.MaskAPIKey(apiKey),
+		Audio:      audioSeconds,
+		Processing: processing.Seconds(),
+		FinishedAt: time.Now(),
+	}
+	if err := ledger.Append(entry); err != nil {
+		logger.LogWarning("Failed to record job throughput: %v", err)
+	}
+}
+
+// placeholderForEmpty turns an empty-audio error into a placeholder transcript
+// when --allow-empty is set. Any other error is returned unchanged.
+func placeholderForEmpty(err error) (string, error) {
+	placeholder, empty := emptyPlaceholder(err)
+	if !empty || !allowEmpty {
+		return "", err
+	}
+
+	fmt.Println(style.Warning("%v, writing placeholder transcript", err))
+	logger.LogWarning("Writing placeholder transcript: %v", err)
+	return placeholder, nil
+}
+
+// printTimingSummary shows how long each phase of the job took
+func printTimingSummary(timings *progress.Timings) {
+	if summary := timings.Summary(); summary != "" {
+		fmt.Printf("Timing: %s\n", summary)
+		logger.LogInfo("Timing: %s", summary)
+	}
+}
+
+// transcriptBasePath returns the path the transcript is saved under: the
+// given output path, or a generated name in the default output directory
+func transcriptBasePath(source string, sourceType string, outputPath string) (string, error) {
 	// Determine output path
 	var finalOutputPath string
 	if outputPath != "" {
@@ -557,7 +1284,7 @@ func saveTranscript(transcript string, source string, sourceType string) error {
 		// Generate default path
 		defaultPath := config.GetOutputPath()
 		if err := os.MkdirAll(defaultPath, 0755); err != nil {
-			return fmt.Errorf("failed to create output directory: %v", err)
+			return "", fmt.Errorf("failed to create output directory: %v", err)
 		}
 
 		// Generate filename based on source
@@ -582,6 +1309,8 @@ func saveTranscript(transcript string, source string, sourceType string) error {
 			if title == "" {
 				title = "youtube-video"
 			}
+		} else if sourceType == "url" {
+			title = remoteTitle(source)
 		} else {
 			// For local files, use the filename without extension
 			baseName := filepath.Base(source)
@@ -601,21 +1330,91 @@ func saveTranscript(transcript string, source string, sourceType string) error {
 			title = "transcript"
 		}
 
-		// Add simple timestamp for uniqueness (just date)
-		timestamp := time.Now().Format("20060102")
-		filename = fmt.Sprintf("%s-%s.txt", title, timestamp)
+		// Add timestamp for uniqueness unless disabled
+		filename = generatedName(title) + ".txt"
 
 		finalOutputPath = filepath.Join(defaultPath, filename)
 	}
 
-	// Write transcript to file
-	if err := os.WriteFile(finalOutputPath, []byte(transcript), 0644); err != nil {
-		return fmt.Errorf("failed to write transcript file: %v", err)
+	return finalOutputPath, nil
+}
+
+// saveTranscript writes the transcript in every selected format next to
+// finalOutputPath and returns the files written. An explicit output path is
+// used as-is when only one format is written.
+func saveTranscript(transcript string, source string, finalOutputPath string, explicit bool, result *transcript.Transcript) ([]string, error) {
+	// Interactive mode does not go through the command's flag handling
+	selectedFormats := formats
+	if len(selectedFormats) == 0 {
+		selectedFormats = config.GetDefaultFormats()
+	}
+	selectedFormats, err := validateFormats(selectedFormats)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Saved to: %s (%d chars)\n", finalOutputPath, len(transcript))
+	// Write transcript in each requested format
+	paths := outputPathsFor(finalOutputPath, explicit, selectedFormats)
+	var written []string
+	for _, format := range selectedFormats {
+		path := paths[format]
+		content := outputFormats[format](transcript, source, result)
+		if err := writeOutput(path, []byte(content)); err != nil {
+			return written, fmt.Errorf("failed to write transcript file: %v", err)
+		}
+		written = append(written, path)
 
-	return nil
+		fmt.Printf("Saved to: %s (%d chars)\n", path, len(content))
+	}
+
+	// The changes belong to the transcript postProcess last returned
+	changes := glossaryChanges
+	glossaryChanges = nil
+	if len(changes) > 0 {
+		path := changeLogPath(paths[selectedFormats[0]])
+		if err := writeOutput(path, []byte(formatChangeLog(changes))); err != nil {
+			return written, fmt.Errorf("failed to write corrections log: %v", err)
+		}
+		written = append(written, path)
+		fmt.Printf("Saved corrections log to: %s\n", path)
+	}
+
+	if showNotes {
+		path := showNotesPath(paths[selectedFormats[0]])
+		if result == nil || len(result.Chapters) == 0 {
+			fmt.Println(style.Warning("No chapters were returned; skipping show notes"))
+			logger.LogWarning("Show notes skipped for %s: no chapters in the result", source)
+		} else if err := writeOutput(path, []byte(formatShowNotes(source, transcript, result))); err != nil {
+			return written, fmt.Errorf("failed to write show notes: %v", err)
+		} else {
+			written = append(written, path)
+			fmt.Printf("Saved show notes to: %s\n", path)
+		}
+	}
+
+	// A failed proofreading pass never loses the transcript that was just saved
+	if proofreadOutput {
+		corrected, err := proofreadTranscript(transcript)
+		if err != nil {
+			fmt.Println(style.Warning("Proofreading failed: %v", err))
+			logger.LogWarning("Proofreading failed: %v", err)
+			return written, nil
+		}
+		for _, format := range selectedFormats {
+			if timedFormats[format] {
+				continue
+			}
+			path := variantPath(paths[format], "corrected")
+			content := outputFormats[format](corrected, source, result)
+			if err := writeOutput(path, []byte(content)); err != nil {
+				return written, fmt.Errorf("failed to write corrected transcript: %v", err)
+			}
+			written = append(written, path)
+			fmt.Printf("Saved corrected copy to: %s\n", path)
+		}
+	}
+
+	return written, nil
 }
 
 // sanitizeFilename removes invalid characters from a filename and makes it cleaner
@@ -654,22 +1453,26 @@ func sanitizeFilename(name string) string {
 	return name
 }
 
-// SetOutputPath sets the output path for the transcript
-func SetOutputPath(path string) {
-	outputPath = path
-}
-
-// SetSpeechModel sets the speech model to use
-func SetSpeechModel(model string) {
-	speechModel = model
+// formatFilenameTimestamp renders t with the configured layout, replacing
+// characters that are not allowed in filenames
+func formatFilenameTimestamp(t time.Time, layout string) string {
+	if strings.TrimSpace(layout) == "" {
+		return ""
+	}
+	reg := regexp.MustCompile(`[\\/:*?"<>|\s]`)
+	return reg.ReplaceAllString(t.Format(layout), "-")
 }
 
-// ProcessYouTubeVideo processes a YouTube video URL
-func ProcessYouTubeVideo(url string, outputPath string, speechModel string) error {
-	return processYouTubeVideo(url, outputPath, speechModel)
+// ProcessYouTubeVideo processes a YouTube video URL with the given options.
+// The other transcription settings (formats, profile, provider and the rest
+// of the transcribe flags) are shared by the package, so calls that change
+// them must not overlap.
+func ProcessYouTubeVideo(url string, opts Options) error {
+	return processYouTubeVideo(url, opts.withDefaults())
 }
 
-// ProcessLocalAudio processes a local audio file
-func ProcessLocalAudio(filePath string, outputPath string, speechModel string) error {
-	return processLocalAudio(filePath, outputPath, speechModel)
+// ProcessLocalAudio processes a local audio file with the given options.
+// Like ProcessYouTubeVideo, it reads the package's other transcription settings.
+func ProcessLocalAudio(filePath string, opts Options) error {
+	return processLocalAudio(filePath, opts.withDefaults())
 }
@@ -0,0 +1,78 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// searchWindow bounds how far ahead a word is looked for in the text, so a
+// word that was rewritten (e.g. by formatting) does not match much later
+const searchWindow = 200
+
+// validateUncertainThreshold rejects thresholds outside 0..1
+func validateUncertainThreshold(threshold float64) error {
+	if threshold < 0 || threshold > 1 {
+		return fmt.Errorf("--mark-uncertain must be between 0 and 1, got %g", threshold)
+	}
+	return nil
+}
+
+// markUncertain wraps every word with a confidence below threshold in the
+// open and close markers, in both the plain text and the utterances
+func markUncertain(result *transcript.Transcript, threshold float64, open string, close string) int {
+	if threshold <= 0 || len(result.Words) == 0 {
+		return 0
+	}
+
+	var marked int
+	result.Text, marked = markWords(result.Text, result.Words, threshold, open, close)
+
+	for i, utterance := range result.Segments {
+		var words []transcript.Word
+		for _, word := range result.Words {
+			if word.Start >= utterance.Start && word.End <= utterance.End {
+				words = append(words, word)
+			}
+		}
+		result.Segments[i].Text, _ = markWords(utterance.Text, words, threshold, open, close)
+	}
+
+	return marked
+}
+
+// markWords walks the words in order, locating each in text after the
+// previous one, and wraps the uncertain ones
+func markWords(text string, words []transcript.Word, threshold float64, open string, close string) (string, int) {
+	var b strings.Builder
+	cursor, marked := 0, 0
+
+	for _, word := range words {
+		core := strings.TrimFunc(word.Text, func(r rune) bool {
+			return unicode.IsPunct(r) && r != '\'' && r != '-'
+		})
+		if core == "" {
+			continue
+		}
+
+		rest := text[cursor:]
+		index := strings.Index(rest, core)
+		if index < 0 || index > searchWindow {
+			continue
+		}
+
+		b.WriteString(rest[:index])
+		if word.Confidence < threshold {
+			b.WriteString(open + core + close)
+			marked++
+		} else {
+			b.WriteString(core)
+		}
+		cursor += index + len(core)
+	}
+
+	b.WriteString(text[cursor:])
+	return b.String(), marked
+}
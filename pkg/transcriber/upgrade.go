@@ -0,0 +1,156 @@
+package transcriber
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/progress"
+	"github.com/Harsh-2002/Sona/pkg/style"
+)
+
+// autoUpgrade re-runs poor-quality transcripts without asking
+var autoUpgrade bool
+
+// enhanceFilter cleans up speech before a re-run: it removes rumble below
+// 80 Hz, reduces steady background noise and evens out the loudness
+const enhanceFilter = "highpass=f=80,afftdn=nf=-25,loudnorm=I=-16:TP=-1.5:LRA=11"
+
+// upgradedVariant names the files of a re-run, e.g. talk.upgraded.txt, so
+// they sit next to the first transcript for comparison
+const upgradedVariant = "upgraded"
+
+// rerunSource is what a re-run needs to know about the first transcript
+type rerunSource struct {
+	AudioPath  string
+	Source     string
+	SourceType string
+	BasePath   string
+	Options    Options
+	Quality    *library.Quality
+}
+
+// rerunPlan is how a re-run tries to do better than the first transcript
+type rerunPlan struct {
+	Model   string
+	Enhance bool
+}
+
+// describe renders the plan for messages, e.g. "with --model best"
+func (p rerunPlan) describe(original string) string {
+	var changes []string
+	if p.Model != original {
+		changes = append(changes, "--model "+p.Model)
+	}
+	if p.Enhance {
+		changes = append(changes, "cleaned-up audio")
+	}
+	return "with " + strings.Join(changes, " and ")
+}
+
+// planRerun upgrades the model when a more accurate one is available and
+// cleans up the audio when it is noisy or the model cannot be upgraded
+func planRerun(model string, quality *library.Quality) rerunPlan {
+	plan := rerunPlan{Model: model}
+	// The streaming provider has a single model
+	if model != mostAccurateModel && provider != providerStreaming {
+		plan.Model = mostAccurateModel
+	}
+	noisy := quality.SNR != nil && *quality.SNR < noisySNR
+	plan.Enhance = noisy || plan.Model == model
+	return plan
+}
+
+// offerRerun re-transcribes a transcript whose quality score is below
+// quality.rerun_below, after asking or straight away with --auto-upgrade.
+// The re-run is saved next to the first transcript so both can be compared;
+// the first transcript is kept whatever happens, so failures are only reported.
+func offerRerun(run rerunSource, timings *progress.Timings) {
+	threshold := config.GetRerunBelow()
+	if run.Quality == nil || threshold <= 0 || run.Quality.Score >= threshold {
+		return
+	}
+
+	plan := planRerun(run.Options.SpeechModel, run.Quality)
+	logger.LogInfo("Quality %d is below %d, re-run %s", run.Quality.Score, threshold, plan.describe(run.Options.SpeechModel))
+	if !autoUpgrade && !confirmRerun(plan.describe(run.Options.SpeechModel)) {
+		return
+	}
+
+	if err := rerun(run, plan, timings); err != nil {
+		fmt.Println(style.Warning("Re-run failed, keeping the first transcript: %v", err))
+		logger.LogWarning("Re-run of %s failed: %v", run.Source, err)
+	}
+}
+
+// confirmRerun asks whether to re-transcribe. Without a terminal it only
+// points at --auto-upgrade.
+func confirmRerun(plan string) bool {
+	if !canPrompt() {
+		fmt.Println(style.Hint("Use --auto-upgrade to re-transcribe poor results %s automatically", plan))
+		return false
+	}
+
+	fmt.Printf("Re-transcribe %s and keep both transcripts? (y/n): ", plan)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Scan()
+	return strings.ToLower(strings.TrimSpace(scanner.Text())) == "y"
+}
+
+// rerun transcribes the audio again following the plan, then saves and
+// records the result under the upgraded variant of the first transcript
+func rerun(run rerunSource, plan rerunPlan, timings *progress.Timings) error {
+	fmt.Printf("%sRe-transcribing %s\n", style.Icon("🔁 "), plan.describe(run.Options.SpeechModel))
+
+	audioPath := run.AudioPath
+	if plan.Enhance {
+		timings.Begin("enhance")
+		enhanced, err := enhanceAudio(audioPath)
+		timings.End()
+		if err != nil {
+			return err
+		}
+		audioPath = enhanced
+	}
+
+	basePath := variantPath(run.BasePath, upgradedVariant)
+	livePath := liveTranscriptPath(basePath)
+	transcript, result, err := transcribeAudio(audioPath, plan.Model, run.Options.LanguageCode, livePath, timings)
+	if err != nil {
+		return err
+	}
+
+	quality := assessQuality(result, audioPath)
+	if quality != nil {
+		fmt.Printf("Re-run quality: %s (first run %d/100)\n", quality.Summary(), run.Quality.Score)
+	}
+
+	files, err := saveTranscript(transcript, run.Source, basePath, run.Options.OutputPath != "", result)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	discardLiveTranscript(livePath)
+	recordTranscript(basePath, run.Source, run.SourceType, run.AudioPath, run.Options, plan.Model, transcript, result, files, sourceIdentity{}, quality)
+
+	if quality != nil && quality.Score <= run.Quality.Score {
+		fmt.Println(style.Hint("The re-run did not score higher; compare both before choosing one"))
+	}
+	return nil
+}
+
+// enhanceAudio writes a denoised, loudness-normalized copy of the audio next
+// to it
+func enhanceAudio(audioPath string) (string, error) {
+	fmt.Println("Cleaning up audio...")
+	path := filepath.Join(filepath.Dir(audioPath), "enhanced.mp3")
+	args := []string{"-hide_banner", "-y", "-i", audioPath, "-af", enhanceFilter, "-f", "mp3", path}
+	if err := runFFmpeg(args, ""); err != nil {
+		return "", fmt.Errorf("failed to clean up audio: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,72 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+// Codecs for the audio sent to AssemblyAI
+const (
+	uploadMP3  = "mp3"
+	uploadOpus = "opus"
+)
+
+// uploadCodec selects the codec of the uploaded audio; empty uploads the converted MP3
+var uploadCodec string
+
+// Opus bitrates that keep speech intelligible: narrowband sources need less
+const (
+	opusSpeechBitrate     = "32k"
+	opusNarrowbandBitrate = "24k"
+)
+
+func validateUploadCodec(codec string) error {
+	switch codec {
+	case "", uploadMP3, uploadOpus:
+		return nil
+	default:
+		return fmt.Errorf("unsupported --upload-codec %q (use %s or %s)", codec, uploadMP3, uploadOpus)
+	}
+}
+
+// audioForUpload returns the file to upload for audioPath. With
+// --upload-codec opus it is transcoded to low-bitrate mono Opus in Ogg next
+// to the original; when that fails or does not save space, the original
+// is uploaded.
+func audioForUpload(audioPath string) string {
+	if uploadCodec != uploadOpus {
+		return audioPath
+	}
+
+	bitrate := opusSpeechBitrate
+	if stream, err := probeAudioStream(audioPath); err == nil && stream.SampleRate <= 16000 {
+		bitrate = opusNarrowbandBitrate
+	}
+
+	opusPath := strings.TrimSuffix(audioPath, filepath.Ext(audioPath)) + ".upload.ogg"
+	args := []string{"-hide_banner", "-y", "-i", audioPath, "-vn", "-ac", "1",
+		"-c:a", "libopus", "-b:a", bitrate, "-application", "voip", "-f", "ogg", opusPath}
+	if err := runFFmpeg(args, ""); err != nil {
+		fmt.Println(style.Warning("Could not encode Opus (%v), uploading MP3 instead", err))
+		logger.LogWarning("Opus transcode of %s failed: %v", audioPath, err)
+		return audioPath
+	}
+
+	original, err1 := os.Stat(audioPath)
+	encoded, err2 := os.Stat(opusPath)
+	if err1 != nil || err2 != nil || encoded.Size() >= original.Size() {
+		logger.LogInfo("Opus upload of %s would not be smaller, uploading the original", audioPath)
+		os.Remove(opusPath)
+		return audioPath
+	}
+
+	logger.LogInfo("Uploading Opus at %s: %d bytes instead of %d", bitrate, encoded.Size(), original.Size())
+	fmt.Printf("Upload size: %s (Opus) instead of %s\n", workspace.FormatSize(encoded.Size()), workspace.FormatSize(original.Size()))
+	return opusPath
+}
@@ -0,0 +1,433 @@
+package transcriber
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/library"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+// Voice notes are recordings of up to voiceNoteLength. Those of up to
+// voiceNoteSize in a format AssemblyAI accepts are uploaded without
+// conversion, and their transcripts are polled for more often.
+const (
+	voiceNoteLength = time.Minute
+	voiceNoteSize   = 2 * 1024 * 1024
+
+	voiceNoteMinPoll = 500 * time.Millisecond
+	voiceNoteMaxPoll = 2 * time.Second
+)
+
+// --batch-voice-notes merges up to maxMergedVoiceNotes voice notes, or
+// maxMergedAudio of them, into one request, with voiceNoteGap of silence
+// after each so no word straddles two notes
+const (
+	maxMergedVoiceNotes = 100
+	maxMergedAudio      = time.Hour
+	voiceNoteGap        = time.Second
+)
+
+// voiceNotesDir is the folder given to --batch-voice-notes
+var voiceNotesDir string
+
+// voiceNote is a short recording to merge with others
+type voiceNote struct {
+	Path     string
+	Duration time.Duration
+	Identity sourceIdentity
+}
+
+// slot is the stretch of the merged audio the note takes up: its duration
+// rounded up to a second, then the gap
+func (n voiceNote) slot() time.Duration {
+	return time.Duration(math.Ceil(n.Duration.Seconds()))*time.Second + voiceNoteGap
+}
+
+// isVoiceNote reports whether the file is a voice note that can be
+// uploaded as it is
+func isVoiceNote(path string) bool {
+	if !uploadableFormats[strings.ToLower(filepath.Ext(path))] {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > voiceNoteSize {
+		return false
+	}
+	duration := audioDurationOrZero(path)
+	return duration > 0 && duration <= voiceNoteLength
+}
+
+// copyVoiceNote puts the voice note into outputDir as it is, linked when
+// the file system allows, so later steps never write beside the original
+func copyVoiceNote(path string, outputDir string) (string, error) {
+	dest := filepath.Join(outputDir, "voice-note"+strings.ToLower(filepath.Ext(path)))
+	if err := os.Link(path, dest); err != nil {
+		if err := deps.CopyFile(path, dest, 0644); err != nil {
+			return "", fmt.Errorf("failed to copy voice note: %v", err)
+		}
+	}
+	logger.LogInfo("Uploading voice note %s without conversion", path)
+	return dest, nil
+}
+
+// voiceNotePolling polls at least as often as voiceNoteMinPoll and
+// voiceNoteMaxPoll, since a voice note is done within seconds
+func voiceNotePolling(policy assemblyai.PollPolicy) assemblyai.PollPolicy {
+	if policy.MinInterval <= 0 || policy.MinInterval > voiceNoteMinPoll {
+		policy.MinInterval = voiceNoteMinPoll
+	}
+	if policy.MaxInterval <= 0 || policy.MaxInterval > voiceNoteMaxPoll {
+		policy.MaxInterval = voiceNoteMaxPoll
+	}
+	return policy
+}
+
+// validateVoiceNotes rejects flags that cannot apply to a folder of notes
+func validateVoiceNotes() error {
+	switch {
+	case transcribeOptions.OutputPath != "":
+		return fmt.Errorf("--output cannot be used with --batch-voice-notes; each note gets its own transcript")
+	case audioStart > 0 || audioEnd > 0:
+		return fmt.Errorf("--start and --end cannot be used with --batch-voice-notes")
+	case queueOffline:
+		return fmt.Errorf("--queue cannot be used with --batch-voice-notes")
+	}
+	return nil
+}
+
+// listVoiceNotes returns the media files in dir in name order, which for
+// exported chats is the order they were recorded in
+func listVoiceNotes(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || isPartialName(name) || !mediaExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no audio files found in %s", dir)
+	}
+	return paths, nil
+}
+
+// runVoiceNotes transcribes a folder of voice notes for --batch-voice-notes
+func runVoiceNotes(dir string) {
+	if err := validateVoiceNotes(); err != nil {
+		fmt.Println(style.Error("%v", err))
+		os.Exit(1)
+	}
+	paths, err := listVoiceNotes(dir)
+	if err != nil {
+		fmt.Println(style.Error("%v", err))
+		os.Exit(1)
+	}
+
+	startRun([]sourceSpec{{Source: dir, LanguageCode: transcribeOptions.LanguageCode}})
+	if err := checkAndInstallDependencies(paths); err != nil {
+		finishRun(false, "dependency check failed: "+err.Error())
+		fmt.Println(style.Error("Dependency check failed: %v", err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Source: %s (%d files)\n", dir, len(paths))
+	if failed := transcribeVoiceNotes(paths); failed > 0 {
+		message := fmt.Sprintf("%d of %d voice notes failed", failed, len(paths))
+		finishRun(false, message)
+		notifyFinished(false, message)
+		fmt.Println(style.Hint("Run the command again to retry them; notes already transcribed are skipped"))
+		os.Exit(1)
+	}
+
+	message := fmt.Sprintf("Finished %d voice notes from %s", len(paths), dir)
+	fmt.Println("Transcription completed successfully")
+	finishRun(true, message)
+	notifyFinished(true, message)
+}
+
+// transcribeVoiceNotes merges the voice notes among paths into as few
+// requests as possible and transcribes the other files one by one. Notes
+// transcribed before are skipped. It returns the failure count.
+func transcribeVoiceNotes(paths []string) int {
+	blocker := voiceNoteMergeBlocker()
+	if blocker != "" {
+		fmt.Println(style.Info("Transcribing the files one by one: %s", blocker))
+	}
+
+	var notes []voiceNote
+	var others []sourceSpec
+	for _, path := range paths {
+		var duration time.Duration
+		if blocker == "" {
+			duration = audioDurationOrZero(path)
+		}
+		if duration <= 0 || duration > voiceNoteLength {
+			others = append(others, sourceSpec{Source: path, LanguageCode: transcribeOptions.LanguageCode})
+			continue
+		}
+
+		identity := identifyAudio(path)
+		if record := transcribedBefore(path, identity); record != nil {
+			fmt.Printf("Skipping %s, already transcribed as %s\n", filepath.Base(path), record.Name)
+			continue
+		}
+		notes = append(notes, voiceNote{Path: path, Duration: duration, Identity: identity})
+	}
+
+	failed := 0
+	for _, group := range groupVoiceNotes(notes) {
+		failed += transcribeMergedNotes(group)
+	}
+	if len(others) > 0 {
+		if len(notes) > 0 {
+			fmt.Printf("\n%d files are not voice notes and are transcribed one by one\n", len(others))
+		}
+		failed += runBatch(others)
+	}
+	return failed
+}
+
+// voiceNoteMergeBlocker returns why the notes cannot share a request, ""
+// when they can
+func voiceNoteMergeBlocker() string {
+	switch {
+	case provider != "assemblyai":
+		return fmt.Sprintf("the %s provider transcribes each file on its own", provider)
+	case multilingual:
+		return "--multilingual detects the language of each file"
+	case musicMode != "":
+		return "--music looks for music in each file"
+	case showNotes:
+		return "--show-notes summarizes each file"
+	case speechThreshold > 0:
+		return "--speech-threshold checks each file"
+	}
+	if _, err := FindBinary("ffmpeg"); err != nil {
+		return "FFmpeg is needed to merge voice notes"
+	}
+	return ""
+}
+
+// transcribedBefore returns the library record of an identical file, so
+// running again over a folder only transcribes new and failed notes
+func transcribedBefore(path string, identity sourceIdentity) *library.Record {
+	if allowDuplicate || identity.Hash == "" {
+		return nil
+	}
+	d, err := findDuplicate(path, sourceIdentity{Hash: identity.Hash})
+	if err != nil {
+		logger.LogWarning("Duplicate check failed: %v", err)
+		return nil
+	}
+	if d == nil {
+		return nil
+	}
+	return &d.Record
+}
+
+// groupVoiceNotes splits the notes into groups that fit in one request
+func groupVoiceNotes(notes []voiceNote) [][]voiceNote {
+	var groups [][]voiceNote
+	var group []voiceNote
+	var length time.Duration
+	for _, note := range notes {
+		if len(group) > 0 && (len(group) == maxMergedVoiceNotes || length+note.slot() > maxMergedAudio) {
+			groups = append(groups, group)
+			group, length = nil, 0
+		}
+		group = append(group, note)
+		length += note.slot()
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// mergeVoiceNotes joins the notes into one mono MP3 at output, each in a
+// slot of its own, and returns where each slot starts
+func mergeVoiceNotes(notes []voiceNote, output string) ([]time.Duration, error) {
+	args := []string{"-hide_banner", "-y"}
+	var filters, labels []string
+	offsets := make([]time.Duration, len(notes))
+	var offset time.Duration
+	for i, note := range notes {
+		args = append(args, "-i", note.Path)
+		slot := note.slot().Seconds()
+		filters = append(filters, fmt.Sprintf("[%d:a]aresample=16000,aformat=channel_layouts=mono,apad=whole_dur=%.3f,atrim=duration=%.3f[n%d]", i, slot, slot, i))
+		labels = append(labels, fmt.Sprintf("[n%d]", i))
+		offsets[i] = offset
+		offset += note.slot()
+	}
+	filter := strings.Join(filters, ";") + ";" + strings.Join(labels, "") + fmt.Sprintf("concat=n=%d:v=0:a=1[merged]", len(notes))
+	args = append(args, "-filter_complex", filter, "-map", "[merged]", "-b:a", "48k", "-f", "mp3", output)
+	if err := runFFmpeg(args, ""); err != nil {
+		return nil, err
+	}
+	return offsets, nil
+}
+
+// transcribeMergedNotes transcribes the notes in one request and saves a
+// transcript for each. It returns the failure count.
+func transcribeMergedNotes(notes []voiceNote) int {
+	var length time.Duration
+	for _, note := range notes {
+		length += note.Duration
+	}
+	fmt.Printf("\nMerging %d voice notes (%s)\n", len(notes), formatTimestamp(length))
+
+	fail := func(err error) int {
+		fmt.Println(style.Failure("Could not transcribe %d voice notes: %v", len(notes), err))
+		logger.LogError("Transcribing %d merged voice notes failed: %v", len(notes), err)
+		noteLimitFailure(notes[0].Path, err)
+		for _, note := range notes {
+			webhookFailed(note.Path, err)
+		}
+		return len(notes)
+	}
+
+	ws, err := workspace.New()
+	if err != nil {
+		return fail(err)
+	}
+	defer ws.Remove()
+
+	merged := ws.Path("voice-notes.mp3")
+	offsets, err := mergeVoiceNotes(notes, merged)
+	if err != nil {
+		fmt.Println(style.Warning("Could not merge the voice notes, transcribing them one by one: %v", err))
+		logger.LogWarning("Merging %d voice notes failed: %v", len(notes), err)
+		specs := make([]sourceSpec, len(notes))
+		for i, note := range notes {
+			specs[i] = sourceSpec{Source: note.Path, LanguageCode: transcribeOptions.LanguageCode}
+		}
+		return runBatch(specs)
+	}
+
+	opts := transcribeOptions.withDefaults()
+	if err := checkMonthlyBudget(merged, opts.SpeechModel); err != nil {
+		return fail(err)
+	}
+	profile, err := lookupProfile(profileName)
+	if err != nil {
+		return fail(err)
+	}
+	profile = profile.withNumberStyle(numberStyle).withSpeakers(speakerCount)
+
+	timings := newTimings("")
+	result, err := batchTranscription(merged, opts.SpeechModel, opts.LanguageCode, profile, timings)
+	if err != nil {
+		return fail(err)
+	}
+	recordUsage(result, merged)
+
+	failed := 0
+	for i, note := range notes {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(notes), filepath.Base(note.Path))
+		if err := saveVoiceNote(note, splitVoiceNote(result, note, offsets[i]), profile, opts); err != nil {
+			fmt.Println(style.Failure("%s: %v", filepath.Base(note.Path), err))
+			logger.LogError("Voice note %s failed: %v", note.Path, err)
+			webhookFailed(note.Path, err)
+			failed++
+		}
+	}
+	printTimingSummary(timings)
+	return failed
+}
+
+// splitVoiceNote takes the words of the note starting at offset out of the
+// merged result, on the note's own timeline. Speaker labels stay those of
+// the merged audio, so a speaker keeps their label across notes.
+func splitVoiceNote(result *transcript.Transcript, note voiceNote, offset time.Duration) *transcript.Transcript {
+	start, end := offset.Milliseconds(), (offset + note.slot()).Milliseconds()
+	inNote := func(from, to int64) bool {
+		middle := (from + to) / 2
+		return middle >= start && middle < end
+	}
+
+	piece := &transcript.Transcript{
+		ID:                 result.ID,
+		LanguageCode:       result.LanguageCode,
+		LanguageConfidence: result.LanguageConfidence,
+		Duration:           note.Duration.Seconds(),
+		APIKey:             result.APIKey,
+		Region:             result.Region,
+	}
+	var texts []string
+	for _, word := range result.Words {
+		if !inNote(word.Start, word.End) {
+			continue
+		}
+		word.Start -= start
+		word.End -= start
+		piece.Words = append(piece.Words, word)
+		texts = append(texts, word.Text)
+	}
+	piece.Text = strings.Join(texts, " ")
+	for _, entity := range result.Entities {
+		if inNote(entity.Start, entity.End) {
+			entity.Start -= start
+			entity.End -= start
+			piece.Entities = append(piece.Entities, entity)
+		}
+	}
+	if len(result.Segments) > 0 {
+		piece.Segments = segmentsFromWords(piece.Words)
+	}
+	return piece
+}
+
+// segmentsFromWords groups consecutive words of the same speaker
+func segmentsFromWords(words []transcript.Word) []transcript.Segment {
+	var segments []transcript.Segment
+	for _, word := range words {
+		if n := len(segments); n > 0 && segments[n-1].Speaker == word.Speaker {
+			segments[n-1].Text += " " + word.Text
+			segments[n-1].End = word.End
+			continue
+		}
+		segments = append(segments, transcript.Segment{Speaker: word.Speaker, Text: word.Text, Start: word.Start, End: word.End})
+	}
+	return segments
+}
+
+// saveVoiceNote finishes the transcript of one note and saves it as if the
+// note had been transcribed on its own
+func saveVoiceNote(note voiceNote, result *transcript.Transcript, profile outputProfile, opts Options) error {
+	text, err := finishTranscript(result, profile, note.Path)
+	if err != nil {
+		if text, err = placeholderForEmpty(err); err != nil {
+			return err
+		}
+		result = nil
+	}
+
+	basePath, err := transcriptBasePath(note.Path, "local", "")
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	quality := assessQuality(result, note.Path)
+	printQuality(quality, opts.SpeechModel)
+
+	files, err := saveTranscript(text, note.Path, basePath, false, result)
+	if err != nil {
+		return fmt.Errorf("failed to save transcript: %v", err)
+	}
+	recordTranscript(basePath, note.Path, "local", note.Path, opts, opts.SpeechModel, text, result, files, note.Identity, quality)
+	return nil
+}
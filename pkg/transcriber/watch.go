@@ -0,0 +1,291 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+// mediaExtensions are the files sona watch and directory sources transcribe
+var mediaExtensions = map[string]bool{
+	".mp3": true, ".wav": true, ".m4a": true, ".aac": true, ".flac": true,
+	".ogg": true, ".opus": true, ".wma": true, ".aiff": true, ".webm": true,
+	".mp4": true, ".mov": true, ".mkv": true, ".avi": true, ".m4v": true,
+}
+
+// partialPrefixes start the names sync clients give files they are still
+// writing. Temporary names ending in .tmp, .part or .crdownload are skipped
+// by their extension, and hidden ones like Syncthing's .syncthing.*.tmp for
+// being hidden.
+var partialPrefixes = []string{"~syncthing~", "~$"}
+
+var (
+	watchInterval        time.Duration
+	watchSettle          time.Duration
+	watchListen          string
+	watchShutdownTimeout time.Duration
+)
+
+var WatchCmd = &cobra.Command{
+	Use:   "watch <directory>",
+	Short: "Transcribe audio files as they appear in a directory",
+	Long: `Watch a directory, including its subdirectories, and transcribe every audio
+or video file that appears in it, with the options of --preset and the
+defaults.* config keys. Files already transcribed, or that failed, are
+remembered in watch.json in the data directory and not tried again unless
+they change.
+
+A file is only transcribed once it has stopped changing for --settle and
+can be opened, so recordings still being copied or synced, e.g. by Dropbox
+or Syncthing, are not transcribed half-written. Empty files, such as
+placeholders of files not yet downloaded, wait until they have content.
+
+Every flag can also be set as an environment variable, e.g. SONA_INTERVAL,
+and every config key too, e.g. SONA_DEFAULTS_MODEL. With --listen, /healthz
+and /readyz are served for container health checks. On SIGTERM the watcher
+lets the running job finish within --shutdown-timeout.`,
+	Example: `  sona watch ~/Recordings
+  sona watch /data/inbox --preset meeting --interval 1m --listen :8080
+  sona watch ~/Dropbox/Recordings --settle 1m`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runWatch(cmd, args[0]); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	WatchCmd.Flags().DurationVar(&watchInterval, "interval", 30*time.Second, "How often to look for new files")
+	WatchCmd.Flags().DurationVar(&watchSettle, "settle", 10*time.Second, "How long a file must stop changing before it is transcribed (0 = at once)")
+	WatchCmd.Flags().StringVar(&presetName, "preset", "", "Transcribe with the flags of a saved preset")
+	WatchCmd.Flags().StringVar(&watchListen, "listen", "", "Serve /healthz and /readyz on this address, e.g. :8080")
+	WatchCmd.Flags().DurationVar(&watchShutdownTimeout, "shutdown-timeout", 25*time.Second, "How long to let the running job finish on shutdown")
+}
+
+// watchedFile is what sona watch remembers about a file it has seen
+type watchedFile struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// settlingFile is a new or changed file waiting to stop changing
+type settlingFile struct {
+	size    int64
+	modTime time.Time
+	// since is when the file was first seen at this size and mod time
+	since time.Time
+}
+
+func runWatch(cmd *cobra.Command, dir string) error {
+	if watchInterval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+	if watchSettle < 0 {
+		return fmt.Errorf("--settle cannot be negative")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	d, err := newDaemon(cmd, watchShutdownTimeout)
+	if err != nil {
+		return err
+	}
+	if watchListen != "" {
+		mux := http.NewServeMux()
+		d.handleHealth(mux)
+		d.listen(watchListen, mux)
+	}
+
+	seen, err := loadWatchState()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Watching %s every %v\n", dir, watchInterval)
+	logger.LogInfo("Watching %s", dir)
+
+	settling := make(map[string]settlingFile)
+	go func() {
+		for {
+			// Look again as soon as a waiting file may have settled
+			wait := watchInterval
+			if next := watchOnce(d, dir, seen, settling); next > 0 && next < wait {
+				wait = next
+			}
+			select {
+			case <-d.stopped():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+	d.wait()
+	return nil
+}
+
+// watchOnce transcribes the files in dir that are new or changed since they
+// were last seen and have settled. It returns how long until the next file
+// still settling may be ready, 0 when none is waiting.
+func watchOnce(d *daemon, dir string, seen map[string]watchedFile, settling map[string]settlingFile) time.Duration {
+	if err := d.ready(); err != nil {
+		logger.LogWarning("Not ready to transcribe: %v", err)
+		return 0
+	}
+
+	present := make(map[string]bool)
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			logger.LogWarning("Cannot read %s: %v", path, err)
+			return nil
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != dir {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !mediaExtensions[strings.ToLower(filepath.Ext(path))] || isPartialName(entry.Name()) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if previous, ok := seen[path]; ok && previous.Size == info.Size() && previous.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		present[path] = true
+		if !settled(path, info, settling) {
+			return nil
+		}
+
+		file := watchedFile{Size: info.Size(), ModTime: info.ModTime()}
+		ran := d.run(func() {
+			fmt.Printf("\nTranscribing %s\n", path)
+			if _, err := runDaemonJob(jobFor(sourceSpec{Source: path})); err != nil {
+				file.Error = err.Error()
+				fmt.Println(style.Error("%v", err))
+			}
+		})
+		if !ran {
+			return filepath.SkipAll
+		}
+		seen[path] = file
+		if err := saveWatchState(seen); err != nil {
+			logger.LogWarning("Failed to save watch state: %v", err)
+		}
+		return nil
+	})
+
+	// Forget files that were removed or renamed while settling
+	var next time.Duration
+	for path, file := range settling {
+		if !present[path] {
+			delete(settling, path)
+			continue
+		}
+		// Files that could not be opened are looked at again next interval
+		if left := watchSettle - time.Since(file.since); left > 0 && (next == 0 || left < next) {
+			next = left
+		}
+	}
+	return next
+}
+
+// settled reports whether a new or changed file is complete: it has content
+// and has kept its size and mod time for --settle, and it can be opened,
+// which fails on Windows while another program is still writing it
+func settled(path string, info fs.FileInfo, settling map[string]settlingFile) bool {
+	if info.Size() == 0 {
+		return false
+	}
+	if watchSettle > 0 {
+		file, ok := settling[path]
+		if !ok || file.size != info.Size() || !file.modTime.Equal(info.ModTime()) {
+			if !ok {
+				logger.LogInfo("Waiting for %s to stop changing", path)
+			}
+			settling[path] = settlingFile{size: info.Size(), modTime: info.ModTime(), since: time.Now()}
+			return false
+		}
+		if time.Since(file.since) < watchSettle {
+			return false
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		logger.LogInfo("Waiting for %s to be released: %v", path, err)
+		return false
+	}
+	f.Close()
+	delete(settling, path)
+	return true
+}
+
+// isPartialName reports whether a file name is one a sync client gives a
+// file it is still writing
+func isPartialName(name string) bool {
+	for _, prefix := range partialPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func watchStatePath() (string, error) {
+	return datadir.Path("watch.json")
+}
+
+// loadWatchState returns the files sona watch has seen, by path
+func loadWatchState() (map[string]watchedFile, error) {
+	path, err := watchStatePath()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]watchedFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return seen, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch state: %v", err)
+	}
+	if err := json.Unmarshal(data, &seen); err != nil {
+		return nil, fmt.Errorf("failed to parse watch state %s: %v", path, err)
+	}
+	return seen, nil
+}
+
+func saveWatchState(seen map[string]watchedFile) error {
+	path, err := watchStatePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(seen, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0644)
+}
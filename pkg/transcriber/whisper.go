@@ -0,0 +1,161 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/transcript"
+)
+
+// whisperBinary is the command line tool of whisper.cpp, which transcribes
+// on this machine
+const whisperBinary = "whisper-cli"
+
+// whisperSampleRate is the only sample rate whisper.cpp reads
+const whisperSampleRate = 16000
+
+// nonSpeechPattern matches whisper annotations such as [BLANK_AUDIO] or
+// (music) that stand for a whole segment without speech
+var nonSpeechPattern = regexp.MustCompile(`^\s*(\[[^\]]*\]|\([^)]*\))\s*$`)
+
+// whisperSegment is a stretch of a local transcript. Start and End are in
+// milliseconds.
+type whisperSegment struct {
+	Start int64
+	End   int64
+	Text  string
+	Words []transcript.Word
+}
+
+// confidence is the mean confidence of the words of the segment, 1 when it has none
+func (s whisperSegment) confidence() float64 {
+	if len(s.Words) == 0 {
+		return 1
+	}
+	var total float64
+	for _, word := range s.Words {
+		total += word.Confidence
+	}
+	return total / float64(len(s.Words))
+}
+
+// whisperOutput is the part of whisper.cpp's --output-json-full file sona reads
+type whisperOutput struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets whisperOffsets `json:"offsets"`
+		Text    string         `json:"text"`
+		Tokens  []struct {
+			Text    string         `json:"text"`
+			Offsets whisperOffsets `json:"offsets"`
+			P       float64        `json:"p"`
+		} `json:"tokens"`
+	} `json:"transcription"`
+}
+
+// whisperOffsets are in milliseconds
+type whisperOffsets struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// localTranscription transcribes the audio with whisper.cpp and the model
+// in whisper.model. It returns the segments and the language whisper
+// detected, or the one given.
+func localTranscription(audioPath string, languageCode string) ([]whisperSegment, string, error) {
+	whisperPath, err := FindBinary(whisperBinary)
+	if err != nil {
+		return nil, "", &deps.MissingError{Binary: whisperBinary, Message: "whisper-cli not found; install whisper.cpp to use the hybrid provider"}
+	}
+	model := config.GetWhisperModel()
+	if model == "" {
+		return nil, "", fmt.Errorf("no whisper.cpp model set; download one and run: sona config set whisper.model ~/models/ggml-base.en.bin")
+	}
+	if _, err := os.Stat(model); err != nil {
+		return nil, "", fmt.Errorf("whisper.cpp model not found: %s", model)
+	}
+
+	dir := filepath.Dir(audioPath)
+	wavPath := filepath.Join(dir, "whisper.wav")
+	args := []string{"-hide_banner", "-y", "-i", audioPath, "-ar", fmt.Sprint(whisperSampleRate), "-ac", "1", "-c:a", "pcm_s16le", wavPath}
+	if err := runFFmpeg(args, ""); err != nil {
+		return nil, "", fmt.Errorf("failed to prepare audio for whisper.cpp: %w", err)
+	}
+
+	// whisper.cpp takes plain language codes, e.g. en for en_us
+	language := "auto"
+	if languageCode != "" {
+		language, _, _ = strings.Cut(languageCode, "_")
+	}
+	outputBase := filepath.Join(dir, "whisper")
+	cmd := exec.Command(whisperPath, "-m", model, "-f", wavPath, "-l", language, "-ojf", "-of", outputBase, "-np")
+	if _, err := logger.RunCommand(cmd); err != nil {
+		return nil, "", fmt.Errorf("whisper.cpp failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outputBase + ".json")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read whisper.cpp output: %v", err)
+	}
+	segments, detected, err := parseWhisperOutput(data)
+	if err != nil {
+		return nil, "", err
+	}
+	if languageCode != "" {
+		detected = languageCode
+	}
+	return segments, detected, nil
+}
+
+// parseWhisperOutput reads whisper.cpp's full JSON output. Tokens are joined
+// into words at the spaces that start them, and a word is as confident as
+// its least confident token. Control tokens and segments without speech are
+// dropped.
+func parseWhisperOutput(data []byte) ([]whisperSegment, string, error) {
+	var output whisperOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, "", fmt.Errorf("invalid whisper.cpp output: %v", err)
+	}
+
+	var segments []whisperSegment
+	for _, entry := range output.Transcription {
+		text := strings.TrimSpace(entry.Text)
+		if text == "" || nonSpeechPattern.MatchString(text) {
+			continue
+		}
+
+		segment := whisperSegment{Start: entry.Offsets.From, End: entry.Offsets.To, Text: text}
+		for _, token := range entry.Tokens {
+			// Control tokens look like [_BEG_] or [_TT_150]
+			if strings.HasPrefix(token.Text, "[_") {
+				continue
+			}
+			n := len(segment.Words)
+			if n == 0 || strings.HasPrefix(token.Text, " ") {
+				segment.Words = append(segment.Words, transcript.Word{
+					Text:       strings.TrimSpace(token.Text),
+					Start:      token.Offsets.From,
+					End:        token.Offsets.To,
+					Confidence: token.P,
+				})
+				continue
+			}
+			word := &segment.Words[n-1]
+			word.Text += token.Text
+			word.End = token.Offsets.To
+			word.Confidence = min(word.Confidence, token.P)
+		}
+		segments = append(segments, segment)
+	}
+	return segments, output.Result.Language, nil
+}
@@ -0,0 +1,90 @@
+package transcript
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Occurrence is one match of a phrase in the words of a transcript
+type Occurrence struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	// Context is the match with the surrounding words, the match in brackets
+	Context string
+}
+
+// SearchTerms splits a phrase into normalized words
+func SearchTerms(phrase string) []string {
+	var terms []string
+	for _, field := range strings.Fields(phrase) {
+		if term := normalizeWord(field); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// normalizeWord lowercases a word and strips surrounding punctuation, so
+// "Pricing," matches "pricing"
+func normalizeWord(word string) string {
+	return strings.ToLower(strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}))
+}
+
+// FindPhrase returns every run of words matching the terms, with up to
+// context words on either side
+func FindPhrase(words []Word, terms []string, context int) []Occurrence {
+	normalized := make([]string, len(words))
+	for i, word := range words {
+		normalized[i] = normalizeWord(word.Text)
+	}
+
+	var occurrences []Occurrence
+	for i := 0; i+len(terms) <= len(words); i++ {
+		if !matchesAt(normalized, i, terms) {
+			continue
+		}
+		last := i + len(terms) - 1
+
+		var parts []string
+		from, to := max(0, i-context), min(len(words)-1, last+context)
+		if from > 0 {
+			parts = append(parts, "…")
+		}
+		for j := from; j <= to; j++ {
+			text := words[j].Text
+			if j == i {
+				text = "[" + text
+			}
+			if j == last {
+				text += "]"
+			}
+			parts = append(parts, text)
+		}
+		if to < len(words)-1 {
+			parts = append(parts, "…")
+		}
+
+		occurrences = append(occurrences, Occurrence{
+			Start:   time.Duration(words[i].Start) * time.Millisecond,
+			End:     time.Duration(words[last].End) * time.Millisecond,
+			Speaker: words[i].Speaker,
+			Context: strings.Join(parts, " "),
+		})
+		i = last
+	}
+	return occurrences
+}
+
+// matchesAt reports whether the terms follow each other from word i
+func matchesAt(normalized []string, i int, terms []string) bool {
+	for j, term := range terms {
+		if normalized[i+j] != term {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+// Package transcript is sona's own model of a finished transcript. Every
+// provider normalizes its result into a Transcript and every output format
+// is rendered from one, so formats do not depend on how a provider shapes
+// its responses.
+package transcript
+
+// Transcript is a finished transcription of one piece of audio
+type Transcript struct {
+	// ID is the provider's ID of the transcript, when it keeps one
+	ID   string
+	Text string
+	// Confidence is the provider's confidence in the whole transcript (0-1),
+	// 0 when it reports none
+	Confidence float64
+	// LanguageCode is the spoken language, as detected or requested
+	LanguageCode       string
+	LanguageConfidence float64
+	// Duration is the length of the audio in seconds
+	Duration float64
+	Words    []Word
+	// Segments are stretches of speech by one speaker, when speakers were
+	// told apart
+	Segments []Segment
+	// Chapters, Highlights and Entities are only set when requested
+	Chapters   []Chapter
+	Highlights []Highlight
+	Entities   []Entity
+	// APIKey is the AssemblyAI key whose account made the transcript
+	APIKey string
+	// Region is the AssemblyAI region the transcript was made in
+	Region string
+	// LocalSeconds is audio transcribed on this machine instead, which
+	// AssemblyAI does not bill
+	LocalSeconds float64
+}
+
+// Word is a single recognized word. Start and End are in milliseconds.
+type Word struct {
+	Text       string  `json:"text"`
+	Start      int64   `json:"start"`
+	End        int64   `json:"end"`
+	Confidence float64 `json:"confidence"`
+	Speaker    string  `json:"speaker,omitempty"`
+}
+
+// Segment is a stretch of speech by one speaker. Start and End are in
+// milliseconds.
+type Segment struct {
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+	Start   int64  `json:"start"`
+	End     int64  `json:"end"`
+}
+
+// Chapter is a stretch of the audio on one topic. Start and End are in
+// milliseconds.
+type Chapter struct {
+	Gist     string `json:"gist"`
+	Headline string `json:"headline"`
+	Summary  string `json:"summary"`
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+}
+
+// Highlight is a key phrase with how often it occurs and its relevance (0-1)
+type Highlight struct {
+	Text  string  `json:"text"`
+	Count int     `json:"count"`
+	Rank  float64 `json:"rank"`
+}
+
+// Entity is a named entity mentioned in the audio. Start and End are in
+// milliseconds.
+type Entity struct {
+	EntityType string `json:"entity_type"`
+	Text       string `json:"text"`
+	Start      int64  `json:"start"`
+	End        int64  `json:"end"`
+}
+
+// Speakers returns the speakers in the order they first speak
+func (t *Transcript) Speakers() []string {
+	var speakers []string
+	seen := make(map[string]bool)
+	add := func(speaker string) {
+		if speaker != "" && !seen[speaker] {
+			seen[speaker] = true
+			speakers = append(speakers, speaker)
+		}
+	}
+	for _, segment := range t.Segments {
+		add(segment.Speaker)
+	}
+	if len(speakers) == 0 {
+		for _, word := range t.Words {
+			add(word.Speaker)
+		}
+	}
+	return speakers
+}
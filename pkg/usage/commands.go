@@ -0,0 +1,146 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/assemblyai"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/ledger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var UsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show hours transcribed, open AssemblyAI jobs and recent limit failures",
+	Long: `Show how much audio sona transcribed today and this month, with an
+estimated cost when usage.price_per_hour is set, alongside the transcripts
+still queued or processing at AssemblyAI and jobs recently refused because
+of an account limit.
+
+AssemblyAI does not report an account's balance or concurrency limit, so
+set usage.concurrency_limit to see how many concurrent slots are left.`,
+	Example: `  sona usage`,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showUsage(); err != nil {
+			fmt.Println(style.Error("%v", err))
+			os.Exit(1)
+		}
+	},
+}
+
+func showUsage() error {
+	today, err := Today()
+	if err != nil {
+		return err
+	}
+	month, err := Month()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Transcribed")
+	if limit := config.GetDailyMinutes(); limit > 0 {
+		fmt.Printf("  Today:       %.0f min of %d min daily budget\n", today, limit)
+	} else {
+		fmt.Printf("  Today:       %.0f min\n", today)
+	}
+	fmt.Printf("  This month:  %.1f h", month/60)
+	if price := config.GetPricePerHour(); price > 0 {
+		fmt.Printf(" (about %.2f at %g per hour)", month/60*price, price)
+	}
+	fmt.Println()
+	if models, _ := monthBy(func(entry ledger.Entry) string { return entry.Model }); models != "" {
+		fmt.Printf("  By model:    %s\n", models)
+	}
+	// Only worth showing when jobs were spread over several keys
+	if keys, n := monthBy(func(entry ledger.Entry) string { return entry.Key }); n > 1 {
+		fmt.Printf("  By API key:  %s\n", keys)
+	}
+
+	fmt.Println("\nAssemblyAI")
+	showOpenJobs()
+
+	failures, err := LimitFailures()
+	if err != nil {
+		return err
+	}
+	fmt.Println("\nRecent limit failures")
+	if len(failures) == 0 {
+		fmt.Println("  none")
+	}
+	for _, failure := range failures {
+		fmt.Printf("  %s  %s: %s\n", failure.At.Format("2006-01-02 15:04"), failure.Source, failure.Error)
+	}
+	return nil
+}
+
+// monthBy sums this month's hours from the jobs ledger per group, e.g. per
+// model "slam-1 10.1 h, best 2.3 h", and returns how many groups there are.
+// Jobs without a group are left out.
+func monthBy(group func(entry ledger.Entry) string) (string, int) {
+	entries, err := ledger.Load()
+	if err != nil {
+		return "", 0
+	}
+
+	month := time.Now().Format("2006-01")
+	hours := make(map[string]float64)
+	for _, entry := range entries {
+		if name := group(entry); name != "" && entry.FinishedAt.Format("2006-01") == month {
+			hours[name] += entry.Audio / 3600
+		}
+	}
+
+	names := make([]string, 0, len(hours))
+	for name := range hours {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return hours[names[i]] > hours[names[j]] })
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %.1f h", name, hours[name])
+	}
+	return strings.Join(parts, ", "), len(parts)
+}
+
+// showOpenJobs prints the transcripts queued or processing at AssemblyAI and
+// the concurrency left when the limit is configured
+func showOpenJobs() {
+	apiKey := config.GetAPIKeyNoExit()
+	if apiKey == "" {
+		fmt.Println("  API key not set")
+		return
+	}
+
+	client := assemblyai.NewClient(apiKey)
+	client.Region = config.GetRegion()
+	client.UseTransport(assemblyai.TransportOptions{
+		HTTP2:          config.GetHTTP2(),
+		ConnectTimeout: config.GetConnectTimeout(),
+		RequestTimeout: config.GetRequestTimeout(),
+	})
+	counts := make(map[string]int)
+	for _, status := range []string{"queued", "processing"} {
+		transcripts, err := client.ListTranscripts(status)
+		if err != nil {
+			fmt.Printf("  Could not list transcripts: %v\n", err)
+			return
+		}
+		counts[status] = len(transcripts)
+	}
+
+	open := counts["queued"] + counts["processing"]
+	fmt.Printf("  Open jobs:   %d (%d queued, %d processing)\n", open, counts["queued"], counts["processing"])
+	if limit := config.GetConcurrencyLimit(); limit > 0 {
+		fmt.Printf("  Concurrency: %d of %d slots free\n", max(0, limit-open), limit)
+	} else {
+		fmt.Println("  Concurrency: limit unknown (set usage.concurrency_limit)")
+	}
+}
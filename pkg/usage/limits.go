@@ -0,0 +1,82 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// keepLimitFailures is how many limit failures are kept
+const keepLimitFailures = 20
+
+// LimitFailure is a job AssemblyAI refused because of an account limit
+type LimitFailure struct {
+	Source string    `json:"source"`
+	Error  string    `json:"error"`
+	At     time.Time `json:"at"`
+}
+
+// limitsPath returns the location of the limit failure log (~/.sona/limit-failures.json)
+func limitsPath() (string, error) {
+	return datadir.Path("limit-failures.json")
+}
+
+// LimitFailures returns the recorded limit failures, newest first
+func LimitFailures() ([]LimitFailure, error) {
+	path, err := limitsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read limit failures: %v", err)
+	}
+
+	var failures []LimitFailure
+	if err := json.Unmarshal(data, &failures); err != nil {
+		return nil, fmt.Errorf("limit failure file %s is corrupted: %v", path, err)
+	}
+	return failures, nil
+}
+
+// RecordLimitFailure logs a job refused because of an account limit,
+// keeping the newest keepLimitFailures
+func RecordLimitFailure(source string, failure error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	failures, err := LimitFailures()
+	if err != nil {
+		return err
+	}
+	failures = append([]LimitFailure{{Source: source, Error: failure.Error(), At: time.Now()}}, failures...)
+	if len(failures) > keepLimitFailures {
+		failures = failures[:keepLimitFailures]
+	}
+
+	path, err := limitsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(failures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode limit failures: %v", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write limit failures: %v", err)
+	}
+	return nil
+}
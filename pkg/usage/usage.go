@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+)
+
+// keepDays is how many days of usage are kept
+const keepDays = 31
+
+// dayLayout keys the usage file by local calendar day
+const dayLayout = "2006-01-02"
+
+// mu keeps jobs transcribed in parallel from overwriting each other's
+// updates of the usage files
+var mu sync.Mutex
+
+// Path returns the location of the usage file (~/.sona/usage.json), which
+// holds the minutes of audio transcribed on each day
+func Path() (string, error) {
+	return datadir.Path("usage.json")
+}
+
+// Today returns the minutes of audio transcribed today
+func Today() (float64, error) {
+	days, err := load()
+	if err != nil {
+		return 0, err
+	}
+	return days[time.Now().Format(dayLayout)], nil
+}
+
+// Month returns the minutes of audio transcribed this calendar month
+func Month() (float64, error) {
+	days, err := load()
+	if err != nil {
+		return 0, err
+	}
+
+	// Days of the month share its "2006-01" prefix
+	month := time.Now().Format("2006-01")
+	var minutes float64
+	for day, used := range days {
+		if strings.HasPrefix(day, month) {
+			minutes += used
+		}
+	}
+	return minutes, nil
+}
+
+// Add records audio transcribed now and prunes days older than keepDays
+func Add(audio time.Duration) error {
+	if audio <= 0 {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	days, err := load()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	days[now.Format(dayLayout)] += audio.Minutes()
+
+	oldest := now.AddDate(0, 0, -keepDays).Format(dayLayout)
+	for day := range days {
+		// Days sort as strings
+		if day < oldest {
+			delete(days, day)
+		}
+	}
+	return save(days)
+}
+
+// NextDay returns the start of the day after t, when a new budget begins
+func NextDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
+func load() (map[string]float64, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]float64)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return days, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage: %v", err)
+	}
+	if err := json.Unmarshal(data, &days); err != nil {
+		return nil, fmt.Errorf("usage file %s is corrupted: %v", path, err)
+	}
+	return days, nil
+}
+
+// save writes the usage atomically
+func save(days map[string]float64) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode usage: %v", err)
+	}
+
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write usage: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanTemp   bool
+	cleanCache  bool
+	cleanLogs   bool
+	cleanDryRun bool
+)
+
+// cleanup is a kind of file another package keeps for 'sona clean' to remove
+type cleanup struct {
+	selected *bool
+	clean    func(dryRun bool) int64
+}
+
+var cleanups []cleanup
+
+// AddCleanup makes 'sona clean' also remove files another package keeps,
+// with a --name flag to clean only those. clean returns the bytes freed,
+// or that would be freed in a dry run.
+func AddCleanup(name string, usage string, clean func(dryRun bool) int64) {
+	selected := new(bool)
+	CleanCmd.Flags().BoolVar(selected, name, false, usage)
+	cleanups = append(cleanups, cleanup{selected: selected, clean: clean})
+}
+
+// CleanCmd removes leftover temporary files, cached downloads and old logs
+var CleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove leftover temp files, cached downloads and old logs",
+	Long: `Remove files Sona no longer needs and report how much space was freed.
+
+Without flags everything is cleaned:
+- temp:  sona-* directories left behind by runs that crashed or were killed
+- cache: cached downloads and YouTube video details in ~/.sona/cache
+- logs:  rotated logs and --debug-http files, and the current log file is emptied
+- audio: audio kept beside transcripts that archive.audio no longer keeps`,
+	Example: `  sona clean
+  sona clean --temp --dry-run
+  sona clean --logs
+  sona clean --audio --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		all := !cleanTemp && !cleanCache && !cleanLogs
+		for _, c := range cleanups {
+			all = all && !*c.selected
+		}
+
+		if cleanDryRun {
+			fmt.Println("Dry run, nothing will be removed")
+		}
+
+		var freed int64
+		if all || cleanTemp {
+			freed += cleanTempDirs()
+		}
+		if all || cleanCache {
+			freed += cleanCacheDir()
+		}
+		if all || cleanLogs {
+			freed += cleanLogFiles()
+		}
+		for _, c := range cleanups {
+			if all || *c.selected {
+				freed += c.clean(cleanDryRun)
+			}
+		}
+
+		if cleanDryRun {
+			fmt.Printf("\nWould free %s\n", FormatSize(freed))
+		} else {
+			fmt.Printf("\nFreed %s\n", FormatSize(freed))
+			logger.LogInfo("sona clean freed %d bytes", freed)
+		}
+	},
+}
+
+func init() {
+	CleanCmd.Flags().BoolVar(&cleanTemp, "temp", false, "Remove abandoned temp directories")
+	CleanCmd.Flags().BoolVar(&cleanCache, "cache", false, "Remove cached downloads and YouTube video details")
+	CleanCmd.Flags().BoolVar(&cleanLogs, "logs", false, "Remove rotated logs and HTTP debug files and empty the current log")
+	CleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "Only report what would be removed")
+}
+
+// removePath deletes a path unless this is a dry run and returns the bytes it held
+func removePath(path string) int64 {
+	size := Size(path)
+	fmt.Printf("   %s (%s)\n", path, FormatSize(size))
+	if cleanDryRun {
+		return size
+	}
+	if err := os.RemoveAll(path); err != nil {
+		fmt.Println("   " + style.Warning("Failed to remove %s: %v", path, err))
+		return 0
+	}
+	return size
+}
+
+func cleanTempDirs() int64 {
+	fmt.Println("\nTemporary directories:")
+
+	dirs, err := Leftovers()
+	if err != nil {
+		fmt.Println("   " + style.Warning("%v", err))
+		return 0
+	}
+	if len(dirs) == 0 {
+		fmt.Println("   Nothing to clean")
+		return 0
+	}
+
+	var freed int64
+	for _, dir := range dirs {
+		freed += removePath(dir)
+	}
+	return freed
+}
+
+func cleanCacheDir() int64 {
+	fmt.Println("\nCached downloads and video details:")
+
+	cacheDir, err := CacheDir()
+	if err != nil {
+		fmt.Println("   " + style.Warning("%v", err))
+		return 0
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil || len(entries) == 0 {
+		fmt.Println("   Nothing to clean")
+		return 0
+	}
+
+	var freed int64
+	for _, entry := range entries {
+		freed += removePath(filepath.Join(cacheDir, entry.Name()))
+	}
+	return freed
+}
+
+func cleanLogFiles() int64 {
+	fmt.Println("\nLogs:")
+
+	logPath := logger.GetLogPath()
+	var freed int64
+
+	// Rotated logs (sona.log.1, sona.log.old, ...)
+	matches, _ := filepath.Glob(logPath + ".*")
+	for _, match := range matches {
+		freed += removePath(match)
+	}
+
+	// HTTP debug files of --debug-http runs
+	if debugDir, err := datadir.Path("debug"); err == nil {
+		debugFiles, _ := filepath.Glob(filepath.Join(debugDir, "http-*.log"))
+		for _, path := range debugFiles {
+			freed += removePath(path)
+		}
+	}
+
+	// The current log stays in place, but is emptied
+	if info, err := os.Stat(logPath); err == nil && info.Size() > 0 {
+		fmt.Printf("   %s (%s, emptied)\n", logPath, FormatSize(info.Size()))
+		if !cleanDryRun {
+			if err := logger.Truncate(); err != nil {
+				fmt.Println("   " + style.Warning("Failed to empty %s: %v", logPath, err))
+				return freed
+			}
+		}
+		freed += info.Size()
+	}
+
+	if freed == 0 {
+		fmt.Println("   Nothing to clean")
+	}
+	return freed
+}
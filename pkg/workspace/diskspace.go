@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// spaceMargin is added to every estimate to leave room for metadata and rounding
+const spaceMargin = 50 * 1024 * 1024
+
+// existingParent walks up from path to the nearest directory that exists,
+// so free space can be checked for locations that are not created yet
+func existingParent(path string) string {
+	path = filepath.Clean(path)
+	for {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// CheckFreeSpace fails with a clear message when the filesystem holding path
+// has less than required bytes (plus a safety margin) available
+func CheckFreeSpace(path string, required int64, purpose string) error {
+	dir := existingParent(path)
+
+	available, err := FreeSpace(dir)
+	if err != nil {
+		// Not being able to measure should never block a job
+		logger.LogWarning("Could not check free space in %s: %v", dir, err)
+		return nil
+	}
+
+	needed := uint64(required) + spaceMargin
+	logger.LogInfo("Free space in %s: %s, needed for %s: %s", dir, FormatSize(int64(available)), purpose, FormatSize(int64(needed)))
+
+	if available < needed {
+		return fmt.Errorf("not enough free space in %s for the %s: need about %s, only %s available. Free up space (try 'sona clean') or point TMPDIR at a larger disk",
+			dir, purpose, FormatSize(int64(needed)), FormatSize(int64(available)))
+	}
+	return nil
+}
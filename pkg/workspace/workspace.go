@@ -0,0 +1,223 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Harsh-2002/Sona/pkg/datadir"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+)
+
+// TempPrefix is the name prefix of every temporary directory sona creates
+const TempPrefix = "sona-"
+
+// ownerFile records the PID of the process that owns a temp directory
+const ownerFile = ".sona-owner"
+
+var (
+	mu             sync.Mutex
+	active         = make(map[string]*Workspace)
+	interruptHooks []func()
+	shutdownHook   func()
+)
+
+// Workspace is a temporary directory for the artifacts of a single job
+// (downloads, converted audio). It is tracked so it can be removed on
+// failure, on interrupt, or later by 'sona clean'.
+type Workspace struct {
+	Dir string
+}
+
+// New creates and registers a temporary workspace
+func New() (*Workspace, error) {
+	dir, err := os.MkdirTemp("", TempPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+
+	// Mark the directory as ours so 'sona clean' can tell abandoned ones apart
+	owner := filepath.Join(dir, ownerFile)
+	if err := os.WriteFile(owner, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to initialize temp directory: %v", err)
+	}
+
+	ws := &Workspace{Dir: dir}
+
+	mu.Lock()
+	active[dir] = ws
+	mu.Unlock()
+
+	logger.LogInfo("Created workspace: %s", dir)
+	return ws, nil
+}
+
+// Path returns the path of a file inside the workspace
+func (w *Workspace) Path(name string) string {
+	return filepath.Join(w.Dir, name)
+}
+
+// Remove deletes the workspace and everything in it. It is safe to call more than once.
+func (w *Workspace) Remove() {
+	mu.Lock()
+	delete(active, w.Dir)
+	mu.Unlock()
+
+	if err := os.RemoveAll(w.Dir); err != nil {
+		logger.LogWarning("Failed to remove workspace %s: %v", w.Dir, err)
+		return
+	}
+	logger.LogInfo("Removed workspace: %s", w.Dir)
+}
+
+// RemoveAll deletes every workspace still registered by this process.
+// Call it before os.Exit, which skips deferred cleanups.
+func RemoveAll() {
+	mu.Lock()
+	workspaces := make([]*Workspace, 0, len(active))
+	for _, ws := range active {
+		workspaces = append(workspaces, ws)
+	}
+	mu.Unlock()
+
+	for _, ws := range workspaces {
+		ws.Remove()
+	}
+}
+
+// OnInterrupt registers a function to run when sona is interrupted, before
+// temporary files are removed and the process exits
+func OnInterrupt(hook func()) {
+	mu.Lock()
+	interruptHooks = append(interruptHooks, hook)
+	mu.Unlock()
+}
+
+// OnShutdown makes the first interrupt or SIGTERM call hook instead of
+// exiting, so a long-running command can finish its current job and stop
+// on its own. A second signal exits right away.
+func OnShutdown(hook func()) {
+	mu.Lock()
+	shutdownHook = hook
+	mu.Unlock()
+}
+
+// HandleSignals removes all workspaces when sona is interrupted or terminated
+func HandleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		mu.Lock()
+		shutdown := shutdownHook
+		mu.Unlock()
+		if shutdown != nil {
+			logger.LogInfo("Received %v, shutting down", sig)
+			go shutdown()
+			sig = <-signals
+		}
+		logger.LogWarning("Received %v, cleaning up temporary files", sig)
+
+		mu.Lock()
+		hooks := interruptHooks
+		mu.Unlock()
+		for _, hook := range hooks {
+			hook()
+		}
+
+		RemoveAll()
+		fmt.Println("\nInterrupted, temporary files removed")
+		os.Exit(130)
+	}()
+}
+
+// isAbandoned reports whether a sona temp directory no longer belongs to a running process
+func isAbandoned(dir string) bool {
+	data, err := os.ReadFile(filepath.Join(dir, ownerFile))
+	if err != nil {
+		// Directories from older versions carry no owner marker
+		return true
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return true
+	}
+	if pid == os.Getpid() {
+		return false
+	}
+	return !processAlive(pid)
+}
+
+// processAlive checks whether a process with the given PID is running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows FindProcess fails for processes that do not exist
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	// On Unix FindProcess always succeeds; signal 0 probes for existence
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Leftovers returns sona temp directories left behind by processes that are no longer running
+func Leftovers() ([]string, error) {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temp directory: %v", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), TempPrefix) {
+			continue
+		}
+		dir := filepath.Join(os.TempDir(), entry.Name())
+		if isAbandoned(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// CacheDir returns the directory for cached downloads (~/.sona/cache)
+func CacheDir() (string, error) {
+	return datadir.Path("cache")
+}
+
+// Size returns the total size of a file or directory tree in bytes
+func Size(path string) int64 {
+	var total int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// FormatSize renders a byte count for humans, e.g. "1.4 GB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -1,58 +1,93 @@
 package youtube
 
 import (
-	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/deps"
 	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
 )
 
-// DownloadAudio downloads audio from a YouTube URL using yt-dlp
-func DownloadAudio(url string, outputDir string) (string, error) {
-	logger.LogInfo("Downloading audio from YouTube URL: %s", url)
+// downloadAttempts is how many times yt-dlp is run for one download; each
+// run resumes where the last one stopped
+const downloadAttempts = 3
+
+// DownloadDir returns the cache directory a video is downloaded into, keyed
+// by its video ID (~/.sona/cache/youtube/<id>). Partial downloads stay there
+// when a download fails, so the next attempt resumes them.
+func DownloadDir(videoURL string) (string, error) {
+	cacheDir, err := workspace.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "youtube", cacheKey(videoURL)), nil
+}
+
+// RemoveDownload removes a video's download directory once its audio is no
+// longer needed
+func RemoveDownload(videoURL string) {
+	if dir, err := DownloadDir(videoURL); err == nil {
+		os.RemoveAll(dir)
+	}
+}
+
+// DownloadAudio downloads the audio of a YouTube URL as MP3 into its
+// download directory using yt-dlp and returns the file. A dropped
+// connection is resumed, within this call and on the next one.
+func DownloadAudio(videoURL string) (string, error) {
+	logger.LogInfo("Downloading audio from YouTube URL: %s", videoURL)
 
 	// Check if yt-dlp is installed
 	ytdlpPath, err := FindBinary("yt-dlp")
 	if err != nil {
 		logger.LogError("yt-dlp not found: %v", err)
-		return "", fmt.Errorf("yt-dlp not found. Run 'sona install' to install dependencies")
+		return "", &deps.MissingError{Binary: "yt-dlp", Message: "yt-dlp not found. Run 'sona install' to install dependencies"}
 	}
 
 	logger.LogInfo("Using yt-dlp: %s", ytdlpPath)
 
-	// Create output filename
-	outputFilename := "youtube_audio.mp3"
-	outputPath := filepath.Join(outputDir, outputFilename)
-
-	// Get ffmpeg location for yt-dlp (consistent across Unix-like systems)
-	ffmpegPath := ""
-
-	// First try system PATH
-	if path, err := exec.LookPath("ffmpeg"); err == nil {
-		ffmpegPath = path
-	} else {
-		// For Unix-like systems, try user's bin directory
-		if runtime.GOOS != "windows" {
-			homeDir, _ := os.UserHomeDir()
-			userBinPath := filepath.Join(homeDir, "bin", "ffmpeg")
-			if _, err := os.Stat(userBinPath); err == nil {
-				ffmpegPath = userBinPath
-			}
-		}
+	dir, err := DownloadDir(videoURL)
+	if err != nil {
+		return "", err
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) > 0 {
+		fmt.Println("Resuming earlier download...")
+		logger.LogInfo("Resuming partial download in %s", dir)
 	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %v", err)
+	}
+	outputPath := filepath.Join(dir, "audio.mp3")
+
+	// Get ffmpeg location for yt-dlp
+	ffmpegPath, _ := FindBinary("ffmpeg")
 
-	// Build yt-dlp command with additional options for better compatibility
+	// Build yt-dlp command with additional options for better compatibility.
+	// --continue and --part keep what was downloaded when the connection
+	// drops, and the retries ride out short outages within a run.
 	args := []string{
 		"--extract-audio",
 		"--audio-format", "mp3",
 		"--audio-quality", "0",
-		"--output", outputPath,
+		"--output", filepath.Join(dir, "audio.%(ext)s"),
 		"--no-playlist",
+		"--continue",
+		"--part",
+		"--retries", "10",
+		"--fragment-retries", "10",
 	}
 
 	// Add ffmpeg location if found
@@ -61,82 +96,114 @@ func DownloadAudio(url string, outputDir string) (string, error) {
 		logger.LogInfo("Using ffmpeg at: %s", ffmpegPath)
 	}
 
-	args = append(args, url)
-
-	logger.LogInfo("Running yt-dlp command: yt-dlp %v", args)
+	// Honor the configured bandwidth limit
+	args = append(args, limitRateArgs()...)
 
-	// Execute yt-dlp
-	cmd := exec.Command(ytdlpPath, args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	// After a failed attempt, other player clients often work
+	fallbackArgs := append(args[:len(args):len(args)], "--extractor-args", "youtube:player_client=android,web")
 
-	if err := cmd.Run(); err != nil {
-		logger.LogError("yt-dlp command failed: %v, stderr: %s", err, stderr.String())
-
-		// Try fallback options if first attempt fails
-		logger.LogInfo("First attempt failed, trying fallback options")
-		fallbackArgs := []string{
-			"--extract-audio",
-			"--audio-format", "mp3",
-			"--audio-quality", "0",
-			"--output", outputPath,
-			"--no-playlist",
-			"--extractor-args", "youtube:player_client=android,web",
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		attemptArgs := args
+		if attempt > 1 {
+			attemptArgs = fallbackArgs
+			fmt.Printf("Download interrupted, resuming (attempt %d of %d)...\n", attempt, downloadAttempts)
 		}
+		attemptArgs = append(attemptArgs[:len(attemptArgs):len(attemptArgs)], videoURL)
 
-		// Add ffmpeg location to fallback as well
-		if ffmpegPath != "" {
-			fallbackArgs = append(fallbackArgs, "--ffmpeg-location", ffmpegPath)
+		logger.LogInfo("Running yt-dlp command: yt-dlp %v", attemptArgs)
+		cmd := exec.Command(ytdlpPath, attemptArgs...)
+		if _, err = logger.RunCommand(cmd); err == nil {
+			break
 		}
+		logger.LogError("yt-dlp attempt %d failed: %v", attempt, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to download audio: %v (the partial download is kept and resumed on the next run)", err)
+	}
+
+	logger.LogInfo("Audio download completed successfully: %s", outputPath)
+	return outputPath, nil
+}
 
-		fallbackArgs = append(fallbackArgs, url)
+// cacheKey names the download directory of a video: its ID, or a hash of
+// the URL when no ID can be found in it
+func cacheKey(videoURL string) string {
+	if id := VideoID(videoURL); id != "" {
+		return id
+	}
+	sum := sha256.Sum256([]byte(videoURL))
+	return hex.EncodeToString(sum[:8])
+}
 
-		cmd = exec.Command(ytdlpPath, fallbackArgs...)
-		cmd.Stderr = &stderr
+// videoIDPattern is what a YouTube video ID looks like
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{6,}$`)
 
-		if err := cmd.Run(); err != nil {
-			logger.LogError("yt-dlp fallback also failed: %v, stderr: %s", err, stderr.String())
-			return "", fmt.Errorf("failed to download audio: %v", err)
-		}
+// VideoID returns the ID of the video a YouTube URL points at, or "" when
+// there is none, e.g. for a channel
+func VideoID(videoURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(videoURL))
+	if err != nil {
+		return ""
+	}
 
-		logger.LogInfo("yt-dlp fallback succeeded")
+	var id string
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	switch {
+	case strings.Contains(parsed.Host, "youtu.be"):
+		id = segments[0]
+	case parsed.Query().Get("v") != "":
+		id = parsed.Query().Get("v")
+	case len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "live" || segments[0] == "embed"):
+		id = segments[1]
 	}
 
-	logger.LogInfo("Audio download completed successfully: %s", outputPath)
-	return outputPath, nil
+	if !videoIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
 }
 
-// FindBinary finds a binary in PATH or user's bin directory
-func FindBinary(binaryName string) (string, error) {
-	// First check if it's in PATH
-	if path, err := exec.LookPath(binaryName); err == nil {
-		return path, nil
+// limitRateArgs returns the yt-dlp arguments for network.max_download_rate
+func limitRateArgs() []string {
+	rate := config.GetMaxDownloadRate()
+	if rate <= 0 {
+		return nil
 	}
+	logger.LogInfo("Limiting download rate to %d bytes/s", rate)
+	return []string{"--limit-rate", strconv.FormatInt(rate, 10)}
+}
 
-	// For Unix-like systems, check user's bin directory
-	if runtime.GOOS != "windows" {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			userBinPath := filepath.Join(homeDir, "bin", binaryName)
-			if _, err := os.Stat(userBinPath); err == nil {
-				return userBinPath, nil
-			}
-		}
+// ProbeDuration returns the duration of a video without downloading it
+func ProbeDuration(url string) (time.Duration, error) {
+	metadata, err := FetchMetadata(url)
+	if err != nil {
+		return 0, err
+	}
+	// Live streams have no duration
+	if metadata.Duration <= 0 {
+		return 0, fmt.Errorf("yt-dlp reports no duration for %s", url)
 	}
+	return time.Duration(metadata.Duration * float64(time.Second)), nil
+}
 
-	// Not found
-	return "", fmt.Errorf("%s not found", binaryName)
+// FindBinary finds a binary in sona's bin directory, PATH or ~/bin
+func FindBinary(binaryName string) (string, error) {
+	return deps.FindBinary(binaryName)
 }
 
-// InstallYtDlp attempts to install yt-dlp
-func InstallYtDlp() error {
+// InstallYtDlp attempts to install yt-dlp from the given source
+func InstallYtDlp(source deps.InstallSource) error {
 	// Direct binary download is more reliable across platforms
-	logger.LogInfo("Installing yt-dlp binary directly")
-	return downloadYtDlpBinary()
+	logger.LogInfo("Installing yt-dlp binary from %s", source.Describe())
+	if err := downloadYtDlpBinary(source); err != nil {
+		return err
+	}
+	_, err := deps.VerifyManaged("yt-dlp")
+	return err
 }
 
 // downloadYtDlpBinary downloads yt-dlp binary directly for the current platform
-func downloadYtDlpBinary() error {
+func downloadYtDlpBinary(source deps.InstallSource) error {
 	platform, arch := getPlatform(), getArchitecture()
 	logger.LogInfo("Detected platform: %s, architecture: %s", platform, arch)
 
@@ -147,24 +214,22 @@ func downloadYtDlpBinary() error {
 
 	logger.LogInfo("Download URL: %s", downloadURL)
 
-	// Create bin directory if it doesn't exist (consistent path across Unix-like systems)
-	homeDir, err := os.UserHomeDir()
+	// Create sona's bin directory if it doesn't exist
+	binDir, err := deps.EnsureBinDir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %v", err)
+		return err
 	}
 
-	binDir := filepath.Join(homeDir, "bin")
-	if err := os.MkdirAll(binDir, 0755); err != nil {
-		return fmt.Errorf("failed to create bin directory: %v", err)
-	}
-
-	// Download the binary
+	// Download the binary, or copy a ready one from the install directory
 	outputPath := filepath.Join(binDir, "yt-dlp")
 	logger.LogInfo("Downloading yt-dlp binary to: %s", binDir)
 
-	cmd := exec.Command("curl", "-L", "-o", outputPath, downloadURL)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		logger.LogError("Failed to download yt-dlp: %v, output: %s", err, string(output))
+	if localPath, ok := source.LocalBinary("yt-dlp"); ok {
+		if err := deps.CopyFile(localPath, outputPath, 0755); err != nil {
+			return fmt.Errorf("copy failed: %v", err)
+		}
+	} else if err := source.Fetch(path.Base(downloadURL), downloadURL, outputPath); err != nil {
+		logger.LogError("Failed to download yt-dlp: %v", err)
 		return fmt.Errorf("download failed: %v", err)
 	}
 
@@ -0,0 +1,118 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Harsh-2002/Sona/pkg/atomicfile"
+	"github.com/Harsh-2002/Sona/pkg/config"
+	"github.com/Harsh-2002/Sona/pkg/logger"
+	"github.com/Harsh-2002/Sona/pkg/workspace"
+)
+
+// Metadata is what sona uses of the details yt-dlp reports for a video
+type Metadata struct {
+	ID       string  `json:"id"`
+	Title    string  `json:"title"`
+	Uploader string  `json:"uploader,omitempty"`
+	Duration float64 `json:"duration"`
+	// UploadDate is YYYYMMDD
+	UploadDate string    `json:"upload_date,omitempty"`
+	Chapters   []Chapter `json:"chapters,omitempty"`
+}
+
+// Chapter is a chapter of a video, in seconds from its start
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
+
+// metadataPath returns where the details of a video are cached. They are
+// kept apart from the download directory, which goes once the audio is
+// transcribed, so a retry or a later run can still use them.
+func metadataPath(videoURL string) (string, error) {
+	cacheDir, err := workspace.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "youtube-metadata", cacheKey(videoURL)+".json"), nil
+}
+
+// FetchMetadata returns the details of a video, from the cache while they
+// are younger than youtube.metadata_ttl, otherwise from yt-dlp
+func FetchMetadata(videoURL string) (*Metadata, error) {
+	ttl := config.GetMetadataTTL()
+	path, err := metadataPath(videoURL)
+	if err != nil {
+		ttl = 0
+	}
+	if ttl > 0 {
+		if metadata := cachedMetadata(path, ttl); metadata != nil {
+			logger.LogInfo("Using cached details of %s", videoURL)
+			return metadata, nil
+		}
+	}
+
+	ytdlpPath, err := FindBinary("yt-dlp")
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(ytdlpPath, "--dump-json", "--skip-download", "--no-playlist", "--no-warnings", videoURL)
+	var stderr bytes.Buffer
+	cmd.Stderr = logger.CommandOutput(&stderr)
+	output, err := cmd.Output()
+	// The full JSON runs to hundreds of KB of formats, so only its size is logged
+	logger.LogCommand(ytdlpPath, cmd.Args[1:], fmt.Sprintf("%d bytes of JSON\n%s", len(output), stderr.String()), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query video details: %v", err)
+	}
+
+	var metadata Metadata
+	if err := json.Unmarshal(output, &metadata); err != nil {
+		return nil, fmt.Errorf("unexpected video details from yt-dlp: %v", err)
+	}
+	if ttl > 0 {
+		saveMetadata(path, &metadata)
+	}
+	return &metadata, nil
+}
+
+// cachedMetadata reads the cached details at path, or returns nil when
+// there are none or they are older than ttl
+func cachedMetadata(path string, ttl time.Duration) *Metadata {
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > ttl {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var metadata Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		logger.LogWarning("Ignoring unreadable cached video details %s: %v", path, err)
+		return nil
+	}
+	return &metadata
+}
+
+// saveMetadata caches the details of a video; a failure only costs a query
+// next time
+func saveMetadata(path string, metadata *Metadata) {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = atomicfile.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		logger.LogWarning("Could not cache video details: %v", err)
+	}
+}